@@ -55,6 +55,11 @@ func NewImporter(request ImageImportRequest, computeClient compute.Client, stora
 	if err != nil {
 		return nil, err
 	}
+
+	nat, err := newTemporaryNAT(request, computeClient)
+	if err != nil {
+		return nil, err
+	}
 	return &importer{
 		project:      request.Project,
 		zone:         request.Zone,
@@ -68,6 +73,7 @@ func NewImporter(request ImageImportRequest, computeClient compute.Client, stora
 			logger,
 		},
 		diskClient: computeClient,
+		nat:        nat,
 		logger:     logger,
 	}, nil
 }
@@ -81,6 +87,7 @@ type importer struct {
 	inflater          Inflater
 	processorProvider processorProvider
 	diskClient        diskClient
+	nat               *temporaryNAT
 	logger            logging.Logger
 	timeout           time.Duration
 }
@@ -95,6 +102,14 @@ func (i *importer) Run(ctx context.Context) error {
 		return err
 	}
 
+	// The NAT is shared by all workflows of the import.
+	if i.nat != nil {
+		if err := i.nat.create(); err != nil {
+			return err
+		}
+		defer i.nat.delete()
+	}
+
 	defer i.deleteDisk()
 
 	if err := i.runInflate(ctx); err != nil {
@@ -101,6 +101,38 @@ func TestRun_DeletesDisk_AfterImportingImage(t *testing.T) {
 	assert.Equal(t, diskURI, mockDiskClient.uri)
 }
 
+func TestRun_CreatesNATOnce_AndDeletesItAfterImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockLogger := mocks.NewMockLogger(ctrl)
+
+	routerClient := &mockRouterClient{}
+	nat, err := newTemporaryNAT(ImageImportRequest{
+		ExecutionID:  "abc",
+		Project:      "project",
+		Zone:         "us-west1-a",
+		Network:      "global/networks/default",
+		ProvisionNAT: true,
+	}, routerClient)
+	assert.NoError(t, err)
+
+	importer := importer{
+		project:      "project",
+		zone:         "us-west1-a",
+		diskClient:   &mockDiskClient{},
+		nat:          nat,
+		preValidator: mockValidator{},
+		inflater:     &mockInflater{},
+		processorProvider: &mockProcessorProvider{
+			processors: []processor{&mockProcessor{}, &mockProcessor{}},
+		},
+		logger: mockLogger,
+	}
+	assert.NoError(t, importer.Run(context.Background()))
+	assert.Equal(t, 1, routerClient.createCalls)
+	assert.Equal(t, []string{"nat-router-abc"}, routerClient.deleted)
+}
+
 func TestRun_NoErrorLoggedWhenDeletingDiskThatWasNotCreated(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,183 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"google.golang.org/api/option"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/logging"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/storage"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+const (
+	// The upload is split between uploadWorkers chunks of uploadBufSize/uploadWorkers
+	// bytes, which are staged on the local disk. These values, and uploadWriteSize,
+	// determine the chunk boundaries, so changing them prevents resuming uploads
+	// started by earlier versions.
+	uploadBufSize   = 1 << 30
+	uploadWorkers   = 4
+	uploadWriteSize = 1 << 20
+
+	uploadProgressInterval = 30 * time.Second
+
+	// Uploads are kept outside of the scratch directory of the import, which
+	// is unique to each run, so that a rerun resumes them.
+	uploadDir = "local-uploads"
+)
+
+// LocalFileUploader uploads local source files to the scratch bucket, so that
+// they are imported like files in Cloud Storage.
+type LocalFileUploader struct {
+	ctx           context.Context
+	storageClient domain.StorageClientInterface
+	newClient     func(ctx context.Context, oauth string) (domain.StorageClientInterface, error)
+	oauth         string
+	logger        logging.Logger
+}
+
+// NewLocalFileUploader returns a LocalFileUploader that authenticates with
+// the credentials file oauth, or the default credentials if it's empty.
+func NewLocalFileUploader(ctx context.Context, storageClient domain.StorageClientInterface,
+	oauth string, logger logging.Logger) *LocalFileUploader {
+	return &LocalFileUploader{
+		ctx:           ctx,
+		storageClient: storageClient,
+		newClient: func(ctx context.Context, oauth string) (domain.StorageClientInterface, error) {
+			return storage.NewStorageClient(ctx, logger, option.WithCredentialsFile(oauth))
+		},
+		oauth:  oauth,
+		logger: logger,
+	}
+}
+
+// Upload copies a local source file to the bucket of scratchBucketGcsPath
+// and returns the uploaded file. The chunks uploaded by an interrupted run
+// for the same file are reused, and a file left by an interrupted import is
+// not uploaded again.
+func (u *LocalFileUploader) Upload(source Source, scratchBucketGcsPath string) (Source, error) {
+	local, ok := source.(localFileSource)
+	if !ok {
+		return nil, daisy.Errf("%q is not a local file", source.Path())
+	}
+	bkt, _, err := storage.GetGCSObjectPathElements(scratchBucketGcsPath)
+	if err != nil {
+		return nil, err
+	}
+	id := local.uploadID()
+	obj := path.Join(uploadDir, id, filepath.Base(local.path))
+	gcsPath := fmt.Sprintf("gs://%s/%s", bkt, obj)
+
+	if attrs, err := u.storageClient.GetObjectAttrs(bkt, obj); err == nil && attrs.Size == local.size {
+		if local.hasCRC32C(attrs.CRC32C) {
+			u.logger.User(fmt.Sprintf("Reusing %s, uploaded by a previous import of %s.", gcsPath, local.path))
+			return newFileSource(gcsPath, u.storageClient)
+		}
+		u.logger.User(fmt.Sprintf("The content of %s differs from %s, so the file is uploaded again.", gcsPath, local.path))
+	}
+
+	u.logger.User(fmt.Sprintf("Uploading %s (%s) to %s.", local.path, humanize.IBytes(uint64(local.size)), gcsPath))
+	start := time.Now()
+	if err := u.copy(local, id, bkt, obj); err != nil {
+		return nil, daisy.Errf("failed to upload %q to %q: %v", local.path, gcsPath, err)
+	}
+	u.logger.User(fmt.Sprintf("Uploaded %s in %s.", local.path, time.Since(start).Round(time.Second)))
+	return newFileSource(gcsPath, u.storageClient)
+}
+
+// CleanUp deletes a file returned by Upload, whether the import succeeded
+// or not.
+func (u *LocalFileUploader) CleanUp(uploaded Source, importErr error) {
+	if err := u.storageClient.DeleteObject(uploaded.Path()); err != nil {
+		u.logger.User(fmt.Sprintf("Failed to delete %s: %v", uploaded.Path(), err))
+	}
+}
+
+func (u *LocalFileUploader) copy(local localFileSource, id, bkt, obj string) error {
+	f, err := os.Open(local.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	staging, err := ioutil.TempDir("", "image-import-upload")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	writer := storage.NewBufferedWriter(u.ctx, uploadBufSize, uploadWorkers, u.newClient, u.oauth, staging, bkt, obj)
+	writer.Resume(id)
+
+	// Chunks only match the ones of an earlier upload when written with the
+	// same sized writes, so the file is read in full buffers.
+	buf := make([]byte, uploadWriteSize)
+	var written int64
+	lastReport := time.Now()
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			if _, err := writer.Write(buf[:n]); err != nil {
+				return err
+			}
+			written += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if time.Since(lastReport) >= uploadProgressInterval {
+			u.logger.User(fmt.Sprintf("Uploaded %s of %s (%d%%).", humanize.IBytes(uint64(written)),
+				humanize.IBytes(uint64(local.size)), written*100/local.size))
+			lastReport = time.Now()
+		}
+	}
+	if written != local.size {
+		return daisy.Errf("%q changed during the upload", local.path)
+	}
+	return writer.Close()
+}
+
+// hasCRC32C returns true if the content of the file has the CRC32C crc.
+func (s localFileSource) hasCRC32C(crc uint32) bool {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	fileCRC, err := storage.CRC32C(f)
+	return err == nil && fileCRC == crc
+}
+
+// uploadID identifies the uploads of a version of the file, to resume them.
+func (s localFileSource) uploadID() string {
+	h := sha256.Sum256([]byte(fmt.Sprint(s.path, s.size, s.modTime.UnixNano(),
+		uploadBufSize, uploadWorkers, uploadWriteSize)))
+	return fmt.Sprintf("%x", h[:8])
+}
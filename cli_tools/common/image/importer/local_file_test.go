@@ -0,0 +1,256 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/test"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/mocks"
+)
+
+func TestLocalFilesAreValidated(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	for _, tt := range []struct {
+		name, content, errMessage string
+	}{
+		{"disk.vmdk", "fileContent", ""},
+		{"empty.vmdk", "", "cannot import an image from an empty file"},
+		{"disk.vmdk.gz", test.CreateCompressedFile(), "the input file is a gzip file"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			assert.NoError(t, ioutil.WriteFile(path, []byte(tt.content), 0644))
+			source, err := NewSourceFactory(nil).Init(path, "")
+			if tt.errMessage != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMessage)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, IsLocalFile(source))
+			assert.Equal(t, path, source.Path())
+		})
+	}
+}
+
+func TestLocalDirectoriesAreRejected(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	_, err := NewSourceFactory(nil).Init(dir, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is a directory")
+}
+
+func TestMissingLocalFilesAreInvalidSources(t *testing.T) {
+	for _, path := range []string{"gs:/bucket/disk.vmdk", "missing/disk.vmdk"} {
+		_, err := NewSourceFactory(nil).Init(path, "")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "is not a valid Cloud Storage object path")
+	}
+}
+
+func TestUploadReusesUploadedFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	source := writeLocalSource(t, dir, "fileContent")
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	obj := fmt.Sprintf("local-uploads/%s/disk.vmdk", source.uploadID())
+	mockStorageObject := mocks.NewMockStorageObject(mockCtrl)
+	mockStorageObject.EXPECT().NewReader().Return(ioutil.NopCloser(strings.NewReader("fileContent")), nil)
+	mockStorageClient := mocks.NewMockStorageClientInterface(mockCtrl)
+	mockStorageClient.EXPECT().GetObjectAttrs("bucket", obj).Return(&storage.ObjectAttrs{Size: source.size, CRC32C: crc32c("fileContent")}, nil)
+	mockStorageClient.EXPECT().GetObject("bucket", obj).Return(mockStorageObject)
+	mockLogger := mocks.NewMockLogger(mockCtrl)
+	mockLogger.EXPECT().User(gomock.Any()).AnyTimes()
+
+	uploader := NewLocalFileUploader(context.Background(), mockStorageClient, "", mockLogger)
+	uploaded, err := uploader.Upload(source, "gs://bucket/gce-image-import-id")
+	assert.NoError(t, err)
+	assert.Equal(t, fileSource{gcsPath: "gs://bucket/" + obj, bucket: "bucket", object: obj}, uploaded)
+}
+
+func TestUploadCopiesFileWhenUploadedFileDiffers(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	source := writeLocalSource(t, dir, "fileContent")
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	obj := fmt.Sprintf("local-uploads/%s/disk.vmdk", source.uploadID())
+	fake := newFakeBucket(mockCtrl)
+	fake.data[obj] = bytes.NewBufferString("fileC0ntent")
+	mockLogger := mocks.NewMockLogger(mockCtrl)
+	mockLogger.EXPECT().User(gomock.Any()).AnyTimes()
+
+	uploader := NewLocalFileUploader(context.Background(), fake.client, "", mockLogger)
+	uploader.newClient = func(ctx context.Context, oauth string) (domain.StorageClientInterface, error) {
+		return fake.client, nil
+	}
+	uploaded, err := uploader.Upload(source, "gs://bucket/gce-image-import-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "gs://bucket/"+obj, uploaded.Path())
+	assert.Equal(t, map[string]string{obj: "fileContent"}, fake.objects())
+}
+
+func TestUploadCopiesFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	source := writeLocalSource(t, dir, "fileContent")
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	obj := fmt.Sprintf("local-uploads/%s/disk.vmdk", source.uploadID())
+	fake := newFakeBucket(mockCtrl)
+	mockLogger := mocks.NewMockLogger(mockCtrl)
+	mockLogger.EXPECT().User(gomock.Any()).AnyTimes()
+
+	uploader := NewLocalFileUploader(context.Background(), fake.client, "", mockLogger)
+	uploader.newClient = func(ctx context.Context, oauth string) (domain.StorageClientInterface, error) {
+		return fake.client, nil
+	}
+	uploaded, err := uploader.Upload(source, "gs://bucket/gce-image-import-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "gs://bucket/"+obj, uploaded.Path())
+	assert.Equal(t, map[string]string{obj: "fileContent"}, fake.objects())
+}
+
+func TestCleanUpDeletesUploadedFile(t *testing.T) {
+	for _, importErr := range []error{nil, errors.New("import failed")} {
+		mockCtrl := gomock.NewController(t)
+		mockStorageClient := mocks.NewMockStorageClientInterface(mockCtrl)
+		mockStorageClient.EXPECT().DeleteObject("gs://bucket/local-uploads/id/disk.vmdk").Return(nil)
+
+		uploader := NewLocalFileUploader(context.Background(), mockStorageClient, "", nil)
+		uploader.CleanUp(fileSource{gcsPath: "gs://bucket/local-uploads/id/disk.vmdk"}, importErr)
+		mockCtrl.Finish()
+	}
+}
+
+func TestUploadRejectsNonLocalSources(t *testing.T) {
+	uploader := NewLocalFileUploader(context.Background(), nil, "", nil)
+	_, err := uploader.Upload(imageSource{uri: "global/images/ubuntu-1604"}, "gs://bucket/gce-image-import-id")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a local file")
+}
+
+func Test_validate_RejectsLocalSource(t *testing.T) {
+	request := makeValidRequest()
+	request.Source = localFileSource{path: "/tmp/disk.vmdk"}
+	err := request.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "has to be uploaded to Cloud Storage before importing it")
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "local-file")
+	assert.NoError(t, err)
+	return dir
+}
+
+func writeLocalSource(t *testing.T, dir, content string) localFileSource {
+	path := filepath.Join(dir, "disk.vmdk")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	source, err := newLocalFileSource(path)
+	assert.NoError(t, err)
+	return source.(localFileSource)
+}
+
+// fakeBucket keeps the objects written to "bucket" in memory.
+type fakeBucket struct {
+	client *mocks.MockStorageClientInterface
+	mx     sync.Mutex
+	data   map[string]*bytes.Buffer
+}
+
+func newFakeBucket(mockCtrl *gomock.Controller) *fakeBucket {
+	f := &fakeBucket{data: map[string]*bytes.Buffer{}}
+	f.client = mocks.NewMockStorageClientInterface(mockCtrl)
+	f.client.EXPECT().Close().Return(nil).AnyTimes()
+	f.client.EXPECT().GetObjectAttrs("bucket", gomock.Any()).DoAndReturn(func(bkt, obj string) (*storage.ObjectAttrs, error) {
+		f.mx.Lock()
+		defer f.mx.Unlock()
+		if b, ok := f.data[obj]; ok {
+			return &storage.ObjectAttrs{Size: int64(b.Len()), CRC32C: crc32c(b.String())}, nil
+		}
+		return nil, storage.ErrObjectNotExist
+	}).AnyTimes()
+	f.client.EXPECT().GetObject("bucket", gomock.Any()).DoAndReturn(func(bkt, obj string) domain.StorageObject {
+		o := mocks.NewMockStorageObject(mockCtrl)
+		o.EXPECT().ObjectName().Return(obj).AnyTimes()
+		o.EXPECT().NewWriter().DoAndReturn(func() *testBuffer {
+			f.mx.Lock()
+			defer f.mx.Unlock()
+			f.data[obj] = &bytes.Buffer{}
+			return &testBuffer{f.data[obj]}
+		}).AnyTimes()
+		o.EXPECT().NewReader().DoAndReturn(func() (*testBuffer, error) {
+			f.mx.Lock()
+			defer f.mx.Unlock()
+			return &testBuffer{bytes.NewBuffer(f.data[obj].Bytes())}, nil
+		}).AnyTimes()
+		o.EXPECT().CopyFrom(gomock.Any()).DoAndReturn(func(src domain.StorageObject) (*storage.ObjectAttrs, error) {
+			f.mx.Lock()
+			defer f.mx.Unlock()
+			f.data[obj] = bytes.NewBuffer(f.data[src.ObjectName()].Bytes())
+			return nil, nil
+		}).AnyTimes()
+		o.EXPECT().Delete().DoAndReturn(func() error {
+			f.mx.Lock()
+			defer f.mx.Unlock()
+			delete(f.data, obj)
+			return nil
+		}).AnyTimes()
+		return o
+	}).AnyTimes()
+	return f
+}
+
+func (f *fakeBucket) objects() map[string]string {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	objs := map[string]string{}
+	for k, v := range f.data {
+		objs[k] = v.String()
+	}
+	return objs
+}
+
+func crc32c(content string) uint32 {
+	return crc32.Checksum([]byte(content), crc32.MakeTable(crc32.Castagnoli))
+}
+
+type testBuffer struct {
+	*bytes.Buffer
+}
+
+func (testBuffer) Close() error {
+	return nil
+}
@@ -0,0 +1,92 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"log"
+	"strings"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/paramhelper"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// routerClient is the subset of the GCP API that is used by temporaryNAT.
+type routerClient interface {
+	CreateRouter(project, region string, r *compute.Router) error
+	DeleteRouter(project, region, name string) error
+}
+
+// temporaryNAT is a Cloud Router with an auto-allocated Cloud NAT that lets
+// the import's workers reach the internet without external IPs. It's created
+// once and shared by all of the import's workflows.
+type temporaryNAT struct {
+	client          routerClient
+	project, region string
+	router          *compute.Router
+	created         bool
+}
+
+// newTemporaryNAT returns the NAT to provision for request, or nil if the
+// request doesn't ask for one.
+func newTemporaryNAT(request ImageImportRequest, client routerClient) (*temporaryNAT, error) {
+	if !request.ProvisionNAT || request.Network == "" {
+		return nil, nil
+	}
+	region, err := paramhelper.GetRegion(request.Zone)
+	if err != nil {
+		return nil, err
+	}
+	network := request.Network
+	if !strings.HasPrefix(network, "projects/") && !strings.HasPrefix(network, "https://") {
+		network = "projects/" + request.Project + "/" + network
+	}
+	return &temporaryNAT{
+		client:  client,
+		project: request.Project,
+		region:  region,
+		router: &compute.Router{
+			Name:    "nat-router-" + request.ExecutionID,
+			Network: network,
+			Nats: []*compute.RouterNat{{
+				Name:                          "nat",
+				NatIpAllocateOption:           "AUTO_ONLY",
+				SourceSubnetworkIpRangesToNat: "ALL_SUBNETWORKS_ALL_IP_RANGES",
+			}},
+		},
+	}, nil
+}
+
+func (n *temporaryNAT) create() error {
+	if err := n.client.CreateRouter(n.project, n.region, n.router); err != nil {
+		return daisy.Errf("Failed to create Cloud NAT router %q: %v", n.router.Name, err)
+	}
+	n.created = true
+	return nil
+}
+
+func (n *temporaryNAT) delete() {
+	if !n.created {
+		return
+	}
+	if err := n.client.DeleteRouter(n.project, n.region, n.router.Name); err != nil {
+		gAPIErr, isGAPIErr := err.(*googleapi.Error)
+		if !isGAPIErr || gAPIErr.Code != 404 {
+			log.Printf("Failed to remove temporary Cloud NAT router %q: %v", n.router.Name, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestNewTemporaryNAT_NilWhenNotRequested(t *testing.T) {
+	for _, request := range []ImageImportRequest{
+		{Network: "global/networks/default", Zone: "us-west1-a"},
+		{ProvisionNAT: true, Zone: "us-west1-a"},
+	} {
+		nat, err := newTemporaryNAT(request, &mockRouterClient{})
+		assert.NoError(t, err)
+		assert.Nil(t, nat)
+	}
+}
+
+func TestNewTemporaryNAT_RouterInRegionOfZone(t *testing.T) {
+	for _, tt := range []struct {
+		network, expectedNetwork string
+	}{
+		{"global/networks/default", "projects/project/global/networks/default"},
+		{"projects/host/global/networks/shared", "projects/host/global/networks/shared"},
+	} {
+		t.Run(tt.network, func(t *testing.T) {
+			client := &mockRouterClient{}
+			nat, err := newTemporaryNAT(ImageImportRequest{
+				ExecutionID:  "abc",
+				Project:      "project",
+				Zone:         "us-west1-a",
+				Network:      tt.network,
+				ProvisionNAT: true,
+			}, client)
+			assert.NoError(t, err)
+			assert.NoError(t, nat.create())
+			assert.Equal(t, "project", client.project)
+			assert.Equal(t, "us-west1", client.region)
+			assert.Equal(t, "nat-router-abc", client.created.Name)
+			assert.Equal(t, tt.expectedNetwork, client.created.Network)
+			assert.Len(t, client.created.Nats, 1)
+		})
+	}
+}
+
+func TestTemporaryNAT_DeleteSkippedWhenNotCreated(t *testing.T) {
+	client := &mockRouterClient{createErr: errors.New("quota")}
+	nat, err := newTemporaryNAT(ImageImportRequest{
+		Project: "project", Zone: "us-west1-a", Network: "default", ProvisionNAT: true,
+	}, client)
+	assert.NoError(t, err)
+	assert.Error(t, nat.create())
+	nat.delete()
+	assert.Empty(t, client.deleted)
+}
+
+func TestTemporaryNAT_DeleteIgnoresNotFound(t *testing.T) {
+	client := &mockRouterClient{deleteErr: &googleapi.Error{Code: 404}}
+	nat, err := newTemporaryNAT(ImageImportRequest{
+		Project: "project", Zone: "us-west1-a", Network: "default", ProvisionNAT: true,
+	}, client)
+	assert.NoError(t, err)
+	assert.NoError(t, nat.create())
+	nat.delete()
+	assert.Equal(t, []string{nat.router.Name}, client.deleted)
+}
+
+type mockRouterClient struct {
+	project, region      string
+	created              *compute.Router
+	createCalls          int
+	deleted              []string
+	createErr, deleteErr error
+}
+
+func (m *mockRouterClient) CreateRouter(project, region string, r *compute.Router) error {
+	m.createCalls++
+	m.project = project
+	m.region = region
+	m.created = r
+	return m.createErr
+}
+
+func (m *mockRouterClient) DeleteRouter(project, region, name string) error {
+	m.deleted = append(m.deleted, name)
+	return m.deleteErr
+}
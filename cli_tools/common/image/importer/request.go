@@ -51,6 +51,9 @@ func (args *ImageImportRequest) validate() error {
 		return fmt.Errorf("-%s and -%s can't be both specified",
 			OSFlag, CustomWorkflowFlag)
 	}
+	if IsLocalFile(args.Source) || IsURL(args.Source) {
+		return fmt.Errorf("source file %q has to be uploaded to Cloud Storage before importing it", args.Source.Path())
+	}
 	if !strings.HasSuffix(args.ScratchBucketGcsPath, args.ExecutionID) {
 		return fmt.Errorf("Scratch bucket should have been namespaced with execution ID")
 	}
@@ -94,6 +97,7 @@ type ImageImportRequest struct {
 	BYOL                  bool
 	OS                    string
 	Project               string `name:"project" validate:"required"`
+	ProvisionNAT          bool
 	ScratchBucketGcsPath  string `name:"scratch_bucket_gcs_path" validate:"required"`
 	Source                Source `name:"source" validate:"required"`
 	StdoutLogsDisabled    bool
@@ -139,6 +143,7 @@ func (args ImageImportRequest) EnvironmentSettings() daisycommon.EnvironmentSett
 		Subnet:                args.Subnet,
 		ComputeServiceAccount: args.ComputeServiceAccount,
 		NoExternalIP:          args.NoExternalIP,
+		ProvisionNAT:          args.ProvisionNAT,
 		WorkflowDirectory:     args.WorkflowDir,
 	}
 }
@@ -16,9 +16,14 @@ package importer
 
 import (
 	"compress/gzip"
+	"io"
 	"net/url"
+	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
 	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/param"
@@ -36,6 +41,15 @@ type Source interface {
 	Path() string
 }
 
+// SourceUploader copies a Source that can't be imported directly, such as a
+// local file, to Cloud Storage.
+type SourceUploader interface {
+	// Upload copies source to the bucket of scratchBucketGcsPath, and returns the copy.
+	Upload(source Source, scratchBucketGcsPath string) (Source, error)
+	// CleanUp deletes the copy once the import finished with importErr.
+	CleanUp(uploaded Source, importErr error)
+}
+
 // SourceFactory takes the sourceFile and sourceImage specified by the user
 // and determines which, if any, is importable. It is an error if both sourceFile and
 // sourceImage are specified.
@@ -67,6 +81,12 @@ func (factory sourceFactory) Init(sourceFile, sourceImage string) (Source, error
 	}
 
 	if sourceFile != "" {
+		if isLocalPath(sourceFile) {
+			return newLocalFileSource(sourceFile)
+		}
+		if isURLPath(sourceFile) {
+			return newURLSource(sourceFile)
+		}
 		return newFileSource(sourceFile, factory.storageClient)
 	}
 
@@ -85,6 +105,37 @@ func isImage(s Source) bool {
 	return ok
 }
 
+// IsLocalFile returns whether the resource is a file on the local filesystem,
+// which has to be uploaded with LocalFileUploader before it is imported.
+func IsLocalFile(s Source) bool {
+	_, ok := s.(localFileSource)
+	return ok
+}
+
+// IsURL returns whether the resource is a file served over HTTPS, which
+// has to be uploaded with URLUploader before it is imported.
+func IsURL(s Source) bool {
+	_, ok := s.(urlSource)
+	return ok
+}
+
+// Whether sourceFile refers to a local file rather than a URL. Paths that
+// don't exist aren't local files, so that a mistyped URL such as gs:/bucket/x
+// is reported as an invalid source rather than a missing file.
+func isLocalPath(sourceFile string) bool {
+	if strings.Contains(sourceFile, "://") {
+		return false
+	}
+	_, err := os.Stat(sourceFile)
+	return err == nil
+}
+
+// Whether sourceFile is an HTTP(S) URL.
+func isURLPath(sourceFile string) bool {
+	lowered := strings.ToLower(sourceFile)
+	return strings.HasPrefix(lowered, "https://") || strings.HasPrefix(lowered, "http://")
+}
+
 // An importable source backed by a GCS object.
 type fileSource struct {
 	gcsPath string
@@ -123,10 +174,15 @@ func (s fileSource) validate(storageClient domain.StorageObjectCreatorInterface)
 			"file from bucket %q, file %q: %v", s.bucket, s.object, err)
 	}
 	defer rc.Close()
+	return validateFileContent(rc)
+}
 
-	byteCountingReader := daisycommon.NewByteCountingReader(rc)
+// validateFileContent reads a few bytes from r. It is an error if the file
+// is empty, or if the file is compressed with gzip.
+func validateFileContent(r io.Reader) error {
+	byteCountingReader := daisycommon.NewByteCountingReader(r)
 	// Detect whether it's a compressed file by extracting compressed file header
-	if _, err = gzip.NewReader(byteCountingReader); err == nil {
+	if _, err := gzip.NewReader(byteCountingReader); err == nil {
 		return daisy.Errf("the input file is a gzip file, which is not supported by " +
 			"image import. To import a file that was exported from Google Compute " +
 			"Engine, please use image create. To import a file that was exported " +
@@ -144,6 +200,77 @@ func (s fileSource) validate(storageClient domain.StorageObjectCreatorInterface)
 	return nil
 }
 
+// An importable source backed by a file on the local filesystem. It is
+// uploaded to GCS before the import starts.
+type localFileSource struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Create a localFileSource from the path of a local disk image file. It is an
+// error if the file doesn't exist, is empty, or is compressed with gzip.
+func newLocalFileSource(path string) (Source, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, daisy.Errf("%q is not a valid Cloud Storage object path or local file: %v", path, err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, daisy.Errf("%q is a directory, -source_file has to be a disk image file", path)
+	}
+	if err := validateFileContent(f); err != nil {
+		return nil, err
+	}
+	return localFileSource{path: abs, size: fi.Size(), modTime: fi.ModTime()}, nil
+}
+
+// The resource path for localFileSource is its absolute path.
+func (s localFileSource) Path() string {
+	return s.path
+}
+
+// An importable source backed by a file served over HTTPS. It is uploaded to
+// GCS before the import starts.
+type urlSource struct {
+	url string
+}
+
+// Create a urlSource from the URL of a disk image file. No I/O is performed,
+// the file is validated once it is uploaded.
+func newURLSource(rawURL string) (Source, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, daisy.Errf("%q is not a valid URL: %v", rawURL, err)
+	}
+	if parsed.Scheme != "https" {
+		return nil, daisy.Errf("%q is not an https:// URL. Files can only be downloaded over HTTPS", rawURL)
+	}
+	if parsed.Host == "" || path.Base(parsed.Path) == "/" || path.Base(parsed.Path) == "." {
+		return nil, daisy.Errf("%q is not a valid URL of a disk image file", rawURL)
+	}
+	return urlSource{url: rawURL}, nil
+}
+
+// The resource path for urlSource is its URL.
+func (s urlSource) Path() string {
+	return s.url
+}
+
+// fileName returns the name of the file in the URL.
+func (s urlSource) fileName() string {
+	parsed, _ := url.Parse(s.url)
+	return path.Base(parsed.Path)
+}
+
 // An importable source backed by a GCE disk image.
 type imageSource struct {
 	uri string
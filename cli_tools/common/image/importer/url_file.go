@@ -0,0 +1,89 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/logging"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/storage"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// URLUploader downloads source files served over HTTPS to the scratch
+// directory of the import.
+type URLUploader struct {
+	storageClient domain.StorageClientInterface
+	copier        *storage.URLCopier
+	logger        logging.Logger
+}
+
+// NewURLUploader returns a URLUploader that verifies the downloaded file
+// against checksum, and sends header with the requests. Both are optional,
+// see storage.ValidateChecksum and storage.ParseHTTPHeader for their format.
+func NewURLUploader(ctx context.Context, storageClient domain.StorageClientInterface,
+	checksum, header string, logger logging.Logger) (*URLUploader, error) {
+	copier := storage.NewURLCopier(ctx, storageClient, logger)
+	if checksum != "" {
+		if err := storage.ValidateChecksum(checksum); err != nil {
+			return nil, err
+		}
+		copier.Checksum = checksum
+	}
+	if header != "" {
+		h, err := storage.ParseHTTPHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		copier.Header = h
+	}
+	return &URLUploader{storageClient: storageClient, copier: copier, logger: logger}, nil
+}
+
+// Upload downloads the file to scratchBucketGcsPath and returns the copy.
+func (u *URLUploader) Upload(source Source, scratchBucketGcsPath string) (Source, error) {
+	src, ok := source.(urlSource)
+	if !ok {
+		return nil, daisy.Errf("%q is not a URL", source.Path())
+	}
+	gcsPath := strings.TrimSuffix(scratchBucketGcsPath, "/") + "/source/" + src.fileName()
+	bkt, obj, err := storage.GetGCSObjectPathElements(gcsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	u.logger.User(fmt.Sprintf("Downloading %s to %s.", src.url, gcsPath))
+	start := time.Now()
+	size, err := u.copier.Copy(src.url, bkt, obj)
+	if err != nil {
+		return nil, err
+	}
+	u.logger.User(fmt.Sprintf("Downloaded %s (%s) in %s.", src.url, humanize.IBytes(uint64(size)),
+		time.Since(start).Round(time.Second)))
+	return newFileSource(gcsPath, u.storageClient)
+}
+
+// CleanUp deletes the downloaded file.
+func (u *URLUploader) CleanUp(uploaded Source, importErr error) {
+	if err := u.storageClient.DeleteObject(uploaded.Path()); err != nil {
+		u.logger.User(fmt.Sprintf("Failed to delete %s: %v", uploaded.Path(), err))
+	}
+}
@@ -0,0 +1,73 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLsAreValidated(t *testing.T) {
+	for _, tt := range []struct {
+		url, errMessage string
+	}{
+		{"https://example.com/images/disk.vmdk?token=abc", ""},
+		{"HTTPS://example.com/disk.vmdk", ""},
+		{"http://example.com/disk.vmdk", "Files can only be downloaded over HTTPS"},
+		{"https://example.com/", "is not a valid URL of a disk image file"},
+		{"https:///disk.vmdk", "is not a valid URL of a disk image file"},
+	} {
+		t.Run(tt.url, func(t *testing.T) {
+			source, err := NewSourceFactory(nil).Init(tt.url, "")
+			if tt.errMessage != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMessage)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, IsURL(source))
+			assert.Equal(t, tt.url, source.Path())
+			assert.Equal(t, "disk.vmdk", source.(urlSource).fileName())
+		})
+	}
+}
+
+func TestNewURLUploaderValidatesChecksumAndHeader(t *testing.T) {
+	_, err := NewURLUploader(context.Background(), nil, "sha256:abc", "", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a valid sha256 digest")
+
+	_, err = NewURLUploader(context.Background(), nil, "", "Bearer token", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP header has to be of the form NAME: VALUE")
+}
+
+func TestURLUploaderRejectsOtherSources(t *testing.T) {
+	uploader, err := NewURLUploader(context.Background(), nil, "", "", nil)
+	assert.NoError(t, err)
+	_, err = uploader.Upload(localFileSource{path: "/tmp/disk.vmdk"}, "gs://bucket/gce-image-import-id")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a URL")
+}
+
+func Test_validate_RejectsURLSource(t *testing.T) {
+	request := makeValidRequest()
+	request.Source = urlSource{url: "https://example.com/disk.vmdk"}
+	err := request.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "has to be uploaded to Cloud Storage before importing it")
+}
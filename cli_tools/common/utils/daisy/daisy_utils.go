@@ -310,6 +310,40 @@ func UpdateAllInstanceNoExternalIP(workflow *daisy.Workflow, noExternalIP bool)
 	})
 }
 
+// ProvisionNATStepName is the name of the step added by ProvisionTemporaryNAT.
+const ProvisionNATStepName = "provision-nat"
+
+// ProvisionTemporaryNAT adds a step that creates a Cloud Router with an
+// auto-allocated Cloud NAT on network, in the region of the workflow's zone,
+// and makes every other step depend on it. This lets workers without an
+// external IP reach the internet. The router is deleted with the workflow's
+// other resources when the workflow finishes.
+func ProvisionTemporaryNAT(workflow *daisy.Workflow, network string, provisionNAT bool) {
+	if !provisionNAT || network == "" {
+		return
+	}
+	// NewStep fails if the NAT step was already added.
+	natStep, err := workflow.NewStep(ProvisionNATStepName)
+	if err != nil {
+		return
+	}
+	if workflow.Dependencies == nil {
+		workflow.Dependencies = map[string][]string{}
+	}
+	natStep.CreateRouters = &daisy.CreateRouters{{
+		Router: compute.Router{
+			Name:    "nat-router",
+			Network: network,
+			Nats:    []*compute.RouterNat{{Name: "nat"}},
+		},
+	}}
+	for name := range workflow.Steps {
+		if name != ProvisionNATStepName {
+			workflow.Dependencies[name] = append(workflow.Dependencies[name], ProvisionNATStepName)
+		}
+	}
+}
+
 // UpdateToUEFICompatible marks workflow resources (disks and images) to be UEFI
 // compatible by adding "UEFI_COMPATIBLE" to GuestOSFeatures. Debian workers
 // are excluded until UEFI becomes the default boot method.
@@ -211,6 +211,45 @@ func TestUpdateWorkflowInstancesNotModifiedIfNoNetworkInterfaceElement(t *testin
 	}
 }
 
+func TestProvisionTemporaryNAT(t *testing.T) {
+	w := createWorkflowWithCreateInstanceNetworkAccessConfig()
+	ProvisionTemporaryNAT(w, "global/networks/n", true)
+
+	natStep := w.Steps[ProvisionNATStepName]
+	if natStep == nil || natStep.CreateRouters == nil {
+		t.Fatalf("workflow has no %q step creating a router", ProvisionNATStepName)
+	}
+	router := (*natStep.CreateRouters)[0]
+	if router.Network != "global/networks/n" || len(router.Nats) != 1 {
+		t.Errorf("unexpected router: %+v", router.Router)
+	}
+	if deps := w.Dependencies["ci"]; len(deps) != 1 || deps[0] != ProvisionNATStepName {
+		t.Errorf("step ci should depend on %q, got dependencies %v", ProvisionNATStepName, deps)
+	}
+	if deps := w.Dependencies[ProvisionNATStepName]; len(deps) != 0 {
+		t.Errorf("step %q should have no dependencies, got %v", ProvisionNATStepName, deps)
+	}
+
+	// Adding the NAT twice is a no-op.
+	ProvisionTemporaryNAT(w, "global/networks/n", true)
+	if deps := w.Dependencies["ci"]; len(deps) != 1 {
+		t.Errorf("step ci should still have one dependency, got %v", deps)
+	}
+}
+
+func TestProvisionTemporaryNATNotAddedIfDisabled(t *testing.T) {
+	for _, tt := range []struct {
+		network      string
+		provisionNAT bool
+	}{{"global/networks/n", false}, {"", true}} {
+		w := createWorkflowWithCreateInstanceNetworkAccessConfig()
+		ProvisionTemporaryNAT(w, tt.network, tt.provisionNAT)
+		if _, exists := w.Steps[ProvisionNATStepName]; exists {
+			t.Errorf("network=%q, provisionNAT=%v: NAT step should not be added", tt.network, tt.provisionNAT)
+		}
+	}
+}
+
 func TestRemovePrivacyLogInfoNoPrivacyInfo(t *testing.T) {
 	testRemovePrivacyLogInfo(t,
 		"No privacy info",
@@ -18,11 +18,11 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"math"
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -35,6 +35,10 @@ import (
 
 var gcsPermissionErrorRegExp = regexp.MustCompile(".*does not have storage.objects.create access to .*")
 
+// uploadRetryDelay is multiplied by the number of failures before retrying
+// to upload a chunk.
+var uploadRetryDelay = time.Second
+
 type gcsClient func(ctx context.Context, oauth string) (domain.StorageClientInterface, error)
 
 // BufferedWriter is responsible for multipart component upload while using a local buffer.
@@ -47,9 +51,13 @@ type BufferedWriter struct {
 	client   gcsClient
 	id       string
 	bkt, obj string
+	resume   bool
 
-	upload    chan string
+	upload chan string
+	// tmpObjsMx guards tmpObjs, the uploaded chunks, and uploadErr, the error
+	// of a chunk that failed to upload.
 	tmpObjs   []string
+	uploadErr error
 	tmpObjsMx sync.Mutex
 
 	sync.Mutex
@@ -80,15 +88,64 @@ func NewBufferedWriter(ctx context.Context, size, workers int64, client gcsClien
 	return b
 }
 
+// Resume names the chunks with id instead of a random one, and skips
+// uploading the chunks a previous writer with the same id, size and workers
+// already uploaded with the same CRC32C. The data has to be written in the same sized writes for
+// the chunks to match. Resume has to be called before the first Write.
+func (b *BufferedWriter) Resume(id string) {
+	b.id = id
+	b.resume = true
+}
+
 func (b *BufferedWriter) addObj(obj string) {
 	b.tmpObjsMx.Lock()
 	b.tmpObjs = append(b.tmpObjs, obj)
 	b.tmpObjsMx.Unlock()
 }
 
+func (b *BufferedWriter) setUploadErr(err error) {
+	b.tmpObjsMx.Lock()
+	if b.uploadErr == nil {
+		b.uploadErr = err
+	}
+	b.tmpObjsMx.Unlock()
+}
+
+func (b *BufferedWriter) getUploadErr() error {
+	b.tmpObjsMx.Lock()
+	defer b.tmpObjsMx.Unlock()
+	return b.uploadErr
+}
+
+// isUploaded returns true if tmpObj was uploaded with the content of file.
+func (b *BufferedWriter) isUploaded(client domain.StorageClientInterface, file *os.File, tmpObj string) (bool, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	attrs, err := client.GetObjectAttrs(b.bkt, tmpObj)
+	if err != nil || attrs.Size != fi.Size() {
+		return false, nil
+	}
+	crc, err := CRC32C(file)
+	if err != nil {
+		return false, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	return crc == attrs.CRC32C, nil
+}
+
 func (b *BufferedWriter) uploadWorker() {
 	defer b.Done()
 	for in := range b.upload {
+		// Once a chunk failed, the object can't be composed, so the remaining
+		// chunks are dropped.
+		if b.getUploadErr() != nil {
+			os.Remove(in)
+			continue
+		}
 		for i := 1; ; i++ {
 			err := func() error {
 				client, err := b.client(b.ctx, b.oauth)
@@ -104,14 +161,29 @@ func (b *BufferedWriter) uploadWorker() {
 				defer file.Close()
 
 				tmpObj := path.Join(b.obj, strings.TrimPrefix(in, b.prefix))
-				b.addObj(tmpObj)
+				if b.resume {
+					uploaded, err := b.isUploaded(client, file, tmpObj)
+					if err != nil {
+						return err
+					}
+					if uploaded {
+						b.addObj(tmpObj)
+						return nil
+					}
+				}
 				dst := client.GetObject(b.bkt, tmpObj).NewWriter()
 				if _, err := io.Copy(dst, file); err != nil {
 					if io.EOF != err {
 						return err
 					}
 				}
-				return dst.Close()
+				if err := dst.Close(); err != nil {
+					return err
+				}
+				// The chunk is only added once it's uploaded, so that a retried
+				// chunk isn't composed twice.
+				b.addObj(tmpObj)
+				return nil
 			}()
 			if err != nil {
 				// Don't retry if permission error as it's not recoverable.
@@ -123,11 +195,13 @@ func (b *BufferedWriter) uploadWorker() {
 
 				fmt.Printf("Failed %v time(s) to upload '%v', error: %v\n", i, in, err)
 				if i > 16 {
-					log.Fatal(err)
+					b.setUploadErr(fmt.Errorf("failed to upload '%v': %v", in, err))
+					os.Remove(in)
+					break
 				}
 
-				fmt.Printf("Retrying upload '%v' after %v second(s)...\n", in, i)
-				time.Sleep(time.Duration(1*i) * time.Second)
+				fmt.Printf("Retrying upload '%v' after %v...\n", in, time.Duration(i)*uploadRetryDelay)
+				time.Sleep(time.Duration(i) * uploadRetryDelay)
 				continue
 			}
 			os.Remove(in)
@@ -166,6 +240,9 @@ func (b *BufferedWriter) Close() error {
 	}
 	close(b.upload)
 	b.Wait()
+	if err := b.getUploadErr(); err != nil {
+		return err
+	}
 
 	client, err := b.client(b.ctx, b.oauth)
 	if err != nil {
@@ -173,6 +250,10 @@ func (b *BufferedWriter) Close() error {
 	}
 	defer client.Close()
 
+	// The workers add the chunks in the order they finish uploading them, which
+	// may differ from the order of the parts.
+	sort.SliceStable(b.tmpObjs, func(i, j int) bool { return partNumber(b.tmpObjs[i]) < partNumber(b.tmpObjs[j]) })
+
 	// Compose the object.
 	for i := 0; ; i++ {
 		var objs []domain.StorageObject
@@ -206,11 +287,21 @@ func (b *BufferedWriter) Close() error {
 	return nil
 }
 
+// partNumber returns the number of a chunk object, named <id>_part<number>.
+func partNumber(obj string) int {
+	n, _ := strconv.Atoi(obj[strings.LastIndex(obj, "_part")+len("_part"):])
+	return n
+}
+
 // Write writes the passed in bytes to buffer.
 func (b *BufferedWriter) Write(d []byte) (int, error) {
 	b.Lock()
 	defer b.Unlock()
 
+	if err := b.getUploadErr(); err != nil {
+		return 0, err
+	}
+
 	if b.file == nil {
 		if err := b.newChunk(); err != nil {
 			return 0, err
@@ -19,12 +19,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"os"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/storage"
 	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
 	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/mocks"
 	"github.com/golang/mock/gomock"
@@ -410,6 +412,152 @@ func TestClientErrorWhenUploadFailed(t *testing.T) {
 	assert.Contains(t, string(out), "Cannot create client")
 }
 
+func TestResumeSkipsUploadedChunks(t *testing.T) {
+	resetArgs()
+	var err error
+	prefix, err = ioutil.TempDir("", "buffered-writer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(prefix)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	data := []byte("This is a sample data to write")
+	mockStorageClient = mocks.NewMockStorageClientInterface(mockCtrl)
+	mockStorageClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockStorageClient.EXPECT().GetObjectAttrs(bkt, "obj/id_part0").Return(&storage.ObjectAttrs{Size: int64(len(data)), CRC32C: crc32c(data)}, nil)
+
+	buf := NewBufferedWriter(context.Background(), bufferSize, workerNum, mockGcsClient, oauth, prefix, bkt, obj)
+	buf.Resume("id")
+	_, err = buf.Write(data)
+	assert.Nil(t, err)
+	err = buf.flush()
+	assert.Nil(t, err)
+	time.Sleep(time.Second * 2)
+	assert.Equal(t, []string{"obj/id_part0"}, buf.tmpObjs)
+}
+
+func TestResumeUploadsChunksThatDiffer(t *testing.T) {
+	resetArgs()
+	var err error
+	prefix, err = ioutil.TempDir("", "buffered-writer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(prefix)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	data := []byte("This is a sample data to write")
+	var output bytes.Buffer
+	mockStorageObject := mocks.NewMockStorageObject(mockCtrl)
+	mockStorageObject.EXPECT().NewWriter().Return(testWriteCloser{&output})
+	mockStorageClient = mocks.NewMockStorageClientInterface(mockCtrl)
+	mockStorageClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockStorageClient.EXPECT().GetObjectAttrs(bkt, "obj/id_part0").Return(&storage.ObjectAttrs{Size: int64(len(data)), CRC32C: crc32c(data) + 1}, nil)
+	mockStorageClient.EXPECT().GetObject(bkt, "obj/id_part0").Return(mockStorageObject)
+
+	buf := NewBufferedWriter(context.Background(), bufferSize, workerNum, mockGcsClient, oauth, prefix, bkt, obj)
+	buf.Resume("id")
+	_, err = buf.Write(data)
+	assert.Nil(t, err)
+	assert.Nil(t, buf.flush())
+	close(buf.upload)
+	buf.Wait()
+	assert.Equal(t, []string{"obj/id_part0"}, buf.tmpObjs)
+	assert.Equal(t, string(data), output.String())
+}
+
+func TestRetriedChunkIsComposedOnce(t *testing.T) {
+	resetArgs()
+	uploadRetryDelay = time.Millisecond
+	defer func() { uploadRetryDelay = time.Second }()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStorageObject := mocks.NewMockStorageObject(mockCtrl)
+	gomock.InOrder(
+		mockStorageObject.EXPECT().NewWriter().Return(failingWriteCloser{ioutil.Discard}),
+		mockStorageObject.EXPECT().NewWriter().Return(testWriteCloser{ioutil.Discard}),
+	)
+	mockStorageClient = mocks.NewMockStorageClientInterface(mockCtrl)
+	mockStorageClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockStorageClient.EXPECT().GetObject(bkt, gomock.Any()).Return(mockStorageObject).Times(2)
+
+	buf := NewBufferedWriter(context.Background(), bufferSize, 1, mockGcsClient, oauth, prefix, bkt, obj)
+	_, err := buf.Write([]byte("This is a sample data to write"))
+	assert.Nil(t, err)
+	assert.Nil(t, buf.flush())
+	close(buf.upload)
+	buf.Wait()
+	assert.Equal(t, []string{fmt.Sprintf("obj/%s_part0", buf.id)}, buf.tmpObjs)
+}
+
+func TestCloseReturnsErrorWhenChunkFailsToUpload(t *testing.T) {
+	resetArgs()
+	uploadRetryDelay = time.Millisecond
+	defer func() { uploadRetryDelay = time.Second }()
+
+	buf := NewBufferedWriter(context.Background(), bufferSize, workerNum, mockGcsClientError, oauth, prefix, bkt, obj)
+	data := make([]byte, bufferSize/workerNum)
+	_, err := buf.Write(data)
+	assert.Nil(t, err)
+	_, err = buf.Write(data)
+	assert.Nil(t, err)
+	// Once the chunk failed, writes fail too.
+	for err == nil {
+		time.Sleep(10 * time.Millisecond)
+		_, err = buf.Write(data)
+	}
+	assert.Contains(t, err.Error(), "Cannot create client")
+
+	err = buf.Close()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Cannot create client")
+	assert.Empty(t, buf.tmpObjs)
+}
+
+func TestComposeInPartOrder(t *testing.T) {
+	resetArgs()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var composed []string
+	mockStorageClient = mocks.NewMockStorageClientInterface(mockCtrl)
+	mockStorageClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockStorageClient.EXPECT().GetObject(bkt, gomock.Any()).DoAndReturn(func(bkt, obj string) domain.StorageObject {
+		o := mocks.NewMockStorageObject(mockCtrl)
+		o.EXPECT().Delete().Return(nil).AnyTimes()
+		o.EXPECT().NewWriter().Return(testWriteCloser{ioutil.Discard}).AnyTimes()
+		o.EXPECT().ObjectName().Return(obj).AnyTimes()
+		o.EXPECT().CopyFrom(gomock.Any()).Return(nil, nil).AnyTimes()
+		o.EXPECT().Compose(gomock.Any()).DoAndReturn(func(srcs ...domain.StorageObject) (*storage.ObjectAttrs, error) {
+			for _, src := range srcs {
+				composed = append(composed, src.ObjectName())
+			}
+			return nil, nil
+		}).AnyTimes()
+		return o
+	}).AnyTimes()
+
+	data := []byte("This is a sample data to write")
+	buf := NewBufferedWriter(context.Background(), bufferSize, workerNum, mockGcsClient, oauth, prefix, bkt, obj)
+	for i := 0; i < 3; i++ {
+		_, err := buf.Write(data)
+		assert.Nil(t, err)
+		assert.Nil(t, buf.flush())
+		assert.Nil(t, buf.newChunk())
+	}
+	time.Sleep(time.Second * 2)
+	// Simulate workers finishing out of order.
+	buf.tmpObjs[0], buf.tmpObjs[2] = buf.tmpObjs[2], buf.tmpObjs[0]
+
+	err := buf.Close()
+	assert.Nil(t, err)
+	var expected []string
+	for i := 0; i < 4; i++ {
+		expected = append(expected, fmt.Sprintf("obj/%s_part%d", buf.id, i))
+	}
+	assert.Equal(t, expected, composed)
+}
+
 func resetArgs() {
 	bufferSize = 1 * 1024
 	workerNum = 4
@@ -428,6 +576,19 @@ func mockGcsClient(ctx context.Context, oauth string) (domain.StorageClientInter
 	return mockStorageClient, nil
 }
 
+func crc32c(data []byte) uint32 {
+	return crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+}
+
+// failingWriteCloser fails to close, like a GCS writer whose upload failed.
+type failingWriteCloser struct {
+	io.Writer
+}
+
+func (failingWriteCloser) Close() error {
+	return fmt.Errorf("upload failed")
+}
+
 type testWriteCloser struct {
 	io.Writer
 }
@@ -17,6 +17,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"log"
@@ -281,6 +282,16 @@ func GetBucketNameFromGCSPath(p string) (string, error) {
 	return bucket, err
 }
 
+// CRC32C returns the CRC32C of the data read from r, which GCS reports in the
+// CRC32C of ObjectAttrs.
+func CRC32C(r io.Reader) (uint32, error) {
+	hash := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if _, err := io.Copy(hash, r); err != nil {
+		return 0, err
+	}
+	return hash.Sum32(), nil
+}
+
 // HTTPClient implements domain.HTTPClientInterface which abstracts HTTP functionality used by
 // image import features.
 type HTTPClient struct {
@@ -0,0 +1,288 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/logging"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/daisycommon"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+const (
+	urlCopyReadSize         = 1 << 20
+	urlCopyProgressInterval = 30 * time.Second
+	urlCopyMaxRetries       = 8
+)
+
+var checksumHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// ValidateChecksum returns an error if checksum isn't of the form
+// ALGORITHM:HEX_DIGEST, where ALGORITHM is one of md5, sha1, sha256 or sha512.
+func ValidateChecksum(checksum string) error {
+	_, _, err := parseChecksum(checksum)
+	return err
+}
+
+func parseChecksum(checksum string) (hash.Hash, string, error) {
+	parts := strings.SplitN(checksum, ":", 2)
+	if len(parts) != 2 {
+		return nil, "", daisy.Errf("checksum %q has to be of the form ALGORITHM:HEX_DIGEST, e.g. sha256:9f86d0...", checksum)
+	}
+	newHash, ok := checksumHashes[strings.ToLower(parts[0])]
+	if !ok {
+		return nil, "", daisy.Errf("unsupported checksum algorithm %q, use one of md5, sha1, sha256 or sha512", parts[0])
+	}
+	h := newHash()
+	digest := strings.ToLower(parts[1])
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != h.Size() {
+		return nil, "", daisy.Errf("checksum %q is not a valid %s digest", checksum, parts[0])
+	}
+	return h, digest, nil
+}
+
+// ParseHTTPHeader parses a header of the form "NAME: VALUE", such as
+// "Authorization: Bearer TOKEN".
+func ParseHTTPHeader(header string) (http.Header, error) {
+	parts := strings.SplitN(header, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return nil, daisy.Errf("HTTP header has to be of the form NAME: VALUE")
+	}
+	h := http.Header{}
+	h.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	return h, nil
+}
+
+// URLCopier streams files downloaded over HTTP(S) to Cloud Storage. An
+// interrupted download is resumed with a range request, when the server
+// supports them.
+type URLCopier struct {
+	ctx           context.Context
+	storageClient domain.StorageClientInterface
+	httpClient    *http.Client
+	logger        logging.Logger
+
+	// Header is sent with every request, e.g. for authorization.
+	Header http.Header
+	// Checksum, of the form accepted by ValidateChecksum, is verified once the
+	// file is downloaded. The copy is deleted if it doesn't match. It's required
+	// when the server doesn't report the size of the file, as a truncated
+	// download can't be detected otherwise.
+	Checksum string
+
+	retryDelay time.Duration
+}
+
+// NewURLCopier creates a URLCopier.
+func NewURLCopier(ctx context.Context, storageClient domain.StorageClientInterface, logger logging.Logger) *URLCopier {
+	return &URLCopier{
+		ctx:           ctx,
+		storageClient: storageClient,
+		httpClient:    http.DefaultClient,
+		logger:        logger,
+		retryDelay:    time.Second,
+	}
+}
+
+// Copy downloads url to the object obj of bucket bkt, and returns its size.
+func (c *URLCopier) Copy(url, bkt, obj string) (int64, error) {
+	var h hash.Hash
+	var digest string
+	if c.Checksum != "" {
+		var err error
+		if h, digest, err = parseChecksum(c.Checksum); err != nil {
+			return 0, err
+		}
+	}
+
+	dst := c.storageClient.GetObject(bkt, obj)
+	w := dst.NewWriter()
+	size, err := c.download(url, w, h)
+	if err == nil && h != nil {
+		if actual := hex.EncodeToString(h.Sum(nil)); actual != digest {
+			err = daisy.Errf("checksum of %q is %s, expected %s", url, actual, digest)
+		}
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Don't leave a partial or corrupted copy behind. The object doesn't
+		// exist if the upload failed.
+		_ = dst.Delete()
+		return 0, err
+	}
+	return size, nil
+}
+
+// download writes the content of url to w and h, resuming after errors.
+func (c *URLCopier) download(url string, w io.Writer, h hash.Hash) (int64, error) {
+	if h != nil {
+		w = io.MultiWriter(w, h)
+	}
+	var offset int64
+	total := int64(-1)
+	start := time.Now()
+	lastReport := start
+	buf := make([]byte, urlCopyReadSize)
+	for retry := 0; ; retry++ {
+		body, length, err := c.get(url, offset)
+		if err == nil && total < 0 {
+			total = length
+			if total < 0 && h == nil {
+				body.Close()
+				return 0, daisy.Errf("the server doesn't report the size of %q, "+
+					"so a checksum is required to verify that it's downloaded in full", url)
+			}
+		}
+		if err == nil {
+			r := daisycommon.NewByteCountingReader(body)
+			for {
+				n, readErr := r.Read(buf)
+				if n > 0 {
+					if _, err := w.Write(buf[:n]); err != nil {
+						body.Close()
+						return 0, err
+					}
+				}
+				if time.Since(lastReport) >= urlCopyProgressInterval {
+					c.logProgress(offset+r.BytesRead, total, start)
+					lastReport = time.Now()
+				}
+				if readErr != nil {
+					err = readErr
+					break
+				}
+			}
+			body.Close()
+			offset += r.BytesRead
+			if err == io.EOF {
+				// The checksum verifies the downloads of unknown size.
+				if total < 0 || offset == total {
+					c.logProgress(offset, total, start)
+					return offset, nil
+				}
+				err = io.ErrUnexpectedEOF
+			}
+			if r.BytesRead > 0 {
+				retry = 0
+			}
+		}
+		// Requests fail once the context is done, so retrying doesn't help.
+		if ctxErr := c.ctx.Err(); ctxErr != nil {
+			return 0, daisy.Errf("failed to download %q: %v", url, ctxErr)
+		}
+		if _, ok := err.(*permanentError); ok || retry >= urlCopyMaxRetries {
+			return 0, daisy.Errf("failed to download %q: %v", url, err)
+		}
+		c.logger.User(fmt.Sprintf("Download of %s interrupted after %s: %v. Resuming...",
+			url, humanize.IBytes(uint64(offset)), err))
+		select {
+		case <-c.ctx.Done():
+			return 0, daisy.Errf("failed to download %q: %v", url, c.ctx.Err())
+		case <-time.After(c.retryDelay * time.Duration(retry+1)):
+		}
+	}
+}
+
+// get requests url from offset, and returns the body and the total size of
+// the file, or -1 if the server doesn't report it.
+func (c *URLCopier) get(url string, offset int64) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, &permanentError{err}
+	}
+	for k, vs := range c.Header {
+		req.Header[k] = vs
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case offset == 0 && resp.StatusCode == http.StatusOK:
+		return resp.Body, resp.ContentLength, nil
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		// Content-Range: bytes START-END/TOTAL
+		var start, end int64
+		var total string
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%s", &start, &end, &total); err != nil || start != offset {
+			resp.Body.Close()
+			return nil, 0, &permanentError{fmt.Errorf("unexpected Content-Range %q when resuming from byte %d",
+				resp.Header.Get("Content-Range"), offset)}
+		}
+		size, err := strconv.ParseInt(total, 10, 64)
+		if err != nil {
+			size = -1
+		}
+		return resp.Body, size, nil
+	case offset > 0 && resp.StatusCode == http.StatusOK:
+		resp.Body.Close()
+		return nil, 0, &permanentError{fmt.Errorf("the server doesn't support resuming the download from byte %d", offset)}
+	}
+	resp.Body.Close()
+	err = fmt.Errorf("HTTP status %s", resp.Status)
+	if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return nil, 0, &permanentError{err}
+	}
+	return nil, 0, err
+}
+
+func (c *URLCopier) logProgress(done, total int64, start time.Time) {
+	rate := ""
+	if elapsed := time.Since(start).Seconds(); elapsed > 0 {
+		rate = fmt.Sprintf(" at %s/s", humanize.IBytes(uint64(float64(done)/elapsed)))
+	}
+	if total <= 0 {
+		c.logger.User(fmt.Sprintf("Downloaded %s%s.", humanize.IBytes(uint64(done)), rate))
+		return
+	}
+	c.logger.User(fmt.Sprintf("Downloaded %s of %s (%d%%)%s.", humanize.IBytes(uint64(done)),
+		humanize.IBytes(uint64(total)), done*100/total, rate))
+}
+
+// permanentError is an error that retrying the request doesn't fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
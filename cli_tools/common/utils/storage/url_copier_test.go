@@ -0,0 +1,257 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/mocks"
+)
+
+const urlCopyContent = "This is the content of a disk image"
+
+func TestURLCopierCopiesFile(t *testing.T) {
+	var ranges []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "disk.vmdk", time.Time{}, strings.NewReader(urlCopyContent))
+	}))
+	defer server.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var output bytes.Buffer
+	copier := newTestURLCopier(mockCtrl, server, &output, false)
+	copier.Header, _ = ParseHTTPHeader("Authorization: Bearer token")
+	copier.Checksum = "sha256:" + sha256Hex(urlCopyContent)
+	size, err := copier.Copy(server.URL+"/disk.vmdk", "bkt", "obj")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(urlCopyContent)), size)
+	assert.Equal(t, urlCopyContent, output.String())
+	assert.Equal(t, []string{""}, ranges)
+}
+
+func TestURLCopierResumesInterruptedDownload(t *testing.T) {
+	var ranges []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// Announce the whole file, and close the connection after a part of it.
+			w.Header().Set("Content-Length", strconv.Itoa(len(urlCopyContent)))
+			w.Write([]byte(urlCopyContent[:10]))
+			return
+		}
+		http.ServeContent(w, r, "disk.vmdk", time.Time{}, strings.NewReader(urlCopyContent))
+	}))
+	defer server.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var output bytes.Buffer
+	copier := newTestURLCopier(mockCtrl, server, &output, false)
+	copier.Checksum = "sha256:" + sha256Hex(urlCopyContent)
+	size, err := copier.Copy(server.URL+"/disk.vmdk", "bkt", "obj")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(urlCopyContent)), size)
+	assert.Equal(t, urlCopyContent, output.String())
+	assert.Equal(t, []string{"", "bytes=10-"}, ranges)
+}
+
+func TestURLCopierFailsWhenServerDoesNotSupportRanges(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Length", strconv.Itoa(len(urlCopyContent)))
+		if requests == 1 {
+			w.Write([]byte(urlCopyContent[:10]))
+			return
+		}
+		w.Write([]byte(urlCopyContent))
+	}))
+	defer server.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var output bytes.Buffer
+	copier := newTestURLCopier(mockCtrl, server, &output, true)
+	_, err := copier.Copy(server.URL+"/disk.vmdk", "bkt", "obj")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't support resuming the download from byte 10")
+	assert.Equal(t, 2, requests)
+}
+
+func TestURLCopierDeletesCopyWhenChecksumDiffers(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(urlCopyContent))
+	}))
+	defer server.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var output bytes.Buffer
+	copier := newTestURLCopier(mockCtrl, server, &output, true)
+	copier.Checksum = "sha256:" + sha256Hex("other content")
+	_, err := copier.Copy(server.URL+"/disk.vmdk", "bkt", "obj")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expected "+sha256Hex("other content"))
+}
+
+func TestURLCopierDoesNotRetryClientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var output bytes.Buffer
+	copier := newTestURLCopier(mockCtrl, server, &output, true)
+	_, err := copier.Copy(server.URL+"/disk.vmdk", "bkt", "obj")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "403 Forbidden")
+	assert.Equal(t, 1, requests)
+}
+
+func TestURLCopierRequiresChecksumWhenSizeIsUnknown(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(streamWithoutLength))
+	defer server.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var output bytes.Buffer
+	copier := newTestURLCopier(mockCtrl, server, &output, true)
+	_, err := copier.Copy(server.URL+"/disk.vmdk", "bkt", "obj")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "a checksum is required")
+	assert.Empty(t, output.String())
+}
+
+func TestURLCopierCopiesFileOfUnknownSizeWithChecksum(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(streamWithoutLength))
+	defer server.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var output bytes.Buffer
+	copier := newTestURLCopier(mockCtrl, server, &output, false)
+	copier.Checksum = "sha256:" + sha256Hex(urlCopyContent)
+	size, err := copier.Copy(server.URL+"/disk.vmdk", "bkt", "obj")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(urlCopyContent)), size)
+	assert.Equal(t, urlCopyContent, output.String())
+}
+
+func TestURLCopierDoesNotRetryWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	var output bytes.Buffer
+	copier := newTestURLCopier(mockCtrl, server, &output, true)
+	copier.ctx = ctx
+	_, err := copier.Copy(server.URL+"/disk.vmdk", "bkt", "obj")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), context.Canceled.Error())
+	assert.Equal(t, 1, requests)
+}
+
+func TestValidateChecksum(t *testing.T) {
+	for _, tt := range []struct {
+		checksum, errMessage string
+	}{
+		{"sha256:" + sha256Hex(""), ""},
+		{"SHA256:" + strings.ToUpper(sha256Hex("")), ""},
+		{"md5:d41d8cd98f00b204e9800998ecf8427e", ""},
+		{sha256Hex(""), "has to be of the form ALGORITHM:HEX_DIGEST"},
+		{"crc32:00000000", "unsupported checksum algorithm"},
+		{"sha1:d41d8cd98f00b204e9800998ecf8427e", "is not a valid sha1 digest"},
+		{"sha256:not-hex", "is not a valid sha256 digest"},
+	} {
+		t.Run(tt.checksum, func(t *testing.T) {
+			err := ValidateChecksum(tt.checksum)
+			if tt.errMessage == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMessage)
+			}
+		})
+	}
+}
+
+func TestParseHTTPHeader(t *testing.T) {
+	h, err := ParseHTTPHeader("authorization:  Bearer a:b ")
+	assert.NoError(t, err)
+	assert.Equal(t, http.Header{"Authorization": {"Bearer a:b"}}, h)
+
+	for _, invalid := range []string{"Authorization", ": value"} {
+		_, err := ParseHTTPHeader(invalid)
+		assert.Error(t, err)
+	}
+}
+
+// newTestURLCopier creates a URLCopier for server, that writes the copy to
+// output. expectDelete is whether the copy is expected to be deleted.
+func newTestURLCopier(mockCtrl *gomock.Controller, server *httptest.Server, output *bytes.Buffer, expectDelete bool) *URLCopier {
+	mockStorageObject := mocks.NewMockStorageObject(mockCtrl)
+	mockStorageObject.EXPECT().NewWriter().Return(testWriteCloser{output})
+	if expectDelete {
+		mockStorageObject.EXPECT().Delete().Return(nil)
+	}
+	mockStorageClient := mocks.NewMockStorageClientInterface(mockCtrl)
+	mockStorageClient.EXPECT().GetObject("bkt", "obj").Return(mockStorageObject)
+	mockLogger := mocks.NewMockLogger(mockCtrl)
+	mockLogger.EXPECT().User(gomock.Any()).AnyTimes()
+
+	copier := NewURLCopier(context.Background(), mockStorageClient, mockLogger)
+	copier.httpClient = server.Client()
+	copier.retryDelay = time.Millisecond
+	return copier
+}
+
+// streamWithoutLength serves urlCopyContent without a Content-Length.
+func streamWithoutLength(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(urlCopyContent[:10]))
+	w.(http.Flusher).Flush()
+	w.Write([]byte(urlCopyContent[10:]))
+}
+
+func sha256Hex(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
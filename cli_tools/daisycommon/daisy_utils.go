@@ -70,6 +70,9 @@ type EnvironmentSettings struct {
 	Network, Subnet       string
 	ComputeServiceAccount string
 	NoExternalIP          bool
+
+	// Create a temporary Cloud NAT on Network for workers without external IPs.
+	ProvisionNAT bool
 }
 
 // ApplyWorkerCustomizations sets variables on daisy.Workflow that
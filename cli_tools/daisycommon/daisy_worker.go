@@ -75,7 +75,7 @@ func (w *defaultDaisyWorker) Cancel(reason string) bool {
 }
 
 func (w *defaultDaisyWorker) preValidateFunction(wf *daisy.Workflow) {
-	// no-op
+	daisy_utils.ProvisionTemporaryNAT(wf, w.env.Network, w.env.ProvisionNAT)
 }
 
 func (w *defaultDaisyWorker) postValidateFunction(wf *daisy.Workflow) {
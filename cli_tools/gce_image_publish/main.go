@@ -29,6 +29,8 @@ import (
 
 	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/gce_image_publish/publish"
 	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy/vars"
 	computeAlpha "google.golang.org/api/compute/v0.alpha"
 )
 
@@ -50,11 +52,15 @@ var (
 	ce             = flag.String("compute_endpoint_override", "", "API endpoint to override default, will override ComputeEndpoint in template")
 	filter         = flag.String("filter", "", "regular expression to filter images to publish by prefixes")
 	rolloutRate    = flag.Int("rollout_rate", 60, "The number of minutes between the image rolling out between zones. 0 minutes will not use a rollout policy.")
+	maxOperations  = flag.Int("max_concurrent_operations", 0, "maximum number of Compute operations in flight across all workflows, 0 for no limit")
+	apiRPS         = flag.Float64("api_rps", 0, "maximum Compute API requests per second for each API method across all workflows, 0 for no limit")
+	apiMethodRPS   = flag.String("api_method_rps", "", "comma separated per API method overrides of -api_rps, in the form 'images.insert=2'")
+	varFlags       = vars.RegisterFlags(flag.CommandLine)
 )
 
 const (
 	flgDefValue   = "flag generated for workflow variable"
-	varFlagPrefix = "var:"
+	varFlagPrefix = vars.FlagPrefix
 )
 
 func addFlags(args []string) {
@@ -105,12 +111,20 @@ func main() {
 	addFlags(os.Args[1:])
 	flag.Parse()
 
-	varMap := map[string]string{}
-	flag.Visit(func(flg *flag.Flag) {
-		if strings.HasPrefix(flg.Name, varFlagPrefix) {
-			varMap[strings.TrimPrefix(flg.Name, varFlagPrefix)] = flg.Value.String()
-		}
-	})
+	vs, err := varFlags.Load(os.Environ())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	vs.AddFlags(flag.CommandLine)
+	if *print && len(vs) > 0 {
+		fmt.Println("[Publish] Workflow variables and where they were set:")
+		vs.Report(os.Stdout)
+	}
+	// Variables from the environment are only used by the publish
+	// templates, the publish workflows don't declare any variables.
+	varMap := vs.Map()
+	wfVarMap := vs.MapFor(func(string) bool { return false })
 
 	if *rolloutRate < 0 {
 		fmt.Println("-rollout_rate cannot be less than 0.")
@@ -136,6 +150,16 @@ func main() {
 		}
 	}
 
+	var limiter *daisyCompute.Limiter
+	if *maxOperations > 0 || *apiRPS > 0 || *apiMethodRPS != "" {
+		methodRPS, err := daisyCompute.ParseMethodRPS(*apiMethodRPS)
+		if err != nil {
+			fmt.Println("-api_method_rps flag not valid:", err)
+			os.Exit(1)
+		}
+		limiter = daisyCompute.NewLimiter(*maxOperations, *apiRPS, methodRPS)
+	}
+
 	ctx := context.Background()
 
 	var errs []error
@@ -150,7 +174,8 @@ func main() {
 			errs = append(errs, loadErr)
 			continue
 		}
-		w, err := p.CreateWorkflows(ctx, varMap, regex, *rollback, *skipDup, *replace, *noRoot, *oauth, time.Now(), *rolloutRate)
+		p.ComputeLimiter = limiter
+		w, err := p.CreateWorkflows(ctx, wfVarMap, regex, *rollback, *skipDup, *replace, *noRoot, *oauth, time.Now(), *rolloutRate)
 		if err != nil {
 			createWorkflowErr := fmt.Errorf("Workflow creation error: %s", err)
 			fmt.Println(createWorkflowErr)
@@ -52,6 +52,9 @@ type Publish struct {
 	PublishProject string `json:",omitempty"`
 	// Optional compute endpoint override
 	ComputeEndpoint string `json:",omitempty"`
+	// Optional limiter for the compute API calls of all workflows created
+	// from this publish, may be shared between publishes.
+	ComputeLimiter *daisyCompute.Limiter `json:"-"`
 	// Optional period of time to keep images, any images with an create time
 	// older than this period will be deleted.
 	// Format consists of 2 sections, the first must parsable by
@@ -548,6 +551,8 @@ func (p *Publish) createWorkflow(ctx context.Context, img *Image, varMap map[str
 	for k, v := range varMap {
 		w.AddVar(k, v)
 	}
+	w.AddVar("source_version", p.sourceVersion)
+	w.AddVar("publish_version", p.publishVersion)
 
 	if oauth != "" {
 		w.OAuthPath = oauth
@@ -556,6 +561,7 @@ func (p *Publish) createWorkflow(ctx context.Context, img *Image, varMap map[str
 	if p.ComputeEndpoint != "" {
 		w.ComputeEndpoint = p.ComputeEndpoint
 	}
+	w.ComputeLimiter = p.ComputeLimiter
 
 	if err := w.PopulateClients(ctx); err != nil {
 		return nil, fmt.Errorf("PopulateClients failed: %s", err)
@@ -16,6 +16,7 @@ package importer
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -34,6 +35,7 @@ type awsImportArguments struct {
 	executablePath     string
 	exportLocation     string
 	sourceFilePath     string
+	snapshotID         string
 	gcsComputeEndpoint string
 	gcsProjectPtr      *string
 	gcsZone            string
@@ -41,6 +43,8 @@ type awsImportArguments struct {
 	gcsScratchBucket   string
 	gcsStorageLocation string
 	region             string
+	s3Endpoint         string
+	s3ForcePathStyle   bool
 	secretAccessKey    string
 	sessionToken       string
 
@@ -48,6 +52,7 @@ type awsImportArguments struct {
 	exportBucket   string
 	exportFolder   string
 	exportKey      string
+	exportETag     string
 	exportFileSize int64
 }
 
@@ -60,11 +65,19 @@ const (
 	awsSessionTokenFlag      = "aws_session_token"
 	awsRegionFlag            = "aws_region"
 	awsSourceAMIFilePathFlag = "aws_source_ami_file_path"
+	awsS3EndpointFlag        = "aws_s3_endpoint"
+	awsS3ForcePathStyleFlag  = "aws_s3_force_path_style"
+	awsSnapshotIDFlag        = "aws_snapshot_id"
 )
 
+// defaultS3CompatibleRegion is the signing region used for an S3-compatible
+// endpoint when -aws_region isn't specified.
+const defaultS3CompatibleRegion = "us-east-1"
+
 var (
 	bucketNameRegex = `[a-z0-9][-_.a-z0-9]*`
 	s3PathRegex     = regexp.MustCompile(fmt.Sprintf(`^s3://(%s)(\/.*)?$`, bucketNameRegex))
+	snapshotIDRegex = regexp.MustCompile(`^snap-[0-9a-f]+$`)
 )
 
 // newAWSImportArguments creates a new AWSImportArgument instance.
@@ -76,6 +89,7 @@ func newAWSImportArguments(args *OneStepImportArguments) *awsImportArguments {
 		executablePath:     args.ExecutablePath,
 		exportLocation:     args.AWSAMIExportLocation,
 		sourceFilePath:     args.AWSSourceAMIFilePath,
+		snapshotID:         args.AWSSnapshotID,
 		gcsComputeEndpoint: args.ComputeEndpoint,
 		gcsProjectPtr:      args.ProjectPtr,
 		gcsZone:            args.Zone,
@@ -83,11 +97,20 @@ func newAWSImportArguments(args *OneStepImportArguments) *awsImportArguments {
 		gcsScratchBucket:   args.ScratchBucketGcsPath,
 		gcsStorageLocation: args.StorageLocation,
 		region:             args.AWSRegion,
+		s3Endpoint:         args.AWSS3Endpoint,
+		s3ForcePathStyle:   args.AWSS3ForcePathStyle,
 		secretAccessKey:    args.AWSSecretAccessKey,
 		sessionToken:       args.AWSSessionToken,
 	}
 }
 
+// isAWSImport returns true if any of the AWS flags is specified.
+func isAWSImport(args *OneStepImportArguments) bool {
+	return args.AWSAccessKeyID != "" || args.AWSSecretAccessKey != "" || args.AWSSessionToken != "" ||
+		args.AWSRegion != "" || args.AWSAMIID != "" || args.AWSAMIExportLocation != "" ||
+		args.AWSSourceAMIFilePath != "" || args.AWSSnapshotID != "" || args.AWSS3Endpoint != "" || args.AWSS3ForcePathStyle
+}
+
 // ValidateAndPopulate validates args related to import from AWS, and populates
 // any missing parameters.
 func (args *awsImportArguments) validateAndPopulate(populator param.Populator) error {
@@ -102,6 +125,9 @@ func (args *awsImportArguments) validateAndPopulate(populator param.Populator) e
 		return err
 	}
 
+	if args.isSnapshotImport() {
+		return nil
+	}
 	return args.generateS3PathElements()
 }
 
@@ -112,6 +138,9 @@ func (args *awsImportArguments) validate() error {
 	if err := validation.ValidateStringFlagNotEmpty(args.secretAccessKey, awsSecretAccessKeyFlag); err != nil {
 		return err
 	}
+	if args.s3Endpoint != "" {
+		return args.validateS3CompatibleEndpoint()
+	}
 	if err := validation.ValidateStringFlagNotEmpty(args.region, awsRegionFlag); err != nil {
 		return err
 	}
@@ -119,21 +148,49 @@ func (args *awsImportArguments) validate() error {
 		return err
 	}
 
-	needsExport := args.amiID != "" && args.exportLocation != "" && args.sourceFilePath == ""
-	isResumeExported := args.amiID == "" && args.exportLocation == "" && args.sourceFilePath != ""
+	needsExport := args.amiID != "" && args.exportLocation != "" && args.sourceFilePath == "" && args.snapshotID == ""
+	isResumeExported := args.amiID == "" && args.exportLocation == "" && args.sourceFilePath != "" && args.snapshotID == ""
+	isSnapshot := args.amiID == "" && args.exportLocation == "" && args.sourceFilePath == "" && args.snapshotID != ""
 
-	if !(needsExport || isResumeExported) {
+	if !(needsExport || isResumeExported || isSnapshot) {
 		return daisy.Errf("specify -%v to import from "+
-			"exported image file, or both -%v and -%v to "+
-			"import from AMI", awsSourceAMIFilePathFlag, awsAMIIDFlag, awsAMIExportLocationFlag)
+			"exported image file, -%v to import from EBS snapshot, or both -%v and -%v to "+
+			"import from AMI", awsSourceAMIFilePathFlag, awsSnapshotIDFlag, awsAMIIDFlag, awsAMIExportLocationFlag)
+	}
+	if isSnapshot && !snapshotIDRegex.MatchString(args.snapshotID) {
+		return daisy.Errf("%v is not a valid EBS snapshot ID", args.snapshotID)
 	}
 
 	return nil
 }
 
+// validateS3CompatibleEndpoint validates args for import from an S3-compatible
+// store, such as MinIO or Ceph. The AMI can't be exported there, so only an
+// exported image file can be imported, and the session token is optional.
+func (args *awsImportArguments) validateS3CompatibleEndpoint() error {
+	u, err := url.Parse(args.s3Endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return daisy.Errf("-%v has to be an http:// or https:// URL, got %v", awsS3EndpointFlag, args.s3Endpoint)
+	}
+	if args.amiID != "" || args.exportLocation != "" || args.snapshotID != "" || args.sourceFilePath == "" {
+		return daisy.Errf("specify -%v to import from an exported image file; "+
+			"-%v, -%v and -%v can't be used with -%v", awsSourceAMIFilePathFlag,
+			awsAMIIDFlag, awsAMIExportLocationFlag, awsSnapshotIDFlag, awsS3EndpointFlag)
+	}
+	if args.region == "" {
+		args.region = defaultS3CompatibleRegion
+	}
+	return nil
+}
+
 // isExportRequired returns true if AMI needs to be exported, false otherwise.
 func (args *awsImportArguments) isExportRequired() bool {
-	return args.sourceFilePath == ""
+	return args.sourceFilePath == "" && args.snapshotID == ""
+}
+
+// isSnapshotImport returns true if the EBS snapshot is read directly.
+func (args *awsImportArguments) isSnapshotImport() bool {
+	return args.snapshotID != ""
 }
 
 // generateS3PathElements gets bucket name, and folder or object key depending on if
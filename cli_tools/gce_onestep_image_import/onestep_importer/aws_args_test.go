@@ -21,7 +21,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-const exportFlagErrorMsg = "specify -aws_source_ami_file_path to import from exported image file, or both -aws_ami_id and -aws_ami_export_location to import from AMI"
+const exportFlagErrorMsg = "specify -aws_source_ami_file_path to import from exported image file, -aws_snapshot_id to import from EBS snapshot, or both -aws_ami_id and -aws_ami_export_location to import from AMI"
 
 func TestSplitS3PathObjectInFolder(t *testing.T) {
 	bucket, object, err := splitS3Path("s3://bucket_name/folder_name/object_name")
@@ -195,6 +195,29 @@ func TestFailWhenAllExportFlagsProvided(t *testing.T) {
 	assert.EqualError(t, expectFailedAWSValidation(t, args), exportFlagErrorMsg)
 }
 
+func TestValidateSnapshotImport(t *testing.T) {
+	args := setUpAWSArgs(awsSourceAMIFilePathFlag, false, "-aws_snapshot_id=snap-0123456789abcdef0")
+	awsArgs := getAWSImportArgs(args)
+	assert.NoError(t, awsArgs.validateAndPopulate(mockPopulator{}))
+	assert.True(t, awsArgs.isSnapshotImport())
+	assert.False(t, awsArgs.isExportRequired())
+}
+
+func TestFailWhenSnapshotIDInvalid(t *testing.T) {
+	args := setUpAWSArgs(awsSourceAMIFilePathFlag, false, "-aws_snapshot_id=vol-0123456789abcdef0")
+	assert.EqualError(t, expectFailedAWSValidation(t, args), "vol-0123456789abcdef0 is not a valid EBS snapshot ID")
+}
+
+func TestFailWhenSnapshotIDAndSourceFileProvided(t *testing.T) {
+	args := setUpAWSArgs("", false, "-aws_snapshot_id=snap-0123456789abcdef0")
+	assert.EqualError(t, expectFailedAWSValidation(t, args), exportFlagErrorMsg)
+}
+
+func TestFailWhenSnapshotIDAndAMIProvided(t *testing.T) {
+	args := setUpAWSArgs("", true, "-aws_snapshot_id=snap-0123456789abcdef0")
+	assert.EqualError(t, expectFailedAWSValidation(t, args), exportFlagErrorMsg)
+}
+
 func expectFailedAWSValidation(t *testing.T, args []string) error {
 	importArgs, err := NewOneStepImportArguments(args)
 	assert.NoError(t, err)
@@ -204,3 +227,46 @@ func expectFailedAWSValidation(t *testing.T, args []string) error {
 	assert.Error(t, err)
 	return err
 }
+
+func TestValidateS3CompatibleEndpoint(t *testing.T) {
+	endpointArgs := []string{"-aws_access_key_id=my-access-key", "-aws_secret_access_key=my-secret-key",
+		"-aws_s3_endpoint=http://127.0.0.1:9000"}
+	endpointErrorMsg := "specify -aws_source_ami_file_path to import from an exported image file; " +
+		"-aws_ami_id, -aws_ami_export_location and -aws_snapshot_id can't be used with -aws_s3_endpoint"
+
+	for _, tt := range []struct {
+		name       string
+		args       []string
+		errMessage string
+	}{
+		{"exported file", append([]string{"-aws_source_ami_file_path=s3://bucket/object"}, endpointArgs...), ""},
+		{"session token and region", append([]string{"-aws_source_ami_file_path=s3://bucket/object",
+			"-aws_session_token=my-token", "-aws_region=my-region"}, endpointArgs...), ""},
+		{"export AMI", append([]string{"-aws_ami_id=my-ami-id", "-aws_ami_export_location=s3://bucket"},
+			endpointArgs...), endpointErrorMsg},
+		{"no source", endpointArgs[:3:3], endpointErrorMsg},
+		{"invalid endpoint", []string{"-aws_access_key_id=my-access-key", "-aws_secret_access_key=my-secret-key",
+			"-aws_s3_endpoint=127.0.0.1:9000", "-aws_source_ami_file_path=s3://bucket/object"},
+			"-aws_s3_endpoint has to be an http:// or https:// URL, got 127.0.0.1:9000"},
+		{"no access key", append([]string{"-aws_source_ami_file_path=s3://bucket/object"}, endpointArgs[1:3:3]...),
+			"The flag -aws_access_key_id must be provided"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			awsArgs := getAWSImportArgs(setUpArgs("", tt.args...))
+			err := awsArgs.validate()
+			if tt.errMessage == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.errMessage)
+			}
+		})
+	}
+}
+
+func TestValidateS3CompatibleEndpointDefaultsRegion(t *testing.T) {
+	awsArgs := getAWSImportArgs(setUpArgs("", "-aws_access_key_id=my-access-key",
+		"-aws_secret_access_key=my-secret-key", "-aws_s3_endpoint=https://s3.wasabisys.com",
+		"-aws_source_ami_file_path=s3://bucket/object"))
+	assert.NoError(t, awsArgs.validate())
+	assert.Equal(t, "us-east-1", awsArgs.region)
+}
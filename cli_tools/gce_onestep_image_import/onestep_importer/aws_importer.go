@@ -20,9 +20,6 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
-	"runtime"
 	"time"
 
 	"cloud.google.com/go/storage"
@@ -36,11 +33,12 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ebs"
+	"github.com/aws/aws-sdk-go/service/ebs/ebsiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
-	"github.com/dustin/go-humanize"
 	"google.golang.org/api/option"
 	htransport "google.golang.org/api/transport/http"
 )
@@ -60,19 +58,19 @@ type awsImporter struct {
 	oauth          string
 	paramPopulator param.Populator
 	timeoutChan    chan struct{}
-	uploader       *uploader
 
 	// AWS clients for SDK
 	ec2Client ec2iface.EC2API
 	s3Client  s3iface.S3API
+	ebsClient ebsiface.EBSAPI
 
 	// Impl of the functions
 	exportAWSImageFn            func() error
 	monitorAWSExportImageTaskFn func() error
 	getAWSFileSizeFn            func() error
 	copyFromS3ToGCSFn           func() (string, error)
+	copyFromEBSToGCSFn          func() (string, error)
 	transferFileFn              func() error
-	getUploaderFn               func() *uploader
 	importImageFn               func() error
 	cleanUpFn                   func()
 }
@@ -107,8 +105,9 @@ func newAWSImporter(oauth string, timeoutChan chan struct{}, args *awsImportArgu
 	importer := &awsImporter{
 		args:           args,
 		gcsClient:      client,
-		s3Client:       s3.New(awsSession),
+		s3Client:       createS3Client(awsSession, args),
 		ec2Client:      ec2.New(awsSession),
+		ebsClient:      ebs.New(awsSession),
 		ctx:            ctx,
 		oauth:          oauth,
 		paramPopulator: paramPopulator,
@@ -161,6 +160,16 @@ func createAWSSession(region, accessKeyID, secretAccessKey, sessionToken string)
 	return session, nil
 }
 
+// createS3Client creates a new S3 client. The client talks to the S3-compatible
+// endpoint in args if there is one, and to AWS S3 otherwise.
+func createS3Client(awsSession *session.Session, args *awsImportArguments) s3iface.S3API {
+	config := aws.NewConfig().WithS3ForcePathStyle(args.s3ForcePathStyle)
+	if args.s3Endpoint != "" {
+		config = config.WithEndpoint(args.s3Endpoint)
+	}
+	return s3.New(awsSession, config)
+}
+
 // run runs the aws importer to import AMI.
 func (importer *awsImporter) run(importArgs *OneStepImportArguments) error {
 	needsExport := importer.args.isExportRequired()
@@ -171,7 +180,8 @@ func (importer *awsImporter) run(importArgs *OneStepImportArguments) error {
 		return err
 	}
 
-	// 2. export AMI to AWS S3 if user did not specify an exported AMI path.
+	// 2. export AMI to AWS S3 if user did not specify an exported AMI path
+	// or a snapshot.
 	if needsExport {
 		log.Println("Starting to export image ...")
 		err = importer.exportAWSImage()
@@ -179,13 +189,19 @@ func (importer *awsImporter) run(importArgs *OneStepImportArguments) error {
 			return err
 		}
 	}
-	if err := importer.getAWSFileSize(); err != nil {
-		return err
-	}
 
-	// 3. copy from S3 to GCS
-	log.Println("Starting to copy ...")
-	gcsFilePath, err := importer.copyFromS3ToGCS()
+	// 3. copy from S3, or from the EBS snapshot, to GCS
+	var gcsFilePath string
+	if importer.args.isSnapshotImport() {
+		log.Println("Starting to copy snapshot ...")
+		gcsFilePath, err = importer.copyFromEBSToGCS()
+	} else {
+		if err := importer.getAWSFileSize(); err != nil {
+			return err
+		}
+		log.Println("Starting to copy ...")
+		gcsFilePath, err = importer.copyFromS3ToGCS()
+	}
 	if err != nil {
 		return err
 	}
@@ -287,6 +303,7 @@ func (importer *awsImporter) getAWSFileSize() error {
 	}
 
 	importer.args.exportFileSize = fileSize
+	importer.args.exportETag = aws.StringValue(resp.ETag)
 	return nil
 }
 
@@ -397,35 +414,20 @@ func (importer *awsImporter) copyFromS3ToGCS() (string, error) {
 	}
 
 	start := time.Now()
-	// 1. get GCS path as copy destination.
+	// 1. get GCS path as copy destination. The path only depends on the S3 file,
+	// so that a rerun resumes a copy that failed.
 	gcsFilePath := pathutils.JoinURL(importer.args.gcsScratchBucket,
-		fmt.Sprintf("onestep-image-import-aws-%v.vmdk", pathutils.RandString(5)))
+		transferObjectName(fmt.Sprintf("s3://%v/%v", importer.args.exportBucket, importer.args.exportKey),
+			importer.args.exportETag, importer.args.exportFileSize, ".vmdk"))
 
 	log.Printf("Copying %v to %v.\n", importer.args.sourceFilePath, gcsFilePath)
-
-	// 2. create a new folder for local buffer
-	path := filepath.Join(filepath.Dir(importer.args.executablePath), fmt.Sprint("upload", pathutils.RandString(5)))
-
-	err := os.Mkdir(path, 0755)
-	if err != nil {
-		return "", daisy.ToDError(err)
-	}
-	defer os.RemoveAll(path)
-
-	// 3. get writer
-	bs, err := humanize.ParseBytes(uploadBufSize)
-	if err != nil {
-		return "", daisy.ToDError(err)
-	}
 	bkt, obj, err := storageutils.GetGCSObjectPathElements(gcsFilePath)
 	if err != nil {
 		return "", err
 	}
-	workers := int64(runtime.NumCPU())
-	writer := storageutils.NewBufferedWriter(importer.ctx, int64(bs), workers, createGCSClient, importer.oauth, path, bkt, obj)
 
-	// 4. Transfer file from S3 to GCS
-	if err := importer.transferFile(writer); err != nil {
+	// 2. Transfer file from S3 to GCS
+	if err := importer.transferFile(bkt, obj); err != nil {
 		return gcsFilePath, err
 	}
 	log.Printf("Successfully copied to %v in %v.\n", gcsFilePath, time.Since(start))
@@ -433,89 +435,49 @@ func (importer *awsImporter) copyFromS3ToGCS() (string, error) {
 	return gcsFilePath, nil
 }
 
-func (importer *awsImporter) getUploader(writer io.WriteCloser) *uploader {
-	if importer.getUploaderFn != nil {
-		return importer.getUploaderFn()
-	}
-
-	return &uploader{
-		readerChan:    make(chan io.ReadCloser, downloadBufNum),
-		writer:        writer,
-		totalUploaded: 0,
-		totalFileSize: importer.args.exportFileSize,
-		uploadErrChan: make(chan error),
-	}
-}
-
-// transferFile downloads S3 file and uploads to GCS concurrently.
-func (importer *awsImporter) transferFile(writer io.WriteCloser) error {
+// transferFile copies the S3 file to the GCS object obj in bkt, with parallel
+// ranged downloads that are resumed by a rerun.
+func (importer *awsImporter) transferFile(bkt, obj string) error {
 	if importer.transferFileFn != nil {
 		return importer.transferFileFn()
 	}
-	// 1. Set up download size and get number of chunks to download
-	output, err := humanize.ParseBytes(downloadBufSize)
-	if err != nil {
-		return daisy.ToDError(err)
-	}
-	readSize := int64(output)
-	// Take ceiling to get number of chunks to download.
-	readers := (importer.args.exportFileSize-1)/readSize + 1
-	// Set up download retry delay interval
-	delayTime := []int{1, 2, 4, 8, 8}
-	maxRetryTimes := len(delayTime)
-
-	// 2. Set up upload info
-	importer.uploader = importer.getUploader(writer)
-	importer.uploader.Add(1)
-	go importer.uploader.uploadFile()
-
-	// 3. Range download
-	for i := int64(0); i < readers; i++ {
-		startRange := i * readSize
-		endRange := startRange + readSize - 1
-		for retryAttempt := 0; ; retryAttempt++ {
-			res, err := importer.s3Client.GetObject(&s3.GetObjectInput{
-				Bucket: aws.String(importer.args.exportBucket),
-				Key:    aws.String(importer.args.exportKey),
-				Range:  aws.String(fmt.Sprintf("bytes=%v-%v", startRange, endRange)),
-			})
-			if err != nil {
-				if retryAttempt >= maxRetryTimes {
-					return daisy.Errf("error in downloading from %v: %v", importer.args.sourceFilePath, err)
-				}
-				time.Sleep(time.Duration(delayTime[retryAttempt]) * time.Second)
-				continue
-			}
-			importer.uploader.readerChan <- res.Body
-			break
-		}
 
-		// Stop downloading as soon as one of the upload fails.
-		select {
-		case err := <-importer.uploader.uploadErrChan:
-			importer.uploader.cleanup()
-			return err
-		default:
-			// No error, continue to download.
-		}
+	transfer := importer.newPartTransfer(bkt, obj)
+	transfer.source = fmt.Sprintf("s3://%v/%v", importer.args.exportBucket, importer.args.exportKey)
+	transfer.version = importer.args.exportETag
+	transfer.readRange = importer.readS3Range
+	return transfer.run()
+}
 
-		// Stop downloading if timeout exceeded.
-		select {
-		case <-importer.timeoutChan:
-			importer.uploader.cleanup()
-			return daisy.Errf("timeout exceeded during transfer file")
-		default:
-			// Did not timeout, continue to download.
-		}
+// newPartTransfer creates a partTransfer of exportFileSize bytes to the GCS
+// object obj in bkt.
+func (importer *awsImporter) newPartTransfer(bkt, obj string) *partTransfer {
+	return &partTransfer{
+		gcsClient:   importer.gcsClient,
+		timeoutChan: importer.timeoutChan,
+		size:        importer.args.exportFileSize,
+		dstBucket:   bkt,
+		dstObject:   obj,
+		partSize:    transferPartSizeFor(importer.args.exportFileSize, 1),
+		workers:     transferWorkers,
+		retryDelays: transferRetryDelays,
 	}
+}
 
-	// All file chunks are downloaded, wait for upload to finish.
-	close(importer.uploader.readerChan)
-	importer.uploader.Wait()
-
-	err = importer.uploader.writer.Close()
+// readS3Range reads length bytes of the S3 file from start.
+func (importer *awsImporter) readS3Range(start, length int64) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(importer.args.exportBucket),
+		Key:    aws.String(importer.args.exportKey),
+		Range:  aws.String(fmt.Sprintf("bytes=%v-%v", start, start+length-1)),
+	}
+	if importer.args.exportETag != "" {
+		// Fail rather than mixing ranges of different versions of the file.
+		input.IfMatch = aws.String(importer.args.exportETag)
+	}
+	res, err := importer.s3Client.GetObject(input)
 	if err != nil {
-		return daisy.ToDError(err)
+		return nil, err
 	}
-	return nil
+	return res.Body, nil
 }
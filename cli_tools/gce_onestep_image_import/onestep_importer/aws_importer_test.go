@@ -15,12 +15,14 @@
 package importer
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,8 +38,6 @@ import (
 )
 
 var (
-	shouldSetGetObjectDelay = false
-
 	getObjectResp struct {
 		output *s3.GetObjectOutput
 		err    error
@@ -77,10 +77,6 @@ func (m *mockS3Client) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutp
 }
 
 func (m *mockS3Client) GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error) {
-	// set delay so uploader has time to set values for tests
-	if shouldSetGetObjectDelay {
-		time.Sleep(time.Second * 3)
-	}
 	return getObjectResp.output, getObjectResp.err
 }
 
@@ -174,6 +170,22 @@ func TestRunImporterCopyFile(t *testing.T) {
 	assert.EqualError(t, err, "failed")
 }
 
+func TestRunImporterCopySnapshot(t *testing.T) {
+	args := setUpAWSArgs(awsSourceAMIFilePathFlag, false, "-aws_snapshot_id=snap-0123456789abcdef0")
+	awsImporter := getAWSImporter(t, args)
+	importer, err := NewOneStepImportArguments(args)
+	assert.Nil(t, err)
+
+	awsImporter.getAWSFileSizeFn = func() error {
+		return fmt.Errorf("get file size failed")
+	}
+	awsImporter.copyFromEBSToGCSFn = func() (string, error) {
+		return "", fmt.Errorf("failed")
+	}
+	err = awsImporter.run(importer)
+	assert.EqualError(t, err, "failed")
+}
+
 func TestRunImporterImportImage(t *testing.T) {
 	args := setUpAWSArgs("", false)
 	awsImporter := getAWSImporter(t, args)
@@ -449,121 +461,35 @@ func TestCopyS3FileReturnGCSPath(t *testing.T) {
 
 func TestTransferFileDownloadError(t *testing.T) {
 	resetAPIOutput()
-	var output bytes.Buffer
-	writer := testWriteCloser{Writer: bufio.NewWriter(&output)}
+	defer func(delays []time.Duration) { transferRetryDelays = delays }(transferRetryDelays)
+	transferRetryDelays = nil
 
 	args := setUpAWSArgs("", false)
 	awsImporter := getAWSImporter(t, args)
 	awsImporter.transferFileFn = nil
+	awsImporter.gcsClient = newFakeGCS()
+	awsImporter.args.exportFileSize = 10
 	getObjectResp.err = fmt.Errorf("download file failed")
 
-	err := awsImporter.transferFile(writer)
+	err := awsImporter.transferFile("bucket", "disk.vmdk")
 	assert.Contains(t, err.Error(), "download file failed")
 }
 
-func TestTransferFileDownloadToReaderChan(t *testing.T) {
+func TestTransferFileCopiesS3File(t *testing.T) {
 	resetAPIOutput()
 
-	var output bytes.Buffer
-	writer := testWriteCloser{Writer: bufio.NewWriter(&output)}
-
 	args := setUpAWSArgs("", false)
 	awsImporter := getAWSImporter(t, args)
 	awsImporter.transferFileFn = nil
+	gcs := newFakeGCS()
+	awsImporter.gcsClient = gcs
+	awsImporter.args.exportFileSize = 9
 	getObjectResp.output = &s3.GetObjectOutput{
 		Body: ioutil.NopCloser(bytes.NewReader([]byte("file data"))),
 	}
 
-	awsImporter.getUploaderFn = func() *uploader {
-		u := getTestUploader(writer)
-		u.uploadFileFn = func() {
-			awsImporter.uploader.Done()
-		}
-		return u
-	}
-
-	err := awsImporter.transferFile(writer)
-	assert.NoError(t, err)
-	assert.Len(t, awsImporter.uploader.readerChan, 1)
-
-	reader := <-awsImporter.uploader.readerChan
-	io.Copy(writer, reader)
-	assert.Contains(t, output.String(), "file data")
-}
-
-func TestTransferFileErrorWhenUploadError(t *testing.T) {
-	resetAPIOutput()
-
-	var output bytes.Buffer
-	writer := testWriteCloser{Writer: bufio.NewWriter(&output)}
-
-	args := setUpAWSArgs("", false)
-	awsImporter := getAWSImporter(t, args)
-	awsImporter.transferFileFn = nil
-	getObjectResp.output = &s3.GetObjectOutput{
-		Body: ioutil.NopCloser(bytes.NewReader([]byte("file data"))),
-	}
-
-	awsImporter.getUploaderFn = func() *uploader {
-		u := getTestUploader(writer)
-		u.uploadFileFn = func() {
-			awsImporter.uploader.uploadErrChan <- fmt.Errorf("upload error")
-			awsImporter.uploader.Done()
-		}
-		return u
-	}
-
-	// delay during getObject so uploadFileFn has time to finish updating uploadErr
-	shouldSetGetObjectDelay = true
-	err := awsImporter.transferFile(writer)
-	assert.NotNil(t, err)
-}
-
-func TestTransferFileCallsCleanupWhenTimeout(t *testing.T) {
-	resetAPIOutput()
-
-	var output bytes.Buffer
-	writer := testWriteCloser{Writer: bufio.NewWriter(&output)}
-
-	args := setUpAWSArgs("", false)
-	awsImporter := getAWSImporter(t, args)
-	close(awsImporter.timeoutChan)
-	awsImporter.transferFileFn = nil
-	getObjectResp.output = &s3.GetObjectOutput{
-		Body: ioutil.NopCloser(bytes.NewReader([]byte("file data"))),
-	}
-
-	var isCleanupCalled = false
-	awsImporter.getUploaderFn = func() *uploader {
-		u := getTestUploader(writer)
-		u.cleanupFn = func() {
-			isCleanupCalled = true
-		}
-		return u
-	}
-
-	err := awsImporter.transferFile(writer)
-	assert.NotNil(t, err)
-	assert.Contains(t, err.Error(), "timeout exceeded during transfer file")
-	assert.True(t, isCleanupCalled)
-}
-
-func TestTransferFileReturnErrorWhenWriterCloseFail(t *testing.T) {
-	resetAPIOutput()
-
-	var output bytes.Buffer
-	writer := testWriteCloser{Writer: bufio.NewWriter(&output)}
-	writer.closeReturnVal = fmt.Errorf("close writer failed")
-
-	args := setUpAWSArgs("", false)
-	awsImporter := getAWSImporter(t, args)
-	awsImporter.transferFileFn = nil
-	getObjectResp.output = &s3.GetObjectOutput{
-		Body: ioutil.NopCloser(bytes.NewReader([]byte("file data"))),
-	}
-	err := awsImporter.transferFile(writer)
-	assert.NotNil(t, err)
-	assert.Contains(t, err.Error(), "close writer failed")
+	assert.NoError(t, awsImporter.transferFile("bucket", "disk.vmdk"))
+	assert.Equal(t, "file data", string(gcs.objects["disk.vmdk"]))
 }
 
 func TestImportImageUpdateImporterArgs(t *testing.T) {
@@ -686,6 +612,102 @@ func TestCleanupDeleteS3PathError(t *testing.T) {
 		"you must manually delete the file from the storage location.", "s3://bucket/object", errMsg))
 }
 
+func TestCreateS3ClientUsesVirtualHostedStyleForEndpoint(t *testing.T) {
+	awsArgs := getS3CompatibleImportArgs("https://s3.example.com", false)
+	awsSession, err := createAWSSession(awsArgs.region, awsArgs.accessKeyID, awsArgs.secretAccessKey, awsArgs.sessionToken)
+	assert.NoError(t, err)
+
+	req, _ := createS3Client(awsSession, awsArgs).(*s3.S3).GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("folder/disk.vmdk"),
+	})
+	assert.NoError(t, req.Build())
+	assert.Equal(t, "https://bucket.s3.example.com/folder/disk.vmdk", req.HTTPRequest.URL.String())
+}
+
+func TestCopyFromS3CompatibleEndpoint(t *testing.T) {
+	content := strings.Repeat("disk content", 1000)
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=my-access-key/")
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/s3/aws4_request")
+		http.ServeContent(w, r, "disk.vmdk", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	importer := newS3CompatibleImporter(t, getS3CompatibleImportArgs(server.URL, true))
+	assert.NoError(t, importer.args.validateAndPopulate(mockPopulator{scratchBucket: "gs://bucket"}))
+	assert.NoError(t, importer.getAWSFileSize())
+	assert.Equal(t, int64(len(content)), importer.args.exportFileSize)
+
+	gcsFilePath, err := importer.copyFromS3ToGCS()
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(importer.gcsClient.(*fakeGCS).objects[strings.TrimPrefix(gcsFilePath, "gs://bucket/")]))
+	assert.Equal(t, []string{"HEAD /bucket/folder/disk.vmdk", "GET /bucket/folder/disk.vmdk"}, requests)
+}
+
+// TestCopyFromMinIO copies a file from a MinIO server, for example:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	MINIO_ENDPOINT=http://127.0.0.1:9000 go test -run MinIO
+//
+// MINIO_ACCESS_KEY and MINIO_SECRET_KEY default to the MinIO root credentials.
+func TestCopyFromMinIO(t *testing.T) {
+	endpoint := os.Getenv("MINIO_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MINIO_ENDPOINT isn't set")
+	}
+	accessKey, secretKey := os.Getenv("MINIO_ACCESS_KEY"), os.Getenv("MINIO_SECRET_KEY")
+	if accessKey == "" {
+		accessKey, secretKey = "minioadmin", "minioadmin"
+	}
+	bucket := fmt.Sprintf("onestep-test-%v", time.Now().UnixNano())
+	content := strings.Repeat("disk content", 100000)
+
+	awsArgs := getAWSImportArgs(setUpArgs("", "-aws_access_key_id="+accessKey, "-aws_secret_access_key="+secretKey,
+		"-aws_s3_endpoint="+endpoint, "-aws_s3_force_path_style",
+		fmt.Sprintf("-aws_source_ami_file_path=s3://%v/folder/disk.vmdk", bucket)))
+	importer := newS3CompatibleImporter(t, awsArgs)
+	assert.NoError(t, importer.args.validateAndPopulate(mockPopulator{scratchBucket: "gs://bucket"}))
+	_, err := importer.s3Client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(bucket)})
+	assert.NoError(t, err)
+	_, err = importer.s3Client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String("folder/disk.vmdk"),
+		Body:   strings.NewReader(content),
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, importer.getAWSFileSize())
+	gcsFilePath, err := importer.copyFromS3ToGCS()
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(importer.gcsClient.(*fakeGCS).objects[strings.TrimPrefix(gcsFilePath, "gs://bucket/")]))
+}
+
+func getS3CompatibleImportArgs(endpoint string, forcePathStyle bool) *awsImportArguments {
+	awsArgs := getAWSImportArgs(setUpArgs("", "-aws_access_key_id=my-access-key",
+		"-aws_secret_access_key=my-secret-key", "-aws_s3_endpoint="+endpoint,
+		fmt.Sprintf("-aws_s3_force_path_style=%v", forcePathStyle),
+		"-aws_source_ami_file_path=s3://bucket/folder/disk.vmdk"))
+	awsArgs.region = defaultS3CompatibleRegion
+	return awsArgs
+}
+
+// newS3CompatibleImporter creates an awsImporter that copies from the
+// S3-compatible endpoint in awsArgs to a fake GCS, without an EC2 client.
+func newS3CompatibleImporter(t *testing.T, awsArgs *awsImportArguments) *awsImporter {
+	awsSession, err := createAWSSession(defaultS3CompatibleRegion, awsArgs.accessKeyID,
+		awsArgs.secretAccessKey, awsArgs.sessionToken)
+	assert.NoError(t, err)
+	return &awsImporter{
+		args:        awsArgs,
+		s3Client:    createS3Client(awsSession, awsArgs),
+		gcsClient:   newFakeGCS(),
+		timeoutChan: make(chan struct{}),
+	}
+}
+
 func getAWSImporter(t *testing.T, args []string) *awsImporter {
 	awsArgs := getAWSImportArgs(args)
 	awsImporter, err := newAWSImporter("", make(chan struct{}), awsArgs)
@@ -699,6 +721,7 @@ func getAWSImporter(t *testing.T, args []string) *awsImporter {
 	awsImporter.monitorAWSExportImageTaskFn = func() error { return nil }
 	awsImporter.getAWSFileSizeFn = func() error { return nil }
 	awsImporter.copyFromS3ToGCSFn = func() (string, error) { return "", nil }
+	awsImporter.copyFromEBSToGCSFn = func() (string, error) { return "", nil }
 	awsImporter.transferFileFn = func() error { return nil }
 	awsImporter.importImageFn = func() error { return nil }
 	awsImporter.cleanUpFn = func() {}
@@ -0,0 +1,259 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+
+	pathutils "github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/path"
+	storageutils "github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/storage"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ebs"
+	"github.com/aws/aws-sdk-go/service/ebs/ebsiface"
+	"github.com/dustin/go-humanize"
+)
+
+// Block tokens are listed again when they expire within this margin.
+const blockTokenExpiryMargin = 10 * time.Minute
+
+// ebsSnapshot reads the blocks of an EBS snapshot with the EBS direct APIs.
+// The blocks that were never written aren't listed, and read as zeros.
+type ebsSnapshot struct {
+	client     ebsiface.EBSAPI
+	snapshotID string
+	blockSize  int64
+	volumeSize int64
+
+	mx     sync.Mutex
+	tokens map[int64]string
+	expiry time.Time
+	// listMx is held while the blocks are listed again, so that a single
+	// reader lists them.
+	listMx sync.Mutex
+}
+
+// newEBSSnapshot creates an ebsSnapshot, and lists the blocks of the snapshot.
+func newEBSSnapshot(client ebsiface.EBSAPI, snapshotID string) (*ebsSnapshot, error) {
+	s := &ebsSnapshot{client: client, snapshotID: snapshotID}
+	if err := s.listBlocks(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// listBlocks lists the blocks of the snapshot that have data, with the tokens
+// to read them.
+func (s *ebsSnapshot) listBlocks() error {
+	tokens := map[int64]string{}
+	var expiry time.Time
+	var blockSize, volumeSize int64
+	err := s.client.ListSnapshotBlocksPages(&ebs.ListSnapshotBlocksInput{
+		SnapshotId: aws.String(s.snapshotID),
+	}, func(page *ebs.ListSnapshotBlocksOutput, lastPage bool) bool {
+		blockSize = aws.Int64Value(page.BlockSize)
+		// The volume size is in GiB.
+		volumeSize = aws.Int64Value(page.VolumeSize) * 1024 * 1024 * 1024
+		if page.ExpiryTime != nil && (expiry.IsZero() || page.ExpiryTime.Before(expiry)) {
+			expiry = *page.ExpiryTime
+		}
+		for _, block := range page.Blocks {
+			tokens[aws.Int64Value(block.BlockIndex)] = aws.StringValue(block.BlockToken)
+		}
+		return true
+	})
+	if err != nil {
+		return daisy.Errf("failed to list blocks of snapshot %v: %v", s.snapshotID, err)
+	}
+	if blockSize <= 0 || volumeSize <= 0 {
+		return daisy.Errf("failed to list blocks of snapshot %v: unexpected block size %v and volume size %v",
+			s.snapshotID, blockSize, volumeSize)
+	}
+	// The sizes don't change, and are read without s.mx, so they're only set by
+	// the first listing.
+	if s.blockSize == 0 {
+		s.blockSize, s.volumeSize = blockSize, volumeSize
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.tokens, s.expiry = tokens, expiry
+	return nil
+}
+
+// token returns the token of a block, and false if the block has no data.
+func (s *ebsSnapshot) token(index int64) (string, bool, error) {
+	if _, expiring := s.tokensExpiry(); expiring {
+		if err := s.listBlocksAgain(); err != nil {
+			return "", false, err
+		}
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	token, ok := s.tokens[index]
+	return token, ok, nil
+}
+
+// tokensExpiry returns the expiry of the block tokens, and true if they expire
+// within blockTokenExpiryMargin.
+func (s *ebsSnapshot) tokensExpiry() (time.Time, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.expiry, !s.expiry.IsZero() && time.Now().Add(blockTokenExpiryMargin).After(s.expiry)
+}
+
+// listBlocksAgain lists the blocks for new tokens, unless another reader just
+// did. s.mx isn't held while listing, as it takes a call per page of blocks.
+func (s *ebsSnapshot) listBlocksAgain() error {
+	s.listMx.Lock()
+	defer s.listMx.Unlock()
+	expiry, expiring := s.tokensExpiry()
+	if !expiring {
+		return nil
+	}
+	log.Printf("Listing blocks of snapshot %v again, as their tokens expire at %v.\n", s.snapshotID, expiry)
+	return s.listBlocks()
+}
+
+// isZeroRange returns true if no block of a range has data.
+func (s *ebsSnapshot) isZeroRange(start, length int64) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for index := start / s.blockSize; index*s.blockSize < start+length; index++ {
+		if _, ok := s.tokens[index]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// readRange reads length bytes of the volume from start. Both have to be
+// multiples of the block size.
+func (s *ebsSnapshot) readRange(start, length int64) (io.ReadCloser, error) {
+	if start%s.blockSize != 0 || length%s.blockSize != 0 {
+		return nil, daisy.Errf("range %v-%v of snapshot %v isn't aligned to its %v blocks",
+			start, start+length-1, s.snapshotID, s.blockSize)
+	}
+	return &ebsRangeReader{snapshot: s, next: start / s.blockSize, end: (start + length) / s.blockSize}, nil
+}
+
+// readBlock reads a block of the snapshot, and verifies its checksum.
+func (s *ebsSnapshot) readBlock(index int64) ([]byte, error) {
+	token, ok, err := s.token(index)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return make([]byte, s.blockSize), nil
+	}
+
+	out, err := s.client.GetSnapshotBlock(&ebs.GetSnapshotBlockInput{
+		SnapshotId: aws.String(s.snapshotID),
+		BlockIndex: aws.Int64(index),
+		BlockToken: aws.String(token),
+	})
+	if err != nil {
+		return nil, daisy.Errf("failed to read block %v of snapshot %v: %v", index, s.snapshotID, err)
+	}
+	defer out.BlockData.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(out.BlockData, s.blockSize+1))
+	if err != nil {
+		return nil, daisy.Errf("failed to read block %v of snapshot %v: %v", index, s.snapshotID, err)
+	}
+	if int64(len(data)) != s.blockSize {
+		return nil, daisy.Errf("block %v of snapshot %v has %v bytes, expected %v",
+			index, s.snapshotID, len(data), s.blockSize)
+	}
+	if aws.StringValue(out.ChecksumAlgorithm) == ebs.ChecksumAlgorithmSha256 {
+		sum := sha256.Sum256(data)
+		if checksum := base64.StdEncoding.EncodeToString(sum[:]); checksum != aws.StringValue(out.Checksum) {
+			return nil, daisy.Errf("checksum mismatch for block %v of snapshot %v: got %v, expected %v",
+				index, s.snapshotID, checksum, aws.StringValue(out.Checksum))
+		}
+	}
+	return data, nil
+}
+
+// ebsRangeReader reads the blocks of a range of a snapshot in order.
+type ebsRangeReader struct {
+	snapshot  *ebsSnapshot
+	next, end int64
+	block     []byte
+}
+
+func (r *ebsRangeReader) Read(p []byte) (int, error) {
+	if len(r.block) == 0 {
+		if r.next >= r.end {
+			return 0, io.EOF
+		}
+		block, err := r.snapshot.readBlock(r.next)
+		if err != nil {
+			return 0, err
+		}
+		r.block = block
+		r.next++
+	}
+	n := copy(p, r.block)
+	r.block = r.block[n:]
+	return n, nil
+}
+
+func (r *ebsRangeReader) Close() error {
+	return nil
+}
+
+// copyFromEBSToGCS copies the EBS snapshot to a sparse raw disk file in GCS.
+func (importer *awsImporter) copyFromEBSToGCS() (string, error) {
+	if importer.copyFromEBSToGCSFn != nil {
+		return importer.copyFromEBSToGCSFn()
+	}
+
+	start := time.Now()
+	snapshot, err := newEBSSnapshot(importer.ebsClient, importer.args.snapshotID)
+	if err != nil {
+		return "", err
+	}
+	importer.args.exportFileSize = snapshot.volumeSize
+	log.Printf("Snapshot %v of %v has %v blocks of %v with data.\n", importer.args.snapshotID,
+		humanize.IBytes(uint64(snapshot.volumeSize)), len(snapshot.tokens), humanize.IBytes(uint64(snapshot.blockSize)))
+
+	// The GCS path only depends on the snapshot, so that a rerun resumes a copy
+	// that failed.
+	gcsFilePath := pathutils.JoinURL(importer.args.gcsScratchBucket,
+		transferObjectName(importer.args.snapshotID, "", snapshot.volumeSize, ".raw"))
+	log.Printf("Copying %v to %v.\n", importer.args.snapshotID, gcsFilePath)
+	bkt, obj, err := storageutils.GetGCSObjectPathElements(gcsFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	transfer := importer.newPartTransfer(bkt, obj)
+	transfer.source = importer.args.snapshotID
+	// Parts are read by whole blocks.
+	transfer.partSize = transferPartSizeFor(snapshot.volumeSize, snapshot.blockSize)
+	transfer.readRange = snapshot.readRange
+	transfer.isZeroRange = snapshot.isZeroRange
+	if err := transfer.run(); err != nil {
+		return gcsFilePath, err
+	}
+	log.Printf("Successfully copied to %v in %v.\n", gcsFilePath, time.Since(start))
+
+	return gcsFilePath, nil
+}
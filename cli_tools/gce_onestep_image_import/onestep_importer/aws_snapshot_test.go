@@ -0,0 +1,345 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ebs"
+	"github.com/aws/aws-sdk-go/service/ebs/ebsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSnapshotID = "snap-0123456789abcdef0"
+
+func TestListSnapshotBlocks(t *testing.T) {
+	client := newFakeEBS(map[int64][]byte{1: randomContent(512), 7: randomContent(512), 8: randomContent(512)})
+	snapshot, err := newEBSSnapshot(client, testSnapshotID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(512), snapshot.blockSize)
+	assert.Equal(t, int64(1024*1024*1024), snapshot.volumeSize)
+	assert.Len(t, snapshot.tokens, 3)
+	// The blocks are listed in pages of 2 blocks.
+	assert.Equal(t, 2, client.listPages)
+}
+
+func TestListSnapshotBlocksReturnErrorWhenCallError(t *testing.T) {
+	client := newFakeEBS(nil)
+	client.listErr = fmt.Errorf("access denied")
+	_, err := newEBSSnapshot(client, testSnapshotID)
+	assert.EqualError(t, err, "failed to list blocks of snapshot snap-0123456789abcdef0: access denied")
+}
+
+func TestSnapshotIsZeroRange(t *testing.T) {
+	snapshot, err := newEBSSnapshot(newFakeEBS(map[int64][]byte{5: randomContent(512)}), testSnapshotID)
+	assert.NoError(t, err)
+	assert.True(t, snapshot.isZeroRange(0, 5*512))
+	assert.False(t, snapshot.isZeroRange(4*512, 2*512))
+	assert.True(t, snapshot.isZeroRange(6*512, 4*512))
+}
+
+func TestSnapshotReadRange(t *testing.T) {
+	block1, block2 := randomContent(512), randomContent(512)
+	client := newFakeEBS(map[int64][]byte{1: block1, 2: block2})
+	snapshot, err := newEBSSnapshot(client, testSnapshotID)
+	assert.NoError(t, err)
+
+	reader, err := snapshot.readRange(0, 4*512)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	expected := append(append(append(make([]byte, 512), block1...), block2...), make([]byte, 512)...)
+	assert.Equal(t, expected, data)
+	// Blocks that aren't listed aren't read.
+	assert.Equal(t, []int64{1, 2}, client.reads)
+}
+
+func TestSnapshotReadRangeReturnErrorWhenNotAligned(t *testing.T) {
+	snapshot, err := newEBSSnapshot(newFakeEBS(nil), testSnapshotID)
+	assert.NoError(t, err)
+	_, err = snapshot.readRange(100, 512)
+	assert.EqualError(t, err, "range 100-611 of snapshot snap-0123456789abcdef0 isn't aligned to its 512 blocks")
+}
+
+func TestSnapshotReadRangeReturnErrorOnChecksumMismatch(t *testing.T) {
+	client := newFakeEBS(map[int64][]byte{0: randomContent(512)})
+	client.corrupt = 0
+	snapshot, err := newEBSSnapshot(client, testSnapshotID)
+	assert.NoError(t, err)
+
+	reader, err := snapshot.readRange(0, 512)
+	assert.NoError(t, err)
+	_, err = ioutil.ReadAll(reader)
+	assert.Contains(t, err.Error(), "checksum mismatch for block 0 of snapshot snap-0123456789abcdef0")
+}
+
+func TestSnapshotReadRangeReturnErrorWhenCallError(t *testing.T) {
+	client := newFakeEBS(map[int64][]byte{0: randomContent(512)})
+	client.readErr = fmt.Errorf("throttled")
+	snapshot, err := newEBSSnapshot(client, testSnapshotID)
+	assert.NoError(t, err)
+
+	reader, err := snapshot.readRange(0, 512)
+	assert.NoError(t, err)
+	_, err = ioutil.ReadAll(reader)
+	assert.EqualError(t, err, "failed to read block 0 of snapshot snap-0123456789abcdef0: throttled")
+}
+
+func TestSnapshotListsBlocksAgainWhenTokensExpire(t *testing.T) {
+	client := newFakeEBS(map[int64][]byte{0: randomContent(512)})
+	client.expiry = time.Now().Add(5 * time.Minute)
+	snapshot, err := newEBSSnapshot(client, testSnapshotID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.lists)
+
+	client.expiry = time.Now().Add(time.Hour)
+	reader, err := snapshot.readRange(0, 512)
+	assert.NoError(t, err)
+	_, err = ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.lists)
+
+	// The new tokens don't expire soon, so they're used as they are.
+	_, err = snapshot.readBlock(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.lists)
+}
+
+func TestSnapshotReadsTokensWhileListingBlocksAgain(t *testing.T) {
+	client := newFakeEBS(map[int64][]byte{0: randomContent(512)})
+	client.expiry = time.Now().Add(5 * time.Minute)
+	snapshot, err := newEBSSnapshot(client, testSnapshotID)
+	assert.NoError(t, err)
+
+	client.expiry = time.Now().Add(time.Hour)
+	client.listing = make(chan struct{})
+	read := make(chan error)
+	go func() {
+		_, err := snapshot.readBlock(0)
+		read <- err
+	}()
+	<-client.listing
+
+	zero := make(chan bool)
+	go func() { zero <- snapshot.isZeroRange(0, 512) }()
+	select {
+	case isZero := <-zero:
+		assert.False(t, isZero)
+	case <-time.After(10 * time.Second):
+		t.Fatal("isZeroRange waited for the blocks to be listed")
+	}
+	client.listing <- struct{}{}
+	assert.NoError(t, <-read)
+	assert.Equal(t, 2, client.lists)
+}
+
+func TestTransferSnapshotSkipsZeroParts(t *testing.T) {
+	blocks := map[int64][]byte{1: randomContent(512), 2: randomContent(512), 13: randomContent(512)}
+	client := newFakeEBS(blocks)
+	snapshot, err := newEBSSnapshot(client, testSnapshotID)
+	assert.NoError(t, err)
+	snapshot.volumeSize = 20 * 512
+	gcs := newFakeGCS()
+
+	transfer := newTestSnapshotTransfer(snapshot, gcs)
+	assert.NoError(t, transfer.run())
+
+	expected := make([]byte, snapshot.volumeSize)
+	for index, block := range blocks {
+		copy(expected[index*512:], block)
+	}
+	assert.Equal(t, expected, gcs.objects["disk.raw"])
+	// Only the blocks of parts 0 and 3 are read, and the other parts are
+	// composed from an object of zeros.
+	assert.Equal(t, []int64{1, 2, 13}, client.reads)
+	assert.Len(t, gcs.objects, 1)
+}
+
+func TestTransferSnapshotResumesFromCheckpoint(t *testing.T) {
+	blocks := map[int64][]byte{1: randomContent(512), 9: randomContent(512), 13: randomContent(512)}
+	client := newFakeEBS(blocks)
+	snapshot, err := newEBSSnapshot(client, testSnapshotID)
+	assert.NoError(t, err)
+	snapshot.volumeSize = 20 * 512
+	gcs := newFakeGCS()
+
+	// The first run fails when reading block 13, in part 3.
+	client.failFrom = 13
+	assert.Error(t, newTestSnapshotTransfer(snapshot, gcs).run())
+
+	client.failFrom = -1
+	client.reads = nil
+	assert.NoError(t, newTestSnapshotTransfer(snapshot, gcs).run())
+	assert.Equal(t, []int64{13}, client.reads)
+	assert.Equal(t, blocks[13], gcs.objects["disk.raw"][13*512:14*512])
+}
+
+func TestCopyFromEBSReturnErrorWhenListError(t *testing.T) {
+	client := newFakeEBS(nil)
+	client.listErr = fmt.Errorf("access denied")
+	importer := newSnapshotImporter(client)
+
+	_, err := importer.copyFromEBSToGCS()
+	assert.EqualError(t, err, "failed to list blocks of snapshot snap-0123456789abcdef0: access denied")
+}
+
+func TestSnapshotPartsAreWholeBlocks(t *testing.T) {
+	for _, blockSize := range []int64{512 * 1024, 3000} {
+		for _, volumeSize := range []int64{1024 * 1024 * 1024, 500 * 1024 * 1024 * 1024, 16 * 1024 * 1024 * 1024 * 1024} {
+			transfer := partTransfer{size: volumeSize, partSize: transferPartSizeFor(volumeSize, blockSize)}
+			assert.Zero(t, transfer.partSize%blockSize, "blocks of %v in parts of %v", blockSize, transfer.partSize)
+			assert.True(t, transfer.partCount() <= maxComponents, "%v bytes in %v parts", volumeSize, transfer.partCount())
+		}
+	}
+}
+
+func TestCopyFromEBSReturnErrorWhenGcsPathInvalid(t *testing.T) {
+	importer := newSnapshotImporter(newFakeEBS(nil))
+	importer.args.gcsScratchBucket = "bucket"
+
+	_, err := importer.copyFromEBSToGCS()
+	assert.Error(t, err)
+	assert.Equal(t, int64(1024*1024*1024), importer.args.exportFileSize)
+}
+
+// newTestSnapshotTransfer creates a partTransfer of snapshot in parts of 4 blocks.
+func newTestSnapshotTransfer(snapshot *ebsSnapshot, gcs *fakeGCS) *partTransfer {
+	importer := &awsImporter{
+		args:        &awsImportArguments{exportFileSize: snapshot.volumeSize},
+		gcsClient:   gcs,
+		timeoutChan: make(chan struct{}),
+	}
+	transfer := importer.newPartTransfer("bucket", "disk.raw")
+	transfer.source = snapshot.snapshotID
+	transfer.readRange = snapshot.readRange
+	transfer.isZeroRange = snapshot.isZeroRange
+	transfer.partSize = 4 * snapshot.blockSize
+	transfer.workers = 2
+	transfer.retryDelays = nil
+	return transfer
+}
+
+func newSnapshotImporter(client *fakeEBS) *awsImporter {
+	args := getAWSImportArgs(setUpAWSArgs(awsSourceAMIFilePathFlag, false, "-aws_snapshot_id="+testSnapshotID))
+	args.gcsScratchBucket = "gs://bucket"
+	return &awsImporter{
+		args:        args,
+		ebsClient:   client,
+		gcsClient:   newFakeGCS(),
+		timeoutChan: make(chan struct{}),
+	}
+}
+
+// fakeEBS serves the blocks of a snapshot of 1GiB, in pages of 2 blocks.
+type fakeEBS struct {
+	ebsiface.EBSAPI
+	blockSize int64
+	blocks    map[int64][]byte
+	expiry    time.Time
+	listErr   error
+	readErr   error
+	// corrupt is the index of a block that is served with a wrong checksum.
+	corrupt int64
+	// failFrom is the index of the first block that fails to be read.
+	failFrom int64
+	// listing, when set, is signaled when the blocks are listed again, and the
+	// listing waits for a reply on it.
+	listing chan struct{}
+
+	mx        sync.Mutex
+	lists     int
+	listPages int
+	reads     []int64
+}
+
+func newFakeEBS(blocks map[int64][]byte) *fakeEBS {
+	return &fakeEBS{blockSize: 512, blocks: blocks, corrupt: -1, failFrom: -1}
+}
+
+func (f *fakeEBS) ListSnapshotBlocksPages(input *ebs.ListSnapshotBlocksInput,
+	fn func(*ebs.ListSnapshotBlocksOutput, bool) bool) error {
+	if f.listErr != nil {
+		return f.listErr
+	}
+	f.lists++
+	if f.lists > 1 && f.listing != nil {
+		f.listing <- struct{}{}
+		<-f.listing
+	}
+	var indexes []int64
+	for index := range f.blocks {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	for start := 0; start == 0 || start < len(indexes); start += 2 {
+		page := &ebs.ListSnapshotBlocksOutput{
+			BlockSize:  aws.Int64(f.blockSize),
+			VolumeSize: aws.Int64(1),
+		}
+		if !f.expiry.IsZero() {
+			page.ExpiryTime = aws.Time(f.expiry)
+		}
+		for i := start; i < start+2 && i < len(indexes); i++ {
+			page.Blocks = append(page.Blocks, &ebs.Block{
+				BlockIndex: aws.Int64(indexes[i]),
+				BlockToken: aws.String(fmt.Sprintf("token-%v-%v", indexes[i], f.lists)),
+			})
+		}
+		f.listPages++
+		if !fn(page, start+2 >= len(indexes)) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeEBS) GetSnapshotBlock(input *ebs.GetSnapshotBlockInput) (*ebs.GetSnapshotBlockOutput, error) {
+	index := aws.Int64Value(input.BlockIndex)
+	f.mx.Lock()
+	f.reads = append(f.reads, index)
+	sort.Slice(f.reads, func(i, j int) bool { return f.reads[i] < f.reads[j] })
+	f.mx.Unlock()
+
+	if f.readErr != nil {
+		return nil, f.readErr
+	}
+	if f.failFrom >= 0 && index >= f.failFrom {
+		return nil, fmt.Errorf("block %v failed", index)
+	}
+	if aws.StringValue(input.BlockToken) != fmt.Sprintf("token-%v-%v", index, f.lists) {
+		return nil, fmt.Errorf("invalid token %v", aws.StringValue(input.BlockToken))
+	}
+	data := f.blocks[index]
+	sum := sha256.Sum256(data)
+	if index == f.corrupt {
+		sum[0]++
+	}
+	return &ebs.GetSnapshotBlockOutput{
+		BlockData:         ioutil.NopCloser(bytes.NewReader(data)),
+		Checksum:          aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+		ChecksumAlgorithm: aws.String(ebs.ChecksumAlgorithmSha256),
+		DataLength:        aws.Int64(int64(len(data))),
+	}, nil
+}
@@ -0,0 +1,503 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	transferWorkers   = 8
+	maxComposeSources = 32
+	// maxComponents is the most objects GCS composes into a single object.
+	maxComponents    = 1024
+	checkpointObject = "checkpoint.json"
+)
+
+var (
+	transferPartSize int64 = 256 * 1024 * 1024
+	crc32cTable            = crc32.MakeTable(crc32.Castagnoli)
+	// Delays between the attempts to copy a part.
+	transferRetryDelays = []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second}
+)
+
+// partTransfer copies a file to a GCS object. Ranges of the file are read in
+// parallel, and each range is uploaded to its own part object in GCS. Once all
+// parts are uploaded, they're composed into the destination.
+//
+// Copied parts are recorded with their CRC32C in a checkpoint object next to
+// them, so that a rerun copying the same file skips them. Each part, and the
+// composed object, is verified against the CRC32C of the data read from the file.
+type partTransfer struct {
+	gcsClient   domain.StorageClientInterface
+	timeoutChan chan struct{}
+
+	// source and version identify the copied file in the checkpoint.
+	source, version string
+	size            int64
+	// readRange reads length bytes of the file from start.
+	readRange func(start, length int64) (io.ReadCloser, error)
+	// isZeroRange is optional, and returns true if a range of the file only has
+	// zeros. These ranges aren't read, and a single object of zeros is composed
+	// in place of each of them.
+	isZeroRange func(start, length int64) bool
+
+	dstBucket, dstObject string
+
+	partSize    int64
+	workers     int
+	retryDelays []time.Duration
+
+	zeroParts    map[int64]bool
+	zeroCRCs     map[int64]uint32
+	checkpointMx sync.Mutex
+	checkpoint   transferCheckpoint
+}
+
+// transferCheckpoint is the content of the checkpoint object.
+type transferCheckpoint struct {
+	Source   string `json:"source"`
+	Version  string `json:"version"`
+	Size     int64  `json:"size"`
+	PartSize int64  `json:"partSize"`
+	// Parts maps the index of each copied part to its CRC32C.
+	Parts map[int64]uint32 `json:"parts"`
+}
+
+// transferObjectName returns the name of the GCS object a file is copied to.
+// It only depends on the file, so that a rerun resumes the same copy.
+func transferObjectName(source, version string, size int64, extension string) string {
+	id := sha256.Sum256([]byte(fmt.Sprintf("%v\n%v\n%v", source, version, size)))
+	return fmt.Sprintf("onestep-image-import-aws-%x%v", id[:8], extension)
+}
+
+// transferPartSizeFor returns the size of the parts a file of size bytes is
+// copied in. Parts are at least transferPartSize, big enough for the file to
+// fit in maxComponents parts, and a multiple of unit.
+func transferPartSizeFor(size, unit int64) int64 {
+	partSize := transferPartSize
+	if minPartSize := (size-1)/maxComponents + 1; minPartSize > partSize {
+		partSize = minPartSize
+	}
+	return (partSize + unit - 1) / unit * unit
+}
+
+// run copies the file, resuming from the checkpoint of a previous run.
+func (t *partTransfer) run() error {
+	if t.partCount() > maxComponents {
+		return daisy.Errf("can't copy %v in %v parts, GCS composes at most %v objects",
+			t.source, t.partCount(), maxComponents)
+	}
+	t.zeroParts = map[int64]bool{}
+	for part := int64(0); part < t.partCount(); part++ {
+		if t.isZeroRange != nil && t.isZeroRange(part*t.partSize, t.partLength(part)) {
+			t.zeroParts[part] = true
+		}
+	}
+	t.loadCheckpoint()
+	var parts []int64
+	for part := int64(0); part < t.partCount(); part++ {
+		if _, copied := t.checkpoint.Parts[part]; !copied && !t.zeroParts[part] {
+			parts = append(parts, part)
+		}
+	}
+	if err := t.uploadZeroObjects(); err != nil {
+		return err
+	}
+
+	pending := make(chan int64)
+	errs := make(chan error, t.workers)
+	var wg sync.WaitGroup
+	for i := 0; i < t.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range pending {
+				if err := t.copyPart(part); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for _, part := range parts {
+		select {
+		case pending <- part:
+		case err = <-errs:
+			break feed
+		case <-t.timeoutChan:
+			err = daisy.Errf("timeout exceeded during transfer file")
+			break feed
+		}
+	}
+	// Parts in progress are finished, so that they're recorded for a rerun.
+	close(pending)
+	wg.Wait()
+	if err == nil {
+		select {
+		case err = <-errs:
+		default:
+		}
+	}
+	if err != nil {
+		log.Printf("Copied parts are kept in gs://%v/%v. Rerun the import to resume the copy.\n",
+			t.dstBucket, t.partsPrefix())
+		return err
+	}
+
+	if err := t.compose(); err != nil {
+		return err
+	}
+	t.deleteParts()
+	return nil
+}
+
+// loadCheckpoint reads the checkpoint of a previous run, and keeps the parts it
+// recorded that are still in GCS with the recorded CRC32C.
+func (t *partTransfer) loadCheckpoint() {
+	t.checkpoint = transferCheckpoint{
+		Source:   t.source,
+		Version:  t.version,
+		Size:     t.size,
+		PartSize: t.partSize,
+		Parts:    map[int64]uint32{},
+	}
+
+	reader, err := t.gcsClient.GetObject(t.dstBucket, t.checkpointObject()).NewReader()
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	var previous transferCheckpoint
+	if b, err := ioutil.ReadAll(reader); err != nil || json.Unmarshal(b, &previous) != nil {
+		log.Printf("Ignoring unreadable checkpoint gs://%v/%v.\n", t.dstBucket, t.checkpointObject())
+		return
+	}
+	if previous.Source != t.source || previous.Version != t.version ||
+		previous.Size != t.size || previous.PartSize != t.partSize {
+		log.Printf("Ignoring checkpoint gs://%v/%v of a different copy.\n", t.dstBucket, t.checkpointObject())
+		return
+	}
+
+	for part, crc := range previous.Parts {
+		if part < t.partCount() && !t.zeroParts[part] &&
+			t.verifyObject(t.partObject(part), t.partLength(part), crc) == nil {
+			t.checkpoint.Parts[part] = crc
+		}
+	}
+	log.Printf("Resuming copy: %v of %v parts were copied by a previous run.\n",
+		len(t.checkpoint.Parts), t.dataPartCount())
+}
+
+// uploadZeroObjects uploads the objects of zeros that are composed in place of
+// the zero parts, unless a previous run uploaded them.
+func (t *partTransfer) uploadZeroObjects() error {
+	t.zeroCRCs = map[int64]uint32{}
+	for part := range t.zeroParts {
+		length := t.partLength(part)
+		if _, uploaded := t.zeroCRCs[length]; uploaded {
+			continue
+		}
+		hash := crc32.New(crc32cTable)
+		io.Copy(hash, io.LimitReader(zeroReader{}, length))
+		t.zeroCRCs[length] = hash.Sum32()
+		if t.verifyObject(t.zeroObject(length), length, t.zeroCRCs[length]) == nil {
+			continue
+		}
+
+		err := t.withRetries(fmt.Sprintf("%v zeros", length), func() error {
+			_, err := t.uploadObject(t.zeroObject(length), io.LimitReader(zeroReader{}, length), length)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(t.zeroParts) > 0 {
+		log.Printf("%v of %v parts only have zeros, and aren't copied.\n", len(t.zeroParts), t.partCount())
+	}
+	return nil
+}
+
+// copyPart copies a part, retrying on failure, and records it in the checkpoint.
+func (t *partTransfer) copyPart(part int64) error {
+	return t.withRetries(fmt.Sprintf("part %v", part), func() error {
+		reader, err := t.readRange(part*t.partSize, t.partLength(part))
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		crc, err := t.uploadObject(t.partObject(part), reader, t.partLength(part))
+		if err != nil {
+			return err
+		}
+		t.recordPart(part, crc)
+		return nil
+	})
+}
+
+// withRetries runs fn until it succeeds, or until it failed once for each of
+// the retry delays.
+func (t *partTransfer) withRetries(what string, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt >= len(t.retryDelays) {
+			return daisy.Errf("error in copying %v of %v: %v", what, t.source, err)
+		}
+		log.Printf("Failed to copy %v, retrying: %v\n", what, err)
+		select {
+		case <-t.timeoutChan:
+			return daisy.Errf("timeout exceeded during transfer file")
+		case <-time.After(t.retryDelays[attempt]):
+		}
+	}
+}
+
+// uploadObject uploads length bytes from reader to object, and returns their
+// CRC32C once the object is verified.
+func (t *partTransfer) uploadObject(object string, reader io.Reader, length int64) (uint32, error) {
+	hash := crc32.New(crc32cTable)
+	writer := t.gcsClient.GetObject(t.dstBucket, object).NewWriter()
+	n, err := io.Copy(writer, io.TeeReader(reader, hash))
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n != length {
+		return 0, daisy.Errf("read %v bytes, expected %v", n, length)
+	}
+	crc := hash.Sum32()
+	return crc, t.verifyObject(object, length, crc)
+}
+
+// recordPart adds a copied part to the checkpoint object. The copy goes on when
+// the checkpoint can't be written, as it's only needed to resume a failed copy.
+func (t *partTransfer) recordPart(part int64, crc uint32) {
+	t.checkpointMx.Lock()
+	defer t.checkpointMx.Unlock()
+	t.checkpoint.Parts[part] = crc
+
+	b, err := json.Marshal(t.checkpoint)
+	if err == nil {
+		writer := t.gcsClient.GetObject(t.dstBucket, t.checkpointObject()).NewWriter()
+		if _, err = writer.Write(b); err == nil {
+			err = writer.Close()
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to update checkpoint gs://%v/%v: %v\n", t.dstBucket, t.checkpointObject(), err)
+	}
+	log.Printf("Copied %v of %v parts of %v.\n", len(t.checkpoint.Parts), t.dataPartCount(),
+		humanize.IBytes(uint64(t.size)))
+}
+
+// compose composes the part objects into the destination object, and verifies
+// its size and CRC32C against the ones of the copied file.
+func (t *partTransfer) compose() error {
+	dst := t.gcsClient.GetObject(t.dstBucket, t.dstObject)
+	if t.partCount() == 1 {
+		if _, err := dst.CopyFrom(t.gcsClient.GetObject(t.dstBucket, t.sourceObject(0))); err != nil {
+			return daisy.Errf("failed to copy part to gs://%v/%v: %v", t.dstBucket, t.dstObject, err)
+		}
+		return t.verifyObject(t.dstObject, t.size, t.partCRC(0))
+	}
+
+	// A compose takes up to 32 sources, so the parts are appended to the
+	// destination by batches.
+	for part := int64(0); part < t.partCount(); {
+		var srcs []domain.StorageObject
+		if part > 0 {
+			srcs = append(srcs, dst)
+		}
+		for ; part < t.partCount() && len(srcs) < maxComposeSources; part++ {
+			srcs = append(srcs, t.gcsClient.GetObject(t.dstBucket, t.sourceObject(part)))
+		}
+		if _, err := dst.Compose(srcs...); err != nil {
+			return daisy.Errf("failed to compose parts into gs://%v/%v: %v", t.dstBucket, t.dstObject, err)
+		}
+	}
+
+	crc := t.partCRC(0)
+	for part := int64(1); part < t.partCount(); part++ {
+		crc = crc32cCombine(crc, t.partCRC(part), t.partLength(part))
+	}
+	return t.verifyObject(t.dstObject, t.size, crc)
+}
+
+// verifyObject checks that a GCS object has the expected size and CRC32C.
+func (t *partTransfer) verifyObject(object string, size int64, crc uint32) error {
+	attrs, err := t.gcsClient.GetObjectAttrs(t.dstBucket, object)
+	if err != nil {
+		return err
+	}
+	if attrs.Size != size || attrs.CRC32C != crc {
+		return daisy.Errf("checksum mismatch for gs://%v/%v: got %v bytes with CRC32C %08x, expected %v bytes with CRC32C %08x",
+			t.dstBucket, object, attrs.Size, attrs.CRC32C, size, crc)
+	}
+	return nil
+}
+
+// deleteParts deletes the part objects, the objects of zeros and the checkpoint.
+func (t *partTransfer) deleteParts() {
+	objects := []string{t.checkpointObject()}
+	for length := range t.zeroCRCs {
+		objects = append(objects, t.zeroObject(length))
+	}
+	for part := int64(0); part < t.partCount(); part++ {
+		if !t.zeroParts[part] {
+			objects = append(objects, t.partObject(part))
+		}
+	}
+	for _, object := range objects {
+		if err := t.gcsClient.GetObject(t.dstBucket, object).Delete(); err != nil {
+			log.Printf("Could not delete gs://%v/%v: %v.\n", t.dstBucket, object, err)
+		}
+	}
+}
+
+func (t *partTransfer) partCount() int64 {
+	return (t.size-1)/t.partSize + 1
+}
+
+// dataPartCount returns the number of parts that aren't only zeros.
+func (t *partTransfer) dataPartCount() int64 {
+	return t.partCount() - int64(len(t.zeroParts))
+}
+
+func (t *partTransfer) partLength(part int64) int64 {
+	if end := (part + 1) * t.partSize; end > t.size {
+		return t.size - part*t.partSize
+	}
+	return t.partSize
+}
+
+// partCRC returns the CRC32C of a copied part.
+func (t *partTransfer) partCRC(part int64) uint32 {
+	if t.zeroParts[part] {
+		return t.zeroCRCs[t.partLength(part)]
+	}
+	return t.checkpoint.Parts[part]
+}
+
+// sourceObject returns the object that is composed in place of a part.
+func (t *partTransfer) sourceObject(part int64) string {
+	if t.zeroParts[part] {
+		return t.zeroObject(t.partLength(part))
+	}
+	return t.partObject(part)
+}
+
+func (t *partTransfer) partsPrefix() string {
+	return t.dstObject + ".parts/"
+}
+
+func (t *partTransfer) partObject(part int64) string {
+	return fmt.Sprintf("%vpart-%05d", t.partsPrefix(), part)
+}
+
+func (t *partTransfer) zeroObject(length int64) string {
+	return fmt.Sprintf("%vzero-%v", t.partsPrefix(), length)
+}
+
+func (t *partTransfer) checkpointObject() string {
+	return t.partsPrefix() + checkpointObject
+}
+
+// zeroReader reads an endless stream of zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// crc32cCombine returns the CRC32C of the concatenation of two blocks, given
+// their CRC32Cs and the length of the second one. It's the crc32_combine
+// algorithm of zlib, with the Castagnoli polynomial.
+func crc32cCombine(crc1, crc2 uint32, len2 int64) uint32 {
+	if len2 <= 0 {
+		return crc1
+	}
+	var even, odd [32]uint32
+	// odd is the operator for one zero bit.
+	odd[0] = crc32.Castagnoli
+	row := uint32(1)
+	for n := 1; n < 32; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+	// even is the operator for two zero bits, and odd for four.
+	gf2MatrixSquare(&even, &odd)
+	gf2MatrixSquare(&odd, &even)
+
+	// Apply len2 zero bytes to crc1. The first squaring is for one zero byte.
+	for {
+		gf2MatrixSquare(&even, &odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+		gf2MatrixSquare(&odd, &even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+	return crc1 ^ crc2
+}
+
+func gf2MatrixTimes(mat *[32]uint32, vec uint32) uint32 {
+	var sum uint32
+	for i := 0; vec != 0; i, vec = i+1, vec>>1 {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+	}
+	return sum
+}
+
+func gf2MatrixSquare(square, mat *[32]uint32) {
+	for n := 0; n < 32; n++ {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
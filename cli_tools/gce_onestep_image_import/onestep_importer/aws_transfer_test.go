@@ -0,0 +1,403 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
+)
+
+func TestTransferCopiesPartsInParallel(t *testing.T) {
+	content := randomContent(10*1024 + 3)
+	s3 := newFakeS3(t, content)
+	defer s3.Close()
+	gcs := newFakeGCS()
+
+	transfer := newTestTransfer(t, s3, gcs, 4)
+	assert.NoError(t, transfer.run())
+	assert.Equal(t, content, gcs.objects["disk.vmdk"])
+	// The parts and checkpoint are deleted once the parts are composed.
+	assert.Len(t, gcs.objects, 1)
+	assert.Len(t, s3.ranges(), 11)
+	// 11 parts are composed by batches of 32 sources.
+	assert.Equal(t, 1, gcs.composes)
+}
+
+func TestTransferComposesByBatches(t *testing.T) {
+	content := randomContent(70 * 1024)
+	s3 := newFakeS3(t, content)
+	defer s3.Close()
+	gcs := newFakeGCS()
+
+	assert.NoError(t, newTestTransfer(t, s3, gcs, 8).run())
+	assert.Equal(t, content, gcs.objects["disk.vmdk"])
+	// 32 parts, then the destination and 31 parts twice, then the last 6 parts.
+	assert.Equal(t, 3, gcs.composes)
+}
+
+func TestTransferPartCountFitsInACompose(t *testing.T) {
+	for _, size := range []int64{1, 10 * 1024 * 1024 * 1024, 500 * 1000 * 1000 * 1000, 500 * 1024 * 1024 * 1024, 16 * 1024 * 1024 * 1024 * 1024} {
+		importer := &awsImporter{args: &awsImportArguments{exportFileSize: size}}
+		transfer := importer.newPartTransfer("bucket", "disk.vmdk")
+		assert.True(t, transfer.partCount() <= maxComponents, "%v bytes in %v parts", size, transfer.partCount())
+		assert.True(t, transfer.partSize >= transferPartSize, "%v bytes in parts of %v", size, transfer.partSize)
+		assert.True(t, transfer.partCount()*transfer.partSize >= size, "%v bytes in %v parts of %v", size, transfer.partCount(), transfer.partSize)
+	}
+}
+
+func TestTransferFailsWithTooManyParts(t *testing.T) {
+	s3 := newFakeS3(t, make([]byte, (maxComponents+1)*8))
+	defer s3.Close()
+	gcs := newFakeGCS()
+
+	transfer := newTestTransfer(t, s3, gcs, 4)
+	transfer.partSize = 8
+	assert.Error(t, transfer.run())
+	assert.Empty(t, gcs.objects)
+}
+
+func TestTransferCopiesSinglePart(t *testing.T) {
+	s3 := newFakeS3(t, []byte("disk content"))
+	defer s3.Close()
+	gcs := newFakeGCS()
+
+	assert.NoError(t, newTestTransfer(t, s3, gcs, 4).run())
+	assert.Equal(t, "disk content", string(gcs.objects["disk.vmdk"]))
+	assert.Equal(t, 0, gcs.composes)
+}
+
+func TestTransferResumesFromCheckpoint(t *testing.T) {
+	content := randomContent(10 * 1024)
+	s3 := newFakeS3(t, content)
+	defer s3.Close()
+	gcs := newFakeGCS()
+
+	// The first run fails on the 6th part, after the first 5 are copied.
+	s3.failFrom = 5 * 1024
+	transfer := newTestTransfer(t, s3, gcs, 1)
+	err := transfer.run()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error in copying part 5 of s3://bucket/folder/disk.vmdk")
+	assert.NotContains(t, gcs.objects, "disk.vmdk")
+	var checkpoint transferCheckpoint
+	assert.NoError(t, json.Unmarshal(gcs.objects["disk.vmdk.parts/checkpoint.json"], &checkpoint))
+	assert.Len(t, checkpoint.Parts, 5)
+
+	s3.failFrom = -1
+	s3.requests = nil
+	assert.NoError(t, newTestTransfer(t, s3, gcs, 4).run())
+	assert.Equal(t, content, gcs.objects["disk.vmdk"])
+	assert.ElementsMatch(t, []string{"bytes=5120-6143", "bytes=6144-7167", "bytes=7168-8191",
+		"bytes=8192-9215", "bytes=9216-10239"}, s3.ranges())
+}
+
+func TestTransferRecopiesPartsThatChanged(t *testing.T) {
+	content := randomContent(3 * 1024)
+	s3 := newFakeS3(t, content)
+	defer s3.Close()
+	gcs := newFakeGCS()
+	transfer := newTestTransfer(t, s3, gcs, 1)
+	transfer.loadCheckpoint()
+	for part := int64(0); part < 3; part++ {
+		gcs.objects[transfer.partObject(part)] = content[part*1024 : (part+1)*1024]
+		transfer.recordPart(part, crc32.Checksum(content[part*1024:(part+1)*1024], crc32cTable))
+	}
+	gcs.objects[transfer.partObject(1)] = make([]byte, 1024)
+
+	assert.NoError(t, newTestTransfer(t, s3, gcs, 1).run())
+	assert.Equal(t, content, gcs.objects["disk.vmdk"])
+	assert.Equal(t, []string{"bytes=1024-2047"}, s3.ranges())
+}
+
+func TestTransferIgnoresCheckpointOfDifferentSource(t *testing.T) {
+	content := randomContent(3 * 1024)
+	s3 := newFakeS3(t, content)
+	defer s3.Close()
+	gcs := newFakeGCS()
+	transfer := newTestTransfer(t, s3, gcs, 1)
+	transfer.loadCheckpoint()
+	for part := int64(0); part < 3; part++ {
+		gcs.objects[transfer.partObject(part)] = content[part*1024 : (part+1)*1024]
+		transfer.recordPart(part, crc32.Checksum(content[part*1024:(part+1)*1024], crc32cTable))
+	}
+
+	// The S3 file was overwritten since the checkpoint was written.
+	s3.etag = `"etag2"`
+	assert.NoError(t, newTestTransfer(t, s3, gcs, 1).run())
+	assert.Len(t, s3.ranges(), 3)
+}
+
+func TestTransferFailsWhenS3FileChanges(t *testing.T) {
+	s3 := newFakeS3(t, randomContent(3*1024))
+	defer s3.Close()
+	transfer := newTestTransfer(t, s3, newFakeGCS(), 1)
+	s3.etag = `"etag2"`
+
+	err := transfer.run()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "PreconditionFailed")
+}
+
+func TestTransferFailsOnChecksumMismatch(t *testing.T) {
+	s3 := newFakeS3(t, randomContent(3*1024))
+	defer s3.Close()
+	gcs := newFakeGCS()
+	gcs.corrupt = "disk.vmdk.parts/part-00002"
+
+	err := newTestTransfer(t, s3, gcs, 2).run()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch for gs://bucket/disk.vmdk.parts/part-00002")
+	assert.NotContains(t, gcs.objects, "disk.vmdk")
+}
+
+func TestTransferVerifiesComposedObject(t *testing.T) {
+	s3 := newFakeS3(t, randomContent(3*1024))
+	defer s3.Close()
+	gcs := newFakeGCS()
+	gcs.corrupt = "disk.vmdk"
+
+	err := newTestTransfer(t, s3, gcs, 2).run()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch for gs://bucket/disk.vmdk: got 3072 bytes")
+}
+
+func TestTransferStopsOnTimeout(t *testing.T) {
+	s3 := newFakeS3(t, randomContent(100*1024))
+	defer s3.Close()
+	transfer := newTestTransfer(t, s3, newFakeGCS(), 1)
+	close(transfer.timeoutChan)
+
+	assert.EqualError(t, transfer.run(), "timeout exceeded during transfer file")
+}
+
+func TestTransferObjectName(t *testing.T) {
+	name := transferObjectName("s3://bucket/disk.vmdk", `"etag"`, 10, ".vmdk")
+	assert.Regexp(t, "^onestep-image-import-aws-[0-9a-f]{16}.vmdk$", name)
+	assert.Equal(t, name, transferObjectName("s3://bucket/disk.vmdk", `"etag"`, 10, ".vmdk"))
+	assert.NotEqual(t, name, transferObjectName("s3://bucket/disk.vmdk", `"etag2"`, 10, ".vmdk"))
+	assert.NotEqual(t, name, transferObjectName("s3://bucket/disk2.vmdk", `"etag"`, 10, ".vmdk"))
+}
+
+func TestCRC32CCombine(t *testing.T) {
+	content := randomContent(5000)
+	for _, split := range []int{0, 1, 7, 1024, 4999, 5000} {
+		crc1 := crc32.Checksum(content[:split], crc32cTable)
+		crc2 := crc32.Checksum(content[split:], crc32cTable)
+		assert.Equal(t, crc32.Checksum(content, crc32cTable),
+			crc32cCombine(crc1, crc2, int64(len(content)-split)), "split at %v", split)
+	}
+}
+
+// newTestTransfer creates a partTransfer of the current version of the file
+// served by s3, in parts of 1024 bytes.
+func newTestTransfer(t *testing.T, s3 *fakeS3, gcs *fakeGCS, workers int) *partTransfer {
+	importer := newS3CompatibleImporter(t, getS3CompatibleImportArgs(s3.URL, true))
+	assert.NoError(t, importer.args.validateAndPopulate(mockPopulator{}))
+	importer.gcsClient = gcs
+	importer.args.exportETag = s3.etag
+	importer.args.exportFileSize = int64(len(s3.content))
+
+	transfer := importer.newPartTransfer("bucket", "disk.vmdk")
+	transfer.source = "s3://bucket/folder/disk.vmdk"
+	transfer.version = s3.etag
+	transfer.readRange = importer.readS3Range
+	transfer.partSize = 1024
+	transfer.workers = workers
+	transfer.retryDelays = nil
+	return transfer
+}
+
+func randomContent(size int) []byte {
+	content := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(content)
+	return content
+}
+
+// fakeS3 serves an S3 object with path-style addressing.
+type fakeS3 struct {
+	*httptest.Server
+	content []byte
+
+	mx       sync.Mutex
+	etag     string
+	failFrom int
+	requests []string
+}
+
+func newFakeS3(t *testing.T, content []byte) *fakeS3 {
+	f := &fakeS3{content: content, etag: `"etag1"`, failFrom: -1}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bucket/folder/disk.vmdk", r.URL.Path)
+		f.mx.Lock()
+		f.requests = append(f.requests, r.Header.Get("Range"))
+		etag, failFrom := f.etag, f.failFrom
+		f.mx.Unlock()
+		if failFrom >= 0 && strings.HasPrefix(r.Header.Get("Range"), fmt.Sprintf("bytes=%v-", failFrom)) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "disk.vmdk", time.Time{}, bytes.NewReader(f.content))
+	}))
+	return f
+}
+
+// ranges returns the ranges of the GET requests.
+func (f *fakeS3) ranges() []string {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	var ranges []string
+	for _, r := range f.requests {
+		if r != "" {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// fakeGCS keeps the objects of a GCS bucket in memory.
+type fakeGCS struct {
+	domain.StorageClientInterface
+
+	mx       sync.Mutex
+	objects  map[string][]byte
+	composes int
+	// corrupt is an object whose content is altered when it's written.
+	corrupt string
+}
+
+func newFakeGCS() *fakeGCS {
+	return &fakeGCS{objects: map[string][]byte{}}
+}
+
+func (f *fakeGCS) GetObject(bucket string, object string) domain.StorageObject {
+	return &fakeGCSObject{gcs: f, name: object}
+}
+
+func (f *fakeGCS) GetObjectAttrs(bucket string, object string) (*storage.ObjectAttrs, error) {
+	content, ok := f.get(object)
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	return &storage.ObjectAttrs{Name: object, Size: int64(len(content)),
+		CRC32C: crc32.Checksum(content, crc32cTable)}, nil
+}
+
+func (f *fakeGCS) Close() error {
+	return nil
+}
+
+func (f *fakeGCS) get(object string) ([]byte, bool) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	content, ok := f.objects[object]
+	return content, ok
+}
+
+func (f *fakeGCS) put(object string, content []byte) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if object == f.corrupt {
+		content = append([]byte{content[0] ^ 0xff}, content[1:]...)
+	}
+	f.objects[object] = content
+}
+
+type fakeGCSObject struct {
+	gcs  *fakeGCS
+	name string
+}
+
+func (o *fakeGCSObject) Delete() error {
+	o.gcs.mx.Lock()
+	defer o.gcs.mx.Unlock()
+	if _, ok := o.gcs.objects[o.name]; !ok {
+		return storage.ErrObjectNotExist
+	}
+	delete(o.gcs.objects, o.name)
+	return nil
+}
+
+func (o *fakeGCSObject) GetObjectHandle() *storage.ObjectHandle {
+	return nil
+}
+
+func (o *fakeGCSObject) NewReader() (io.ReadCloser, error) {
+	content, ok := o.gcs.get(o.name)
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (o *fakeGCSObject) NewWriter() io.WriteCloser {
+	return &fakeGCSWriter{object: o}
+}
+
+func (o *fakeGCSObject) ObjectName() string {
+	return o.name
+}
+
+func (o *fakeGCSObject) Compose(srcs ...domain.StorageObject) (*storage.ObjectAttrs, error) {
+	var content []byte
+	for _, src := range srcs {
+		c, ok := o.gcs.get(src.ObjectName())
+		if !ok {
+			return nil, storage.ErrObjectNotExist
+		}
+		content = append(content, c...)
+	}
+	o.gcs.put(o.name, content)
+	o.gcs.mx.Lock()
+	o.gcs.composes++
+	o.gcs.mx.Unlock()
+	return o.gcs.GetObjectAttrs("", o.name)
+}
+
+func (o *fakeGCSObject) CopyFrom(src domain.StorageObject) (*storage.ObjectAttrs, error) {
+	content, ok := o.gcs.get(src.ObjectName())
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	o.gcs.put(o.name, content)
+	return o.gcs.GetObjectAttrs("", o.name)
+}
+
+// fakeGCSWriter writes the object when it's closed.
+type fakeGCSWriter struct {
+	bytes.Buffer
+	object *fakeGCSObject
+}
+
+func (w *fakeGCSWriter) Close() error {
+	w.object.gcs.put(w.object.name, w.Bytes())
+	return nil
+}
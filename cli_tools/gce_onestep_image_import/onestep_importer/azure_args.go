@@ -0,0 +1,182 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"encoding/base64"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/param"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/validation"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// azureImportArguments holds the structured results of parsing CLI arguments,
+// and optionally allows for validating and populating the arguments.
+type azureImportArguments struct {
+	// Passed in by user
+	clientID           string
+	executablePath     string
+	gcsComputeEndpoint string
+	gcsProjectPtr      *string
+	gcsZone            string
+	gcsRegion          string
+	gcsScratchBucket   string
+	gcsStorageLocation string
+	managedDiskID      string
+	sourceVHDURL       string
+	storageAccount     string
+	storageAccountKey  string
+	tenantID           string
+	appID              string
+	appSecret          string
+
+	// Endpoints, overridden by tests and sovereign clouds.
+	authorityHost       string
+	resourceManagerHost string
+
+	// Internal generated
+	exportFileSize int64
+}
+
+// Flags
+const (
+	azureSourceVHDURLFlag      = "azure_source_vhd_url"
+	azureStorageAccountFlag    = "azure_storage_account"
+	azureStorageAccountKeyFlag = "azure_storage_account_key"
+	azureManagedDiskIDFlag     = "azure_managed_disk_id"
+	azureTenantIDFlag          = "azure_tenant_id"
+	azureClientIDFlag          = "azure_client_id"
+	azureClientSecretFlag      = "azure_client_secret"
+)
+
+const (
+	azureDefaultAuthorityHost       = "https://login.microsoftonline.com"
+	azureDefaultResourceManagerHost = "https://management.azure.com"
+)
+
+var azureManagedDiskIDRegex = regexp.MustCompile(
+	`(?i)^/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Compute/disks/[^/]+$`)
+
+// newAzureImportArguments creates a new azureImportArguments instance.
+func newAzureImportArguments(args *OneStepImportArguments) *azureImportArguments {
+	return &azureImportArguments{
+		clientID:            args.ClientID,
+		executablePath:      args.ExecutablePath,
+		gcsComputeEndpoint:  args.ComputeEndpoint,
+		gcsProjectPtr:       args.ProjectPtr,
+		gcsZone:             args.Zone,
+		gcsRegion:           args.Region,
+		gcsScratchBucket:    args.ScratchBucketGcsPath,
+		gcsStorageLocation:  args.StorageLocation,
+		managedDiskID:       args.AzureManagedDiskID,
+		sourceVHDURL:        args.AzureSourceVHDURL,
+		storageAccount:      args.AzureStorageAccount,
+		storageAccountKey:   args.AzureStorageAccountKey,
+		tenantID:            args.AzureTenantID,
+		appID:               args.AzureClientID,
+		appSecret:           args.AzureClientSecret,
+		authorityHost:       azureDefaultAuthorityHost,
+		resourceManagerHost: azureDefaultResourceManagerHost,
+	}
+}
+
+// isAzureImport returns true if any of the Azure flags is specified.
+func isAzureImport(args *OneStepImportArguments) bool {
+	return args.AzureSourceVHDURL != "" || args.AzureStorageAccount != "" ||
+		args.AzureStorageAccountKey != "" || args.AzureManagedDiskID != "" ||
+		args.AzureTenantID != "" || args.AzureClientID != "" || args.AzureClientSecret != ""
+}
+
+// validateAndPopulate validates args related to import from Azure, and populates
+// any missing parameters.
+func (args *azureImportArguments) validateAndPopulate(populator param.Populator) error {
+	if err := args.validate(); err != nil {
+		return err
+	}
+
+	return populator.PopulateMissingParameters(args.gcsProjectPtr, args.clientID, &args.gcsZone,
+		&args.gcsRegion, &args.gcsScratchBucket, "", &args.gcsStorageLocation)
+}
+
+func (args *azureImportArguments) validate() error {
+	isBlobImport := args.sourceVHDURL != "" && args.managedDiskID == ""
+	isDiskImport := args.sourceVHDURL == "" && args.managedDiskID != ""
+	if !(isBlobImport || isDiskImport) {
+		return daisy.Errf("specify -%v to import from a VHD blob, or -%v to "+
+			"import from a managed disk", azureSourceVHDURLFlag, azureManagedDiskIDFlag)
+	}
+
+	if isBlobImport {
+		if args.tenantID != "" || args.appID != "" || args.appSecret != "" {
+			return daisy.Errf("-%v, -%v and -%v can only be used with -%v",
+				azureTenantIDFlag, azureClientIDFlag, azureClientSecretFlag, azureManagedDiskIDFlag)
+		}
+		return args.validateBlobArgs()
+	}
+
+	if args.storageAccount != "" || args.storageAccountKey != "" {
+		return daisy.Errf("-%v and -%v can only be used with -%v",
+			azureStorageAccountFlag, azureStorageAccountKeyFlag, azureSourceVHDURLFlag)
+	}
+	if !azureManagedDiskIDRegex.MatchString(args.managedDiskID) {
+		return daisy.Errf("%v is not a valid managed disk ID. It has to be of the form "+
+			"/subscriptions/SUBSCRIPTION/resourceGroups/GROUP/providers/Microsoft.Compute/disks/DISK",
+			args.managedDiskID)
+	}
+	if err := validation.ValidateStringFlagNotEmpty(args.tenantID, azureTenantIDFlag); err != nil {
+		return err
+	}
+	if err := validation.ValidateStringFlagNotEmpty(args.appID, azureClientIDFlag); err != nil {
+		return err
+	}
+	return validation.ValidateStringFlagNotEmpty(args.appSecret, azureClientSecretFlag)
+}
+
+func (args *azureImportArguments) validateBlobArgs() error {
+	u, err := url.Parse(args.sourceVHDURL)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return daisy.Errf("%v is not a valid blob URL", args.sourceVHDURL)
+	}
+	// Plain HTTP is only allowed for a local emulator, such as Azurite.
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLoopbackHost(u.Hostname())) {
+		return daisy.Errf("%v has to be an https:// blob URL", args.sourceVHDURL)
+	}
+
+	if (args.storageAccount == "") != (args.storageAccountKey == "") {
+		return daisy.Errf("-%v and -%v have to be specified together",
+			azureStorageAccountFlag, azureStorageAccountKeyFlag)
+	}
+	if args.storageAccountKey != "" {
+		if u.Query().Get("sig") != "" {
+			return daisy.Errf("-%v can't be used with a SAS URL", azureStorageAccountKeyFlag)
+		}
+		if _, err := base64.StdEncoding.DecodeString(args.storageAccountKey); err != nil {
+			return daisy.Errf("-%v has to be a base64 encoded key", azureStorageAccountKeyFlag)
+		}
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
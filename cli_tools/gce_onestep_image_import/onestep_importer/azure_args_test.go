@@ -0,0 +1,117 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAzureDiskID = "/subscriptions/sub/resourceGroups/group/providers/Microsoft.Compute/disks/disk"
+	// The well-known key of the Azurite emulator.
+	testAzureAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+func TestAzureArgsValidation(t *testing.T) {
+	diskFlags := []string{"-azure_managed_disk_id=" + testAzureDiskID, "-azure_tenant_id=tenant",
+		"-azure_client_id=app", "-azure_client_secret=secret"}
+
+	for _, tt := range []struct {
+		name       string
+		args       []string
+		errMessage string
+	}{
+		{"SAS URL", []string{"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd?sv=2020&sig=abc"}, ""},
+		{"account key", []string{"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd",
+			"-azure_storage_account=account", "-azure_storage_account_key=" + testAzureAccountKey}, ""},
+		{"Azurite", []string{"-azure_source_vhd_url=http://127.0.0.1:10000/devstoreaccount1/vhds/disk.vhd",
+			"-azure_storage_account=devstoreaccount1", "-azure_storage_account_key=" + testAzureAccountKey}, ""},
+		{"managed disk", diskFlags, ""},
+		{"no source", []string{"-azure_tenant_id=tenant"},
+			"specify -azure_source_vhd_url to import from a VHD blob, or -azure_managed_disk_id to import from a managed disk"},
+		{"both sources", append([]string{"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd"}, diskFlags...),
+			"specify -azure_source_vhd_url to import from a VHD blob, or -azure_managed_disk_id to import from a managed disk"},
+		{"plain HTTP", []string{"-azure_source_vhd_url=http://account.blob.core.windows.net/vhds/disk.vhd"},
+			"has to be an https:// blob URL"},
+		{"no blob", []string{"-azure_source_vhd_url=https://account.blob.core.windows.net"},
+			"is not a valid blob URL"},
+		{"key without account", []string{"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd",
+			"-azure_storage_account_key=" + testAzureAccountKey},
+			"-azure_storage_account and -azure_storage_account_key have to be specified together"},
+		{"key with SAS URL", []string{"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd?sig=abc",
+			"-azure_storage_account=account", "-azure_storage_account_key=" + testAzureAccountKey},
+			"-azure_storage_account_key can't be used with a SAS URL"},
+		{"invalid key", []string{"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd",
+			"-azure_storage_account=account", "-azure_storage_account_key=not base64"},
+			"-azure_storage_account_key has to be a base64 encoded key"},
+		{"service principal with blob", []string{"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd",
+			"-azure_tenant_id=tenant"},
+			"-azure_tenant_id, -azure_client_id and -azure_client_secret can only be used with -azure_managed_disk_id"},
+		{"account with managed disk", append([]string{"-azure_storage_account=account"}, diskFlags...),
+			"-azure_storage_account and -azure_storage_account_key can only be used with -azure_source_vhd_url"},
+		{"invalid managed disk ID", []string{"-azure_managed_disk_id=disk", "-azure_tenant_id=tenant",
+			"-azure_client_id=app", "-azure_client_secret=secret"},
+			"disk is not a valid managed disk ID"},
+		{"missing tenant", diskFlags[:1:1], "The flag -azure_tenant_id must be provided"},
+		{"missing client ID", diskFlags[:2:2], "The flag -azure_client_id must be provided"},
+		{"missing client secret", diskFlags[:3:3], "The flag -azure_client_secret must be provided"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args := getAzureImportArgs(setUpArgs("", tt.args...))
+			err := args.validate()
+			if tt.errMessage == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMessage)
+			}
+		})
+	}
+}
+
+func TestAzureArgsPopulateMissingParameters(t *testing.T) {
+	args := getAzureImportArgs(setUpArgs("",
+		"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd?sig=abc"))
+	err := args.validateAndPopulate(mockPopulator{
+		zone:          "us-west2-a",
+		region:        "us-west2",
+		scratchBucket: "gs://bucket",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "us-west2-a", args.gcsZone)
+	assert.Equal(t, "us-west2", args.gcsRegion)
+	assert.Equal(t, "gs://bucket", args.gcsScratchBucket)
+}
+
+func TestAzureArgsPopulateFailure(t *testing.T) {
+	args := getAzureImportArgs(setUpArgs("",
+		"-azure_source_vhd_url=https://account.blob.core.windows.net/vhds/disk.vhd?sig=abc"))
+	err := args.validateAndPopulate(mockPopulator{err: fmt.Errorf("populate error")})
+	assert.EqualError(t, err, "populate error")
+}
+
+func TestNewImporterRejectsAWSAndAzureFlags(t *testing.T) {
+	_, err := newImporterForCloudProvider(expectSuccessfulParse(t,
+		"-aws_region=us-east-1", "-azure_managed_disk_id="+testAzureDiskID))
+	assert.EqualError(t, err, "AWS and Azure flags can't be used together")
+}
+
+func getAzureImportArgs(args []string) *azureImportArguments {
+	importerArgs, _ := NewOneStepImportArguments(args)
+	return newAzureImportArguments(importerArgs)
+}
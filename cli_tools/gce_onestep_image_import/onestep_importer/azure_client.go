@@ -0,0 +1,428 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+const (
+	azureStorageAPIVersion = "2020-04-08"
+	azureComputeAPIVersion = "2020-12-01"
+	// Access tokens are renewed when they expire within this margin.
+	azureTokenExpiryMargin = 5 * time.Minute
+)
+
+// azureBlobClient reads a blob with the Blob service REST API. Requests are
+// either authorized by the SAS token of the blob URL, or signed with the
+// storage account key.
+type azureBlobClient struct {
+	ctx        context.Context
+	httpClient *http.Client
+	blobURL    string
+	account    string
+	key        []byte
+}
+
+// newAzureBlobClient creates an azureBlobClient for blobURL. The account and
+// key are optional, and only needed when blobURL isn't a SAS URL.
+func newAzureBlobClient(ctx context.Context, httpClient *http.Client, blobURL, account, key string) (*azureBlobClient, error) {
+	client := &azureBlobClient{ctx: ctx, httpClient: httpClient, blobURL: blobURL, account: account}
+	if key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, daisy.Errf("invalid storage account key: %v", err)
+		}
+		client.key = decoded
+	}
+	return client, nil
+}
+
+// getProperties returns the size and type of the blob.
+func (c *azureBlobClient) getProperties() (int64, string, error) {
+	resp, err := c.do(http.MethodHead, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", daisy.Errf("failed to get properties of blob %v: %v", c.redactedURL(), resp.Status)
+	}
+	return resp.ContentLength, resp.Header.Get("x-ms-blob-type"), nil
+}
+
+// getRange returns the bytes start to end of the blob, inclusive.
+func (c *azureBlobClient) getRange(start, end int64) (io.ReadCloser, error) {
+	resp, err := c.do(http.MethodGet, http.Header{
+		"x-ms-range": {fmt.Sprintf("bytes=%v-%v", start, end)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, daisy.Errf("failed to read blob %v: %v", c.redactedURL(), resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (c *azureBlobClient) do(method string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, method, c.blobURL, nil)
+	if err != nil {
+		return nil, daisy.ToDError(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("x-ms-version", azureStorageAPIVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	if c.key != nil {
+		req.Header.Set("Authorization", fmt.Sprintf("SharedKey %v:%v", c.account, c.sign(req)))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The error includes the URL, which may contain a SAS token.
+		return nil, daisy.Errf("request to blob %v failed", c.redactedURL())
+	}
+	return resp, nil
+}
+
+// sign computes the Shared Key signature of req.
+func (c *azureBlobClient) sign(req *http.Request) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(c.stringToSign(req)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stringToSign returns the string signed by the Shared Key of req. See
+// https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (c *azureBlobClient) stringToSign(req *http.Request) string {
+	var msHeaders []string
+	for k := range req.Header {
+		if lower := strings.ToLower(k); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+
+	var b strings.Builder
+	b.WriteString(req.Method + "\n")
+	for _, h := range []string{"Content-Encoding", "Content-Language", "Content-Length", "Content-MD5",
+		"Content-Type", "Date", "If-Modified-Since", "If-Match", "If-None-Match", "If-Unmodified-Since", "Range"} {
+		v := req.Header.Get(h)
+		if h == "Content-Length" && v == "0" {
+			v = ""
+		}
+		b.WriteString(v + "\n")
+	}
+	for _, h := range msHeaders {
+		b.WriteString(h + ":" + strings.TrimSpace(req.Header.Get(h)) + "\n")
+	}
+	b.WriteString("/" + c.account + req.URL.EscapedPath())
+	query := map[string][]string{}
+	var params []string
+	for k, v := range req.URL.Query() {
+		lower := strings.ToLower(k)
+		if _, ok := query[lower]; !ok {
+			params = append(params, lower)
+		}
+		query[lower] = append(query[lower], v...)
+	}
+	sort.Strings(params)
+	for _, k := range params {
+		values := query[k]
+		sort.Strings(values)
+		b.WriteString("\n" + k + ":" + strings.Join(values, ","))
+	}
+	return b.String()
+}
+
+// redactedURL returns the blob URL without its SAS token.
+func (c *azureBlobClient) redactedURL() string {
+	return redactAzureURL(c.blobURL)
+}
+
+func redactAzureURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	u.RawQuery = ""
+	return u.String()
+}
+
+// azureDiskClient grants and revokes read access to managed disks with the
+// Azure Resource Manager REST API, authenticated as a service principal.
+type azureDiskClient struct {
+	ctx                 context.Context
+	httpClient          *http.Client
+	authorityHost       string
+	resourceManagerHost string
+	tenantID            string
+	appID               string
+	appSecret           string
+	pollInterval        time.Duration
+
+	token       string
+	tokenExpiry time.Time
+}
+
+// newAzureDiskClient creates an azureDiskClient from the Azure import arguments.
+func newAzureDiskClient(ctx context.Context, httpClient *http.Client, args *azureImportArguments) *azureDiskClient {
+	return &azureDiskClient{
+		ctx:                 ctx,
+		httpClient:          httpClient,
+		authorityHost:       strings.TrimSuffix(args.authorityHost, "/"),
+		resourceManagerHost: strings.TrimSuffix(args.resourceManagerHost, "/"),
+		tenantID:            args.tenantID,
+		appID:               args.appID,
+		appSecret:           args.appSecret,
+		pollInterval:        10 * time.Second,
+	}
+}
+
+// grantAccess returns a SAS URL to read the disk for duration. The disk has
+// to be detached from VMs, or its VM stopped.
+func (c *azureDiskClient) grantAccess(diskID string, duration time.Duration) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"access":            "Read",
+		"durationInSeconds": int64(duration.Seconds()),
+	})
+	if err != nil {
+		return "", daisy.ToDError(err)
+	}
+	resp, err := c.post(diskID+"/beginGetAccess", string(body))
+	if err != nil {
+		return "", daisy.Errf("failed to grant access to disk %v: %v", diskID, err)
+	}
+
+	var result struct {
+		AccessSAS string `json:"accessSAS"`
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		err = decodeAzureResponse(resp, &result)
+	case http.StatusAccepted:
+		resp.Body.Close()
+		err = c.waitForOperation(resp.Header, &result)
+	default:
+		err = azureResponseError(resp)
+	}
+	if err != nil {
+		return "", daisy.Errf("failed to grant access to disk %v: %v", diskID, err)
+	}
+	if result.AccessSAS == "" {
+		return "", daisy.Errf("failed to grant access to disk %v: no SAS URL returned", diskID)
+	}
+	return result.AccessSAS, nil
+}
+
+// revokeAccess revokes the SAS URLs granted for the disk, without waiting
+// for the operation to finish.
+func (c *azureDiskClient) revokeAccess(diskID string) error {
+	resp, err := c.post(diskID+"/endGetAccess", "")
+	if err != nil {
+		return daisy.Errf("failed to revoke access to disk %v: %v", diskID, err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return daisy.Errf("failed to revoke access to disk %v: %v", diskID, azureResponseError(resp))
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// waitForOperation polls the long running operation of an accepted request
+// until it's done, and decodes its output into result.
+func (c *azureDiskClient) waitForOperation(header http.Header, result interface{}) error {
+	// Azure-AsyncOperation returns the status and output of the operation.
+	// Location returns 202 until the operation is done, and then its output.
+	asyncURL, locationURL := header.Get("Azure-AsyncOperation"), header.Get("Location")
+	if asyncURL == "" && locationURL == "" {
+		return fmt.Errorf("no operation to poll returned")
+	}
+	for {
+		select {
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		case <-time.After(c.pollInterval):
+		}
+
+		if asyncURL != "" {
+			var op struct {
+				Status     string `json:"status"`
+				Properties struct {
+					Output json.RawMessage `json:"output"`
+				} `json:"properties"`
+				Error struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := c.get(asyncURL, &op); err != nil {
+				return err
+			}
+			switch op.Status {
+			case "InProgress":
+				continue
+			case "Succeeded":
+				return json.Unmarshal(op.Properties.Output, result)
+			default:
+				return fmt.Errorf("operation %v: %v", op.Status, op.Error.Message)
+			}
+		}
+
+		resp, err := c.request(http.MethodGet, locationURL, "")
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusAccepted {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return azureResponseError(resp)
+		}
+		return decodeAzureResponse(resp, result)
+	}
+}
+
+func (c *azureDiskClient) post(resourceID, body string) (*http.Response, error) {
+	return c.request(http.MethodPost, fmt.Sprintf("%v%v?api-version=%v",
+		c.resourceManagerHost, resourceID, azureComputeAPIVersion), body)
+}
+
+func (c *azureDiskClient) get(rawURL string, result interface{}) error {
+	resp, err := c.request(http.MethodGet, rawURL, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return azureResponseError(resp)
+	}
+	return decodeAzureResponse(resp, result)
+}
+
+// request sends a request authorized with the access token. The token is
+// renewed before it expires, and once more if the request is unauthorized, as
+// the access to the disk is revoked after a copy that can outlast the token.
+func (c *azureDiskClient) request(method, rawURL, body string) (*http.Response, error) {
+	resp, err := c.authorizedRequest(method, rawURL, body)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+	c.token = ""
+	return c.authorizedRequest(method, rawURL, body)
+}
+
+func (c *azureDiskClient) authorizedRequest(method, rawURL, body string) (*http.Response, error) {
+	if c.token == "" || (!c.tokenExpiry.IsZero() && time.Now().Add(azureTokenExpiryMargin).After(c.tokenExpiry)) {
+		if err := c.authenticate(); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(c.ctx, method, rawURL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.httpClient.Do(req)
+}
+
+// authenticate gets an access token for the Resource Manager with the client
+// credentials flow.
+func (c *azureDiskClient) authenticate() error {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.appID},
+		"client_secret": {c.appSecret},
+		"scope":         {c.resourceManagerHost + "/.default"},
+	}
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost,
+		fmt.Sprintf("%v/%v/oauth2/v2.0/token", c.authorityHost, url.PathEscape(c.tenantID)),
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to authenticate to Azure: %v", azureResponseError(resp))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := decodeAzureResponse(resp, &token); err != nil {
+		return err
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("failed to authenticate to Azure: no access token returned")
+	}
+	c.token = token.AccessToken
+	c.tokenExpiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		c.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return nil
+}
+
+func decodeAzureResponse(resp *http.Response, result interface{}) error {
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// azureResponseError returns an error with the status and the message of
+// an unexpected response.
+func azureResponseError(resp *http.Response) error {
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	// Resource Manager errors have an error object, and Azure AD errors an
+	// error code and description.
+	var armError struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(b, &armError) == nil && armError.Error.Message != "" {
+		return fmt.Errorf("%v: %v", resp.Status, armError.Error.Message)
+	}
+	var adError struct {
+		ErrorDescription string `json:"error_description"`
+	}
+	if json.Unmarshal(b, &adError) == nil && adError.ErrorDescription != "" {
+		return fmt.Errorf("%v: %v", resp.Status, adError.ErrorDescription)
+	}
+	return fmt.Errorf("%v", resp.Status)
+}
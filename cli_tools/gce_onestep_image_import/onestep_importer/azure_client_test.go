@@ -0,0 +1,303 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzureStringToSign(t *testing.T) {
+	client, err := newAzureBlobClient(context.Background(), nil,
+		"", "devstoreaccount1", testAzureAccountKey)
+	assert.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet,
+		"http://127.0.0.1:10000/devstoreaccount1/vhds/disk%20a.vhd?Timeout=30&comp=metadata", nil)
+	req.Header.Set("x-ms-date", "Mon, 01 Feb 2021 00:00:00 GMT")
+	req.Header.Set("x-ms-version", "2020-04-08")
+	req.Header.Set("x-ms-range", "bytes=0-511")
+
+	assert.Equal(t, "GET\n"+strings.Repeat("\n", 11)+
+		"x-ms-date:Mon, 01 Feb 2021 00:00:00 GMT\n"+
+		"x-ms-range:bytes=0-511\n"+
+		"x-ms-version:2020-04-08\n"+
+		"/devstoreaccount1/devstoreaccount1/vhds/disk%20a.vhd\n"+
+		"comp:metadata\n"+
+		"timeout:30", client.stringToSign(req))
+}
+
+func TestAzureBlobClientSignsRequestsWithAccountKey(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		assert.Equal(t, azureStorageAPIVersion, r.Header.Get("x-ms-version"))
+		w.Header().Set("x-ms-blob-type", "PageBlob")
+		w.Header().Set("Content-Length", "1024")
+	}))
+	defer server.Close()
+
+	client, err := newAzureBlobClient(context.Background(), server.Client(),
+		server.URL+"/account/vhds/disk.vhd", "account", testAzureAccountKey)
+	assert.NoError(t, err)
+	size, blobType, err := client.getProperties()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), size)
+	assert.Equal(t, "PageBlob", blobType)
+	assert.Len(t, authorization, 1)
+	assert.Regexp(t, "^SharedKey account:[A-Za-z0-9+/]{43}=$", authorization[0])
+}
+
+func TestAzureBlobClientDoesNotLeakSASToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client, err := newAzureBlobClient(context.Background(), server.Client(),
+		server.URL+"/vhds/disk.vhd?sv=2020&sig=secret", "", "")
+	assert.NoError(t, err)
+	_, _, err = client.getProperties()
+	assert.EqualError(t, err, "failed to get properties of blob "+server.URL+"/vhds/disk.vhd: 403 Forbidden")
+
+	server.Close()
+	_, err = client.getRange(0, 1)
+	assert.EqualError(t, err, "request to blob "+server.URL+"/vhds/disk.vhd failed")
+}
+
+func TestAzureDiskClientGrantsAccessWithAsyncOperation(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+
+	sasURL, err := fake.diskClient().grantAccess(testAzureDiskID, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, fake.URL+"/blob/disk.vhd?sig=sas", sasURL)
+	assert.Equal(t, `{"access":"Read","durationInSeconds":3600}`, fake.grantRequest)
+	assert.Equal(t, 2, fake.operationPolls)
+}
+
+func TestAzureDiskClientGrantsAccessWithLocation(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+	fake.useLocation = true
+
+	sasURL, err := fake.diskClient().grantAccess(testAzureDiskID, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, fake.URL+"/blob/disk.vhd?sig=sas", sasURL)
+	assert.Equal(t, 2, fake.operationPolls)
+}
+
+func TestAzureDiskClientRevokesAccess(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+
+	assert.NoError(t, fake.diskClient().revokeAccess(testAzureDiskID))
+	assert.True(t, fake.revoked)
+}
+
+func TestAzureDiskClientRenewsTokenBeforeExpiry(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+	client := fake.diskClient()
+
+	_, err := client.grantAccess(testAzureDiskID, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fake.tokens)
+
+	// The copy took about as long as the token lasts.
+	client.tokenExpiry = time.Now().Add(time.Minute)
+	assert.NoError(t, client.revokeAccess(testAzureDiskID))
+	assert.True(t, fake.revoked)
+	assert.Equal(t, 2, fake.tokens)
+}
+
+func TestAzureDiskClientRenewsTokenWhenUnauthorized(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+	client := fake.diskClient()
+
+	_, err := client.grantAccess(testAzureDiskID, time.Hour)
+	assert.NoError(t, err)
+	fake.validToken = "another"
+	assert.NoError(t, client.revokeAccess(testAzureDiskID))
+	assert.True(t, fake.revoked)
+	assert.Equal(t, 2, fake.tokens)
+}
+
+func TestAzureDiskClientFailsWhenRenewedTokenIsUnauthorized(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+	fake.unauthorized = true
+
+	err := fake.diskClient().revokeAccess(testAzureDiskID)
+	assert.EqualError(t, err, "failed to revoke access to disk "+testAzureDiskID+
+		": 401 Unauthorized: The access token expiry has passed")
+	assert.False(t, fake.revoked)
+	assert.Equal(t, 2, fake.tokens)
+}
+
+func TestAzureDiskClientReportsErrors(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+	fake.grantStatus = http.StatusConflict
+
+	_, err := fake.diskClient().grantAccess(testAzureDiskID, time.Hour)
+	assert.EqualError(t, err, "failed to grant access to disk "+testAzureDiskID+
+		": 409 Conflict: Disk is attached to a running VM")
+}
+
+func TestAzureDiskClientReportsAuthenticationErrors(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+	client := fake.diskClient()
+	client.appSecret = "wrong"
+
+	_, err := client.grantAccess(testAzureDiskID, time.Hour)
+	assert.EqualError(t, err, "failed to grant access to disk "+testAzureDiskID+
+		": failed to authenticate to Azure: 401 Unauthorized: Invalid client secret")
+}
+
+// fakeAzure serves the Azure AD, Resource Manager and Blob APIs used to
+// import a managed disk, whose content is blob.
+type fakeAzure struct {
+	*httptest.Server
+	t    *testing.T
+	blob []byte
+
+	useLocation    bool
+	grantStatus    int
+	grantRequest   string
+	operationPolls int
+	revoked        bool
+	// tokens is the number of access tokens issued, and validToken the one
+	// accepted by the Resource Manager.
+	tokens     int
+	validToken string
+	// unauthorized rejects all tokens.
+	unauthorized bool
+}
+
+func newFakeAzure(t *testing.T, blob []byte) *fakeAzure {
+	f := &fakeAzure{t: t, blob: blob, grantStatus: http.StatusAccepted}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tenant/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, f.URL+"/.default", r.PostForm.Get("scope"))
+		if r.PostForm.Get("client_id") != "app" || r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"Invalid client secret"}`))
+			return
+		}
+		f.tokens++
+		f.validToken = fmt.Sprintf("token-%v", f.tokens)
+		w.Write([]byte(`{"access_token":"` + f.validToken + `","token_type":"Bearer","expires_in":3599}`))
+	})
+	mux.HandleFunc(testAzureDiskID+"/beginGetAccess", func(w http.ResponseWriter, r *http.Request) {
+		if !f.checkARMRequest(w, r, http.MethodPost) {
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		f.grantRequest = string(b)
+		if f.grantStatus != http.StatusAccepted {
+			w.WriteHeader(f.grantStatus)
+			w.Write([]byte(`{"error":{"code":"OperationNotAllowed","message":"Disk is attached to a running VM"}}`))
+			return
+		}
+		if f.useLocation {
+			w.Header().Set("Location", f.URL+"/location/1")
+		} else {
+			w.Header().Set("Azure-AsyncOperation", f.URL+"/operations/1")
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/operations/1", func(w http.ResponseWriter, r *http.Request) {
+		if !f.checkARMRequest(w, r, http.MethodGet) {
+			return
+		}
+		f.operationPolls++
+		if f.operationPolls == 1 {
+			w.Write([]byte(`{"status":"InProgress"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"Succeeded","properties":{"output":{"accessSAS":"` + f.URL + `/blob/disk.vhd?sig=sas"}}}`))
+	})
+	mux.HandleFunc("/location/1", func(w http.ResponseWriter, r *http.Request) {
+		if !f.checkARMRequest(w, r, http.MethodGet) {
+			return
+		}
+		f.operationPolls++
+		if f.operationPolls == 1 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Write([]byte(`{"accessSAS":"` + f.URL + `/blob/disk.vhd?sig=sas"}`))
+	})
+	mux.HandleFunc(testAzureDiskID+"/endGetAccess", func(w http.ResponseWriter, r *http.Request) {
+		if !f.checkARMRequest(w, r, http.MethodPost) {
+			return
+		}
+		f.revoked = true
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/blob/disk.vhd", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "sas", r.URL.Query().Get("sig"))
+		// The Blob service accepts the range in x-ms-range.
+		if rng := r.Header.Get("x-ms-range"); rng != "" {
+			r.Header.Set("Range", rng)
+		}
+		w.Header().Set("x-ms-blob-type", "PageBlob")
+		http.ServeContent(w, r, "disk.vhd", time.Time{}, strings.NewReader(string(f.blob)))
+	})
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// checkARMRequest checks a Resource Manager request, and replies 401 when it
+// isn't authorized with the valid token.
+func (f *fakeAzure) checkARMRequest(w http.ResponseWriter, r *http.Request, method string) bool {
+	assert.Equal(f.t, method, r.Method)
+	if strings.HasPrefix(r.URL.Path, "/subscriptions/") {
+		assert.Equal(f.t, azureComputeAPIVersion, r.URL.Query().Get("api-version"))
+	}
+	if f.unauthorized || r.Header.Get("Authorization") != "Bearer "+f.validToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"code":"ExpiredAuthenticationToken","message":"The access token expiry has passed"}}`))
+		return false
+	}
+	return true
+}
+
+func (f *fakeAzure) importArgs() *azureImportArguments {
+	args := getAzureImportArgs(setUpArgs("", "-azure_managed_disk_id="+testAzureDiskID,
+		"-azure_tenant_id=tenant", "-azure_client_id=app", "-azure_client_secret=secret"))
+	args.authorityHost = f.URL
+	args.resourceManagerHost = f.URL
+	return args
+}
+
+func (f *fakeAzure) diskClient() *azureDiskClient {
+	client := newAzureDiskClient(context.Background(), f.Client(), f.importArgs())
+	client.pollInterval = time.Millisecond
+	return client
+}
@@ -0,0 +1,376 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/domain"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/compute"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/param"
+	pathutils "github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/path"
+	storageutils "github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/storage"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	azureLogPrefix = "[onestep-import-image-azure]"
+
+	// A VHD ends with a 512 bytes footer, which starts with the cookie and
+	// stores the disk type at offset 60.
+	vhdFooterSize       = 512
+	vhdFooterCookie     = "conectix"
+	vhdDiskTypeOffset   = 60
+	vhdFixedDiskType    = 2
+	azureReadRetryDelay = time.Second
+)
+
+// azureImporter is responsible for importing a VHD blob or a managed disk from Azure.
+type azureImporter struct {
+	args           *azureImportArguments
+	gcsClient      domain.StorageClientInterface
+	ctx            context.Context
+	oauth          string
+	paramPopulator param.Populator
+	timeoutChan    chan struct{}
+	httpClient     *http.Client
+	uploader       *uploader
+
+	// Azure clients
+	blobClient *azureBlobClient
+	diskClient *azureDiskClient
+
+	// Impl of the functions
+	copyFromAzureToGCSFn func() (string, error)
+	importImageFn        func() error
+}
+
+// newAzureImporter creates an new azureImporter instance.
+// Automatically populating dependencies, such as compute/storage clients.
+func newAzureImporter(oauth string, timeoutChan chan struct{}, args *azureImportArguments) (*azureImporter, error) {
+	ctx := context.Background()
+	client, err := createAzureGCSClient(ctx, oauth)
+	if err != nil {
+		return nil, err
+	}
+
+	computeClient, err := param.CreateComputeClient(&ctx, oauth, args.gcsComputeEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataGCE := &compute.MetadataGCE{}
+	paramPopulator := param.NewPopulator(
+		metadataGCE,
+		client,
+		storageutils.NewResourceLocationRetriever(metadataGCE, computeClient),
+		storageutils.NewScratchBucketCreator(ctx, client),
+	)
+
+	return &azureImporter{
+		args:           args,
+		gcsClient:      client,
+		ctx:            ctx,
+		oauth:          oauth,
+		paramPopulator: paramPopulator,
+		timeoutChan:    timeoutChan,
+		httpClient:     &http.Client{},
+	}, nil
+}
+
+// createAzureGCSClient creates a new GCS client, and logs with the Azure prefix.
+func createAzureGCSClient(ctx context.Context, oauth string) (domain.StorageClientInterface, error) {
+	client, err := createGCSClient(ctx, oauth)
+	log.SetPrefix(azureLogPrefix + " ")
+	return client, err
+}
+
+// run runs the azure importer to import the blob or managed disk.
+func (importer *azureImporter) run(importArgs *OneStepImportArguments) error {
+	startTime := time.Now()
+	// 1. validate Azure args
+	if err := importer.args.validateAndPopulate(importer.paramPopulator); err != nil {
+		return err
+	}
+
+	// 2. get a SAS URL for the managed disk, if needed.
+	if importer.args.managedDiskID != "" {
+		log.Println("Granting access to managed disk ...")
+		if importer.diskClient == nil {
+			importer.diskClient = newAzureDiskClient(importer.ctx, importer.httpClient, importer.args)
+		}
+		sasURL, err := importer.diskClient.grantAccess(importer.args.managedDiskID, importArgs.Timeout)
+		if err != nil {
+			return err
+		}
+		defer importer.revokeAccess()
+		importer.args.sourceVHDURL = sasURL
+	}
+
+	// 3. check the VHD blob
+	if err := importer.inspectBlob(); err != nil {
+		return err
+	}
+
+	// 4. copy from Azure to GCS
+	log.Println("Starting to copy ...")
+	gcsFilePath, err := importer.copyFromAzureToGCS()
+	if err != nil {
+		return err
+	}
+
+	// 5. run image import
+	log.Println("Starting to import image ...")
+	if err := importer.importImage(importArgs, startTime, gcsFilePath); err != nil {
+		return err
+	}
+	log.Println("Image import from Azure finished successfully!")
+
+	// 6. clean up temporary image file created in GCS
+	log.Println("Cleaning up ...")
+	importer.cleanUp(gcsFilePath)
+	return nil
+}
+
+// revokeAccess revokes the SAS URL of the managed disk.
+func (importer *azureImporter) revokeAccess() {
+	log.Printf("Revoking access to %v.\n", importer.args.managedDiskID)
+	if err := importer.diskClient.revokeAccess(importer.args.managedDiskID); err != nil {
+		log.Printf("%v. The disk can't be attached until access to it is revoked.\n", err)
+	}
+}
+
+// cleanUp deletes the temporary file created during image import, and closes GCS client.
+func (importer *azureImporter) cleanUp(gcsFilePath string) {
+	err := importer.gcsClient.DeleteGcsPath(gcsFilePath)
+	if err != nil {
+		log.Printf("Could not delete image file %v: %v. "+
+			"To avoid incurring charges to your billing account, "+
+			"you must manually delete the file from the storage location.\n", gcsFilePath, err.Error())
+	}
+
+	importer.gcsClient.Close()
+}
+
+// inspectBlob gets the size of the blob, and verifies that it's a fixed VHD,
+// which is the only format of Azure disks.
+func (importer *azureImporter) inspectBlob() error {
+	if importer.blobClient == nil {
+		client, err := newAzureBlobClient(importer.ctx, importer.httpClient, importer.args.sourceVHDURL,
+			importer.args.storageAccount, importer.args.storageAccountKey)
+		if err != nil {
+			return err
+		}
+		importer.blobClient = client
+	}
+	source := redactAzureURL(importer.args.sourceVHDURL)
+
+	size, _, err := importer.blobClient.getProperties()
+	if err != nil {
+		return err
+	}
+	if size < vhdFooterSize {
+		return daisy.Errf("%v is not a VHD file: it is smaller than a VHD footer", source)
+	}
+	importer.args.exportFileSize = size
+
+	body, err := importer.blobClient.getRange(size-vhdFooterSize, size-1)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	footer := make([]byte, vhdFooterSize)
+	if _, err := io.ReadFull(body, footer); err != nil {
+		return daisy.Errf("failed to read the VHD footer of %v: %v", source, err)
+	}
+	if string(footer[:len(vhdFooterCookie)]) != vhdFooterCookie {
+		return daisy.Errf("%v is not a VHD file", source)
+	}
+	if diskType := binary.BigEndian.Uint32(footer[vhdDiskTypeOffset:]); diskType != vhdFixedDiskType {
+		return daisy.Errf("%v is not a fixed VHD (disk type %v). Convert it to a fixed VHD, "+
+			"as required by Azure, before importing it", source, diskType)
+	}
+	log.Printf("Found fixed VHD %v of %v.\n", source, humanize.IBytes(uint64(size)))
+	return nil
+}
+
+// importImage updates importArgs to contain the image source file and updated timeout duration.
+// It runs image import to import from gcsFilePath to Compute Engine.
+func (importer *azureImporter) importImage(importArgs *OneStepImportArguments, startTime time.Time, gcsFilePath string) error {
+	if importer.importImageFn != nil {
+		return importer.importImageFn()
+	}
+
+	// update source file flag to copied GCS destination
+	importArgs.SourceFile = gcsFilePath
+
+	// adjust timeout to pass into image import
+	importArgs.Timeout = importArgs.Timeout - time.Since(startTime)
+	if importArgs.Timeout <= 0 {
+		return daisy.Errf("timeout exceeded")
+	}
+
+	// add label to indicate the image import is run from onestep import
+	if importArgs.Labels == nil {
+		importArgs.Labels = make(map[string]string)
+	}
+	importArgs.Labels["onestep-image-import"] = "azure"
+
+	err := runImageImport(importArgs)
+	if err != nil {
+		log.Printf("Failed to import image. "+
+			"The image file is copied to Cloud Storage, located at %v.\n", gcsFilePath)
+		return err
+	}
+
+	return nil
+}
+
+// copyFromAzureToGCS copies the VHD file from Azure to GCS.
+func (importer *azureImporter) copyFromAzureToGCS() (string, error) {
+	if importer.copyFromAzureToGCSFn != nil {
+		return importer.copyFromAzureToGCSFn()
+	}
+
+	start := time.Now()
+	// 1. get GCS path as copy destination.
+	gcsFilePath := pathutils.JoinURL(importer.args.gcsScratchBucket,
+		fmt.Sprintf("onestep-image-import-azure-%v.vhd", pathutils.RandString(5)))
+
+	log.Printf("Copying %v to %v.\n", redactAzureURL(importer.args.sourceVHDURL), gcsFilePath)
+
+	// 2. create a new folder for local buffer
+	path := filepath.Join(filepath.Dir(importer.args.executablePath), fmt.Sprint("upload", pathutils.RandString(5)))
+
+	err := os.Mkdir(path, 0755)
+	if err != nil {
+		return "", daisy.ToDError(err)
+	}
+	defer os.RemoveAll(path)
+
+	// 3. get writer
+	bs, err := humanize.ParseBytes(uploadBufSize)
+	if err != nil {
+		return "", daisy.ToDError(err)
+	}
+	bkt, obj, err := storageutils.GetGCSObjectPathElements(gcsFilePath)
+	if err != nil {
+		return "", err
+	}
+	workers := int64(runtime.NumCPU())
+	writer := storageutils.NewBufferedWriter(importer.ctx, int64(bs), workers, createAzureGCSClient, importer.oauth, path, bkt, obj)
+
+	// 4. Transfer file from Azure to GCS
+	if err := importer.transferFile(writer); err != nil {
+		return gcsFilePath, err
+	}
+	log.Printf("Successfully copied to %v in %v.\n", gcsFilePath, time.Since(start))
+
+	return gcsFilePath, nil
+}
+
+// transferFile downloads the blob and uploads it to writer concurrently.
+func (importer *azureImporter) transferFile(writer io.WriteCloser) error {
+	// 1. Set up download size and get number of chunks to download
+	output, err := humanize.ParseBytes(downloadBufSize)
+	if err != nil {
+		return daisy.ToDError(err)
+	}
+	readSize := int64(output)
+	size := importer.args.exportFileSize
+	// Take ceiling to get number of chunks to download.
+	readers := (size-1)/readSize + 1
+	// Set up download retry delay interval
+	delayTime := []int{1, 2, 4, 8, 8}
+	maxRetryTimes := len(delayTime)
+
+	// 2. Set up upload info
+	importer.uploader = &uploader{
+		readerChan:    make(chan io.ReadCloser, downloadBufNum),
+		writer:        writer,
+		totalFileSize: size,
+		uploadErrChan: make(chan error),
+	}
+	importer.uploader.Add(1)
+	go importer.uploader.uploadFile()
+
+	// 3. Range download
+	for i := int64(0); i < readers; i++ {
+		// Stop downloading if timeout exceeded.
+		select {
+		case <-importer.timeoutChan:
+			importer.uploader.cleanup()
+			return daisy.Errf("timeout exceeded during transfer file")
+		default:
+			// Did not timeout, continue to download.
+		}
+
+		startRange := i * readSize
+		endRange := startRange + readSize - 1
+		if endRange >= size {
+			endRange = size - 1
+		}
+		for retryAttempt := 0; ; retryAttempt++ {
+			body, err := importer.blobClient.getRange(startRange, endRange)
+			if err != nil {
+				if retryAttempt >= maxRetryTimes {
+					importer.uploader.cleanup()
+					return daisy.Errf("error in downloading from %v: %v",
+						redactAzureURL(importer.args.sourceVHDURL), err)
+				}
+				time.Sleep(time.Duration(delayTime[retryAttempt]) * azureReadRetryDelay)
+				continue
+			}
+			importer.uploader.readerChan <- body
+			break
+		}
+
+		// Stop downloading as soon as one of the upload fails.
+		select {
+		case err := <-importer.uploader.uploadErrChan:
+			importer.uploader.cleanup()
+			return err
+		default:
+			// No error, continue to download.
+		}
+	}
+
+	// All file chunks are downloaded, wait for upload to finish. The error
+	// channel is closed once the upload finishes.
+	close(importer.uploader.readerChan)
+	if err, ok := <-importer.uploader.uploadErrChan; ok {
+		return daisy.Errf("error in uploading to Cloud Storage: %v", err)
+	}
+	importer.uploader.Wait()
+
+	if importer.uploader.totalUploaded != size {
+		return daisy.Errf("copied %v bytes of %v from %v", importer.uploader.totalUploaded, size,
+			redactAzureURL(importer.args.sourceVHDURL))
+	}
+	if err := importer.uploader.writer.Close(); err != nil {
+		return daisy.ToDError(err)
+	}
+	return nil
+}
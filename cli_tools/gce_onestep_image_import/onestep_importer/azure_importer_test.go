@@ -0,0 +1,259 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/mocks"
+)
+
+func TestAzureRunImportsManagedDisk(t *testing.T) {
+	vhd := createTestVHD("disk content", vhdFixedDiskType)
+	fake := newFakeAzure(t, vhd)
+	defer fake.Close()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockStorageClient := mocks.NewMockStorageClientInterface(mockCtrl)
+	mockStorageClient.EXPECT().DeleteGcsPath("gs://bucket/disk.vhd").Return(nil)
+	mockStorageClient.EXPECT().Close().Return(nil)
+
+	importer := newTestAzureImporter(fake, fake.importArgs(), mockStorageClient)
+	var copied bytes.Buffer
+	importer.copyFromAzureToGCSFn = func() (string, error) {
+		return "gs://bucket/disk.vhd", importer.transferFile(testWriteCloser{bufio.NewWriter(&copied), nil})
+	}
+	imported := false
+	importer.importImageFn = func() error {
+		assert.False(t, fake.revoked)
+		imported = true
+		return nil
+	}
+
+	assert.NoError(t, importer.run(expectSuccessfulParse(t)))
+	assert.True(t, imported)
+	assert.True(t, fake.revoked)
+	assert.Equal(t, vhd, copied.Bytes())
+	assert.Equal(t, int64(len(vhd)), importer.args.exportFileSize)
+}
+
+func TestAzureRunRevokesAccessWhenImportFails(t *testing.T) {
+	fake := newFakeAzure(t, createTestVHD("disk content", vhdFixedDiskType))
+	defer fake.Close()
+
+	importer := newTestAzureImporter(fake, fake.importArgs(), nil)
+	importer.copyFromAzureToGCSFn = func() (string, error) {
+		return "gs://bucket/disk.vhd", nil
+	}
+	importer.importImageFn = func() error {
+		return fmt.Errorf("import failed")
+	}
+
+	assert.EqualError(t, importer.run(expectSuccessfulParse(t)), "import failed")
+	assert.True(t, fake.revoked)
+}
+
+func TestAzureRunLogsWhenAccessCantBeRevoked(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	fake := newFakeAzure(t, createTestVHD("disk content", vhdFixedDiskType))
+	defer fake.Close()
+
+	importer := newTestAzureImporter(fake, fake.importArgs(), nil)
+	importer.copyFromAzureToGCSFn = func() (string, error) {
+		// The service principal lost access during the copy.
+		fake.unauthorized = true
+		return "gs://bucket/disk.vhd", nil
+	}
+	importer.importImageFn = func() error {
+		return fmt.Errorf("import failed")
+	}
+
+	assert.EqualError(t, importer.run(expectSuccessfulParse(t)), "import failed")
+	assert.False(t, fake.revoked)
+	assert.Contains(t, buf.String(), "failed to revoke access to disk "+testAzureDiskID+
+		": 401 Unauthorized: The access token expiry has passed. The disk can't be attached until access to it is revoked.")
+}
+
+func TestAzureRunFailsWhenValidationFails(t *testing.T) {
+	fake := newFakeAzure(t, nil)
+	defer fake.Close()
+	args := fake.importArgs()
+	args.tenantID = ""
+
+	importer := newTestAzureImporter(fake, args, nil)
+	err := importer.run(expectSuccessfulParse(t))
+	assert.EqualError(t, err, "The flag -azure_tenant_id must be provided")
+	assert.Empty(t, fake.grantRequest)
+}
+
+func TestAzureInspectBlob(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		blob       []byte
+		errMessage string
+	}{
+		{"fixed VHD", createTestVHD("disk content", vhdFixedDiskType), ""},
+		{"dynamic VHD", createTestVHD("disk content", 3), "is not a fixed VHD (disk type 3)"},
+		{"not a VHD", bytes.Repeat([]byte{1}, 1024), "is not a VHD file"},
+		{"too small", []byte("disk content"), "is not a VHD file: it is smaller than a VHD footer"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeAzure(t, tt.blob)
+			defer fake.Close()
+			args := fake.importArgs()
+			args.sourceVHDURL = fake.URL + "/blob/disk.vhd?sig=sas"
+
+			err := newTestAzureImporter(fake, args, nil).inspectBlob()
+			if tt.errMessage == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(len(tt.blob)), args.exportFileSize)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMessage)
+				assert.NotContains(t, err.Error(), "sig=sas")
+			}
+		})
+	}
+}
+
+func TestAzureTransferFileStopsOnTimeout(t *testing.T) {
+	fake := newFakeAzure(t, createTestVHD("disk content", vhdFixedDiskType))
+	defer fake.Close()
+	args := fake.importArgs()
+	args.sourceVHDURL = fake.URL + "/blob/disk.vhd?sig=sas"
+	importer := newTestAzureImporter(fake, args, nil)
+	assert.NoError(t, importer.inspectBlob())
+
+	close(importer.timeoutChan)
+	var copied bytes.Buffer
+	err := importer.transferFile(testWriteCloser{bufio.NewWriter(&copied), nil})
+	assert.EqualError(t, err, "timeout exceeded during transfer file")
+}
+
+func TestAzureTransferFileFailsWhenUploadFails(t *testing.T) {
+	fake := newFakeAzure(t, createTestVHD("disk content", vhdFixedDiskType))
+	defer fake.Close()
+	args := fake.importArgs()
+	args.sourceVHDURL = fake.URL + "/blob/disk.vhd?sig=sas"
+	importer := newTestAzureImporter(fake, args, nil)
+	assert.NoError(t, importer.inspectBlob())
+
+	err := importer.transferFile(&failingWriteCloser{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "write failed")
+}
+
+// TestAzureImportFromAzurite copies a VHD from the Azurite emulator, for example:
+//
+//	docker run -p 10000:10000 mcr.microsoft.com/azure-storage/azurite azurite-blob --blobHost 0.0.0.0
+//	AZURITE_BLOB_ENDPOINT=http://127.0.0.1:10000/devstoreaccount1 go test -run Azurite
+func TestAzureImportFromAzurite(t *testing.T) {
+	endpoint := os.Getenv("AZURITE_BLOB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("AZURITE_BLOB_ENDPOINT isn't set")
+	}
+	const account = "devstoreaccount1"
+	container := fmt.Sprintf("onestep-test-%v", time.Now().UnixNano())
+	vhd := createTestVHD(strings.Repeat("disk content", 100000), vhdFixedDiskType)
+	putAzuriteBlob(t, endpoint, account, container, vhd)
+
+	args := getAzureImportArgs(setUpArgs("",
+		fmt.Sprintf("-azure_source_vhd_url=%v/%v/disk.vhd", endpoint, container),
+		"-azure_storage_account="+account, "-azure_storage_account_key="+testAzureAccountKey))
+	assert.NoError(t, args.validate())
+	importer := &azureImporter{args: args, ctx: context.Background(), httpClient: &http.Client{},
+		timeoutChan: make(chan struct{})}
+	assert.NoError(t, importer.inspectBlob())
+
+	var copied bytes.Buffer
+	assert.NoError(t, importer.transferFile(testWriteCloser{bufio.NewWriter(&copied), nil}))
+	assert.Equal(t, vhd, copied.Bytes())
+}
+
+// putAzuriteBlob creates the container, and uploads content to its disk.vhd blob.
+func putAzuriteBlob(t *testing.T, endpoint, account, container string, content []byte) {
+	client, err := newAzureBlobClient(context.Background(), nil, "", account, testAzureAccountKey)
+	assert.NoError(t, err)
+	for _, put := range []struct {
+		url  string
+		body []byte
+	}{
+		{fmt.Sprintf("%v/%v?restype=container", endpoint, container), nil},
+		{fmt.Sprintf("%v/%v/disk.vhd", endpoint, container), content},
+	} {
+		req, err := http.NewRequest(http.MethodPut, put.url, bytes.NewReader(put.body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Length", fmt.Sprint(len(put.body)))
+		req.Header.Set("x-ms-version", azureStorageAPIVersion)
+		req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+		if put.body != nil {
+			req.Header.Set("x-ms-blob-type", "BlockBlob")
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("SharedKey %v:%v", account, client.sign(req)))
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode, put.url)
+	}
+}
+
+func newTestAzureImporter(fake *fakeAzure, args *azureImportArguments,
+	gcsClient *mocks.MockStorageClientInterface) *azureImporter {
+	importer := &azureImporter{
+		args:           args,
+		ctx:            context.Background(),
+		paramPopulator: mockPopulator{scratchBucket: "gs://bucket"},
+		timeoutChan:    make(chan struct{}),
+		httpClient:     fake.Client(),
+		diskClient:     fake.diskClient(),
+	}
+	if gcsClient != nil {
+		importer.gcsClient = gcsClient
+	}
+	return importer
+}
+
+// createTestVHD returns a VHD of the given type, with the footer appended to content.
+func createTestVHD(content string, diskType uint32) []byte {
+	footer := make([]byte, vhdFooterSize)
+	copy(footer, vhdFooterCookie)
+	binary.BigEndian.PutUint32(footer[vhdDiskTypeOffset:], diskType)
+	return append([]byte(content), footer...)
+}
+
+type failingWriteCloser struct{}
+
+func (failingWriteCloser) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("write failed")
+}
+
+func (failingWriteCloser) Close() error {
+	return nil
+}
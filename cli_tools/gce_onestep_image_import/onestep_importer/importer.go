@@ -53,6 +53,9 @@ type OneStepImportArguments struct {
 	Region                string
 	ScratchBucketGcsPath  string
 	SourceFile            string
+	SourceURL             string
+	SourceChecksum        string
+	SourceHeader          string
 	StdoutLogsDisabled    bool
 	StorageLocation       string
 	Subnet                string
@@ -69,6 +72,17 @@ type OneStepImportArguments struct {
 	AWSAMIID             string
 	AWSAMIExportLocation string
 	AWSSourceAMIFilePath string
+	AWSSnapshotID        string
+	AWSS3Endpoint        string
+	AWSS3ForcePathStyle  bool
+
+	AzureSourceVHDURL      string
+	AzureStorageAccount    string
+	AzureStorageAccountKey string
+	AzureManagedDiskID     string
+	AzureTenantID          string
+	AzureClientID          string
+	AzureClientSecret      string
 }
 
 // Flags that are validated.
@@ -116,6 +130,10 @@ func (args *OneStepImportArguments) registerFlags(flagSet *flag.FlagSet) {
 	flagSet.Var((*flags.TrimmedString)(&args.AWSSourceAMIFilePath), awsSourceAMIFilePathFlag,
 		"The S3 resource path of the exported image file.")
 
+	flagSet.Var((*flags.TrimmedString)(&args.AWSSnapshotID), awsSnapshotIDFlag,
+		"The ID of the EBS snapshot to import. Its blocks are read with the EBS direct APIs, "+
+			"without exporting an AMI.")
+
 	flagSet.Var((*flags.TrimmedString)(&args.AWSRegion), awsRegionFlag,
 		"The AWS region for the image that you want to import.")
 
@@ -129,6 +147,53 @@ func (args *OneStepImportArguments) registerFlags(flagSet *flag.FlagSet) {
 			"This credential is associated with an IAM user or role. "+
 			"This IAM user must have permissions to import images.")
 
+	flagSet.Var((*flags.TrimmedString)(&args.AWSS3Endpoint), awsS3EndpointFlag,
+		"The URL of an S3-compatible endpoint, such as MinIO, Ceph or Wasabi, "+
+			"to copy -"+awsSourceAMIFilePathFlag+" from instead of AWS S3. "+
+			"-"+awsSessionTokenFlag+" and -"+awsRegionFlag+" are optional with it.")
+
+	flagSet.BoolVar(&args.AWSS3ForcePathStyle, awsS3ForcePathStyleFlag, false,
+		"Use path-style addressing (ENDPOINT/BUCKET/KEY) for S3 requests, "+
+			"instead of virtual-hosted-style (BUCKET.ENDPOINT/KEY).")
+
+	flagSet.Var((*flags.TrimmedString)(&args.AzureSourceVHDURL), azureSourceVHDURLFlag,
+		"The URL of the fixed VHD blob to import. It is either a SAS URL, "+
+			"or a blob URL when -"+azureStorageAccountKeyFlag+" is specified.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.AzureStorageAccount), azureStorageAccountFlag,
+		"The name of the storage account of -"+azureSourceVHDURLFlag+", "+
+			"when it's accessed with the storage account key.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.AzureStorageAccountKey), azureStorageAccountKeyFlag,
+		"The key of the storage account of -"+azureSourceVHDURLFlag+".")
+
+	flagSet.Var((*flags.TrimmedString)(&args.AzureManagedDiskID), azureManagedDiskIDFlag,
+		"The resource ID of the Azure managed disk to import. Read access to the disk "+
+			"is granted for the import, so it has to be detached, or its VM stopped.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.AzureTenantID), azureTenantIDFlag,
+		"The Azure AD tenant of the service principal that grants access to -"+azureManagedDiskIDFlag+".")
+
+	flagSet.Var((*flags.TrimmedString)(&args.AzureClientID), azureClientIDFlag,
+		"The application ID of the service principal that grants access to -"+azureManagedDiskIDFlag+". "+
+			"It needs the Microsoft.Compute/disks/beginGetAccess/action and "+
+			"Microsoft.Compute/disks/endGetAccess/action permissions.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.AzureClientSecret), azureClientSecretFlag,
+		"The client secret of the service principal.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.SourceURL), sourceURLFlag,
+		"The https:// URL of the virtual disk file to import. "+
+			"The file is downloaded to the scratch bucket before it's imported.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.SourceChecksum), sourceChecksumFlag,
+		"Checksum of the -source_url file, verified once it's downloaded. "+
+			"For example: sha256:HEX_DIGEST. Supported algorithms are md5, sha1, sha256 and sha512.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.SourceHeader), sourceHeaderFlag,
+		"HTTP header sent when downloading the -source_url file, for example "+
+			"'Authorization: Bearer TOKEN'.")
+
 	flagSet.Var((*flags.LowerTrimmedString)(&args.ClientID), clientFlag,
 		"Identifies the client of the importer, e.g. 'gcloud', 'pantheon', or 'api'.")
 
@@ -0,0 +1,75 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"net/url"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/storage"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// Flags
+const (
+	sourceURLFlag      = "source_url"
+	sourceChecksumFlag = "source_checksum"
+	sourceHeaderFlag   = "source_header"
+)
+
+// urlImporter imports a disk image file served over HTTPS. Image import
+// downloads the file to the scratch bucket, resuming interrupted downloads.
+type urlImporter struct {
+	// Test hook for running image import.
+	importImageFn func(importArgs *OneStepImportArguments) error
+}
+
+// newURLImporter validates the URL flags and creates a urlImporter.
+func newURLImporter(args *OneStepImportArguments) (*urlImporter, error) {
+	if args.SourceURL == "" {
+		return nil, daisy.Errf("-%v and -%v can only be used with -%v",
+			sourceChecksumFlag, sourceHeaderFlag, sourceURLFlag)
+	}
+	if isAWSImport(args) || isAzureImport(args) {
+		return nil, daisy.Errf("-%v can't be used with AWS or Azure flags", sourceURLFlag)
+	}
+	u, err := url.Parse(args.SourceURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, daisy.Errf("-%v %q has to be an https:// URL", sourceURLFlag, args.SourceURL)
+	}
+	if args.SourceChecksum != "" {
+		if err := storage.ValidateChecksum(args.SourceChecksum); err != nil {
+			return nil, err
+		}
+	}
+	if args.SourceHeader != "" {
+		if _, err := storage.ParseHTTPHeader(args.SourceHeader); err != nil {
+			return nil, err
+		}
+	}
+	return &urlImporter{importImageFn: runImageImport}, nil
+}
+
+// run imports the file at SourceURL.
+func (importer *urlImporter) run(importArgs *OneStepImportArguments) error {
+	importArgs.SourceFile = importArgs.SourceURL
+
+	// add label to indicate the image import is run from onestep import
+	if importArgs.Labels == nil {
+		importArgs.Labels = make(map[string]string)
+	}
+	importArgs.Labels["onestep-image-import"] = "url"
+
+	return importer.importImageFn(importArgs)
+}
@@ -0,0 +1,76 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewImporterReturnsURLImporter(t *testing.T) {
+	importer, err := newImporterForCloudProvider(expectSuccessfulParse(t,
+		"-source_url=https://example.com/disk.vmdk",
+		"-source_checksum=md5:d41d8cd98f00b204e9800998ecf8427e",
+		"-source_header=Authorization: Bearer token"))
+	assert.NoError(t, err)
+	assert.IsType(t, &urlImporter{}, importer)
+}
+
+func TestURLImporterValidatesFlags(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		args       []string
+		errMessage string
+	}{
+		{"checksum without URL", []string{"-source_checksum=md5:d41d8cd98f00b204e9800998ecf8427e"},
+			"-source_checksum and -source_header can only be used with -source_url"},
+		{"header without URL", []string{"-source_header=Authorization: Bearer token"},
+			"-source_checksum and -source_header can only be used with -source_url"},
+		{"http URL", []string{"-source_url=http://example.com/disk.vmdk"},
+			"has to be an https:// URL"},
+		{"Cloud Storage path", []string{"-source_url=gs://bucket/disk.vmdk"},
+			"has to be an https:// URL"},
+		{"AWS flags", []string{"-source_url=https://example.com/disk.vmdk", "-aws_ami_id=ami-123"},
+			"-source_url can't be used with AWS or Azure flags"},
+		{"Azure flags", []string{"-source_url=https://example.com/disk.vmdk", "-azure_managed_disk_id=disk"},
+			"-source_url can't be used with AWS or Azure flags"},
+		{"invalid checksum", []string{"-source_url=https://example.com/disk.vmdk", "-source_checksum=crc32:00000000"},
+			"unsupported checksum algorithm"},
+		{"invalid header", []string{"-source_url=https://example.com/disk.vmdk", "-source_header=Authorization"},
+			"HTTP header has to be of the form NAME: VALUE"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newImporterForCloudProvider(expectSuccessfulParse(t, tt.args...))
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMessage)
+		})
+	}
+}
+
+func TestURLImporterImportsURL(t *testing.T) {
+	args := expectSuccessfulParse(t, "-source_url=https://example.com/disk.vmdk", "-labels=env=test")
+	importer, err := newURLImporter(args)
+	assert.NoError(t, err)
+	var imported *OneStepImportArguments
+	importer.importImageFn = func(importArgs *OneStepImportArguments) error {
+		imported = importArgs
+		return nil
+	}
+
+	assert.NoError(t, importer.run(args))
+	assert.Equal(t, "https://example.com/disk.vmdk", imported.SourceFile)
+	assert.Equal(t, map[string]string{"env": "test", "onestep-image-import": "url"}, imported.Labels)
+}
@@ -53,9 +53,18 @@ type cloudProviderImporter interface {
 }
 
 // newImporterFormCloudProvider evaluates the cloud provider of the source image
-// and creates a new instance of cloudProviderImporter. Currently, only AWS cloud
-// provider is supported.
+// and creates a new instance of cloudProviderImporter. Currently, AWS, Azure
+// and files served over HTTPS are supported.
 func newImporterForCloudProvider(args *OneStepImportArguments) (cloudProviderImporter, error) {
+	if args.SourceURL != "" || args.SourceChecksum != "" || args.SourceHeader != "" {
+		return newURLImporter(args)
+	}
+	if isAzureImport(args) {
+		if isAWSImport(args) {
+			return nil, daisy.Errf("AWS and Azure flags can't be used together")
+		}
+		return newAzureImporter(args.Oauth, args.TimeoutChan, newAzureImportArguments(args))
+	}
 	return newAWSImporter(args.Oauth, args.TimeoutChan, newAWSImportArguments(args))
 }
 
@@ -104,6 +113,8 @@ func runImageImport(args *OneStepImportArguments) error {
 		fmt.Sprintf("-client_version=%v", args.ClientVersion),
 		fmt.Sprintf("-os=%v", args.OS),
 		fmt.Sprintf("-source_file=%v", args.SourceFile),
+		fmt.Sprintf("-source_checksum=%v", args.SourceChecksum),
+		fmt.Sprintf("-source_header=%v", args.SourceHeader),
 		fmt.Sprintf("-no_guest_environment=%v", args.NoGuestEnvironment),
 		fmt.Sprintf("-family=%v", args.Family),
 		fmt.Sprintf("-description=%v", args.Description),
@@ -48,6 +48,8 @@ type OVFImportParams struct {
 	// Common flags
 	ClientID                    string
 	OvfOvaGcsPath               string
+	SourceChecksum              string
+	SourceHeader                string
 	NoGuestEnvironment          bool
 	CanIPForward                bool
 	DeletionProtection          bool
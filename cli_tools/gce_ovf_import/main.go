@@ -35,7 +35,9 @@ var (
 	machineImageName            = flag.String(ovfimporter.MachineImageNameFlagKey, "", "Name of the machine image to create.")
 	clientID                    = flag.String(ovfimporter.ClientIDFlagKey, "", "Identifies the client of the importer, e.g. `gcloud` or `pantheon`")
 	clientVersion               = flag.String("client-version", "", "Identifies the version of the client of the importer")
-	ovfOvaGcsPath               = flag.String(ovfimporter.OvfGcsPathFlagKey, "", " Google Cloud Storage URI of the OVF or OVA file to import. For example: gs://my-bucket/my-vm.ovf.")
+	ovfOvaGcsPath               = flag.String(ovfimporter.OvfGcsPathFlagKey, "", " Google Cloud Storage URI of the OVF or OVA file to import, or https:// URL of the OVA file to import. For example: gs://my-bucket/my-vm.ovf.")
+	sourceChecksum              = flag.String(ovfimporter.SourceChecksumFlagKey, "", "Checksum of an https:// OVA file, verified once it's downloaded. For example: sha256:HEX_DIGEST. Supported algorithms are md5, sha1, sha256 and sha512.")
+	sourceHeader                = flag.String(ovfimporter.SourceHeaderFlagKey, "", "HTTP header sent when downloading an https:// OVA file, for example 'Authorization: Bearer TOKEN'.")
 	noGuestEnvironment          = flag.Bool("no-guest-environment", false, "Google Guest Environment will not be installed on the image.")
 	canIPForward                = flag.Bool("can-ip-forward", false, "If provided, allows the instances to send and receive packets with non-matching destination or source IP addresses.")
 	deletionProtection          = flag.Bool("deletion-protection", false, "Enables deletion protection for the instance.")
@@ -94,8 +96,8 @@ func buildOVFImportParams() *domain.OVFImportParams {
 	flag.Parse()
 	return &domain.OVFImportParams{InstanceNames: *instanceNames,
 		MachineImageName: *machineImageName, ClientID: *clientID,
-		OvfOvaGcsPath: *ovfOvaGcsPath, NoGuestEnvironment: *noGuestEnvironment,
-		CanIPForward: *canIPForward, DeletionProtection: *deletionProtection, Description: *description,
+		OvfOvaGcsPath: *ovfOvaGcsPath, SourceChecksum: *sourceChecksum, SourceHeader: *sourceHeader,
+		NoGuestEnvironment: *noGuestEnvironment, CanIPForward: *canIPForward, DeletionProtection: *deletionProtection, Description: *description,
 		Labels: *labels, MachineType: *machineType, Network: *network, NetworkTier: *networkTier,
 		Subnet: *subnet, PrivateNetworkIP: *privateNetworkIP, NoExternalIP: *noExternalIP,
 		NoRestartOnFailure: *noRestartOnFailure, OsID: *osID, BYOL: *byol,
@@ -79,6 +79,7 @@ type OVFImporter struct {
 	ovfDescriptorLoader ovfdomain.OvfDescriptorLoaderInterface
 	Logger              logging.Logger
 	gcsPathToClean      string
+	downloadedOvaPath   string
 	workflowPath        string
 	params              *ovfdomain.OVFImportParams
 	imageLocation       string
@@ -396,6 +397,11 @@ func (oi *OVFImporter) Import() (*daisy.Workflow, error) {
 		return nil, err
 	}
 
+	if err := oi.downloadOvaIfURL(); err != nil {
+		oi.Logger.User(err.Error())
+		return nil, err
+	}
+
 	w, err := oi.setUpImportWorkflow()
 
 	if err != nil {
@@ -414,6 +420,38 @@ func (oi *OVFImporter) Import() (*daisy.Workflow, error) {
 	return w, nil
 }
 
+// downloadOvaIfURL copies an OVA file served over HTTPS to the scratch
+// directory, and points OvfOvaGcsPath to the copy.
+func (oi *OVFImporter) downloadOvaIfURL() error {
+	if !isURL(oi.params.OvfOvaGcsPath) {
+		return nil
+	}
+	name, err := ovaURLFileName(oi.params.OvfOvaGcsPath)
+	if err != nil {
+		return err
+	}
+	gcsPath := strings.TrimSuffix(oi.params.ScratchBucketGcsPath, "/") + "/source/" + name
+	bkt, obj, err := storageutils.GetGCSObjectPathElements(gcsPath)
+	if err != nil {
+		return err
+	}
+
+	copier := storageutils.NewURLCopier(oi.ctx, oi.storageClient, oi.Logger)
+	copier.Checksum = oi.params.SourceChecksum
+	if oi.params.SourceHeader != "" {
+		if copier.Header, err = storageutils.ParseHTTPHeader(oi.params.SourceHeader); err != nil {
+			return err
+		}
+	}
+	oi.Logger.User(fmt.Sprintf("Downloading %v to %v", oi.params.OvfOvaGcsPath, gcsPath))
+	if _, err := copier.Copy(oi.params.OvfOvaGcsPath, bkt, obj); err != nil {
+		return err
+	}
+	oi.downloadedOvaPath = gcsPath
+	oi.params.OvfOvaGcsPath = gcsPath
+	return nil
+}
+
 func (oi *OVFImporter) handleTimeout(w *daisy.Workflow) {
 	time.Sleep(oi.params.Deadline.Sub(time.Now()))
 	oi.Logger.User(fmt.Sprintf("Timeout %v exceeded, stopping workflow %q", oi.params.Timeout, w.Name))
@@ -431,6 +469,12 @@ func (oi *OVFImporter) CleanUp() {
 					fmt.Sprintf("couldn't delete GCS path %v: %v", oi.gcsPathToClean, err.Error()))
 			}
 		}
+		if oi.downloadedOvaPath != "" {
+			if err := oi.storageClient.DeleteObject(oi.downloadedOvaPath); err != nil {
+				oi.Logger.User(
+					fmt.Sprintf("couldn't delete %v: %v", oi.downloadedOvaPath, err.Error()))
+			}
+		}
 
 		err := oi.storageClient.Close()
 		if err != nil {
@@ -18,7 +18,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -55,6 +57,12 @@ const (
 	// HostnameFlagKey is key for hostname CLI flag
 	HostnameFlagKey = "hostname"
 
+	// SourceChecksumFlagKey is key for the checksum of an OVA URL CLI flag
+	SourceChecksumFlagKey = "source-checksum"
+
+	// SourceHeaderFlagKey is key for the HTTP header sent to download an OVA URL CLI flag
+	SourceHeaderFlagKey = "source-header"
+
 	// Prefix for valid instance access config scopes
 	instanceAccessScopePrefix = "https://www.googleapis.com/auth/"
 )
@@ -146,8 +154,27 @@ func (p *ParamValidatorAndPopulator) ValidateAndPopulate(params *ovfdomain.OVFIm
 		return err
 	}
 
-	if _, err := storageutils.GetBucketNameFromGCSPath(params.OvfOvaGcsPath); err != nil {
-		return daisy.Errf("%v should be a path to OVF or OVA package in Cloud Storage", OvfGcsPathFlagKey)
+	if isURL(params.OvfOvaGcsPath) {
+		if _, err := ovaURLFileName(params.OvfOvaGcsPath); err != nil {
+			return err
+		}
+	} else if _, err := storageutils.GetBucketNameFromGCSPath(params.OvfOvaGcsPath); err != nil {
+		return daisy.Errf("%v should be a path to OVF or OVA package in Cloud Storage, or an https:// URL of an OVA file", OvfGcsPathFlagKey)
+	}
+
+	if (params.SourceChecksum != "" || params.SourceHeader != "") && !isURL(params.OvfOvaGcsPath) {
+		return daisy.Errf("-%v and -%v can only be used when -%v is an https:// URL",
+			SourceChecksumFlagKey, SourceHeaderFlagKey, OvfGcsPathFlagKey)
+	}
+	if params.SourceChecksum != "" {
+		if err := storageutils.ValidateChecksum(params.SourceChecksum); err != nil {
+			return err
+		}
+	}
+	if params.SourceHeader != "" {
+		if _, err := storageutils.ParseHTTPHeader(params.SourceHeader); err != nil {
+			return err
+		}
 	}
 
 	if params.Labels != "" {
@@ -188,6 +215,26 @@ func (p *ParamValidatorAndPopulator) ValidateAndPopulate(params *ovfdomain.OVFIm
 	return nil
 }
 
+// isURL returns whether path is an HTTP(S) URL rather than a Cloud Storage path.
+func isURL(path string) bool {
+	lowered := strings.ToLower(path)
+	return strings.HasPrefix(lowered, "https://") || strings.HasPrefix(lowered, "http://")
+}
+
+// ovaURLFileName returns the name of the OVA file of an https:// URL. OVF
+// descriptors can't be downloaded, as they reference other files.
+func ovaURLFileName(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return "", daisy.Errf("%v %q is not a valid https:// URL", OvfGcsPathFlagKey, rawURL)
+	}
+	name := path.Base(parsed.Path)
+	if !strings.HasSuffix(strings.ToLower(name), ".ova") {
+		return "", daisy.Errf("%v %q has to be the URL of an OVA file", OvfGcsPathFlagKey, rawURL)
+	}
+	return name, nil
+}
+
 func (p *ParamValidatorAndPopulator) lookupProjectIfMissing(originalProject string) (*string, error) {
 	project, err := param.GetProjectID(p.metadataClient, strings.TrimSpace(originalProject))
 	return &project, err
@@ -268,6 +268,38 @@ func Test_ValidateAndParseParams_ErrorMessages(t *testing.T) {
 				params.OvfOvaGcsPath = "%%%%%"
 			},
 			expectErrorToContain: "ovf-gcs-path should be a path to OVF or OVA package in Cloud Storage",
+		}, {
+			name: "OvfOvaGcsPath URL must use HTTPS",
+			paramModifier: func(params *domain.OVFImportParams) {
+				params.OvfOvaGcsPath = "http://example.com/vm.ova"
+			},
+			expectErrorToContain: "is not a valid https:// URL",
+		}, {
+			name: "OvfOvaGcsPath URL must point to an OVA file",
+			paramModifier: func(params *domain.OVFImportParams) {
+				params.OvfOvaGcsPath = "https://example.com/vm.ovf"
+			},
+			expectErrorToContain: "has to be the URL of an OVA file",
+		}, {
+			name: "SourceChecksum requires an OvfOvaGcsPath URL",
+			paramModifier: func(params *domain.OVFImportParams) {
+				params.SourceChecksum = "md5:d41d8cd98f00b204e9800998ecf8427e"
+			},
+			expectErrorToContain: "-source-checksum and -source-header can only be used when -ovf-gcs-path is an https:// URL",
+		}, {
+			name: "validate SourceChecksum",
+			paramModifier: func(params *domain.OVFImportParams) {
+				params.OvfOvaGcsPath = "https://example.com/vm.ova"
+				params.SourceChecksum = "crc32:00000000"
+			},
+			expectErrorToContain: "unsupported checksum algorithm",
+		}, {
+			name: "validate SourceHeader",
+			paramModifier: func(params *domain.OVFImportParams) {
+				params.OvfOvaGcsPath = "https://example.com/vm.ova"
+				params.SourceHeader = "Authorization"
+			},
+			expectErrorToContain: "HTTP header has to be of the form NAME: VALUE",
 		}, {
 			name: "validate ReleaseTrack",
 			paramModifier: func(params *domain.OVFImportParams) {
@@ -386,6 +418,16 @@ func Test_ValidateAndParseParams_SuccessfulCases(t *testing.T) {
 			checkResult: func(t *testing.T, params *domain.OVFImportParams, importType string) {
 				assert.Equal(t, "gs://bucket/", params.OvfOvaGcsPath)
 			},
+		}, {
+			name: "OvfOvaGcsPath may be an HTTPS URL of an OVA file",
+			paramModifier: func(params *domain.OVFImportParams) {
+				params.OvfOvaGcsPath = "https://example.com/appliances/vm.OVA"
+				params.SourceChecksum = "md5:d41d8cd98f00b204e9800998ecf8427e"
+				params.SourceHeader = "Authorization: Bearer token"
+			},
+			checkResult: func(t *testing.T, params *domain.OVFImportParams, importType string) {
+				assert.Equal(t, "https://example.com/appliances/vm.OVA", params.OvfOvaGcsPath)
+			},
 		}, {
 			name: "Parse node affinities",
 			paramModifier: func(params *domain.OVFImportParams) {
@@ -137,7 +137,7 @@ func buildDaisyVars(destinationURI string, sourceImage string, sourceDiskSnapsho
 func runExportWorkflow(ctx context.Context, exportWorkflowPath string, varMap map[string]string,
 	project string, zone string, timeout string, scratchBucketGcsPath string, oauth string, ce string,
 	gcsLogsDisabled bool, cloudLogsDisabled bool, stdoutLogsDisabled bool,
-	userLabels map[string]string) (*daisy.Workflow, error) {
+	userLabels map[string]string, provisionNAT bool) (*daisy.Workflow, error) {
 
 	workflow, err := daisycommon.ParseWorkflow(exportWorkflowPath, varMap,
 		project, zone, scratchBucketGcsPath, oauth, timeout, ce, gcsLogsDisabled,
@@ -148,6 +148,7 @@ func runExportWorkflow(ctx context.Context, exportWorkflowPath string, varMap ma
 
 	preValidateWorkflowModifier := func(w *daisy.Workflow) {
 		w.SetLogProcessHook(daisyutils.RemovePrivacyLogTag)
+		daisyutils.ProvisionTemporaryNAT(w, w.Vars["export_network"].Value, provisionNAT)
 	}
 
 	postValidateWorkflowModifier := func(w *daisy.Workflow) {
@@ -174,7 +175,8 @@ func runExportWorkflow(ctx context.Context, exportWorkflowPath string, varMap ma
 func Run(clientID string, destinationURI string, sourceImage string, sourceDiskSnapshot string, format string,
 	project *string, network string, subnet string, zone string, timeout string,
 	scratchBucketGcsPath string, oauth string, ce string, computeServiceAccount string, gcsLogsDisabled bool,
-	cloudLogsDisabled bool, stdoutLogsDisabled bool, labels string, currentExecutablePath string) (*daisy.Workflow, error) {
+	cloudLogsDisabled bool, stdoutLogsDisabled bool, labels string, provisionNAT bool,
+	currentExecutablePath string) (*daisy.Workflow, error) {
 
 	log.SetPrefix(logPrefix + " ")
 
@@ -182,6 +184,9 @@ func Run(clientID string, destinationURI string, sourceImage string, sourceDiskS
 	if err != nil {
 		return nil, err
 	}
+	if provisionNAT && strings.TrimSpace(subnet) != "" && strings.TrimSpace(network) == "" {
+		return nil, daisy.Errf("-provision_nat with -subnet also needs -network, the network of the subnet that Cloud NAT is created on")
+	}
 
 	ctx := context.Background()
 	metadataGCE := &compute.MetadataGCE{}
@@ -211,7 +216,7 @@ func Run(clientID string, destinationURI string, sourceImage string, sourceDiskS
 	var w *daisy.Workflow
 	if w, err = runExportWorkflow(ctx, getWorkflowPath(format, currentExecutablePath), varMap, *project,
 		zone, timeout, scratchBucketGcsPath, oauth, ce, gcsLogsDisabled, cloudLogsDisabled,
-		stdoutLogsDisabled, userLabels); err != nil {
+		stdoutLogsDisabled, userLabels, provisionNAT); err != nil {
 
 		daisyutils.PostProcessDErrorForNetworkFlag("image export", err, network, w)
 
@@ -43,13 +43,14 @@ var (
 	cloudLogsDisabled     = flag.Bool("disable_cloud_logging", false, "do not stream logs to Cloud Logging.")
 	stdoutLogsDisabled    = flag.Bool("disable_stdout_logging", false, "do not display individual workflow logs on stdout.")
 	labels                = flag.String("labels", "", "List of label KEY=VALUE pairs to add. Keys must start with a lowercase character and contain only hyphens (-), underscores (_), lowercase characters, and numbers. Values must contain only hyphens (-), underscores (_), lowercase characters, and numbers.")
+	provisionNAT          = flag.Bool("provision_nat", false, "Create a temporary Cloud Router with Cloud NAT on the network used for the image export, so that the export instance can reach the internet without an external IP address. The router is deleted when the export finishes.")
 )
 
 func exportEntry() (service.Loggable, error) {
 	currentExecutablePath := string(os.Args[0])
 	wf, err := exporter.Run(*clientID, *destinationURI, *sourceImage, *sourceDiskSnapshot, *format, project,
 		*network, *subnet, *zone, *timeout, *scratchBucketGcsPath, *oauth, *ce, *computeServiceAccount,
-		*gcsLogsDisabled, *cloudLogsDisabled, *stdoutLogsDisabled, *labels, currentExecutablePath)
+		*gcsLogsDisabled, *cloudLogsDisabled, *stdoutLogsDisabled, *labels, *provisionNAT, currentExecutablePath)
 	return service.NewLoggableFromWorkflow(wf), err
 }
 
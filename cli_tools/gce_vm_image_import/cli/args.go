@@ -26,17 +26,20 @@ import (
 	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/flags"
 	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/param"
 	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/path"
+	"github.com/GoogleCloudPlatform/compute-image-tools/cli_tools/common/utils/storage"
 )
 
 // imageImportArgs receives arguments passed by the user and facilitates creating
 // importer.ImageImportRequest.
 type imageImportArgs struct {
-	ClientID      string
-	ClientVersion string
-	Region        string
-	SourceFile    string
-	SourceImage   string
-	Started       time.Time
+	ClientID       string
+	ClientVersion  string
+	Region         string
+	SourceFile     string
+	SourceImage    string
+	SourceChecksum string
+	SourceHeader   string
+	Started        time.Time
 	importer.ImageImportRequest
 }
 
@@ -75,12 +78,34 @@ func (args *imageImportArgs) populateAndValidate(populator param.Populator,
 	if err != nil {
 		return err
 	}
+	if (args.SourceChecksum != "" || args.SourceHeader != "") && !importer.IsURL(args.Source) {
+		return fmt.Errorf("-source_checksum and -source_header can only be used with an https:// -source_file")
+	}
+	if args.SourceChecksum != "" {
+		if err := storage.ValidateChecksum(args.SourceChecksum); err != nil {
+			return err
+		}
+	}
+	if args.SourceHeader != "" {
+		if _, err := storage.ParseHTTPHeader(args.SourceHeader); err != nil {
+			return err
+		}
+	}
+	// Local and HTTPS source files are uploaded to the scratch bucket, so they
+	// don't determine the bucket's location.
+	gcsSourceFile := args.SourceFile
+	if importer.IsLocalFile(args.Source) || importer.IsURL(args.Source) {
+		gcsSourceFile = ""
+	}
 	if err := populator.PopulateMissingParameters(&args.Project, args.ClientID, &args.Zone, &args.Region,
-		&args.ScratchBucketGcsPath, args.SourceFile, &args.StorageLocation); err != nil {
+		&args.ScratchBucketGcsPath, gcsSourceFile, &args.StorageLocation); err != nil {
 		return err
 	}
 
 	args.Network, args.Subnet = param.ResolveNetworkAndSubnet(args.Network, args.Subnet, args.Region)
+	if args.ProvisionNAT && args.Network == "" {
+		return fmt.Errorf("-provision_nat with -subnet also needs -network, the network of the subnet that Cloud NAT is created on")
+	}
 
 	// Ensure that all workflow logs are put in the same GCS directory.
 	// path.join doesn't work since it converts `gs://` to `gs:/`.
@@ -145,6 +170,12 @@ func (args *imageImportArgs) registerFlags(flagSet *flag.FlagSet) {
 			"Set this flag so that these temporary VMs are not assigned external IP addresses. "+
 			"For more information, see: https://cloud.google.com/compute/docs/import/importing-virtual-disks#no-external-ip")
 
+	flagSet.BoolVar(&args.ProvisionNAT, "provision_nat", false,
+		"Create a temporary Cloud Router with Cloud NAT on the network used by {operation}, "+
+			"so that temporary VMs without external IP addresses can reach the internet. "+
+			"The router is deleted when {operation} finishes. Don't use this flag if the "+
+			"network already has a Cloud NAT gateway in the region.")
+
 	flagSet.Var((*flags.TrimmedString)(&args.ExecutionID), "execution_id",
 		"The execution ID to differentiate GCE resources of each imports.")
 
@@ -172,7 +203,16 @@ func (args *imageImportArgs) registerFlags(flagSet *flag.FlagSet) {
 			"location closest to the source is chosen automatically.")
 
 	flagSet.Var((*flags.TrimmedString)(&args.SourceFile), "source_file",
-		"The Cloud Storage URI of the virtual disk file to import.")
+		"The Cloud Storage URI, https:// URL or local path of the virtual disk file to import. "+
+			"Files that aren't in Cloud Storage are uploaded to the scratch bucket first.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.SourceChecksum), "source_checksum",
+		"Checksum of an https:// -source_file, verified once it's downloaded. "+
+			"For example: sha256:HEX_DIGEST. Supported algorithms are md5, sha1, sha256 and sha512.")
+
+	flagSet.Var((*flags.TrimmedString)(&args.SourceHeader), "source_header",
+		"HTTP header sent when downloading an https:// -source_file, for example "+
+			"'Authorization: Bearer TOKEN'.")
 
 	flagSet.Var((*flags.TrimmedString)(&args.SourceImage), "source_image",
 		"An existing Compute Engine image from which to import.")
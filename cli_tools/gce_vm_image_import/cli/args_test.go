@@ -136,6 +136,20 @@ func Test_populateAndValidate_SupportsNoExternalIp(t *testing.T) {
 	assert.True(t, parseAndPopulate(t, "-no_external_ip").NoExternalIP)
 }
 
+func Test_populateAndValidate_SupportsProvisionNAT(t *testing.T) {
+	assert.False(t, parseAndPopulate(t, "-provision_nat=false").ProvisionNAT)
+	assert.True(t, parseAndPopulate(t, "-provision_nat").ProvisionNAT)
+}
+
+func Test_populateAndValidate_ProvisionNATRequiresNetworkWhenSubnetSpecified(t *testing.T) {
+	actual := addRequiredArgsAndParse(t, "-provision_nat", "-subnet=subnet")
+	err := actual.populateAndValidate(mockPopulator{
+		zone:   "us-west2-a",
+		region: "us-west2",
+	}, mockSourceFactory{})
+	assert.EqualError(t, err, "-provision_nat with -subnet also needs -network, the network of the subnet that Cloud NAT is created on")
+}
+
 func Test_populateAndValidate_TrimsSourceFile(t *testing.T) {
 	assert.Equal(t, "gs://bucket/image.vmdk", parseAndPopulate(
 		t, "-source_file", " gs://bucket/image.vmdk ").SourceFile)
@@ -380,6 +394,39 @@ func Test_populateAndValidate_FailsWhenSourceValidateFails(t *testing.T) {
 	assert.Contains(t, err.Error(), "bad source")
 }
 
+func Test_populateAndValidate_ValidatesSourceChecksumAndHeader(t *testing.T) {
+	for _, tt := range []struct {
+		name, sourceFile, checksum, header, errMessage string
+	}{
+		{"valid", "https://example.com/disk.vmdk", "md5:d41d8cd98f00b204e9800998ecf8427e", "Authorization: Bearer t", ""},
+		{"invalid checksum", "https://example.com/disk.vmdk", "md5:123", "", "is not a valid md5 digest"},
+		{"invalid header", "https://example.com/disk.vmdk", "", "Authorization", "HTTP header has to be of the form NAME: VALUE"},
+		{"not a URL", "gs://bucket/disk.vmdk", "md5:d41d8cd98f00b204e9800998ecf8427e", "", "can only be used with an https:// -source_file"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"-source_file", tt.sourceFile, "-source_checksum", tt.checksum, "-source_header", tt.header,
+				"-image_name=i", "-client_id=c", "-data_disk"}
+			actual, err := parseArgsFromUser(args)
+			assert.NoError(t, err)
+			var sourceFactory importer.SourceFactory = mockSourceFactory{expectedFile: tt.sourceFile, t: t}
+			if strings.HasPrefix(tt.sourceFile, "https://") {
+				sourceFactory = importer.NewSourceFactory(nil)
+			}
+			err = actual.populateAndValidate(mockPopulator{
+				zone:          "us-west2-a",
+				region:        "us-west2",
+				scratchBucket: "gs://custom-bucket/",
+			}, sourceFactory)
+			if tt.errMessage == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMessage)
+			}
+		})
+	}
+}
+
 func Test_populateAndValidate_StandardizesScratchBucketPath(t *testing.T) {
 	started := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
 	id := "abc"
@@ -77,6 +77,26 @@ func Main(args []string, toolLogger logging.ToolLogger, workflowDir string) erro
 		return err
 	}
 
+	// Upload local and HTTPS source files to the scratch bucket.
+	var uploader importer.SourceUploader
+	switch {
+	case importer.IsLocalFile(importArgs.Source):
+		uploader = importer.NewLocalFileUploader(ctx, storageClient, importArgs.Oauth, toolLogger)
+	case importer.IsURL(importArgs.Source):
+		uploader, err = importer.NewURLUploader(ctx, storageClient, importArgs.SourceChecksum, importArgs.SourceHeader, toolLogger)
+		if err != nil {
+			logFailure(importArgs, err)
+			return err
+		}
+	}
+	if uploader != nil {
+		importArgs.Source, err = uploader.Upload(importArgs.Source, importArgs.ScratchBucketGcsPath)
+		if err != nil {
+			logFailure(importArgs, err)
+			return err
+		}
+	}
+
 	// Run the import.
 	importRunner, err := importer.NewImporter(importArgs.ImageImportRequest, computeClient, storageClient, toolLogger)
 	if err != nil {
@@ -86,6 +106,9 @@ func Main(args []string, toolLogger logging.ToolLogger, workflowDir string) erro
 
 	importClosure := func() (service.Loggable, error) {
 		err := importRunner.Run(ctx)
+		if uploader != nil {
+			uploader.CleanUp(importArgs.Source, err)
+		}
 		return service.NewOutputInfoLoggable(toolLogger.ReadOutputInfo()), userFriendlyError(err, importArgs)
 	}
 
@@ -140,6 +140,20 @@ func (mr *MockClientMockRecorder) BasePath() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BasePath", reflect.TypeOf((*MockClient)(nil).BasePath))
 }
 
+// CreateAddress mocks base method.
+func (m *MockClient) CreateAddress(arg0, arg1 string, arg2 *compute2.Address) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAddress", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAddress indicates an expected call of CreateAddress.
+func (mr *MockClientMockRecorder) CreateAddress(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAddress", reflect.TypeOf((*MockClient)(nil).CreateAddress), arg0, arg1, arg2)
+}
+
 // CreateDisk mocks base method.
 func (m *MockClient) CreateDisk(arg0, arg1 string, arg2 *compute2.Disk) error {
 	m.ctrl.T.Helper()
@@ -294,6 +308,34 @@ func (mr *MockClientMockRecorder) CreateInstanceBeta(arg0, arg1, arg2 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInstanceBeta", reflect.TypeOf((*MockClient)(nil).CreateInstanceBeta), arg0, arg1, arg2)
 }
 
+// CreateInstanceGroupManager mocks base method.
+func (m *MockClient) CreateInstanceGroupManager(arg0, arg1 string, arg2 *compute2.InstanceGroupManager) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInstanceGroupManager", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInstanceGroupManager indicates an expected call of CreateInstanceGroupManager.
+func (mr *MockClientMockRecorder) CreateInstanceGroupManager(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInstanceGroupManager", reflect.TypeOf((*MockClient)(nil).CreateInstanceGroupManager), arg0, arg1, arg2)
+}
+
+// CreateInstanceTemplate mocks base method.
+func (m *MockClient) CreateInstanceTemplate(arg0 string, arg1 *compute2.InstanceTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInstanceTemplate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInstanceTemplate indicates an expected call of CreateInstanceTemplate.
+func (mr *MockClientMockRecorder) CreateInstanceTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInstanceTemplate", reflect.TypeOf((*MockClient)(nil).CreateInstanceTemplate), arg0, arg1)
+}
+
 // CreateMachineImage mocks base method.
 func (m *MockClient) CreateMachineImage(arg0 string, arg1 *compute1.MachineImage) error {
 	m.ctrl.T.Helper()
@@ -322,6 +364,48 @@ func (mr *MockClientMockRecorder) CreateNetwork(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNetwork", reflect.TypeOf((*MockClient)(nil).CreateNetwork), arg0, arg1)
 }
 
+// CreateRegionDisk mocks base method.
+func (m *MockClient) CreateRegionDisk(arg0, arg1 string, arg2 *compute2.Disk) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRegionDisk", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRegionDisk indicates an expected call of CreateRegionDisk.
+func (mr *MockClientMockRecorder) CreateRegionDisk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRegionDisk", reflect.TypeOf((*MockClient)(nil).CreateRegionDisk), arg0, arg1, arg2)
+}
+
+// CreateRoute mocks base method.
+func (m *MockClient) CreateRoute(arg0 string, arg1 *compute2.Route) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRoute", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRoute indicates an expected call of CreateRoute.
+func (mr *MockClientMockRecorder) CreateRoute(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRoute", reflect.TypeOf((*MockClient)(nil).CreateRoute), arg0, arg1)
+}
+
+// CreateRouter mocks base method.
+func (m *MockClient) CreateRouter(arg0, arg1 string, arg2 *compute2.Router) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRouter", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRouter indicates an expected call of CreateRouter.
+func (mr *MockClientMockRecorder) CreateRouter(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRouter", reflect.TypeOf((*MockClient)(nil).CreateRouter), arg0, arg1, arg2)
+}
+
 // CreateSnapshot mocks base method.
 func (m *MockClient) CreateSnapshot(arg0, arg1, arg2 string, arg3 *compute2.Snapshot) error {
 	m.ctrl.T.Helper()
@@ -364,6 +448,20 @@ func (mr *MockClientMockRecorder) CreateTargetInstance(arg0, arg1, arg2 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTargetInstance", reflect.TypeOf((*MockClient)(nil).CreateTargetInstance), arg0, arg1, arg2)
 }
 
+// DeleteAddress mocks base method.
+func (m *MockClient) DeleteAddress(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAddress", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAddress indicates an expected call of DeleteAddress.
+func (mr *MockClientMockRecorder) DeleteAddress(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAddress", reflect.TypeOf((*MockClient)(nil).DeleteAddress), arg0, arg1, arg2)
+}
+
 // DeleteDisk mocks base method.
 func (m *MockClient) DeleteDisk(arg0, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -434,6 +532,34 @@ func (mr *MockClientMockRecorder) DeleteInstance(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInstance", reflect.TypeOf((*MockClient)(nil).DeleteInstance), arg0, arg1, arg2)
 }
 
+// DeleteInstanceGroupManager mocks base method.
+func (m *MockClient) DeleteInstanceGroupManager(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInstanceGroupManager", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInstanceGroupManager indicates an expected call of DeleteInstanceGroupManager.
+func (mr *MockClientMockRecorder) DeleteInstanceGroupManager(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInstanceGroupManager", reflect.TypeOf((*MockClient)(nil).DeleteInstanceGroupManager), arg0, arg1, arg2)
+}
+
+// DeleteInstanceTemplate mocks base method.
+func (m *MockClient) DeleteInstanceTemplate(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInstanceTemplate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInstanceTemplate indicates an expected call of DeleteInstanceTemplate.
+func (mr *MockClientMockRecorder) DeleteInstanceTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInstanceTemplate", reflect.TypeOf((*MockClient)(nil).DeleteInstanceTemplate), arg0, arg1)
+}
+
 // DeleteMachineImage mocks base method.
 func (m *MockClient) DeleteMachineImage(arg0, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -462,6 +588,48 @@ func (mr *MockClientMockRecorder) DeleteNetwork(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNetwork", reflect.TypeOf((*MockClient)(nil).DeleteNetwork), arg0, arg1)
 }
 
+// DeleteRegionDisk mocks base method.
+func (m *MockClient) DeleteRegionDisk(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRegionDisk", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRegionDisk indicates an expected call of DeleteRegionDisk.
+func (mr *MockClientMockRecorder) DeleteRegionDisk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRegionDisk", reflect.TypeOf((*MockClient)(nil).DeleteRegionDisk), arg0, arg1, arg2)
+}
+
+// DeleteRoute mocks base method.
+func (m *MockClient) DeleteRoute(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRoute", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRoute indicates an expected call of DeleteRoute.
+func (mr *MockClientMockRecorder) DeleteRoute(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRoute", reflect.TypeOf((*MockClient)(nil).DeleteRoute), arg0, arg1)
+}
+
+// DeleteRouter mocks base method.
+func (m *MockClient) DeleteRouter(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRouter", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRouter indicates an expected call of DeleteRouter.
+func (mr *MockClientMockRecorder) DeleteRouter(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRouter", reflect.TypeOf((*MockClient)(nil).DeleteRouter), arg0, arg1, arg2)
+}
+
 // DeleteSnapshot mocks base method.
 func (m *MockClient) DeleteSnapshot(arg0, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -546,6 +714,21 @@ func (mr *MockClientMockRecorder) DetachDisk(arg0, arg1, arg2, arg3 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachDisk", reflect.TypeOf((*MockClient)(nil).DetachDisk), arg0, arg1, arg2, arg3)
 }
 
+// GetAddress mocks base method.
+func (m *MockClient) GetAddress(arg0, arg1, arg2 string) (*compute2.Address, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAddress", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*compute2.Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAddress indicates an expected call of GetAddress.
+func (mr *MockClientMockRecorder) GetAddress(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAddress", reflect.TypeOf((*MockClient)(nil).GetAddress), arg0, arg1, arg2)
+}
+
 // GetDisk mocks base method.
 func (m *MockClient) GetDisk(arg0, arg1, arg2 string) (*compute2.Disk, error) {
 	m.ctrl.T.Helper()
@@ -741,6 +924,36 @@ func (mr *MockClientMockRecorder) GetInstanceBeta(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceBeta", reflect.TypeOf((*MockClient)(nil).GetInstanceBeta), arg0, arg1, arg2)
 }
 
+// GetInstanceGroupManager mocks base method.
+func (m *MockClient) GetInstanceGroupManager(arg0, arg1, arg2 string) (*compute2.InstanceGroupManager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstanceGroupManager", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*compute2.InstanceGroupManager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstanceGroupManager indicates an expected call of GetInstanceGroupManager.
+func (mr *MockClientMockRecorder) GetInstanceGroupManager(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceGroupManager", reflect.TypeOf((*MockClient)(nil).GetInstanceGroupManager), arg0, arg1, arg2)
+}
+
+// GetInstanceTemplate mocks base method.
+func (m *MockClient) GetInstanceTemplate(arg0, arg1 string) (*compute2.InstanceTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstanceTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*compute2.InstanceTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstanceTemplate indicates an expected call of GetInstanceTemplate.
+func (mr *MockClientMockRecorder) GetInstanceTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceTemplate", reflect.TypeOf((*MockClient)(nil).GetInstanceTemplate), arg0, arg1)
+}
+
 // GetLicense mocks base method.
 func (m *MockClient) GetLicense(arg0, arg1 string) (*compute2.License, error) {
 	m.ctrl.T.Helper()
@@ -816,6 +1029,51 @@ func (mr *MockClientMockRecorder) GetProject(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProject", reflect.TypeOf((*MockClient)(nil).GetProject), arg0)
 }
 
+// GetRegionDisk mocks base method.
+func (m *MockClient) GetRegionDisk(arg0, arg1, arg2 string) (*compute2.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegionDisk", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*compute2.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegionDisk indicates an expected call of GetRegionDisk.
+func (mr *MockClientMockRecorder) GetRegionDisk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegionDisk", reflect.TypeOf((*MockClient)(nil).GetRegionDisk), arg0, arg1, arg2)
+}
+
+// GetRoute mocks base method.
+func (m *MockClient) GetRoute(arg0, arg1 string) (*compute2.Route, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoute", arg0, arg1)
+	ret0, _ := ret[0].(*compute2.Route)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoute indicates an expected call of GetRoute.
+func (mr *MockClientMockRecorder) GetRoute(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoute", reflect.TypeOf((*MockClient)(nil).GetRoute), arg0, arg1)
+}
+
+// GetRouter mocks base method.
+func (m *MockClient) GetRouter(arg0, arg1, arg2 string) (*compute2.Router, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRouter", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*compute2.Router)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRouter indicates an expected call of GetRouter.
+func (mr *MockClientMockRecorder) GetRouter(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRouter", reflect.TypeOf((*MockClient)(nil).GetRouter), arg0, arg1, arg2)
+}
+
 // GetSerialPortOutput mocks base method.
 func (m *MockClient) GetSerialPortOutput(arg0, arg1, arg2 string, arg3, arg4 int64) (*compute2.SerialPortOutput, error) {
 	m.ctrl.T.Helper()
@@ -921,6 +1179,41 @@ func (mr *MockClientMockRecorder) InstanceStopped(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstanceStopped", reflect.TypeOf((*MockClient)(nil).InstanceStopped), arg0, arg1, arg2)
 }
 
+// InstanceSuspended mocks base method.
+func (m *MockClient) InstanceSuspended(arg0, arg1, arg2 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstanceSuspended", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InstanceSuspended indicates an expected call of InstanceSuspended.
+func (mr *MockClientMockRecorder) InstanceSuspended(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstanceSuspended", reflect.TypeOf((*MockClient)(nil).InstanceSuspended), arg0, arg1, arg2)
+}
+
+// ListAddresses mocks base method.
+func (m *MockClient) ListAddresses(arg0, arg1 string, arg2 ...compute.ListCallOption) ([]*compute2.Address, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListAddresses", varargs...)
+	ret0, _ := ret[0].([]*compute2.Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAddresses indicates an expected call of ListAddresses.
+func (mr *MockClientMockRecorder) ListAddresses(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAddresses", reflect.TypeOf((*MockClient)(nil).ListAddresses), varargs...)
+}
+
 // ListDisks mocks base method.
 func (m *MockClient) ListDisks(arg0, arg1 string, arg2 ...compute.ListCallOption) ([]*compute2.Disk, error) {
 	m.ctrl.T.Helper()
@@ -1021,6 +1314,46 @@ func (mr *MockClientMockRecorder) ListImagesAlpha(arg0 interface{}, arg1 ...inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImagesAlpha", reflect.TypeOf((*MockClient)(nil).ListImagesAlpha), varargs...)
 }
 
+// ListInstanceGroupManagers mocks base method.
+func (m *MockClient) ListInstanceGroupManagers(arg0, arg1 string, arg2 ...compute.ListCallOption) ([]*compute2.InstanceGroupManager, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListInstanceGroupManagers", varargs...)
+	ret0, _ := ret[0].([]*compute2.InstanceGroupManager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInstanceGroupManagers indicates an expected call of ListInstanceGroupManagers.
+func (mr *MockClientMockRecorder) ListInstanceGroupManagers(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInstanceGroupManagers", reflect.TypeOf((*MockClient)(nil).ListInstanceGroupManagers), varargs...)
+}
+
+// ListInstanceTemplates mocks base method.
+func (m *MockClient) ListInstanceTemplates(arg0 string, arg1 ...compute.ListCallOption) ([]*compute2.InstanceTemplate, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListInstanceTemplates", varargs...)
+	ret0, _ := ret[0].([]*compute2.InstanceTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInstanceTemplates indicates an expected call of ListInstanceTemplates.
+func (mr *MockClientMockRecorder) ListInstanceTemplates(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInstanceTemplates", reflect.TypeOf((*MockClient)(nil).ListInstanceTemplates), varargs...)
+}
+
 // ListInstances mocks base method.
 func (m *MockClient) ListInstances(arg0, arg1 string, arg2 ...compute.ListCallOption) ([]*compute2.Instance, error) {
 	m.ctrl.T.Helper()
@@ -1121,6 +1454,26 @@ func (mr *MockClientMockRecorder) ListNetworks(arg0 interface{}, arg1 ...interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNetworks", reflect.TypeOf((*MockClient)(nil).ListNetworks), varargs...)
 }
 
+// ListRegionDisks mocks base method.
+func (m *MockClient) ListRegionDisks(arg0, arg1 string, arg2 ...compute.ListCallOption) ([]*compute2.Disk, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListRegionDisks", varargs...)
+	ret0, _ := ret[0].([]*compute2.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRegionDisks indicates an expected call of ListRegionDisks.
+func (mr *MockClientMockRecorder) ListRegionDisks(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRegionDisks", reflect.TypeOf((*MockClient)(nil).ListRegionDisks), varargs...)
+}
+
 // ListRegions mocks base method.
 func (m *MockClient) ListRegions(arg0 string, arg1 ...compute.ListCallOption) ([]*compute2.Region, error) {
 	m.ctrl.T.Helper()
@@ -1141,6 +1494,46 @@ func (mr *MockClientMockRecorder) ListRegions(arg0 interface{}, arg1 ...interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRegions", reflect.TypeOf((*MockClient)(nil).ListRegions), varargs...)
 }
 
+// ListRouters mocks base method.
+func (m *MockClient) ListRouters(arg0, arg1 string, arg2 ...compute.ListCallOption) ([]*compute2.Router, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListRouters", varargs...)
+	ret0, _ := ret[0].([]*compute2.Router)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRouters indicates an expected call of ListRouters.
+func (mr *MockClientMockRecorder) ListRouters(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRouters", reflect.TypeOf((*MockClient)(nil).ListRouters), varargs...)
+}
+
+// ListRoutes mocks base method.
+func (m *MockClient) ListRoutes(arg0 string, arg1 ...compute.ListCallOption) ([]*compute2.Route, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListRoutes", varargs...)
+	ret0, _ := ret[0].([]*compute2.Route)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoutes indicates an expected call of ListRoutes.
+func (mr *MockClientMockRecorder) ListRoutes(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoutes", reflect.TypeOf((*MockClient)(nil).ListRoutes), varargs...)
+}
+
 // ListSnapshots mocks base method.
 func (m *MockClient) ListSnapshots(arg0 string, arg1 ...compute.ListCallOption) ([]*compute2.Snapshot, error) {
 	m.ctrl.T.Helper()
@@ -1221,6 +1614,20 @@ func (mr *MockClientMockRecorder) ListZones(arg0 interface{}, arg1 ...interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListZones", reflect.TypeOf((*MockClient)(nil).ListZones), varargs...)
 }
 
+// PatchInstanceGroupManager mocks base method.
+func (m *MockClient) PatchInstanceGroupManager(arg0, arg1, arg2 string, arg3 *compute2.InstanceGroupManager) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchInstanceGroupManager", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchInstanceGroupManager indicates an expected call of PatchInstanceGroupManager.
+func (mr *MockClientMockRecorder) PatchInstanceGroupManager(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchInstanceGroupManager", reflect.TypeOf((*MockClient)(nil).PatchInstanceGroupManager), arg0, arg1, arg2, arg3)
+}
+
 // ResizeDisk mocks base method.
 func (m *MockClient) ResizeDisk(arg0, arg1, arg2 string, arg3 *compute2.DisksResizeRequest) error {
 	m.ctrl.T.Helper()
@@ -1235,6 +1642,34 @@ func (mr *MockClientMockRecorder) ResizeDisk(arg0, arg1, arg2, arg3 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeDisk", reflect.TypeOf((*MockClient)(nil).ResizeDisk), arg0, arg1, arg2, arg3)
 }
 
+// ResizeRegionDisk mocks base method.
+func (m *MockClient) ResizeRegionDisk(arg0, arg1, arg2 string, arg3 *compute2.RegionDisksResizeRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeRegionDisk", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResizeRegionDisk indicates an expected call of ResizeRegionDisk.
+func (mr *MockClientMockRecorder) ResizeRegionDisk(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeRegionDisk", reflect.TypeOf((*MockClient)(nil).ResizeRegionDisk), arg0, arg1, arg2, arg3)
+}
+
+// ResumeInstance mocks base method.
+func (m *MockClient) ResumeInstance(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeInstance", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResumeInstance indicates an expected call of ResumeInstance.
+func (mr *MockClientMockRecorder) ResumeInstance(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeInstance", reflect.TypeOf((*MockClient)(nil).ResumeInstance), arg0, arg1, arg2)
+}
+
 // Retry mocks base method.
 func (m *MockClient) Retry(arg0 func(...googleapi.CallOption) (*compute2.Operation, error), arg1 ...googleapi.CallOption) (*compute2.Operation, error) {
 	m.ctrl.T.Helper()
@@ -1303,6 +1738,48 @@ func (mr *MockClientMockRecorder) SetDiskAutoDelete(arg0, arg1, arg2, arg3, arg4
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDiskAutoDelete", reflect.TypeOf((*MockClient)(nil).SetDiskAutoDelete), arg0, arg1, arg2, arg3, arg4)
 }
 
+// SetDiskLabels mocks base method.
+func (m *MockClient) SetDiskLabels(arg0, arg1, arg2 string, arg3 *compute2.ZoneSetLabelsRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDiskLabels", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDiskLabels indicates an expected call of SetDiskLabels.
+func (mr *MockClientMockRecorder) SetDiskLabels(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDiskLabels", reflect.TypeOf((*MockClient)(nil).SetDiskLabels), arg0, arg1, arg2, arg3)
+}
+
+// SetImageLabels mocks base method.
+func (m *MockClient) SetImageLabels(arg0, arg1 string, arg2 *compute2.GlobalSetLabelsRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetImageLabels", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetImageLabels indicates an expected call of SetImageLabels.
+func (mr *MockClientMockRecorder) SetImageLabels(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetImageLabels", reflect.TypeOf((*MockClient)(nil).SetImageLabels), arg0, arg1, arg2)
+}
+
+// SetInstanceLabels mocks base method.
+func (m *MockClient) SetInstanceLabels(arg0, arg1, arg2 string, arg3 *compute2.InstancesSetLabelsRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInstanceLabels", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetInstanceLabels indicates an expected call of SetInstanceLabels.
+func (mr *MockClientMockRecorder) SetInstanceLabels(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInstanceLabels", reflect.TypeOf((*MockClient)(nil).SetInstanceLabels), arg0, arg1, arg2, arg3)
+}
+
 // SetInstanceMetadata mocks base method.
 func (m *MockClient) SetInstanceMetadata(arg0, arg1, arg2 string, arg3 *compute2.Metadata) error {
 	m.ctrl.T.Helper()
@@ -1317,6 +1794,34 @@ func (mr *MockClientMockRecorder) SetInstanceMetadata(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInstanceMetadata", reflect.TypeOf((*MockClient)(nil).SetInstanceMetadata), arg0, arg1, arg2, arg3)
 }
 
+// SetRegionDiskLabels mocks base method.
+func (m *MockClient) SetRegionDiskLabels(arg0, arg1, arg2 string, arg3 *compute2.RegionSetLabelsRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRegionDiskLabels", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRegionDiskLabels indicates an expected call of SetRegionDiskLabels.
+func (mr *MockClientMockRecorder) SetRegionDiskLabels(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRegionDiskLabels", reflect.TypeOf((*MockClient)(nil).SetRegionDiskLabels), arg0, arg1, arg2, arg3)
+}
+
+// SetSnapshotLabels mocks base method.
+func (m *MockClient) SetSnapshotLabels(arg0, arg1 string, arg2 *compute2.GlobalSetLabelsRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotLabels", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSnapshotLabels indicates an expected call of SetSnapshotLabels.
+func (mr *MockClientMockRecorder) SetSnapshotLabels(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotLabels", reflect.TypeOf((*MockClient)(nil).SetSnapshotLabels), arg0, arg1, arg2)
+}
+
 // StartInstance mocks base method.
 func (m *MockClient) StartInstance(arg0, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -1344,3 +1849,17 @@ func (mr *MockClientMockRecorder) StopInstance(arg0, arg1, arg2 interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopInstance", reflect.TypeOf((*MockClient)(nil).StopInstance), arg0, arg1, arg2)
 }
+
+// SuspendInstance mocks base method.
+func (m *MockClient) SuspendInstance(arg0, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendInstance", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SuspendInstance indicates an expected call of SuspendInstance.
+func (mr *MockClientMockRecorder) SuspendInstance(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendInstance", reflect.TypeOf((*MockClient)(nil).SuspendInstance), arg0, arg1, arg2)
+}
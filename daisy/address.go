@@ -0,0 +1,100 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	addressURLRegex = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?regions/(?P<region>%[2]s)/addresses/(?P<address>%[2]s)$`, projectRgxStr, rfc1035))
+)
+
+func (w *Workflow) addressExists(project, region, address string) (bool, DError) {
+	return w.addressCache.resourceExists(func(project, region string, opts ...daisyCompute.ListCallOption) (interface{}, error) {
+		return w.ComputeClient.ListAddresses(project, region)
+	}, project, region, address)
+}
+
+// Address is used to create a GCE static address.
+type Address struct {
+	compute.Address
+	Resource
+}
+
+// MarshalJSON is a hacky workaround to compute.Address's implementation.
+func (a *Address) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*a)
+}
+
+func (a *Address) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	a.Name, a.Region, errs = a.Resource.populateWithRegion(ctx, s, a.Name, a.Region)
+
+	if subnetworkURLRegex.MatchString(a.Subnetwork) {
+		a.Subnetwork = extendPartialURL(a.Subnetwork, a.Project)
+	}
+
+	a.Description = strOr(a.Description, defaultDescription("Address", s.w.Name, s.w.username))
+	a.link = fmt.Sprintf("projects/%s/regions/%s/addresses/%s", a.Project, a.Region, a.Name)
+	return errs
+}
+
+func (a *Address) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot create address %q", a.daisyName)
+	errs := a.Resource.validateWithRegion(ctx, s, a.Region, pre)
+
+	types := []string{"", "EXTERNAL", "INTERNAL"}
+	if !strIn(a.AddressType, types) {
+		errs = addErrs(errs, Errf("%s: AddressType %q not one of %v", pre, a.AddressType, types[1:]))
+	}
+	if a.Subnetwork != "" {
+		if _, err := s.w.subnetworks.regUse(a.Subnetwork, s); err != nil {
+			errs = addErrs(errs, err)
+		}
+	}
+
+	// Register creation.
+	errs = addErrs(errs, s.w.addresses.regCreate(a.daisyName, &a.Resource, s, false))
+	return errs
+}
+
+type addressRegistry struct {
+	baseResourceRegistry
+}
+
+func newAddressRegistry(w *Workflow) *addressRegistry {
+	ar := &addressRegistry{baseResourceRegistry: baseResourceRegistry{w: w, typeName: "address", urlRgx: addressURLRegex}}
+	ar.baseResourceRegistry.deleteFn = ar.deleteFn
+	ar.init()
+	return ar
+}
+
+func (ar *addressRegistry) deleteFn(ctx context.Context, res *Resource) DError {
+	m := NamedSubexp(addressURLRegex, res.link)
+	err := daisyCompute.WithContext(ctx, ar.w.ComputeClient).DeleteAddress(m["project"], m["region"], m["address"])
+	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+		return typedErr(resourceDNEError, "failed to delete address", err)
+	}
+	return newErr("failed to delete address", err)
+}
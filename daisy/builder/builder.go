@@ -0,0 +1,284 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package builder builds Daisy workflows in Go:
+//
+//	b := builder.New("create-image")
+//	b.CreateDisks("create-disk", &daisy.Disk{Disk: compute.Disk{Name: "disk", SourceImage: src}}).
+//		Then(b.CreateImages("create-image", &daisy.Image{Image: compute.Image{Name: "image", SourceDisk: "disk"}})).
+//		Then(b.DeleteResources("delete-disk", &daisy.DeleteResources{Disks: []string{"disk"}}))
+//	w, err := b.Build()
+//
+// Each step kind has its own typed method. Build checks the workflow and adds
+// the dependencies its resource references need: a step using a disk, image,
+// instance, machine image or snapshot created by the workflow runs after the
+// step creating it, and the step deleting it runs after every step using it.
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// Builder adds steps to a workflow.
+type Builder struct {
+	w     *daisy.Workflow
+	steps []*Step
+	errs  []string
+	// opaque is set if the workflow has steps whose resources the Builder
+	// doesn't know, such as included workflows.
+	opaque bool
+}
+
+// New returns a Builder for a new workflow with the given name.
+func New(name string) *Builder {
+	w := daisy.New()
+	w.Name = name
+	return From(w)
+}
+
+// From returns a Builder adding steps to w. The resources created by w's
+// existing steps are unknown to the Builder.
+func From(w *daisy.Workflow) *Builder {
+	return &Builder{w: w, opaque: len(w.Steps) > 0}
+}
+
+// Workflow returns the workflow being built, to set fields such as Project,
+// Sources or Vars.
+func (b *Builder) Workflow() *daisy.Workflow {
+	return b.w
+}
+
+// Step is a workflow step added by a Builder.
+type Step struct {
+	b    *Builder
+	name string
+	step *daisy.Step
+}
+
+// Name returns the step's name.
+func (s *Step) Name() string {
+	return s.name
+}
+
+// Step returns the underlying workflow step.
+func (s *Step) Step() *daisy.Step {
+	return s.step
+}
+
+// Then makes next run after s and returns next, so calls can be chained.
+func (s *Step) Then(next *Step) *Step {
+	next.After(s)
+	return next
+}
+
+// After makes s run after the given steps and returns s.
+func (s *Step) After(steps ...*Step) *Step {
+	for _, prev := range steps {
+		if prev.b != s.b {
+			s.b.errorf("step %q can't depend on step %q of another workflow", s.name, prev.name)
+			continue
+		}
+		if err := s.b.w.AddDependency(s.step, prev.step); err != nil {
+			s.b.errs = append(s.b.errs, err.Error())
+		}
+	}
+	return s
+}
+
+// Timeout sets the step's timeout, e.g. "10m", and returns s.
+func (s *Step) Timeout(timeout string) *Step {
+	s.step.Timeout = timeout
+	return s
+}
+
+func (b *Builder) errorf(format string, a ...interface{}) {
+	b.errs = append(b.errs, fmt.Sprintf(format, a...))
+}
+
+// addStep adds a step named name and lets set fill it in.
+func (b *Builder) addStep(name string, set func(*daisy.Step)) *Step {
+	s, err := b.w.NewStep(name)
+	if err != nil {
+		b.errs = append(b.errs, err.Error())
+		// Keep going with a step outside the workflow, Build will fail.
+		s = daisy.NewStepDefaultTimeout(name, b.w)
+	}
+	set(s)
+	st := &Step{b: b, name: name, step: s}
+	b.steps = append(b.steps, st)
+	return st
+}
+
+// Build checks the workflow, adds the dependencies needed by references
+// between steps and returns it.
+func (b *Builder) Build() (*daisy.Workflow, error) {
+	errs := append([]string(nil), b.errs...)
+	errorf := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, a...))
+	}
+
+	creators := map[resource]*Step{}
+	for _, s := range b.steps {
+		if s.name == "" {
+			errorf("steps need a name")
+		}
+		if t := s.step.Timeout; t != "" && !strings.Contains(t, "${") {
+			if _, err := time.ParseDuration(t); err != nil {
+				errorf("step %q: bad timeout %q: %v", s.name, t, err)
+			}
+		}
+		for _, r := range references(s.step).creates {
+			if c, ok := creators[r]; ok {
+				errorf("%s %q is created by both step %q and step %q", r.kind, r.name, c.name, s.name)
+				continue
+			}
+			creators[r] = s
+		}
+	}
+
+	// creator returns the step creating r, nil if it's not created by a step
+	// the Builder knows.
+	creator := func(s *Step, r resource) *Step {
+		c, ok := creators[r]
+		if !ok && !b.opaque && !strings.Contains(r.name, "${") {
+			errorf("step %q uses %s %q, which isn't created by any step", s.name, r.kind, r.name)
+		}
+		return c
+	}
+	// Steps need to run after the steps creating the resources they use,
+	// deleting steps also after all the steps using them.
+	after := map[*Step][]*Step{}
+	users := map[resource][]*Step{}
+	for _, s := range b.steps {
+		for _, r := range references(s.step).uses {
+			if c := creator(s, r); c != nil && c != s {
+				after[s] = append(after[s], c)
+				users[r] = append(users[r], s)
+			}
+		}
+	}
+	deleters := map[resource]*Step{}
+	for _, s := range b.steps {
+		for _, r := range references(s.step).deletes {
+			if d, ok := deleters[r]; ok {
+				errorf("%s %q is deleted by both step %q and step %q", r.kind, r.name, d.name, s.name)
+				continue
+			}
+			deleters[r] = s
+			if c := creator(s, r); c != nil {
+				after[s] = append(after[s], c)
+				after[s] = append(after[s], users[r]...)
+			}
+		}
+	}
+	for _, s := range b.steps {
+		b.dependOn(s, after[s])
+	}
+
+	if cycle := b.cycle(); cycle != nil {
+		errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid workflow %q: %s", b.w.Name, strings.Join(errs, "; "))
+	}
+	return b.w, nil
+}
+
+// JSON builds the workflow and returns it in the workflow file format.
+func (b *Builder) JSON() ([]byte, error) {
+	w, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(w, "", "  ")
+}
+
+// dependOn makes s depend on deps, leaving out the steps it already depends
+// on, directly or through other steps of deps.
+func (b *Builder) dependOn(s *Step, deps []*Step) {
+	for _, d := range deps {
+		needed := d != s && !b.dependsOn(s.name, d.name, map[string]bool{})
+		for _, o := range deps {
+			if needed && o != d && b.dependsOn(o.name, d.name, map[string]bool{}) {
+				needed = false
+			}
+		}
+		if needed {
+			b.w.AddDependency(s.step, d.step)
+		}
+	}
+}
+
+func (b *Builder) dependsOn(s, dep string, seen map[string]bool) bool {
+	if seen[s] {
+		return false
+	}
+	seen[s] = true
+	for _, d := range b.w.Dependencies[s] {
+		if d == dep || b.dependsOn(d, dep, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// cycle returns the steps forming a dependency cycle, nil if there is none.
+func (b *Builder) cycle() []string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(string) []string
+	visit = func(s string) []string {
+		switch state[s] {
+		case visiting:
+			for i, p := range path {
+				if p == s {
+					return append(append([]string(nil), path[i:]...), s)
+				}
+			}
+		case done:
+			return nil
+		}
+		state[s] = visiting
+		path = append(path, s)
+		for _, d := range b.w.Dependencies[s] {
+			if c := visit(d); c != nil {
+				return c
+			}
+		}
+		path = path[:len(path)-1]
+		state[s] = done
+		return nil
+	}
+	// Visit in the order steps were added for stable errors.
+	for _, s := range b.steps {
+		if c := visit(s.name); c != nil {
+			return c
+		}
+	}
+	for s := range b.w.Dependencies {
+		if c := visit(s); c != nil {
+			return c
+		}
+	}
+	return nil
+}
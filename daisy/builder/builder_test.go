@@ -0,0 +1,221 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package builder
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	"google.golang.org/api/compute/v1"
+)
+
+func disk(name, image string) *daisy.Disk {
+	return &daisy.Disk{Disk: compute.Disk{Name: name, SourceImage: image}}
+}
+
+func instance(name string, disks ...string) *daisy.Instance {
+	i := &daisy.Instance{Instance: compute.Instance{Name: name}}
+	for _, d := range disks {
+		i.Disks = append(i.Disks, &compute.AttachedDisk{Source: d})
+	}
+	return i
+}
+
+func TestBuild(t *testing.T) {
+	b := New("wf")
+	disks := b.CreateDisks("create-disks", disk("boot", "projects/p/global/images/i"), disk("data", ""))
+	inst := b.CreateInstances("create-instance", instance("vm", "boot", "data"))
+	wait := inst.Then(b.WaitForInstancesSignal("wait", &daisy.InstanceSignal{Name: "vm", Stopped: true})).Timeout("1h")
+	// Uses the disk and the instance, which is created after the disks.
+	detach := b.DetachDisks("detach", &daisy.DetachDisk{Instance: "vm", DeviceName: "data"}).After(wait)
+	img := b.CreateImages("create-image", &daisy.Image{Image: compute.Image{Name: "image", SourceDisk: "data"}})
+	del := b.DeleteResources("delete", &daisy.DeleteResources{Disks: []string{"data"}, Instances: []string{"vm"}})
+	ext := b.StopInstances("stop-other", "projects/p/zones/z/instances/other")
+
+	w, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		inst.Name():   {disks.Name()},
+		wait.Name():   {inst.Name()},
+		detach.Name(): {wait.Name()},
+		img.Name():    {disks.Name()},
+		del.Name():    {img.Name(), detach.Name()},
+	}
+	if !reflect.DeepEqual(w.Dependencies, want) {
+		t.Errorf("want dependencies %v, got %v", want, w.Dependencies)
+	}
+	if len(w.Steps) != 7 || w.Steps[ext.Name()] != ext.Step() || w.Steps[wait.Name()].Timeout != "1h" {
+		t.Errorf("unexpected steps: %v", w.Steps)
+	}
+
+	// Building again doesn't change anything.
+	if _, err := b.Build(); err != nil || !reflect.DeepEqual(w.Dependencies, want) {
+		t.Errorf("second build changed the workflow: %v, %v", err, w.Dependencies)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		build   func(b *Builder)
+		wantErr string
+	}{
+		{
+			"duplicate step",
+			func(b *Builder) {
+				b.StopInstances("s", "projects/p/zones/z/instances/i")
+				b.StartInstances("s", "projects/p/zones/z/instances/i")
+			},
+			`a step already exists with that name`,
+		},
+		{
+			"resource created twice",
+			func(b *Builder) {
+				b.CreateDisks("a", disk("d", ""))
+				b.CreateDisks("b", disk("d", ""))
+			},
+			`disk "d" is created by both step "a" and step "b"`,
+		},
+		{
+			"unknown resource",
+			func(b *Builder) { b.StartInstances("s", "vm") },
+			`step "s" uses instance "vm", which isn't created by any step`,
+		},
+		{
+			"resource deleted twice",
+			func(b *Builder) {
+				b.CreateDisks("c", disk("d", ""))
+				b.DeleteResources("a", &daisy.DeleteResources{Disks: []string{"d"}})
+				b.DeleteResources("b", &daisy.DeleteResources{Disks: []string{"d"}})
+			},
+			`disk "d" is deleted by both step "a" and step "b"`,
+		},
+		{
+			"cycle",
+			func(b *Builder) {
+				a := b.StopInstances("a", "projects/p/zones/z/instances/i")
+				a.Then(b.StartInstances("b", "projects/p/zones/z/instances/i")).Then(a)
+			},
+			`dependency cycle: a -> b -> a`,
+		},
+		{
+			"use after delete",
+			func(b *Builder) {
+				b.CreateDisks("create", disk("d", "")).
+					Then(b.DeleteResources("delete", &daisy.DeleteResources{Disks: []string{"d"}})).
+					Then(b.CreateImages("image", &daisy.Image{Image: compute.Image{Name: "i", SourceDisk: "d"}}))
+			},
+			`dependency cycle`,
+		},
+		{
+			"bad timeout",
+			func(b *Builder) { b.StopInstances("s", "projects/p/zones/z/instances/i").Timeout("soon") },
+			`step "s": bad timeout "soon"`,
+		},
+		{
+			"other workflow",
+			func(b *Builder) {
+				other := New("other").StopInstances("o", "projects/p/zones/z/instances/i")
+				b.StartInstances("s", "projects/p/zones/z/instances/i").After(other)
+			},
+			`step "s" can't depend on step "o" of another workflow`,
+		},
+	}
+	for _, tt := range tests {
+		b := New("wf")
+		tt.build(b)
+		_, err := b.Build()
+		if err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		} else if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: want error containing %q, got %v", tt.desc, tt.wantErr, err)
+		}
+	}
+}
+
+func TestBuildUnknownReferences(t *testing.T) {
+	// Variables can't be checked.
+	b := New("wf")
+	b.StartInstances("s", "${instance}")
+	if _, err := b.Build(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Included workflows and custom steps create resources the Builder
+	// doesn't know about.
+	b = New("wf")
+	b.IncludeWorkflow("include", daisy.New()).Then(b.StartInstances("s", "vm"))
+	if _, err := b.Build(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	w := daisy.New()
+	w.NewStep("existing")
+	b = From(w)
+	b.StartInstances("s", "vm")
+	if _, err := b.Build(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	b := New("wf")
+	b.Workflow().Project = "p"
+	b.CreateDisks("create-disk", disk("d", "projects/p/global/images/i")).
+		Then(b.CreateInstances("create-instance", instance("vm", "d"))).
+		Then(b.CreateImages("create-image", &daisy.Image{Image: compute.Image{Name: "i", SourceDisk: "d"}}))
+
+	data, err := b.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Steps map[string]map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	// Steps are in the workflow file format, lists of resources.
+	for step, kind := range map[string]string{"create-disk": "CreateDisks", "create-instance": "CreateInstances", "create-image": "CreateImages"} {
+		if s := string(raw.Steps[step][kind]); !strings.HasPrefix(s, "[") {
+			t.Errorf("%s: want a list of resources, got %s", step, s)
+		}
+	}
+
+	w := daisy.New()
+	if err := json.Unmarshal(data, w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "wf" || w.Project != "p" || len(w.Steps) != 3 {
+		t.Errorf("unexpected workflow: %s", data)
+	}
+	if got := w.Steps["create-instance"].CreateInstances.Instances[0]; got.Name != "vm" || got.Disks[0].Source != "d" {
+		t.Errorf("instance not kept: %s", data)
+	}
+	if want := []string{"create-instance"}; !reflect.DeepEqual(w.Dependencies["create-image"], want) {
+		t.Errorf("want create-image dependencies %v, got %v", want, w.Dependencies["create-image"])
+	}
+
+	b = New("wf")
+	b.StartInstances("s", "vm")
+	if _, err := b.JSON(); err == nil {
+		t.Error("expected error, got none")
+	}
+}
@@ -0,0 +1,132 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package builder
+
+import (
+	"strings"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+const (
+	kindDisk         = "disk"
+	kindImage        = "image"
+	kindInstance     = "instance"
+	kindMachineImage = "machine image"
+	kindSnapshot     = "snapshot"
+)
+
+// resource is a workflow resource, referred to by its name in the workflow.
+type resource struct {
+	kind, name string
+}
+
+// stepRefs are the workflow resources a step creates, uses and deletes.
+type stepRefs struct {
+	creates, uses, deletes []resource
+}
+
+func (r *stepRefs) create(kind, name string) {
+	r.creates = append(r.creates, resource{kind, name})
+}
+
+// use records a reference to a resource. Only plain names refer to workflow
+// resources, URLs refer to existing ones.
+func (r *stepRefs) use(kind string, names ...string) {
+	for _, n := range names {
+		if n != "" && !strings.Contains(n, "/") {
+			r.uses = append(r.uses, resource{kind, n})
+		}
+	}
+}
+
+func (r *stepRefs) delete(kind string, names ...string) {
+	for _, n := range names {
+		if n != "" && !strings.Contains(n, "/") {
+			r.deletes = append(r.deletes, resource{kind, n})
+		}
+	}
+}
+
+// references lists the workflow resources s refers to, for the step kinds
+// the Builder has methods for.
+func references(s *daisy.Step) stepRefs {
+	var r stepRefs
+	switch {
+	case s.AttachDisks != nil:
+		for _, ad := range *s.AttachDisks {
+			r.use(kindInstance, ad.Instance)
+			r.use(kindDisk, ad.Source)
+		}
+	case s.CreateDisks != nil:
+		for _, d := range *s.CreateDisks {
+			r.create(kindDisk, d.Name)
+			r.use(kindImage, d.SourceImage)
+			r.use(kindSnapshot, d.SourceSnapshot)
+		}
+	case s.CreateImages != nil:
+		for _, i := range s.CreateImages.Images {
+			r.create(kindImage, i.Name)
+			r.use(kindDisk, i.SourceDisk)
+			r.use(kindImage, i.SourceImage)
+		}
+	case s.CreateInstances != nil:
+		for _, i := range s.CreateInstances.Instances {
+			r.create(kindInstance, i.Name)
+			for _, d := range i.Disks {
+				r.use(kindDisk, d.Source)
+				if d.InitializeParams != nil {
+					r.use(kindImage, d.InitializeParams.SourceImage)
+				}
+			}
+		}
+	case s.CreateMachineImages != nil:
+		for _, mi := range *s.CreateMachineImages {
+			r.create(kindMachineImage, mi.Name)
+			r.use(kindInstance, mi.SourceInstance)
+		}
+	case s.CreateSnapshots != nil:
+		for _, ss := range *s.CreateSnapshots {
+			r.create(kindSnapshot, ss.Name)
+			r.use(kindDisk, ss.SourceDisk)
+		}
+	case s.DeleteResources != nil:
+		r.delete(kindDisk, s.DeleteResources.Disks...)
+		r.delete(kindImage, s.DeleteResources.Images...)
+		r.delete(kindInstance, s.DeleteResources.Instances...)
+		r.delete(kindMachineImage, s.DeleteResources.MachineImages...)
+	case s.DetachDisks != nil:
+		for _, dd := range *s.DetachDisks {
+			r.use(kindInstance, dd.Instance)
+		}
+	case s.StartInstances != nil:
+		r.use(kindInstance, s.StartInstances.Instances...)
+	case s.StopInstances != nil:
+		r.use(kindInstance, s.StopInstances.Instances...)
+	case s.UpdateInstancesMetadata != nil:
+		for _, um := range *s.UpdateInstancesMetadata {
+			r.use(kindInstance, um.Instance)
+		}
+	case s.WaitForInstancesSignal != nil:
+		for _, is := range *s.WaitForInstancesSignal {
+			r.use(kindInstance, is.Name)
+		}
+	case s.WaitForAnyInstancesSignal != nil:
+		for _, is := range *s.WaitForAnyInstancesSignal {
+			r.use(kindInstance, is.Name)
+		}
+	}
+	return r
+}
@@ -0,0 +1,149 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package builder
+
+import (
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// AttachDisks adds a step attaching disks to instances.
+func (b *Builder) AttachDisks(name string, disks ...*daisy.AttachDisk) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		ad := daisy.AttachDisks(disks)
+		s.AttachDisks = &ad
+	})
+}
+
+// CopyGCSObjects adds a step copying GCS objects.
+func (b *Builder) CopyGCSObjects(name string, objects ...daisy.CopyGCSObject) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		co := daisy.CopyGCSObjects(objects)
+		s.CopyGCSObjects = &co
+	})
+}
+
+// CreateDisks adds a step creating disks.
+func (b *Builder) CreateDisks(name string, disks ...*daisy.Disk) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		cd := daisy.CreateDisks(disks)
+		s.CreateDisks = &cd
+	})
+}
+
+// CreateImages adds a step creating images.
+func (b *Builder) CreateImages(name string, images ...*daisy.Image) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		s.CreateImages = &daisy.CreateImages{Images: images}
+	})
+}
+
+// CreateInstances adds a step creating instances.
+func (b *Builder) CreateInstances(name string, instances ...*daisy.Instance) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		s.CreateInstances = &daisy.CreateInstances{Instances: instances}
+	})
+}
+
+// CreateMachineImages adds a step creating machine images.
+func (b *Builder) CreateMachineImages(name string, machineImages ...*daisy.MachineImage) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		cm := daisy.CreateMachineImages(machineImages)
+		s.CreateMachineImages = &cm
+	})
+}
+
+// CreateSnapshots adds a step creating snapshots.
+func (b *Builder) CreateSnapshots(name string, snapshots ...*daisy.Snapshot) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		cs := daisy.CreateSnapshots(snapshots)
+		s.CreateSnapshots = &cs
+	})
+}
+
+// DeleteResources adds a step deleting resources.
+func (b *Builder) DeleteResources(name string, resources *daisy.DeleteResources) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		s.DeleteResources = resources
+	})
+}
+
+// DetachDisks adds a step detaching disks from instances.
+func (b *Builder) DetachDisks(name string, disks ...*daisy.DetachDisk) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		dd := daisy.DetachDisks(disks)
+		s.DetachDisks = &dd
+	})
+}
+
+// StartInstances adds a step starting instances.
+func (b *Builder) StartInstances(name string, instances ...string) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		s.StartInstances = &daisy.StartInstances{Instances: instances}
+	})
+}
+
+// StopInstances adds a step stopping instances.
+func (b *Builder) StopInstances(name string, instances ...string) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		s.StopInstances = &daisy.StopInstances{Instances: instances}
+	})
+}
+
+// UpdateInstancesMetadata adds a step updating the metadata of instances.
+func (b *Builder) UpdateInstancesMetadata(name string, updates ...*daisy.UpdateInstanceMetadata) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		um := daisy.UpdateInstancesMetadata(updates)
+		s.UpdateInstancesMetadata = &um
+	})
+}
+
+// WaitForInstancesSignal adds a step waiting for all of the signals.
+func (b *Builder) WaitForInstancesSignal(name string, signals ...*daisy.InstanceSignal) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		ws := daisy.WaitForInstancesSignal(signals)
+		s.WaitForInstancesSignal = &ws
+	})
+}
+
+// WaitForAnyInstancesSignal adds a step waiting for any of the signals.
+func (b *Builder) WaitForAnyInstancesSignal(name string, signals ...*daisy.InstanceSignal) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		ws := daisy.WaitForAnyInstancesSignal(signals)
+		s.WaitForAnyInstancesSignal = &ws
+	})
+}
+
+// IncludeWorkflow adds a step running w as part of the workflow, sharing its
+// resources.
+func (b *Builder) IncludeWorkflow(name string, w *daisy.Workflow) *Step {
+	b.opaque = true
+	return b.addStep(name, func(s *daisy.Step) {
+		s.IncludeWorkflow = &daisy.IncludeWorkflow{Workflow: w}
+	})
+}
+
+// SubWorkflow adds a step running w as a separate workflow.
+func (b *Builder) SubWorkflow(name string, w *daisy.Workflow) *Step {
+	return b.addStep(name, func(s *daisy.Step) {
+		s.SubWorkflow = &daisy.SubWorkflow{Workflow: w}
+	})
+}
+
+// Custom adds a step that set fills in, for step kinds without a method of
+// their own. The Builder doesn't know the resources such steps use.
+func (b *Builder) Custom(name string, set func(*daisy.Step)) *Step {
+	b.opaque = true
+	return b.addStep(name, set)
+}
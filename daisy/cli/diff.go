@@ -0,0 +1,89 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy/vars"
+)
+
+// diff prints the differences between two workflows after expansion, and
+// exits with status 1 if there are any:
+// daisy diff [-variables key=value,...] old.wf.json new.wf.json
+func diff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	variables := fs.String("variables", "", "comma separated list of variables passed to both workflows, in the form 'key=value'")
+	project := fs.String("project", "", "project to expand both workflows in, overrides what is set in the workflows")
+	zone := fs.String("zone", "", "zone to expand both workflows in, overrides what is set in the workflows")
+	varFlags := vars.RegisterFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		log.Fatal("Wrong number of args, pass the paths of the two workflow files to compare.")
+	}
+	vs, err := varFlags.Load(os.Environ())
+	if err != nil {
+		log.Fatal(err)
+	}
+	vs.AddList(*variables, "flag -variables")
+
+	var ws []*daisy.Workflow
+	for _, path := range fs.Args() {
+		w, err := readDiffWorkflow(path, vs, *project, *zone)
+		if err != nil {
+			log.Fatalf("error parsing workflow %q: %v", path, err)
+		}
+		ws = append(ws, w)
+	}
+
+	d, err := daisy.DiffWorkflows(context.Background(), ws[0], ws[1])
+	if err != nil {
+		log.Fatalf("error expanding workflows: %v", err)
+	}
+	if d.Empty() {
+		fmt.Println("[Daisy] The workflows are the same after expansion.")
+		return
+	}
+	d.Write(os.Stdout)
+	os.Exit(1)
+}
+
+// readDiffWorkflow reads the workflow at path and sets the variables it
+// declares, as a variable may be added or removed by the change being
+// reviewed.
+func readDiffWorkflow(path string, vs vars.Vars, project, zone string) (*daisy.Workflow, error) {
+	w, err := daisy.NewFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range vs {
+		if _, ok := w.Vars[k]; ok {
+			w.AddVar(k, v.Value)
+		}
+	}
+	if project != "" {
+		w.Project = project
+	}
+	if zone != "" {
+		w.Zone = zone
+	}
+	return w, nil
+}
@@ -24,12 +24,15 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"cloud.google.com/go/compute/metadata"
 	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy/vars"
 )
 
 var (
@@ -47,47 +50,51 @@ var (
 	gcsLogsDisabled    = flag.Bool("disable_gcs_logging", false, "do not stream logs to GCS")
 	cloudLogsDisabled  = flag.Bool("disable_cloud_logging", false, "do not stream logs to Cloud Logging")
 	stdoutLogsDisabled = flag.Bool("disable_stdout_logging", false, "do not display individual workflow logs on stdout")
+	tui                = flag.Bool("tui", false, "show a live table of the workflow steps instead of logs on stdout, when it is a terminal")
+	includeCacheDir    = flag.String("include_cache_dir", "", "local directory to cache remote IncludeWorkflow and SubWorkflow files in")
+	localLogsDir       = flag.String("local_logs_dir", "", "local directory to also write the daisy log, serial port output and a run summary to")
+	report             = flag.String("report", "", "write a run report to this path, as HTML if it ends in .html and JSON otherwise")
+	offlineIncludes    = flag.Bool("offline_includes", false, "do not fetch remote IncludeWorkflow and SubWorkflow files, only use the include cache")
+	maxOperations      = flag.Int("max_concurrent_operations", 0, "maximum number of Compute operations in flight across all workflows, 0 for no limit")
+	apiRPS             = flag.Float64("api_rps", 0, "maximum Compute API requests per second for each API method across all workflows, 0 for no limit")
+	apiMethodRPS       = flag.String("api_method_rps", "", "comma separated per API method overrides of -api_rps, in the form 'disks.insert=2'")
+	varFlags           = vars.RegisterFlags(flag.CommandLine)
 )
 
 const (
 	flgDefValue   = "flag generated for workflow variable"
-	varFlagPrefix = "var:"
+	varFlagPrefix = vars.FlagPrefix
 )
 
-func populateVars(input string) map[string]string {
-	varMap := map[string]string{}
-	if input != "" {
-		for _, v := range strings.Split(input, ",") {
-			i := strings.Index(v, "=")
-			if i == -1 {
-				continue
-			}
-			varMap[v[:i]] = v[i+1:]
-		}
+// populateVars collects the workflow variables in increasing order of
+// precedence: DAISY_VAR_* environment variables, -var_file files, the
+// -variables list and -var:<name> flags.
+func populateVars(input string) (vars.Vars, error) {
+	vs, err := varFlags.Load(os.Environ())
+	if err != nil {
+		return nil, err
 	}
-
-	flag.Visit(func(flg *flag.Flag) {
-		if strings.HasPrefix(flg.Name, varFlagPrefix) {
-			varMap[strings.TrimPrefix(flg.Name, varFlagPrefix)] = flg.Value.String()
-		}
-	})
-
-	return varMap
+	vs.AddList(input, "flag -variables")
+	vs.AddFlags(flag.CommandLine)
+	return vs, nil
 }
 
-func parseWorkflow(ctx context.Context, path string, varMap map[string]string, project, zone, gcsPath, oauth, dTimeout, cEndpoint string, disableGCSLogs, diableCloudLogs, disableStdoutLogs bool) (*daisy.Workflow, error) {
+func parseWorkflow(ctx context.Context, path string, vs vars.Vars, project, zone, gcsPath, oauth, dTimeout, cEndpoint string, disableGCSLogs, diableCloudLogs, disableStdoutLogs bool) (*daisy.Workflow, error) {
 	w, err := daisy.NewFromFile(path)
 	if err != nil {
 		return nil, err
 	}
 Loop:
-	for k, v := range varMap {
+	for k, v := range vs {
 		for wv := range w.Vars {
 			if k == wv {
-				w.AddVar(k, v)
+				w.AddVar(k, v.Value)
 				continue Loop
 			}
 		}
+		if v.FromEnv {
+			continue
+		}
 		return nil, fmt.Errorf("unknown workflow Var %q passed to Workflow %q", k, w.Name)
 	}
 
@@ -216,12 +223,65 @@ func printPerfProfile(workflow *daisy.Workflow) {
 	fmt.Printf("Total time: %v\n\n", formatDuration(wfEndTime.Sub(wfStartTime)))
 }
 
+// writeReport writes the run report of w to path. When several workflows are
+// run, the workflow name is added to each report's file name.
+func writeReport(w *daisy.Workflow, path string, multiple bool) error {
+	ext := filepath.Ext(path)
+	if multiple {
+		path = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), w.Name, ext)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	r := w.Report()
+	if strings.EqualFold(ext, ".html") {
+		err = r.WriteHTML(f)
+	} else {
+		err = r.WriteJSON(f)
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+// newLimiter returns the Compute API limiter set up by the flags, or nil if
+// there are no limits.
+func newLimiter() (*daisyCompute.Limiter, error) {
+	if *maxOperations <= 0 && *apiRPS <= 0 && *apiMethodRPS == "" {
+		return nil, nil
+	}
+	methodRPS, err := daisyCompute.ParseMethodRPS(*apiMethodRPS)
+	if err != nil {
+		return nil, err
+	}
+	return daisyCompute.NewLimiter(*maxOperations, *apiRPS, methodRPS), nil
+}
+
 func formatDuration(d time.Duration) string {
 	s := int(d.Seconds())
 	return fmt.Sprintf("[hh:mm:ss] %v:%v:%v", s/3600, s/60%60, s%60)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		schema(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		diff(os.Args[2:])
+		return
+	}
+
 	addFlags(os.Args[1:])
 	flag.Parse()
 
@@ -242,15 +302,55 @@ func main() {
 	ctx := context.Background()
 
 	var ws []*daisy.Workflow
-	varMap := populateVars(*variables)
+	vs, err := populateVars(*variables)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *print && len(vs) > 0 {
+		fmt.Println("[Daisy] Workflow variables and where they were set:")
+		vs.Report(os.Stdout)
+	}
+
+	limiter, err := newLimiter()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var view *progressView
+	if *tui && !*print && !*validate {
+		if isTerminal(os.Stdout) {
+			view = &progressView{out: os.Stdout, width: func() int { return terminalWidth(os.Stdout) }}
+		} else {
+			fmt.Fprintln(os.Stderr, "[Daisy] stdout is not a terminal, ignoring -tui")
+		}
+	}
 
 	for _, path := range flag.Args() {
-		w, err := parseWorkflow(ctx, path, varMap, *project, *zone, *gcsPath, *oauth, *defaultTimeout, *ce, *gcsLogsDisabled, *cloudLogsDisabled, *stdoutLogsDisabled)
+		w, err := parseWorkflow(ctx, path, vs, *project, *zone, *gcsPath, *oauth, *defaultTimeout, *ce, *gcsLogsDisabled, *cloudLogsDisabled, *stdoutLogsDisabled || view != nil)
 		if err != nil {
 			log.Fatalf("error parsing workflow %q: %v", path, err)
 		}
+		if changes := w.PendingMigrations(); len(changes) > 0 {
+			fmt.Fprintf(os.Stderr, "[Daisy] Warning: workflow file %q uses deprecated forms, run \"daisy migrate %s\" to update it:\n", path, path)
+			for _, c := range changes {
+				fmt.Fprintf(os.Stderr, "  %s\n", c)
+			}
+		}
+		if *includeCacheDir != "" {
+			w.SetIncludeCacheDir(*includeCacheDir)
+		}
+		if *offlineIncludes {
+			w.EnableOfflineIncludes()
+		}
+		if *localLogsDir != "" {
+			w.SetLocalLogsDir(*localLogsDir)
+		}
+		w.ComputeLimiter = limiter
 		ws = append(ws, w)
 	}
+	if view != nil {
+		view.ws = ws
+	}
 
 	errors := make(chan error, len(ws))
 	var wg sync.WaitGroup
@@ -281,18 +381,46 @@ func main() {
 		wg.Add(1)
 		go func(w *daisy.Workflow) {
 			defer wg.Done()
-			if *printPerf {
+			if *printPerf && view == nil {
 				defer printPerfProfile(w)
 			}
-			fmt.Printf("[Daisy] Running workflow %q (id=%s)\n", w.Name, w.ID())
+			if *report != "" {
+				defer func() {
+					if err := writeReport(w, *report, len(ws) > 1); err != nil {
+						fmt.Fprintf(os.Stderr, "[Daisy] Error writing report for workflow %q: %v\n", w.Name, err)
+					}
+				}()
+			}
+			if view == nil {
+				fmt.Printf("[Daisy] Running workflow %q (id=%s)\n", w.Name, w.ID())
+			}
 			if err := w.Run(ctx); err != nil {
 				errors <- fmt.Errorf("%s: %v", w.Name, err)
 				return
 			}
-			fmt.Printf("[Daisy] Workflow %q finished\n", w.Name)
+			if view == nil {
+				fmt.Printf("[Daisy] Workflow %q finished\n", w.Name)
+			}
 		}(w)
 	}
-	wg.Wait()
+	if view != nil {
+		stop, done := make(chan struct{}), make(chan struct{})
+		go func() {
+			view.run(stop)
+			close(done)
+		}()
+		wg.Wait()
+		close(stop)
+		<-done
+		// Printed after the view stopped redrawing.
+		if *printPerf {
+			for _, w := range ws {
+				printPerfProfile(w)
+			}
+		}
+	} else {
+		wg.Wait()
+	}
 
 	select {
 	case err := <-errors:
@@ -18,8 +18,15 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy/vars"
 )
 
 func TestPopulateVars(t *testing.T) {
@@ -38,13 +45,49 @@ func TestPopulateVars(t *testing.T) {
 	flag.CommandLine.Parse([]string{"-var:test1", "value"})
 
 	for _, tt := range tests {
-		got := populateVars(tt.input)
-		if !reflect.DeepEqual(tt.want, got) {
+		vs, err := populateVars(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := vs.Map(); !reflect.DeepEqual(tt.want, got) {
 			t.Errorf("splitVariables did not split %q as expected, want: %q, got: %q", tt.input, tt.want, got)
 		}
 	}
 }
 
+func TestPopulateVarsSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daisy-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "vars.yaml")
+	if err := ioutil.WriteFile(file, []byte("file: a,b\nenv: file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("DAISY_VAR_env", "env")
+	os.Setenv("DAISY_VAR_only_env", "env")
+	flag.CommandLine.Set("var_file", file)
+	defer func() {
+		os.Unsetenv("DAISY_VAR_env")
+		os.Unsetenv("DAISY_VAR_only_env")
+		varFlags = vars.RegisterFlags(flag.NewFlagSet("", flag.ContinueOnError))
+	}()
+
+	vs, err := populateVars("env=list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"test1": "value", "file": "a,b", "env": "list", "only_env": "env"}
+	if got := vs.Map(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want vars %q, got %q", want, got)
+	}
+	wantOverrides := []string{"environment variable DAISY_VAR_env", "file " + file}
+	if got := vs["env"].Overrides; !reflect.DeepEqual(wantOverrides, got) {
+		t.Errorf("want env to override %q, got %q", wantOverrides, got)
+	}
+}
+
 func TestAddFlags(t *testing.T) {
 	firstFlag := "var:first_var"
 	secondFlag := "var:second_var"
@@ -74,14 +117,18 @@ func TestAddFlags(t *testing.T) {
 
 func TestParseWorkflows(t *testing.T) {
 	path := "../../daisy/test_data/test.wf.json"
-	varMap := map[string]string{"key1": "var1", "key2": "var2"}
+	vs := vars.Vars{}
+	vs.Set("key1", "var1", "test")
+	vs.Set("key2", "var2", "test")
+	// Only set in the environment, for another workflow.
+	vs.AddEnv([]string{"DAISY_VAR_other=value"})
 	project := "project"
 	zone := "zone"
 	gcsPath := "gcspath"
 	oauth := "oauthpath"
 	dTimeout := "10m"
 	endpoint := "endpoint"
-	w, err := parseWorkflow(context.Background(), path, varMap, project, zone, gcsPath, oauth, dTimeout, endpoint, true, true, true)
+	w, err := parseWorkflow(context.Background(), path, vs, project, zone, gcsPath, oauth, dTimeout, endpoint, true, true, true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -103,7 +150,47 @@ func TestParseWorkflows(t *testing.T) {
 		}
 	}
 
-	if reflect.DeepEqual(w.Vars, varMap) {
-		t.Errorf("unexpected vars, want: %v, got: %v", varMap, w.Vars)
+	if reflect.DeepEqual(w.Vars, vs.Map()) {
+		t.Errorf("unexpected vars, want: %v, got: %v", vs.Map(), w.Vars)
+	}
+	if _, ok := w.Vars["other"]; ok {
+		t.Error("variable only set in the environment was added to the workflow")
+	}
+
+	vs.Set("other", "value", "test")
+	if _, err := parseWorkflow(context.Background(), path, vs, project, zone, gcsPath, oauth, dTimeout, endpoint, true, true, true); err == nil {
+		t.Error("expected error for unknown variable, got none")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daisy-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := daisy.New()
+	w.Name = "wf"
+	for _, tt := range []struct {
+		path     string
+		multiple bool
+		want     string
+		prefix   string
+	}{
+		{filepath.Join(dir, "report.json"), false, filepath.Join(dir, "report.json"), "{"},
+		{filepath.Join(dir, "report.html"), false, filepath.Join(dir, "report.html"), "<!DOCTYPE html>"},
+		{filepath.Join(dir, "report.json"), true, filepath.Join(dir, "report-wf.json"), "{"},
+	} {
+		if err := writeReport(w, tt.path, tt.multiple); err != nil {
+			t.Fatalf("writeReport(%q, %t): %v", tt.path, tt.multiple, err)
+		}
+		b, err := ioutil.ReadFile(tt.want)
+		if err != nil {
+			t.Fatalf("report not written to %q: %v", tt.want, err)
+		}
+		if !strings.HasPrefix(string(b), tt.prefix) {
+			t.Errorf("report %q does not start with %q", tt.want, tt.prefix)
+		}
 	}
 }
@@ -0,0 +1,77 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// migrate updates workflow files to the current schema version:
+// daisy migrate [-dry_run] workflow...
+func migrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry_run", false, "only report the changes, don't write the files")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("Not enough args, pass the paths of the workflow files to migrate.")
+	}
+
+	failed := false
+	for _, path := range fs.Args() {
+		if err := migrateWorkflow(path, *dryRun); err != nil {
+			fmt.Fprintf(os.Stderr, "[Daisy] Error migrating workflow file %q: %v\n", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func migrateWorkflow(path string, dryRun bool) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	newData, changes, err := daisy.Migrate(data)
+	if err != nil {
+		return daisy.JSONError(path, data, err)
+	}
+	if bytes.Equal(data, newData) {
+		fmt.Printf("[Daisy] Workflow file %q is up to date\n", path)
+		return nil
+	}
+
+	fmt.Printf("[Daisy] Migrating workflow file %q to schema version %d\n", path, daisy.CurrentSchemaVersion)
+	for _, c := range changes {
+		fmt.Printf("  %s\n", c)
+	}
+	if dryRun {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, newData, fi.Mode())
+}
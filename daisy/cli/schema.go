@@ -0,0 +1,68 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// schema prints the JSON Schema of workflow files, or checks files against it:
+// daisy schema [-check workflow...]
+func schema(args []string) {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	check := fs.Bool("check", false, "check the given workflow files against the schema instead of printing it")
+	fs.Parse(args)
+
+	if !*check {
+		data, err := json.MarshalIndent(daisy.WorkflowSchema(), "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if fs.NArg() == 0 {
+		log.Fatal("Not enough args, pass the paths of the workflow files to check.")
+	}
+	failed := false
+	for _, path := range fs.Args() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[Daisy] Error reading workflow file %q: %v\n", path, err)
+			failed = true
+			continue
+		}
+		errs, err := daisy.ValidateWorkflowSchema(data)
+		if err != nil {
+			err = daisy.JSONError(path, data, err)
+			errs = []string{err.Error()}
+		}
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, e)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy/server"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy/vars"
+)
+
+// serveFlags are the workflow flags that also apply to served workflows.
+var serveFlags = []string{
+	"oauth", "project", "gcs_path", "zone", "default_timeout", "compute_endpoint_override",
+	"disable_gcs_logging", "disable_cloud_logging", "include_cache_dir", "offline_includes",
+	"max_concurrent_operations", "api_rps", "api_method_rps",
+}
+
+// serve runs the daisy server: daisy serve [flags].
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to serve the API on, the API has no authentication so only make it reachable by trusted clients")
+	dataDir := fs.String("data_dir", "daisy-server", "directory to keep the run history and logs in")
+	workflowDir := fs.String("workflow_dir", "", "directory of the workflow files that can be run by path, if unset only inline workflows are run")
+	maxRuns := fs.Int("max_concurrent_runs", 4, "maximum number of workflows running at the same time, further runs are queued")
+	for _, name := range serveFlags {
+		f := flag.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	fs.Parse(args)
+
+	limiter, err := newLimiter()
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv, err := server.New(server.Config{
+		DataDir:           *dataDir,
+		WorkflowDir:       *workflowDir,
+		MaxConcurrentRuns: *maxRuns,
+		Load: func(path string, varMap map[string]string) (*daisy.Workflow, error) {
+			vs := vars.Vars{}
+			for k, v := range varMap {
+				vs.Set(k, v, "run request")
+			}
+			w, err := parseWorkflow(context.Background(), path, vs, *project, *zone, *gcsPath, *oauth, *defaultTimeout, *ce, *gcsLogsDisabled, *cloudLogsDisabled, true)
+			if err != nil {
+				return nil, err
+			}
+			if *includeCacheDir != "" {
+				w.SetIncludeCacheDir(*includeCacheDir)
+			}
+			if *offlineIncludes {
+				w.EnableOfflineIncludes()
+			}
+			w.ComputeLimiter = limiter
+			return w, nil
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("[Daisy] Serving on %s, keeping runs in %q", *addr, *dataDir)
+	log.Fatal(http.ListenAndServe(*addr, srv))
+}
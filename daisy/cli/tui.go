@@ -0,0 +1,150 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+const (
+	tuiRefresh = 500 * time.Millisecond
+	// maxStatusLen truncates StatusMatch lines to keep table rows on one line.
+	maxStatusLen = 60
+)
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// progressView redraws a table of the progress of workflows in place.
+type progressView struct {
+	out io.Writer
+	// width returns the number of columns of out, or 0 if it isn't known.
+	width func() int
+	ws    []*daisy.Workflow
+	lines int
+}
+
+// run redraws the view until stop is closed, then draws it a last time.
+func (v *progressView) run(stop <-chan struct{}) {
+	tick := time.NewTicker(tuiRefresh)
+	defer tick.Stop()
+	for {
+		v.draw(time.Now())
+		select {
+		case <-stop:
+			v.draw(time.Now())
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+func (v *progressView) draw(now time.Time) {
+	var buf bytes.Buffer
+	for _, w := range v.ws {
+		renderProgress(&buf, w.Name, w.ID(), w.Progress(), now)
+	}
+	// Lines are cut to the width of the terminal, as the rows of wrapped lines
+	// wouldn't be cleared by the next drawing.
+	var width int
+	if v.width != nil {
+		width = v.width()
+	}
+	out := truncateLines(buf.String(), width)
+	// Move the cursor back to the start of the previous drawing and clear it.
+	if v.lines > 0 {
+		fmt.Fprintf(v.out, "\x1b[%dA", v.lines)
+	}
+	fmt.Fprint(v.out, "\r\x1b[J", out)
+	v.lines = strings.Count(out, "\n")
+}
+
+// truncateLines cuts the lines of s longer than width-1 characters, leaving
+// the last column free so that terminals don't wrap them. A width of 0 keeps
+// the lines as they are.
+func truncateLines(s string, width int) string {
+	if width <= 1 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if r := []rune(l); len(r) > width-1 {
+			lines[i] = string(r[:width-1])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderProgress writes the steps of a workflow as a table, followed by the
+// resources it has in flight.
+func renderProgress(out io.Writer, name, id string, p *daisy.Progress, now time.Time) {
+	counts := map[daisy.StepState]int{}
+	for _, s := range p.Steps {
+		counts[s.State]++
+	}
+	fmt.Fprintf(out, "Workflow %q (id=%s): %d done, %d running, %d failed, %d pending\n",
+		name, id, counts[daisy.StepDone], counts[daisy.StepRunning], counts[daisy.StepFailed], counts[daisy.StepPending])
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  STEP\tSTATE\tELAPSED\tSTATUS")
+	for _, s := range p.Steps {
+		elapsed := "-"
+		if !s.StartTime.IsZero() {
+			elapsed = s.Elapsed(now).Round(time.Second).String()
+		}
+		if s.Timeout > 0 {
+			elapsed += " / " + s.Timeout.String()
+		}
+		var status []string
+		for _, is := range s.Instances {
+			line := is.Line
+			if r := []rune(line); len(r) > maxStatusLen {
+				line = string(r[:maxStatusLen-3]) + "..."
+			}
+			status = append(status, fmt.Sprintf("%s: %s", is.Instance, line))
+		}
+		if len(status) == 0 {
+			status = []string{""}
+		}
+		// Nested steps are indented under their IncludeWorkflow or SubWorkflow step.
+		indent := strings.Repeat("  ", strings.Count(s.Name, "."))
+		fmt.Fprintf(tw, "  %s%s\t%s\t%s\t%s\n", indent, s.Name, s.State, elapsed, status[0])
+		for _, st := range status[1:] {
+			fmt.Fprintf(tw, "  \t\t\t%s\n", st)
+		}
+	}
+	tw.Flush()
+
+	if len(p.Resources) == 0 {
+		fmt.Fprintln(out, "Resources in flight: none")
+		return
+	}
+	var rs []string
+	for _, r := range p.Resources {
+		rs = append(rs, r.Type+" "+r.Name)
+	}
+	fmt.Fprintf(out, "Resources in flight: %s\n", strings.Join(rs, ", "))
+}
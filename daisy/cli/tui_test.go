@@ -0,0 +1,103 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+func TestRenderProgress(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &daisy.Progress{
+		Steps: []daisy.StepProgress{
+			{Name: "create", State: daisy.StepDone, StartTime: start, EndTime: start.Add(5 * time.Second), Timeout: 10 * time.Minute},
+			{Name: "include", State: daisy.StepRunning, StartTime: start.Add(5 * time.Second), Timeout: time.Hour},
+			{Name: "inc.wait", State: daisy.StepRunning, StartTime: start.Add(5 * time.Second), Instances: []daisy.InstanceStatus{
+				{Instance: "i1", Line: "Status: " + strings.Repeat("x", 70)},
+				{Instance: "i2", Line: "Status: two"},
+				{Instance: "i3", Line: "Status: " + strings.Repeat("é", 70)},
+			}},
+			{Name: "delete", State: daisy.StepPending, Timeout: 10 * time.Minute},
+		},
+		Resources: []daisy.ResourceReport{{Type: "disk", Name: "d"}, {Type: "instance", Name: "i1"}},
+	}
+	var buf bytes.Buffer
+	renderProgress(&buf, "wf", "abc", p, start.Add(65*time.Second))
+
+	want := `Workflow "wf" (id=abc): 1 done, 2 running, 0 failed, 1 pending
+  STEP        STATE    ELAPSED        STATUS
+  create      done     5s / 10m0s     
+  include     running  1m0s / 1h0m0s  
+    inc.wait  running  1m0s           i1: Status: ` + strings.Repeat("x", 49) + `...
+                                      i2: Status: two
+                                      i3: Status: ` + strings.Repeat("é", 49) + `...
+  delete      pending  - / 10m0s      
+Resources in flight: disk d, instance i1
+`
+	if buf.String() != want {
+		t.Errorf("want\n%s\ngot\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	renderProgress(&buf, "wf", "abc", &daisy.Progress{}, start)
+	if !strings.HasSuffix(buf.String(), "Resources in flight: none\n") {
+		t.Errorf("want no resources in flight, got\n%s", buf.String())
+	}
+}
+
+func TestProgressViewTruncatesToWidth(t *testing.T) {
+	w := daisy.New()
+	w.Name = strings.Repeat("w", 100)
+	var buf bytes.Buffer
+	v := &progressView{out: &buf, width: func() int { return 40 }, ws: []*daisy.Workflow{w}}
+
+	v.draw(time.Now())
+	out := strings.TrimPrefix(buf.String(), "\r\x1b[J")
+	for _, l := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if len(l) > 39 {
+			t.Errorf("line longer than the terminal: %q", l)
+		}
+	}
+	// The next drawing moves the cursor up by the rows of this one.
+	lines := v.lines
+	buf.Reset()
+	v.draw(time.Now())
+	if want := fmt.Sprintf("\x1b[%dA", lines); !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("want drawing to start with %q, got %q", want, buf.String())
+	}
+}
+
+func TestTruncateLines(t *testing.T) {
+	for _, tt := range []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"short\nlines\n", 10, "short\nlines\n"},
+		{"a long line\nok\n", 5, "a lo\nok\n"},
+		{"unknown width\n", 0, "unknown width\n"},
+		{"héllo wörld\n", 6, "héllo\n"},
+	} {
+		if got := truncateLines(tt.s, tt.width); got != tt.want {
+			t.Errorf("truncateLines(%q, %d): want %q, got %q", tt.s, tt.width, tt.want, got)
+		}
+	}
+}
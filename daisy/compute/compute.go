@@ -41,6 +41,11 @@ type Client interface {
 	CreateDiskBeta(project, zone string, d *computeBeta.Disk) error
 	CreateForwardingRule(project, region string, fr *compute.ForwardingRule) error
 	CreateFirewallRule(project string, i *compute.Firewall) error
+	CreateAddress(project, region string, a *compute.Address) error
+	CreateRoute(project string, r *compute.Route) error
+	CreateRouter(project, region string, r *compute.Router) error
+	CreateInstanceTemplate(project string, t *compute.InstanceTemplate) error
+	CreateInstanceGroupManager(project, zone string, m *compute.InstanceGroupManager) error
 	CreateImage(project string, i *compute.Image) error
 	CreateImageAlpha(project string, i *computeAlpha.Image) error
 	CreateImageBeta(project string, i *computeBeta.Image) error
@@ -54,10 +59,17 @@ type Client interface {
 	DeleteDisk(project, zone, name string) error
 	DeleteForwardingRule(project, region, name string) error
 	DeleteFirewallRule(project, name string) error
+	DeleteAddress(project, region, name string) error
+	DeleteRoute(project, name string) error
+	DeleteRouter(project, region, name string) error
+	DeleteInstanceTemplate(project, name string) error
+	DeleteInstanceGroupManager(project, zone, name string) error
 	DeleteImage(project, name string) error
 	DeleteInstance(project, zone, name string) error
 	StartInstance(project, zone, name string) error
 	StopInstance(project, zone, name string) error
+	SuspendInstance(project, zone, name string) error
+	ResumeInstance(project, zone, name string) error
 	DeleteNetwork(project, name string) error
 	DeleteSubnetwork(project, region, name string) error
 	DeleteTargetInstance(project, zone, name string) error
@@ -75,6 +87,11 @@ type Client interface {
 	GetDiskBeta(project, zone, name string) (*computeBeta.Disk, error)
 	GetForwardingRule(project, region, name string) (*compute.ForwardingRule, error)
 	GetFirewallRule(project, name string) (*compute.Firewall, error)
+	GetAddress(project, region, name string) (*compute.Address, error)
+	GetRoute(project, name string) (*compute.Route, error)
+	GetRouter(project, region, name string) (*compute.Router, error)
+	GetInstanceTemplate(project, name string) (*compute.InstanceTemplate, error)
+	GetInstanceGroupManager(project, zone, name string) (*compute.InstanceGroupManager, error)
 	GetImage(project, name string) (*compute.Image, error)
 	GetImageAlpha(project, name string) (*computeAlpha.Image, error)
 	GetImageBeta(project, name string) (*computeBeta.Image, error)
@@ -85,6 +102,7 @@ type Client interface {
 	GetTargetInstance(project, zone, name string) (*compute.TargetInstance, error)
 	InstanceStatus(project, zone, name string) (string, error)
 	InstanceStopped(project, zone, name string) (bool, error)
+	InstanceSuspended(project, zone, name string) (bool, error)
 	ListMachineTypes(project, zone string, opts ...ListCallOption) ([]*compute.MachineType, error)
 	ListLicenses(project string, opts ...ListCallOption) ([]*compute.License, error)
 	ListZones(project string, opts ...ListCallOption) ([]*compute.Zone, error)
@@ -95,6 +113,12 @@ type Client interface {
 	ListDisks(project, zone string, opts ...ListCallOption) ([]*compute.Disk, error)
 	ListForwardingRules(project, zone string, opts ...ListCallOption) ([]*compute.ForwardingRule, error)
 	ListFirewallRules(project string, opts ...ListCallOption) ([]*compute.Firewall, error)
+	ListAddresses(project, region string, opts ...ListCallOption) ([]*compute.Address, error)
+	ListRoutes(project string, opts ...ListCallOption) ([]*compute.Route, error)
+	ListRouters(project, region string, opts ...ListCallOption) ([]*compute.Router, error)
+	ListInstanceTemplates(project string, opts ...ListCallOption) ([]*compute.InstanceTemplate, error)
+	ListInstanceGroupManagers(project, zone string, opts ...ListCallOption) ([]*compute.InstanceGroupManager, error)
+	PatchInstanceGroupManager(project, zone, name string, m *compute.InstanceGroupManager) error
 	ListImages(project string, opts ...ListCallOption) ([]*compute.Image, error)
 	ListImagesAlpha(project string, opts ...ListCallOption) ([]*computeAlpha.Image, error)
 	GetSnapshot(project, name string) (*compute.Snapshot, error)
@@ -107,7 +131,17 @@ type Client interface {
 	ResizeDisk(project, zone, disk string, drr *compute.DisksResizeRequest) error
 	SetInstanceMetadata(project, zone, name string, md *compute.Metadata) error
 	SetCommonInstanceMetadata(project string, md *compute.Metadata) error
+	SetDiskLabels(project, zone, name string, req *compute.ZoneSetLabelsRequest) error
+	SetRegionDiskLabels(project, region, name string, req *compute.RegionSetLabelsRequest) error
+	SetImageLabels(project, name string, req *compute.GlobalSetLabelsRequest) error
+	SetInstanceLabels(project, zone, name string, req *compute.InstancesSetLabelsRequest) error
+	SetSnapshotLabels(project, name string, req *compute.GlobalSetLabelsRequest) error
 	SetDiskAutoDelete(project, zone, instance string, autoDelete bool, deviceName string) error
+	CreateRegionDisk(project, region string, d *compute.Disk) error
+	DeleteRegionDisk(project, region, name string) error
+	GetRegionDisk(project, region, name string) (*compute.Disk, error)
+	ListRegionDisks(project, region string, opts ...ListCallOption) ([]*compute.Disk, error)
+	ResizeRegionDisk(project, region, disk string, drr *compute.RegionDisksResizeRequest) error
 
 	// Beta API calls
 	GetGuestAttributes(project, zone, name, queryPath, variableKey string) (*computeBeta.GuestAttributes, error)
@@ -121,6 +155,24 @@ type Client interface {
 	BasePath() string
 }
 
+// ContextClient is a Client that can make its calls with a context. It's
+// separate from Client so that other implementations of Client keep working.
+type ContextClient interface {
+	Client
+	// WithContext returns a client whose API calls, retries and operation
+	// waits are made with ctx, so they stop as soon as ctx is done.
+	WithContext(ctx context.Context) Client
+}
+
+// WithContext returns c making its calls with ctx if it's a ContextClient,
+// and c unchanged otherwise.
+func WithContext(ctx context.Context, c Client) Client {
+	if cc, ok := c.(ContextClient); ok {
+		return cc.WithContext(ctx)
+	}
+	return c
+}
+
 // A ListCallOption is an option for a Google Compute API *ListCall.
 type ListCallOption interface {
 	listCallOptionApply(interface{}) interface{}
@@ -151,10 +203,22 @@ func (o OrderBy) listCallOptionApply(i interface{}) interface{} {
 		return c.OrderBy(string(o))
 	case *compute.DisksListCall:
 		return c.OrderBy(string(o))
+	case *compute.RegionDisksListCall:
+		return c.OrderBy(string(o))
 	case *compute.NetworksListCall:
 		return c.OrderBy(string(o))
 	case *compute.SubnetworksListCall:
 		return c.OrderBy(string(o))
+	case *compute.AddressesListCall:
+		return c.OrderBy(string(o))
+	case *compute.RoutesListCall:
+		return c.OrderBy(string(o))
+	case *compute.RoutersListCall:
+		return c.OrderBy(string(o))
+	case *compute.InstanceTemplatesListCall:
+		return c.OrderBy(string(o))
+	case *compute.InstanceGroupManagersListCall:
+		return c.OrderBy(string(o))
 	case *compute.InstancesAggregatedListCall:
 		return c.OrderBy(string(o))
 	case *compute.DisksAggregatedListCall:
@@ -190,10 +254,22 @@ func (o Filter) listCallOptionApply(i interface{}) interface{} {
 		return c.Filter(string(o))
 	case *compute.DisksListCall:
 		return c.Filter(string(o))
+	case *compute.RegionDisksListCall:
+		return c.Filter(string(o))
 	case *compute.NetworksListCall:
 		return c.Filter(string(o))
 	case *compute.SubnetworksListCall:
 		return c.Filter(string(o))
+	case *compute.AddressesListCall:
+		return c.Filter(string(o))
+	case *compute.RoutesListCall:
+		return c.Filter(string(o))
+	case *compute.RoutersListCall:
+		return c.Filter(string(o))
+	case *compute.InstanceTemplatesListCall:
+		return c.Filter(string(o))
+	case *compute.InstanceGroupManagersListCall:
+		return c.Filter(string(o))
 	case *compute.InstancesAggregatedListCall:
 		return c.Filter(string(o))
 	case *compute.DisksAggregatedListCall:
@@ -217,12 +293,17 @@ type client struct {
 	raw      *compute.Service
 	rawBeta  *computeBeta.Service
 	rawAlpha *computeAlpha.Service
+	limiter  *Limiter
+	// ctx is the context API calls are made with, see WithContext.
+	ctx context.Context
 }
 
 // shouldRetryWithWait returns true if the HTTP response / error indicates
 // that the request should be attempted again.
-func shouldRetryWithWait(tripper http.RoundTripper, err error, multiplier int) bool {
-	if err == nil {
+// It waits before returning true, and returns false without waiting once ctx
+// is done.
+func shouldRetryWithWait(ctx context.Context, tripper http.RoundTripper, err error, multiplier int) bool {
+	if err == nil || ctx.Err() != nil {
 		return false
 	}
 	tkValid := true
@@ -263,12 +344,34 @@ func shouldRetryWithWait(tripper http.RoundTripper, err error, multiplier int) b
 	}
 
 	sleep := (time.Duration(rand.Intn(1000))*time.Millisecond + 1*time.Second) * time.Duration(multiplier)
-	time.Sleep(sleep)
-	return true
+	return sleepCtx(ctx, sleep)
+}
+
+// sleepCtx waits for d and returns true, or returns false as soon as ctx is
+// done.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 // NewClient creates a new Google Cloud Compute client.
 func NewClient(ctx context.Context, opts ...option.ClientOption) (Client, error) {
+	return newClient(ctx, nil, opts...)
+}
+
+// NewLimitedClient creates a new Google Cloud Compute client whose API
+// requests and operations are limited by l. l may be shared between clients.
+func NewLimitedClient(ctx context.Context, l *Limiter, opts ...option.ClientOption) (Client, error) {
+	return newClient(ctx, l, opts...)
+}
+
+func newClient(ctx context.Context, l *Limiter, opts ...option.ClientOption) (Client, error) {
 	// Set these scopes to be align with compute.NewService
 	o := []option.ClientOption{
 		option.WithScopes(
@@ -285,21 +388,31 @@ func NewClient(ctx context.Context, opts ...option.ClientOption) (Client, error)
 	if err != nil {
 		return nil, fmt.Errorf("error creating HTTP API client: %v", err)
 	}
-	rawService, err := compute.New(hc)
+	// The services get their own HTTP client so that hc.Transport stays the
+	// oauth2 transport shouldRetryWithWait inspects.
+	shc := hc
+	if l != nil {
+		base := hc.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		shc = &http.Client{Transport: &limitedTransport{base: base, limiter: l}}
+	}
+	rawService, err := compute.New(shc)
 	if err != nil {
 		return nil, fmt.Errorf("compute client: %v", err)
 	}
 	if ep != "" {
 		rawService.BasePath = ep
 	}
-	rawBetaService, err := computeBeta.New(hc)
+	rawBetaService, err := computeBeta.New(shc)
 	if err != nil {
 		return nil, fmt.Errorf("beta compute client: %v", err)
 	}
 	if ep != "" {
 		rawBetaService.BasePath = ep
 	}
-	rawAlphaService, err := computeAlpha.New(hc)
+	rawAlphaService, err := computeAlpha.New(shc)
 	if err != nil {
 		return nil, fmt.Errorf("alpha compute client: %v", err)
 	}
@@ -307,7 +420,7 @@ func NewClient(ctx context.Context, opts ...option.ClientOption) (Client, error)
 		rawAlphaService.BasePath = ep
 	}
 
-	c := &client{hc: hc, raw: rawService, rawBeta: rawBetaService, rawAlpha: rawAlphaService}
+	c := &client{hc: hc, raw: rawService, rawBeta: rawBetaService, rawAlpha: rawAlphaService, limiter: l}
 	c.i = c
 
 	return c, nil
@@ -318,11 +431,26 @@ func (c *client) BasePath() string {
 	return c.raw.BasePath
 }
 
+// WithContext returns a copy of this client that makes its calls with ctx.
+func (c *client) WithContext(ctx context.Context) Client {
+	nc := *c
+	nc.ctx = ctx
+	nc.i = &nc
+	return &nc
+}
+
+func (c *client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 type operationGetterFunc func() (*compute.Operation, error)
 
 func (c *client) zoneOperationsWait(project, zone, name string) error {
 	return c.operationsWaitHelper(project, name, func() (op *compute.Operation, err error) {
-		op, err = c.Retry(c.raw.ZoneOperations.Wait(project, zone, name).Do)
+		op, err = c.Retry(c.raw.ZoneOperations.Wait(project, zone, name).Context(c.context()).Do)
 		if err != nil {
 			err = fmt.Errorf("failed to get zone operation %s: %v", name, err)
 		}
@@ -332,7 +460,7 @@ func (c *client) zoneOperationsWait(project, zone, name string) error {
 
 func (c *client) regionOperationsWait(project, region, name string) error {
 	return c.operationsWaitHelper(project, name, func() (op *compute.Operation, err error) {
-		op, err = c.Retry(c.raw.RegionOperations.Wait(project, region, name).Do)
+		op, err = c.Retry(c.raw.RegionOperations.Wait(project, region, name).Context(c.context()).Do)
 		if err != nil {
 			err = fmt.Errorf("failed to get region operation %s: %v", name, err)
 		}
@@ -342,7 +470,7 @@ func (c *client) regionOperationsWait(project, region, name string) error {
 
 func (c *client) globalOperationsWait(project, name string) error {
 	return c.operationsWaitHelper(project, name, func() (op *compute.Operation, err error) {
-		op, err = c.Retry(c.raw.GlobalOperations.Wait(project, name).Do)
+		op, err = c.Retry(c.raw.GlobalOperations.Wait(project, name).Context(c.context()).Do)
 		if err != nil {
 			err = fmt.Errorf("failed to get global operation %s: %v", name, err)
 		}
@@ -364,7 +492,9 @@ func (c *client) operationsWaitHelper(project, name string, getOperation operati
 
 		switch op.Status {
 		case "PENDING", "RUNNING":
-			time.Sleep(1 * time.Second)
+			if !sleepCtx(c.context(), 1*time.Second) {
+				return fmt.Errorf("stopped waiting for operation %s: %v", name, c.context().Err())
+			}
 			continue
 		case "DONE":
 			if op.Error != nil {
@@ -392,7 +522,7 @@ func (c *client) Retry(f func(opts ...googleapi.CallOption) (*compute.Operation,
 		if err == nil {
 			return op, nil
 		}
-		if !shouldRetryWithWait(c.hc.Transport, err, i) {
+		if !shouldRetryWithWait(c.context(), c.hc.Transport, err, i) {
 			return nil, err
 		}
 	}
@@ -408,7 +538,7 @@ func (c *client) RetryBeta(f func(opts ...googleapi.CallOption) (*computeBeta.Op
 		if err == nil {
 			return op, nil
 		}
-		if !shouldRetryWithWait(c.hc.Transport, err, i) {
+		if !shouldRetryWithWait(c.context(), c.hc.Transport, err, i) {
 			return nil, err
 		}
 	}
@@ -424,7 +554,7 @@ func (c *client) RetryAlpha(f func(opts ...googleapi.CallOption) (*computeAlpha.
 		if err == nil {
 			return op, nil
 		}
-		if !shouldRetryWithWait(c.hc.Transport, err, i) {
+		if !shouldRetryWithWait(c.context(), c.hc.Transport, err, i) {
 			return nil, err
 		}
 	}
@@ -433,7 +563,12 @@ func (c *client) RetryAlpha(f func(opts ...googleapi.CallOption) (*computeAlpha.
 
 // AttachDisk attaches a GCE persistent disk to an instance.
 func (c *client) AttachDisk(project, zone, instance string, d *compute.AttachedDisk) error {
-	op, err := c.Retry(c.raw.Instances.AttachDisk(project, zone, instance, d).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.AttachDisk(project, zone, instance, d).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -443,7 +578,12 @@ func (c *client) AttachDisk(project, zone, instance string, d *compute.AttachedD
 
 // DetachDisk detaches a GCE persistent disk to an instance.
 func (c *client) DetachDisk(project, zone, instance, disk string) error {
-	op, err := c.Retry(c.raw.Instances.DetachDisk(project, zone, instance, disk).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.DetachDisk(project, zone, instance, disk).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -453,7 +593,12 @@ func (c *client) DetachDisk(project, zone, instance, disk string) error {
 
 // CreateDisk creates a GCE persistent disk.
 func (c *client) CreateDisk(project, zone string, d *compute.Disk) error {
-	op, err := c.Retry(c.raw.Disks.Insert(project, zone, d).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Disks.Insert(project, zone, d).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -472,7 +617,12 @@ func (c *client) CreateDisk(project, zone string, d *compute.Disk) error {
 
 // CreateDiskAlpha creates a GCE persistent disk.
 func (c *client) CreateDiskAlpha(project, zone string, d *computeAlpha.Disk) error {
-	op, err := c.RetryAlpha(c.rawAlpha.Disks.Insert(project, zone, d).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryAlpha(c.rawAlpha.Disks.Insert(project, zone, d).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -491,7 +641,12 @@ func (c *client) CreateDiskAlpha(project, zone string, d *computeAlpha.Disk) err
 
 // CreateDiskBeta creates a GCE persistent disk.
 func (c *client) CreateDiskBeta(project, zone string, d *computeBeta.Disk) error {
-	op, err := c.RetryBeta(c.rawBeta.Disks.Insert(project, zone, d).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryBeta(c.rawBeta.Disks.Insert(project, zone, d).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -510,7 +665,12 @@ func (c *client) CreateDiskBeta(project, zone string, d *computeBeta.Disk) error
 
 // CreateForwardingRule creates a GCE forwarding rule.
 func (c *client) CreateForwardingRule(project, region string, fr *compute.ForwardingRule) error {
-	op, err := c.Retry(c.raw.ForwardingRules.Insert(project, region, fr).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.ForwardingRules.Insert(project, region, fr).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -528,7 +688,12 @@ func (c *client) CreateForwardingRule(project, region string, fr *compute.Forwar
 }
 
 func (c *client) CreateFirewallRule(project string, i *compute.Firewall) error {
-	op, err := c.Retry(c.raw.Firewalls.Insert(project, i).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Firewalls.Insert(project, i).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -545,12 +710,137 @@ func (c *client) CreateFirewallRule(project string, i *compute.Firewall) error {
 	return nil
 }
 
+// CreateAddress creates a GCE address.
+func (c *client) CreateAddress(project, region string, a *compute.Address) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Addresses.Insert(project, region, a).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	if err := c.i.regionOperationsWait(project, region, op.Name); err != nil {
+		return err
+	}
+
+	var createdAddress *compute.Address
+	if createdAddress, err = c.i.GetAddress(project, region, a.Name); err != nil {
+		return err
+	}
+	*a = *createdAddress
+	return nil
+}
+
+// CreateRoute creates a GCE route.
+func (c *client) CreateRoute(project string, r *compute.Route) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Routes.Insert(project, r).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	if err := c.i.globalOperationsWait(project, op.Name); err != nil {
+		return err
+	}
+
+	var createdRoute *compute.Route
+	if createdRoute, err = c.i.GetRoute(project, r.Name); err != nil {
+		return err
+	}
+	*r = *createdRoute
+	return nil
+}
+
+// CreateRouter creates a GCE router.
+func (c *client) CreateRouter(project, region string, r *compute.Router) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Routers.Insert(project, region, r).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	if err := c.i.regionOperationsWait(project, region, op.Name); err != nil {
+		return err
+	}
+
+	var createdRouter *compute.Router
+	if createdRouter, err = c.i.GetRouter(project, region, r.Name); err != nil {
+		return err
+	}
+	*r = *createdRouter
+	return nil
+}
+
+// CreateInstanceTemplate creates a GCE instance template.
+func (c *client) CreateInstanceTemplate(project string, t *compute.InstanceTemplate) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.InstanceTemplates.Insert(project, t).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	if err := c.i.globalOperationsWait(project, op.Name); err != nil {
+		return err
+	}
+
+	var createdTemplate *compute.InstanceTemplate
+	if createdTemplate, err = c.i.GetInstanceTemplate(project, t.Name); err != nil {
+		return err
+	}
+	*t = *createdTemplate
+	return nil
+}
+
+// CreateInstanceGroupManager creates a GCE managed instance group.
+func (c *client) CreateInstanceGroupManager(project, zone string, m *compute.InstanceGroupManager) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.InstanceGroupManagers.Insert(project, zone, m).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	if err := c.i.zoneOperationsWait(project, zone, op.Name); err != nil {
+		return err
+	}
+
+	var createdManager *compute.InstanceGroupManager
+	if createdManager, err = c.i.GetInstanceGroupManager(project, zone, m.Name); err != nil {
+		return err
+	}
+	*m = *createdManager
+	return nil
+}
+
 // CreateImage creates a GCE image.
 // Only one of sourceDisk or sourceFile must be specified, sourceDisk is the
 // url (full or partial) to the source disk, sourceFile is the full Google
 // Cloud Storage URL where the disk image is stored.
 func (c *client) CreateImage(project string, i *compute.Image) error {
-	op, err := c.Retry(c.raw.Images.Insert(project, i).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Images.Insert(project, i).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -572,7 +862,12 @@ func (c *client) CreateImage(project string, i *compute.Image) error {
 // url (full or partial) to the source disk, sourceFile is the full Google
 // Cloud Storage URL where the disk image is stored.
 func (c *client) CreateImageBeta(project string, i *computeBeta.Image) error {
-	op, err := c.RetryBeta(c.rawBeta.Images.Insert(project, i).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryBeta(c.rawBeta.Images.Insert(project, i).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -594,7 +889,12 @@ func (c *client) CreateImageBeta(project string, i *computeBeta.Image) error {
 // url (full or partial) to the source disk, sourceFile is the full Google
 // Cloud Storage URL where the disk image is stored.
 func (c *client) CreateImageAlpha(project string, i *computeAlpha.Image) error {
-	op, err := c.RetryAlpha(c.rawAlpha.Images.Insert(project, i).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryAlpha(c.rawAlpha.Images.Insert(project, i).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -612,7 +912,12 @@ func (c *client) CreateImageAlpha(project string, i *computeAlpha.Image) error {
 }
 
 func (c *client) CreateInstance(project, zone string, i *compute.Instance) error {
-	op, err := c.Retry(c.raw.Instances.Insert(project, zone, i).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.Insert(project, zone, i).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -631,7 +936,12 @@ func (c *client) CreateInstance(project, zone string, i *compute.Instance) error
 
 // CreateInstanceAlpha creates a GCE image using Alpha API.
 func (c *client) CreateInstanceAlpha(project, zone string, i *computeAlpha.Instance) error {
-	op, err := c.RetryAlpha(c.rawAlpha.Instances.Insert(project, zone, i).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryAlpha(c.rawAlpha.Instances.Insert(project, zone, i).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -650,7 +960,12 @@ func (c *client) CreateInstanceAlpha(project, zone string, i *computeAlpha.Insta
 
 // CreateInstanceBeta creates a GCE image using Beta API.
 func (c *client) CreateInstanceBeta(project, zone string, i *computeBeta.Instance) error {
-	op, err := c.RetryBeta(c.rawBeta.Instances.Insert(project, zone, i).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryBeta(c.rawBeta.Instances.Insert(project, zone, i).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -668,7 +983,12 @@ func (c *client) CreateInstanceBeta(project, zone string, i *computeBeta.Instanc
 }
 
 func (c *client) CreateNetwork(project string, n *compute.Network) error {
-	op, err := c.Retry(c.raw.Networks.Insert(project, n).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Networks.Insert(project, n).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -686,7 +1006,12 @@ func (c *client) CreateNetwork(project string, n *compute.Network) error {
 }
 
 func (c *client) CreateSubnetwork(project, region string, n *compute.Subnetwork) error {
-	op, err := c.Retry(c.raw.Subnetworks.Insert(project, region, n).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Subnetworks.Insert(project, region, n).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -706,7 +1031,12 @@ func (c *client) CreateSubnetwork(project, region string, n *compute.Subnetwork)
 // CreateTargetInstance creates a GCE Target Instance, which can be used as
 // target on ForwardingRule
 func (c *client) CreateTargetInstance(project, zone string, ti *compute.TargetInstance) error {
-	op, err := c.Retry(c.raw.TargetInstances.Insert(project, zone, ti).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.TargetInstances.Insert(project, zone, ti).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -725,7 +1055,12 @@ func (c *client) CreateTargetInstance(project, zone string, ti *compute.TargetIn
 
 // DeleteFirewallRule deletes a GCE FirewallRule.
 func (c *client) DeleteFirewallRule(project, name string) error {
-	op, err := c.Retry(c.raw.Firewalls.Delete(project, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Firewalls.Delete(project, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -735,7 +1070,12 @@ func (c *client) DeleteFirewallRule(project, name string) error {
 
 // DeleteImage deletes a GCE image.
 func (c *client) DeleteImage(project, name string) error {
-	op, err := c.Retry(c.raw.Images.Delete(project, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Images.Delete(project, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -745,7 +1085,12 @@ func (c *client) DeleteImage(project, name string) error {
 
 // DeleteDisk deletes a GCE persistent disk.
 func (c *client) DeleteDisk(project, zone, name string) error {
-	op, err := c.Retry(c.raw.Disks.Delete(project, zone, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Disks.Delete(project, zone, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -755,7 +1100,12 @@ func (c *client) DeleteDisk(project, zone, name string) error {
 
 // SetDiskAutoDelete set auto-delete of an attached disk
 func (c *client) SetDiskAutoDelete(project, zone, instance string, autoDelete bool, deviceName string) error {
-	op, err := c.Retry(c.raw.Instances.SetDiskAutoDelete(project, zone, instance, autoDelete, deviceName).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.SetDiskAutoDelete(project, zone, instance, autoDelete, deviceName).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -765,7 +1115,12 @@ func (c *client) SetDiskAutoDelete(project, zone, instance string, autoDelete bo
 
 // DeleteForwardingRule deletes a GCE ForwardingRule.
 func (c *client) DeleteForwardingRule(project, region, name string) error {
-	op, err := c.Retry(c.raw.ForwardingRules.Delete(project, region, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.ForwardingRules.Delete(project, region, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -773,9 +1128,90 @@ func (c *client) DeleteForwardingRule(project, region, name string) error {
 	return c.i.regionOperationsWait(project, region, op.Name)
 }
 
+// DeleteAddress deletes a GCE Address.
+func (c *client) DeleteAddress(project, region, name string) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Addresses.Delete(project, region, name).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
+// DeleteRoute deletes a GCE Route.
+func (c *client) DeleteRoute(project, name string) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Routes.Delete(project, name).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.globalOperationsWait(project, op.Name)
+}
+
+// DeleteRouter deletes a GCE Router.
+func (c *client) DeleteRouter(project, region, name string) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Routers.Delete(project, region, name).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
+// DeleteInstanceTemplate deletes a GCE instance template.
+func (c *client) DeleteInstanceTemplate(project, name string) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.InstanceTemplates.Delete(project, name).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.globalOperationsWait(project, op.Name)
+}
+
+// DeleteInstanceGroupManager deletes a GCE managed instance group, including
+// the instances it manages.
+func (c *client) DeleteInstanceGroupManager(project, zone, name string) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.InstanceGroupManagers.Delete(project, zone, name).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
 // DeleteInstance deletes a GCE instance.
 func (c *client) DeleteInstance(project, zone, name string) error {
-	op, err := c.Retry(c.raw.Instances.Delete(project, zone, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.Delete(project, zone, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -785,7 +1221,12 @@ func (c *client) DeleteInstance(project, zone, name string) error {
 
 // StartInstance starts a GCE instance.
 func (c *client) StartInstance(project, zone, name string) error {
-	op, err := c.Retry(c.raw.Instances.Start(project, zone, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.Start(project, zone, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -795,7 +1236,43 @@ func (c *client) StartInstance(project, zone, name string) error {
 
 // StopInstance stops a GCE instance.
 func (c *client) StopInstance(project, zone, name string) error {
-	op, err := c.Retry(c.raw.Instances.Stop(project, zone, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.Stop(project, zone, name).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// SuspendInstance suspends a GCE instance, preserving its memory and device
+// state.
+func (c *client) SuspendInstance(project, zone, name string) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryBeta(c.rawBeta.Instances.Suspend(project, zone, name).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// ResumeInstance resumes a suspended GCE instance.
+func (c *client) ResumeInstance(project, zone, name string) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryBeta(c.rawBeta.Instances.Resume(project, zone, name, &computeBeta.InstancesResumeRequest{}).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -805,7 +1282,12 @@ func (c *client) StopInstance(project, zone, name string) error {
 
 // DeleteNetwork deletes a GCE network.
 func (c *client) DeleteNetwork(project, name string) error {
-	op, err := c.Retry(c.raw.Networks.Delete(project, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Networks.Delete(project, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -815,7 +1297,12 @@ func (c *client) DeleteNetwork(project, name string) error {
 
 // DeleteSubnetwork deletes a GCE subnetwork.
 func (c *client) DeleteSubnetwork(project, region, name string) error {
-	op, err := c.Retry(c.raw.Subnetworks.Delete(project, region, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Subnetworks.Delete(project, region, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -825,7 +1312,12 @@ func (c *client) DeleteSubnetwork(project, region, name string) error {
 
 // DeleteTargetInstance deletes a GCE TargetInstance.
 func (c *client) DeleteTargetInstance(project, zone, name string) error {
-	op, err := c.Retry(c.raw.TargetInstances.Delete(project, zone, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.TargetInstances.Delete(project, zone, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -835,7 +1327,12 @@ func (c *client) DeleteTargetInstance(project, zone, name string) error {
 
 // DeprecateImage sets deprecation status on a GCE image.
 func (c *client) DeprecateImage(project, name string, deprecationstatus *compute.DeprecationStatus) error {
-	op, err := c.Retry(c.raw.Images.Deprecate(project, name, deprecationstatus).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Images.Deprecate(project, name, deprecationstatus).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -845,7 +1342,12 @@ func (c *client) DeprecateImage(project, name string, deprecationstatus *compute
 
 // DeprecateImageAlpha sets deprecation status on a GCE image using the Alpha API.
 func (c *client) DeprecateImageAlpha(project, name string, deprecationstatus *computeAlpha.DeprecationStatus) error {
-	op, err := c.RetryAlpha(c.rawAlpha.Images.Deprecate(project, name, deprecationstatus).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryAlpha(c.rawAlpha.Images.Deprecate(project, name, deprecationstatus).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -854,9 +1356,9 @@ func (c *client) DeprecateImageAlpha(project, name string, deprecationstatus *co
 
 // GetMachineType gets a GCE MachineType.
 func (c *client) GetMachineType(project, zone, machineType string) (*compute.MachineType, error) {
-	mt, err := c.raw.MachineTypes.Get(project, zone, machineType).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.MachineTypes.Get(project, zone, machineType).Do()
+	mt, err := c.raw.MachineTypes.Get(project, zone, machineType).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.MachineTypes.Get(project, zone, machineType).Context(c.context()).Do()
 	}
 	return mt, err
 }
@@ -865,12 +1367,12 @@ func (c *client) GetMachineType(project, zone, machineType string) (*compute.Mac
 func (c *client) ListMachineTypes(project, zone string, opts ...ListCallOption) ([]*compute.MachineType, error) {
 	var mts []*compute.MachineType
 	var pt string
-	call := c.raw.MachineTypes.List(project, zone)
+	call := c.raw.MachineTypes.List(project, zone).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.MachineTypesListCall)
 	}
 	for mtl, err := call.PageToken(pt).Do(); ; mtl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			mtl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -887,27 +1389,27 @@ func (c *client) ListMachineTypes(project, zone string, opts ...ListCallOption)
 
 // GetProject gets a GCE Project.
 func (c *client) GetProject(project string) (*compute.Project, error) {
-	p, err := c.raw.Projects.Get(project).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Projects.Get(project).Do()
+	p, err := c.raw.Projects.Get(project).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Projects.Get(project).Context(c.context()).Do()
 	}
 	return p, err
 }
 
 // GetSerialPortOutput gets the serial port output of a GCE instance.
 func (c *client) GetSerialPortOutput(project, zone, name string, port, start int64) (*compute.SerialPortOutput, error) {
-	sp, err := c.raw.Instances.GetSerialPortOutput(project, zone, name).Start(start).Port(port).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Instances.GetSerialPortOutput(project, zone, name).Start(start).Port(port).Do()
+	sp, err := c.raw.Instances.GetSerialPortOutput(project, zone, name).Context(c.context()).Start(start).Port(port).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Instances.GetSerialPortOutput(project, zone, name).Context(c.context()).Start(start).Port(port).Do()
 	}
 	return sp, err
 }
 
 // GetZone gets a GCE Zone.
 func (c *client) GetZone(project, zone string) (*compute.Zone, error) {
-	z, err := c.raw.Zones.Get(project, zone).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Zones.Get(project, zone).Do()
+	z, err := c.raw.Zones.Get(project, zone).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Zones.Get(project, zone).Context(c.context()).Do()
 	}
 	return z, err
 }
@@ -916,12 +1418,12 @@ func (c *client) GetZone(project, zone string) (*compute.Zone, error) {
 func (c *client) ListZones(project string, opts ...ListCallOption) ([]*compute.Zone, error) {
 	var zs []*compute.Zone
 	var pt string
-	call := c.raw.Zones.List(project)
+	call := c.raw.Zones.List(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.ZonesListCall)
 	}
 	for zl, err := call.PageToken(pt).Do(); ; zl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			zl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -940,12 +1442,12 @@ func (c *client) ListZones(project string, opts ...ListCallOption) ([]*compute.Z
 func (c *client) ListRegions(project string, opts ...ListCallOption) ([]*compute.Region, error) {
 	var rs []*compute.Region
 	var pt string
-	call := c.raw.Regions.List(project)
+	call := c.raw.Regions.List(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.RegionsListCall)
 	}
 	for rl, err := call.PageToken(pt).Do(); ; rl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			rl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -962,27 +1464,27 @@ func (c *client) ListRegions(project string, opts ...ListCallOption) ([]*compute
 
 // GetInstance gets a GCE Instance using GA API.
 func (c *client) GetInstance(project, zone, name string) (*compute.Instance, error) {
-	i, err := c.raw.Instances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Instances.Get(project, zone, name).Do()
+	i, err := c.raw.Instances.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Instances.Get(project, zone, name).Context(c.context()).Do()
 	}
 	return i, err
 }
 
 // GetInstance gets a GCE Instance using Alpha API.
 func (c *client) GetInstanceAlpha(project, zone, name string) (*computeAlpha.Instance, error) {
-	i, err := c.rawAlpha.Instances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawAlpha.Instances.Get(project, zone, name).Do()
+	i, err := c.rawAlpha.Instances.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.rawAlpha.Instances.Get(project, zone, name).Context(c.context()).Do()
 	}
 	return i, err
 }
 
 // GetInstance gets a GCE Instance using Beta API.
 func (c *client) GetInstanceBeta(project, zone, name string) (*computeBeta.Instance, error) {
-	i, err := c.rawBeta.Instances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawBeta.Instances.Get(project, zone, name).Do()
+	i, err := c.rawBeta.Instances.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.rawBeta.Instances.Get(project, zone, name).Context(c.context()).Do()
 	}
 	return i, err
 }
@@ -991,12 +1493,12 @@ func (c *client) GetInstanceBeta(project, zone, name string) (*computeBeta.Insta
 func (c *client) AggregatedListInstances(project string, opts ...ListCallOption) ([]*compute.Instance, error) {
 	var is []*compute.Instance
 	var pt string
-	call := c.raw.Instances.AggregatedList(project)
+	call := c.raw.Instances.AggregatedList(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.InstancesAggregatedListCall)
 	}
 	for ial, err := call.PageToken(pt).Do(); ; ial, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			ial, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1016,12 +1518,12 @@ func (c *client) AggregatedListInstances(project string, opts ...ListCallOption)
 func (c *client) ListInstances(project, zone string, opts ...ListCallOption) ([]*compute.Instance, error) {
 	var is []*compute.Instance
 	var pt string
-	call := c.raw.Instances.List(project, zone)
+	call := c.raw.Instances.List(project, zone).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.InstancesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1038,27 +1540,27 @@ func (c *client) ListInstances(project, zone string, opts ...ListCallOption) ([]
 
 // GetDisk gets a GCE Disk.
 func (c *client) GetDisk(project, zone, name string) (*compute.Disk, error) {
-	d, err := c.raw.Disks.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Disks.Get(project, zone, name).Do()
+	d, err := c.raw.Disks.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Disks.Get(project, zone, name).Context(c.context()).Do()
 	}
 	return d, err
 }
 
 // GetDiskAlpha gets a GCE Disk.
 func (c *client) GetDiskAlpha(project, zone, name string) (*computeAlpha.Disk, error) {
-	d, err := c.rawAlpha.Disks.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawAlpha.Disks.Get(project, zone, name).Do()
+	d, err := c.rawAlpha.Disks.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.rawAlpha.Disks.Get(project, zone, name).Context(c.context()).Do()
 	}
 	return d, err
 }
 
 // GetDiskBeta gets a GCE Disk.
 func (c *client) GetDiskBeta(project, zone, name string) (*computeBeta.Disk, error) {
-	d, err := c.rawBeta.Disks.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawBeta.Disks.Get(project, zone, name).Do()
+	d, err := c.rawBeta.Disks.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.rawBeta.Disks.Get(project, zone, name).Context(c.context()).Do()
 	}
 	return d, err
 }
@@ -1067,12 +1569,12 @@ func (c *client) GetDiskBeta(project, zone, name string) (*computeBeta.Disk, err
 func (c *client) AggregatedListDisks(project string, opts ...ListCallOption) ([]*compute.Disk, error) {
 	var is []*compute.Disk
 	var pt string
-	call := c.raw.Disks.AggregatedList(project)
+	call := c.raw.Disks.AggregatedList(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.DisksAggregatedListCall)
 	}
 	for ial, err := call.PageToken(pt).Do(); ; ial, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			ial, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1092,12 +1594,12 @@ func (c *client) AggregatedListDisks(project string, opts ...ListCallOption) ([]
 func (c *client) ListDisks(project, zone string, opts ...ListCallOption) ([]*compute.Disk, error) {
 	var ds []*compute.Disk
 	var pt string
-	call := c.raw.Disks.List(project, zone)
+	call := c.raw.Disks.List(project, zone).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.DisksListCall)
 	}
 	for dl, err := call.PageToken(pt).Do(); ; dl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			dl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1114,9 +1616,9 @@ func (c *client) ListDisks(project, zone string, opts ...ListCallOption) ([]*com
 
 // GetForwardingRule gets a GCE ForwardingRule.
 func (c *client) GetForwardingRule(project, region, name string) (*compute.ForwardingRule, error) {
-	n, err := c.raw.ForwardingRules.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.ForwardingRules.Get(project, region, name).Do()
+	n, err := c.raw.ForwardingRules.Get(project, region, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.ForwardingRules.Get(project, region, name).Context(c.context()).Do()
 	}
 	return n, err
 }
@@ -1125,12 +1627,12 @@ func (c *client) GetForwardingRule(project, region, name string) (*compute.Forwa
 func (c *client) ListForwardingRules(project, region string, opts ...ListCallOption) ([]*compute.ForwardingRule, error) {
 	var frs []*compute.ForwardingRule
 	var pt string
-	call := c.raw.ForwardingRules.List(project, region)
+	call := c.raw.ForwardingRules.List(project, region).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.ForwardingRulesListCall)
 	}
 	for frl, err := call.PageToken(pt).Do(); ; frl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			frl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1145,11 +1647,192 @@ func (c *client) ListForwardingRules(project, region string, opts ...ListCallOpt
 	}
 }
 
+// GetAddress gets a GCE Address.
+func (c *client) GetAddress(project, region, name string) (*compute.Address, error) {
+	n, err := c.raw.Addresses.Get(project, region, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Addresses.Get(project, region, name).Context(c.context()).Do()
+	}
+	return n, err
+}
+
+// GetRoute gets a GCE Route.
+func (c *client) GetRoute(project, name string) (*compute.Route, error) {
+	n, err := c.raw.Routes.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Routes.Get(project, name).Context(c.context()).Do()
+	}
+	return n, err
+}
+
+// GetRouter gets a GCE Router.
+func (c *client) GetRouter(project, region, name string) (*compute.Router, error) {
+	n, err := c.raw.Routers.Get(project, region, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Routers.Get(project, region, name).Context(c.context()).Do()
+	}
+	return n, err
+}
+
+// ListAddresses gets a list of GCE Addresses.
+func (c *client) ListAddresses(project, region string, opts ...ListCallOption) ([]*compute.Address, error) {
+	var items []*compute.Address
+	var pt string
+	call := c.raw.Addresses.List(project, region).Context(c.context())
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.AddressesListCall)
+	}
+	for l, err := call.PageToken(pt).Do(); ; l, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+			l, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, l.Items...)
+
+		if l.NextPageToken == "" {
+			return items, nil
+		}
+		pt = l.NextPageToken
+	}
+}
+
+// ListRoutes gets a list of GCE Routes.
+func (c *client) ListRoutes(project string, opts ...ListCallOption) ([]*compute.Route, error) {
+	var items []*compute.Route
+	var pt string
+	call := c.raw.Routes.List(project).Context(c.context())
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.RoutesListCall)
+	}
+	for l, err := call.PageToken(pt).Do(); ; l, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+			l, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, l.Items...)
+
+		if l.NextPageToken == "" {
+			return items, nil
+		}
+		pt = l.NextPageToken
+	}
+}
+
+// ListRouters gets a list of GCE Routers.
+func (c *client) ListRouters(project, region string, opts ...ListCallOption) ([]*compute.Router, error) {
+	var items []*compute.Router
+	var pt string
+	call := c.raw.Routers.List(project, region).Context(c.context())
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.RoutersListCall)
+	}
+	for l, err := call.PageToken(pt).Do(); ; l, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+			l, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, l.Items...)
+
+		if l.NextPageToken == "" {
+			return items, nil
+		}
+		pt = l.NextPageToken
+	}
+}
+
+// GetInstanceTemplate gets a GCE InstanceTemplate.
+func (c *client) GetInstanceTemplate(project, name string) (*compute.InstanceTemplate, error) {
+	t, err := c.raw.InstanceTemplates.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.InstanceTemplates.Get(project, name).Context(c.context()).Do()
+	}
+	return t, err
+}
+
+// GetInstanceGroupManager gets a GCE InstanceGroupManager.
+func (c *client) GetInstanceGroupManager(project, zone, name string) (*compute.InstanceGroupManager, error) {
+	m, err := c.raw.InstanceGroupManagers.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.InstanceGroupManagers.Get(project, zone, name).Context(c.context()).Do()
+	}
+	return m, err
+}
+
+// ListInstanceTemplates gets a list of GCE InstanceTemplates.
+func (c *client) ListInstanceTemplates(project string, opts ...ListCallOption) ([]*compute.InstanceTemplate, error) {
+	var items []*compute.InstanceTemplate
+	var pt string
+	call := c.raw.InstanceTemplates.List(project).Context(c.context())
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.InstanceTemplatesListCall)
+	}
+	for l, err := call.PageToken(pt).Do(); ; l, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+			l, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, l.Items...)
+
+		if l.NextPageToken == "" {
+			return items, nil
+		}
+		pt = l.NextPageToken
+	}
+}
+
+// ListInstanceGroupManagers gets a list of GCE InstanceGroupManagers.
+func (c *client) ListInstanceGroupManagers(project, zone string, opts ...ListCallOption) ([]*compute.InstanceGroupManager, error) {
+	var items []*compute.InstanceGroupManager
+	var pt string
+	call := c.raw.InstanceGroupManagers.List(project, zone).Context(c.context())
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.InstanceGroupManagersListCall)
+	}
+	for l, err := call.PageToken(pt).Do(); ; l, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+			l, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, l.Items...)
+
+		if l.NextPageToken == "" {
+			return items, nil
+		}
+		pt = l.NextPageToken
+	}
+}
+
+// PatchInstanceGroupManager patches a GCE managed instance group using the
+// fields set in m, e.g. to roll out a new instance template version.
+func (c *client) PatchInstanceGroupManager(project, zone, name string, m *compute.InstanceGroupManager) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.InstanceGroupManagers.Patch(project, zone, name, m).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
 // GetFirewallRule gets a GCE FirewallRule.
 func (c *client) GetFirewallRule(project, name string) (*compute.Firewall, error) {
-	i, err := c.raw.Firewalls.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Firewalls.Get(project, name).Do()
+	i, err := c.raw.Firewalls.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Firewalls.Get(project, name).Context(c.context()).Do()
 	}
 	return i, err
 }
@@ -1158,12 +1841,12 @@ func (c *client) GetFirewallRule(project, name string) (*compute.Firewall, error
 func (c *client) ListFirewallRules(project string, opts ...ListCallOption) ([]*compute.Firewall, error) {
 	var is []*compute.Firewall
 	var pt string
-	call := c.raw.Firewalls.List(project)
+	call := c.raw.Firewalls.List(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.FirewallsListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1180,36 +1863,36 @@ func (c *client) ListFirewallRules(project string, opts ...ListCallOption) ([]*c
 
 // GetImage gets a GCE Image.
 func (c *client) GetImage(project, name string) (*compute.Image, error) {
-	i, err := c.raw.Images.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Images.Get(project, name).Do()
+	i, err := c.raw.Images.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Images.Get(project, name).Context(c.context()).Do()
 	}
 	return i, err
 }
 
 // GetImageAlpha gets a GCE Image using Alpha API
 func (c *client) GetImageAlpha(project, name string) (*computeAlpha.Image, error) {
-	i, err := c.rawAlpha.Images.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawAlpha.Images.Get(project, name).Do()
+	i, err := c.rawAlpha.Images.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.rawAlpha.Images.Get(project, name).Context(c.context()).Do()
 	}
 	return i, err
 }
 
 // GetImageBeta gets a GCE Image using Beta API
 func (c *client) GetImageBeta(project, name string) (*computeBeta.Image, error) {
-	i, err := c.rawBeta.Images.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawBeta.Images.Get(project, name).Do()
+	i, err := c.rawBeta.Images.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.rawBeta.Images.Get(project, name).Context(c.context()).Do()
 	}
 	return i, err
 }
 
 // GetImageFromFamily gets a GCE Image from an image family.
 func (c *client) GetImageFromFamily(project, family string) (*compute.Image, error) {
-	i, err := c.raw.Images.GetFromFamily(project, family).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Images.GetFromFamily(project, family).Do()
+	i, err := c.raw.Images.GetFromFamily(project, family).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Images.GetFromFamily(project, family).Context(c.context()).Do()
 	}
 	return i, err
 }
@@ -1218,12 +1901,12 @@ func (c *client) GetImageFromFamily(project, family string) (*compute.Image, err
 func (c *client) ListImages(project string, opts ...ListCallOption) ([]*compute.Image, error) {
 	var is []*compute.Image
 	var pt string
-	call := c.raw.Images.List(project)
+	call := c.raw.Images.List(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.ImagesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1242,13 +1925,13 @@ func (c *client) ListImages(project string, opts ...ListCallOption) ([]*compute.
 func (c *client) ListImagesAlpha(project string, opts ...ListCallOption) ([]*computeAlpha.Image, error) {
 	var is []*computeAlpha.Image
 	var pt string
-	call := c.rawAlpha.Images.List(project)
+	call := c.rawAlpha.Images.List(project).Context(c.context())
 
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*computeAlpha.ImagesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1266,7 +1949,12 @@ func (c *client) ListImagesAlpha(project string, opts ...ListCallOption) ([]*com
 // CreateSnapshot creates a GCE snapshot.
 // SourceDisk is the url (full or partial) to the source disk.
 func (c *client) CreateSnapshot(project, zone, disk string, s *compute.Snapshot) error {
-	op, err := c.Retry(c.raw.Disks.CreateSnapshot(project, zone, disk, s).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Disks.CreateSnapshot(project, zone, disk, s).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -1285,16 +1973,21 @@ func (c *client) CreateSnapshot(project, zone, disk string, s *compute.Snapshot)
 
 // GetSnapshot gets a GCE Snapshot.
 func (c *client) GetSnapshot(project, name string) (*compute.Snapshot, error) {
-	n, err := c.raw.Snapshots.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Snapshots.Get(project, name).Do()
+	n, err := c.raw.Snapshots.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Snapshots.Get(project, name).Context(c.context()).Do()
 	}
 	return n, err
 }
 
 // DeleteSnapshot deletes a GCE Snapshot.
 func (c *client) DeleteSnapshot(project, name string) error {
-	op, err := c.Retry(c.raw.Snapshots.Delete(project, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Snapshots.Delete(project, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -1306,12 +1999,12 @@ func (c *client) DeleteSnapshot(project, name string) error {
 func (c *client) ListSnapshots(project string, opts ...ListCallOption) ([]*compute.Snapshot, error) {
 	var ss []*compute.Snapshot
 	var pt string
-	call := c.raw.Snapshots.List(project)
+	call := c.raw.Snapshots.List(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.SnapshotsListCall)
 	}
 	for sl, err := call.PageToken(pt).Do(); ; sl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			sl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1328,9 +2021,9 @@ func (c *client) ListSnapshots(project string, opts ...ListCallOption) ([]*compu
 
 // GetNetwork gets a GCE Network.
 func (c *client) GetNetwork(project, name string) (*compute.Network, error) {
-	n, err := c.raw.Networks.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Networks.Get(project, name).Do()
+	n, err := c.raw.Networks.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Networks.Get(project, name).Context(c.context()).Do()
 	}
 	return n, err
 }
@@ -1339,12 +2032,12 @@ func (c *client) GetNetwork(project, name string) (*compute.Network, error) {
 func (c *client) ListNetworks(project string, opts ...ListCallOption) ([]*compute.Network, error) {
 	var ns []*compute.Network
 	var pt string
-	call := c.raw.Networks.List(project)
+	call := c.raw.Networks.List(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.NetworksListCall)
 	}
 	for nl, err := call.PageToken(pt).Do(); ; nl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			nl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1361,9 +2054,9 @@ func (c *client) ListNetworks(project string, opts ...ListCallOption) ([]*comput
 
 // GetSubnetwork gets a GCE subnetwork.
 func (c *client) GetSubnetwork(project, region, name string) (*compute.Subnetwork, error) {
-	n, err := c.raw.Subnetworks.Get(project, region, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Subnetworks.Get(project, region, name).Do()
+	n, err := c.raw.Subnetworks.Get(project, region, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Subnetworks.Get(project, region, name).Context(c.context()).Do()
 	}
 	return n, err
 }
@@ -1372,12 +2065,12 @@ func (c *client) GetSubnetwork(project, region, name string) (*compute.Subnetwor
 func (c *client) AggregatedListSubnetworks(project string, opts ...ListCallOption) ([]*compute.Subnetwork, error) {
 	var ss []*compute.Subnetwork
 	var pt string
-	call := c.raw.Subnetworks.AggregatedList(project)
+	call := c.raw.Subnetworks.AggregatedList(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.SubnetworksAggregatedListCall)
 	}
 	for sal, err := call.PageToken(pt).Do(); ; sal, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			sal, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1397,12 +2090,12 @@ func (c *client) AggregatedListSubnetworks(project string, opts ...ListCallOptio
 func (c *client) ListSubnetworks(project, region string, opts ...ListCallOption) ([]*compute.Subnetwork, error) {
 	var ns []*compute.Subnetwork
 	var pt string
-	call := c.raw.Subnetworks.List(project, region)
+	call := c.raw.Subnetworks.List(project, region).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.SubnetworksListCall)
 	}
 	for nl, err := call.PageToken(pt).Do(); ; nl, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			nl, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1419,9 +2112,9 @@ func (c *client) ListSubnetworks(project, region string, opts ...ListCallOption)
 
 // GetTargetInstance gets a GCE TargetInstance.
 func (c *client) GetTargetInstance(project, zone, name string) (*compute.TargetInstance, error) {
-	n, err := c.raw.TargetInstances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.TargetInstances.Get(project, zone, name).Do()
+	n, err := c.raw.TargetInstances.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.TargetInstances.Get(project, zone, name).Context(c.context()).Do()
 	}
 	return n, err
 }
@@ -1430,12 +2123,12 @@ func (c *client) GetTargetInstance(project, zone, name string) (*compute.TargetI
 func (c *client) ListTargetInstances(project, zone string, opts ...ListCallOption) ([]*compute.TargetInstance, error) {
 	var tis []*compute.TargetInstance
 	var pt string
-	call := c.raw.TargetInstances.List(project, zone)
+	call := c.raw.TargetInstances.List(project, zone).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.TargetInstancesListCall)
 	}
 	for til, err := call.PageToken(pt).Do(); ; til, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			til, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1452,9 +2145,9 @@ func (c *client) ListTargetInstances(project, zone string, opts ...ListCallOptio
 
 // GetLicense gets a GCE License.
 func (c *client) GetLicense(project, name string) (*compute.License, error) {
-	l, err := c.raw.Licenses.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.raw.Licenses.Get(project, name).Do()
+	l, err := c.raw.Licenses.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.Licenses.Get(project, name).Context(c.context()).Do()
 	}
 	return l, err
 }
@@ -1463,12 +2156,12 @@ func (c *client) GetLicense(project, name string) (*compute.License, error) {
 func (c *client) ListLicenses(project string, opts ...ListCallOption) ([]*compute.License, error) {
 	var ls []*compute.License
 	var pt string
-	call := c.raw.Licenses.List(project)
+	call := c.raw.Licenses.List(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*compute.LicensesListCall)
 	}
 	for ll, err := call.PageToken(pt).Do(); ; ll, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			ll, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1485,9 +2178,9 @@ func (c *client) ListLicenses(project string, opts ...ListCallOption) ([]*comput
 
 // InstanceStatus returns an instances Status.
 func (c *client) InstanceStatus(project, zone, name string) (string, error) {
-	is, err := c.raw.Instances.Get(project, zone, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		is, err = c.raw.Instances.Get(project, zone, name).Do()
+	is, err := c.raw.Instances.Get(project, zone, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		is, err = c.raw.Instances.Get(project, zone, name).Context(c.context()).Do()
 	}
 
 	if err != nil {
@@ -1503,7 +2196,7 @@ func (c *client) InstanceStopped(project, zone, name string) (bool, error) {
 		return false, err
 	}
 	switch status {
-	case "PROVISIONING", "REPAIRING", "RUNNING", "STAGING", "STOPPING":
+	case "PROVISIONING", "REPAIRING", "RUNNING", "STAGING", "STOPPING", "SUSPENDING", "SUSPENDED":
 		return false, nil
 	case "TERMINATED", "STOPPED":
 		return true, nil
@@ -1512,9 +2205,30 @@ func (c *client) InstanceStopped(project, zone, name string) (bool, error) {
 	}
 }
 
+// InstanceSuspended checks if a GCE instance is in a 'SUSPENDED' state.
+func (c *client) InstanceSuspended(project, zone, name string) (bool, error) {
+	status, err := c.i.InstanceStatus(project, zone, name)
+	if err != nil {
+		return false, err
+	}
+	switch status {
+	case "PROVISIONING", "REPAIRING", "RUNNING", "STAGING", "STOPPING", "TERMINATED", "STOPPED", "SUSPENDING":
+		return false, nil
+	case "SUSPENDED":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unexpected instance status %q", status)
+	}
+}
+
 // ResizeDisk resizes a GCE persistent disk. You can only increase the size of the disk.
 func (c *client) ResizeDisk(project, zone, disk string, drr *compute.DisksResizeRequest) error {
-	op, err := c.Retry(c.raw.Disks.Resize(project, zone, disk, drr).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Disks.Resize(project, zone, disk, drr).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -1522,9 +2236,102 @@ func (c *client) ResizeDisk(project, zone, disk string, drr *compute.DisksResize
 	return c.i.zoneOperationsWait(project, zone, op.Name)
 }
 
+// CreateRegionDisk creates a GCE regional persistent disk.
+func (c *client) CreateRegionDisk(project, region string, d *compute.Disk) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.RegionDisks.Insert(project, region, d).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	if err := c.i.regionOperationsWait(project, region, op.Name); err != nil {
+		return err
+	}
+
+	var createdDisk *compute.Disk
+	if createdDisk, err = c.i.GetRegionDisk(project, region, d.Name); err != nil {
+		return err
+	}
+	*d = *createdDisk
+	return nil
+}
+
+// DeleteRegionDisk deletes a GCE regional persistent disk.
+func (c *client) DeleteRegionDisk(project, region, name string) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.RegionDisks.Delete(project, region, name).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
+// GetRegionDisk gets a GCE regional persistent disk.
+func (c *client) GetRegionDisk(project, region, name string) (*compute.Disk, error) {
+	d, err := c.raw.RegionDisks.Get(project, region, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.raw.RegionDisks.Get(project, region, name).Context(c.context()).Do()
+	}
+	return d, err
+}
+
+// ListRegionDisks gets a list of GCE regional persistent disks.
+func (c *client) ListRegionDisks(project, region string, opts ...ListCallOption) ([]*compute.Disk, error) {
+	var ds []*compute.Disk
+	var pt string
+	call := c.raw.RegionDisks.List(project, region).Context(c.context())
+	for _, opt := range opts {
+		call = opt.listCallOptionApply(call).(*compute.RegionDisksListCall)
+	}
+	for dl, err := call.PageToken(pt).Do(); ; dl, err = call.PageToken(pt).Do() {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+			dl, err = call.PageToken(pt).Do()
+		}
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, dl.Items...)
+
+		if dl.NextPageToken == "" {
+			return ds, nil
+		}
+		pt = dl.NextPageToken
+	}
+}
+
+// ResizeRegionDisk resizes a GCE regional persistent disk. You can only
+// increase the size of the disk.
+func (c *client) ResizeRegionDisk(project, region, disk string, drr *compute.RegionDisksResizeRequest) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.RegionDisks.Resize(project, region, disk, drr).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
 // SetInstanceMetadata sets an instances metadata.
 func (c *client) SetInstanceMetadata(project, zone, name string, md *compute.Metadata) error {
-	op, err := c.Retry(c.raw.Instances.SetMetadata(project, zone, name, md).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.SetMetadata(project, zone, name, md).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -1533,7 +2340,87 @@ func (c *client) SetInstanceMetadata(project, zone, name string, md *compute.Met
 
 // SetCommonInstanceMetadata sets an instances metadata.
 func (c *client) SetCommonInstanceMetadata(project string, md *compute.Metadata) error {
-	op, err := c.Retry(c.raw.Projects.SetCommonInstanceMetadata(project, md).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Projects.SetCommonInstanceMetadata(project, md).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.globalOperationsWait(project, op.Name)
+}
+
+// SetDiskLabels sets a GCE disk's labels.
+func (c *client) SetDiskLabels(project, zone, name string, req *compute.ZoneSetLabelsRequest) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Disks.SetLabels(project, zone, name, req).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// SetRegionDiskLabels sets a GCE regional disk's labels.
+func (c *client) SetRegionDiskLabels(project, region, name string, req *compute.RegionSetLabelsRequest) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.RegionDisks.SetLabels(project, region, name, req).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.regionOperationsWait(project, region, op.Name)
+}
+
+// SetImageLabels sets a GCE image's labels.
+func (c *client) SetImageLabels(project, name string, req *compute.GlobalSetLabelsRequest) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Images.SetLabels(project, name, req).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.globalOperationsWait(project, op.Name)
+}
+
+// SetInstanceLabels sets a GCE instance's labels.
+func (c *client) SetInstanceLabels(project, zone, name string, req *compute.InstancesSetLabelsRequest) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Instances.SetLabels(project, zone, name, req).Context(c.context()).Do)
+	if err != nil {
+		return err
+	}
+
+	return c.i.zoneOperationsWait(project, zone, op.Name)
+}
+
+// SetSnapshotLabels sets a GCE snapshot's labels.
+func (c *client) SetSnapshotLabels(project, name string, req *compute.GlobalSetLabelsRequest) error {
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.Retry(c.raw.Snapshots.SetLabels(project, name, req).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -1543,7 +2430,7 @@ func (c *client) SetCommonInstanceMetadata(project string, md *compute.Metadata)
 
 // GetGuestAttributes gets a Guest Attributes.
 func (c *client) GetGuestAttributes(project, zone, name, queryPath, variableKey string) (*computeBeta.GuestAttributes, error) {
-	call := c.rawBeta.Instances.GetGuestAttributes(project, zone, name)
+	call := c.rawBeta.Instances.GetGuestAttributes(project, zone, name).Context(c.context())
 	if queryPath != "" {
 		call = call.QueryPath(queryPath)
 	}
@@ -1551,7 +2438,7 @@ func (c *client) GetGuestAttributes(project, zone, name, queryPath, variableKey
 		call = call.VariableKey(variableKey)
 	}
 	a, err := call.Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 		return call.Do()
 	}
 	return a, err
@@ -1561,12 +2448,12 @@ func (c *client) GetGuestAttributes(project, zone, name, queryPath, variableKey
 func (c *client) ListMachineImages(project string, opts ...ListCallOption) ([]*computeBeta.MachineImage, error) {
 	var is []*computeBeta.MachineImage
 	var pt string
-	call := c.rawBeta.MachineImages.List(project)
+	call := c.rawBeta.MachineImages.List(project).Context(c.context())
 	for _, opt := range opts {
 		call = opt.listCallOptionApply(call).(*computeBeta.MachineImagesListCall)
 	}
 	for il, err := call.PageToken(pt).Do(); ; il, err = call.PageToken(pt).Do() {
-		if shouldRetryWithWait(c.hc.Transport, err, 2) {
+		if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
 			il, err = call.PageToken(pt).Do()
 		}
 		if err != nil {
@@ -1583,7 +2470,12 @@ func (c *client) ListMachineImages(project string, opts ...ListCallOption) ([]*c
 
 // DeleteMachineImage deletes a GCE machine image.
 func (c *client) DeleteMachineImage(project, name string) error {
-	op, err := c.RetryBeta(c.rawBeta.MachineImages.Delete(project, name).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryBeta(c.rawBeta.MachineImages.Delete(project, name).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -1595,7 +2487,12 @@ func (c *client) DeleteMachineImage(project, name string) error {
 // sourceInstance must be specified, which is the url (full or partial) to the
 // source instance
 func (c *client) CreateMachineImage(project string, mi *computeBeta.MachineImage) error {
-	op, err := c.RetryBeta(c.rawBeta.MachineImages.Insert(project, mi).Do)
+	done, err := c.limiter.startOperation(c.context())
+	if err != nil {
+		return err
+	}
+	defer done()
+	op, err := c.RetryBeta(c.rawBeta.MachineImages.Insert(project, mi).Context(c.context()).Do)
 	if err != nil {
 		return err
 	}
@@ -1614,9 +2511,9 @@ func (c *client) CreateMachineImage(project string, mi *computeBeta.MachineImage
 
 // GetMachineImage gets a GCE Machine Image using Beta API
 func (c *client) GetMachineImage(project, name string) (*computeBeta.MachineImage, error) {
-	i, err := c.rawBeta.MachineImages.Get(project, name).Do()
-	if shouldRetryWithWait(c.hc.Transport, err, 2) {
-		return c.rawBeta.MachineImages.Get(project, name).Do()
+	i, err := c.rawBeta.MachineImages.Get(project, name).Context(c.context()).Do()
+	if shouldRetryWithWait(c.context(), c.hc.Transport, err, 2) {
+		return c.rawBeta.MachineImages.Get(project, name).Context(c.context()).Do()
 	}
 	return i, err
 }
@@ -16,12 +16,14 @@ package compute
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/kylelemons/godebug/pretty"
 	computeAlpha "google.golang.org/api/compute/v0.alpha"
@@ -31,24 +33,30 @@ import (
 )
 
 var (
-	testProject              = "test-project"
-	testZone                 = "test-zone"
-	testRegion               = "test-region"
-	testDisk                 = "test-disk"
-	testDisk2                = "test-disk2"
-	testResize         int64 = 128
-	testForwardingRule       = "test-forwarding-rule"
-	testFirewallRule         = "test-firewall-rule"
-	testImage                = "test-image"
-	testImageAlpha           = "test-image-alpha"
-	testImageBeta            = "test-image-beta"
-	testMachineImage         = "test-machine-image"
-	testInstance             = "test-instance"
-	testInstanceAlpha        = "test-instance-alpha"
-	testInstanceBeta         = "test-instance-beta"
-	testNetwork              = "test-network"
-	testSubnetwork           = "test-subnetwork"
-	testTargetInstance       = "test-target-instance"
+	testProject                    = "test-project"
+	testZone                       = "test-zone"
+	testRegion                     = "test-region"
+	testDisk                       = "test-disk"
+	testDisk2                      = "test-disk2"
+	testResize               int64 = 128
+	testForwardingRule             = "test-forwarding-rule"
+	testFirewallRule               = "test-firewall-rule"
+	testAddress                    = "test-address"
+	testRoute                      = "test-route"
+	testRouter                     = "test-router"
+	testInstanceTemplate           = "test-instance-template"
+	testInstanceGroupManager       = "test-instance-group-manager"
+	testImage                      = "test-image"
+	testImageAlpha                 = "test-image-alpha"
+	testImageBeta                  = "test-image-beta"
+	testMachineImage               = "test-machine-image"
+	testInstance                   = "test-instance"
+	testInstanceAlpha              = "test-instance-alpha"
+	testInstanceBeta               = "test-instance-beta"
+	testNetwork                    = "test-network"
+	testSubnetwork                 = "test-subnetwork"
+	testSnapshot                   = "test-snapshot"
+	testTargetInstance             = "test-target-instance"
 )
 
 func TestShouldRetryWithWait(t *testing.T) {
@@ -67,7 +75,7 @@ func TestShouldRetryWithWait(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		if got := shouldRetryWithWait(nil, tt.err, 0); got != tt.want {
+		if got := shouldRetryWithWait(context.Background(), nil, tt.err, 0); got != tt.want {
 			t.Errorf("%s case: shouldRetryWithWait == %t, want %t", tt.desc, got, tt.want)
 		}
 	}
@@ -123,8 +131,14 @@ func TestCreates(t *testing.T) {
 	}
 
 	d := &compute.Disk{Name: testDisk}
+	rd := &compute.Disk{Name: testDisk}
 	fr := &compute.ForwardingRule{Name: testForwardingRule}
 	fir := &compute.Firewall{Name: testFirewallRule}
+	ad := &compute.Address{Name: testAddress}
+	ro := &compute.Route{Name: testRoute}
+	rt := &compute.Router{Name: testRouter}
+	it := &compute.InstanceTemplate{Name: testInstanceTemplate}
+	igm := &compute.InstanceGroupManager{Name: testInstanceGroupManager}
 	im := &compute.Image{Name: testImage}
 	imAlpha := &computeAlpha.Image{Name: testImageAlpha}
 	imBeta := &computeBeta.Image{Name: testImageBeta}
@@ -149,6 +163,14 @@ func TestCreates(t *testing.T) {
 			&compute.Disk{Name: testDisk},
 			d,
 		},
+		{
+			"regionDisks",
+			func() error { return c.CreateRegionDisk(testProject, testRegion, rd) },
+			fmt.Sprintf("/%s/regions/%s/disks/%s?alt=json&prettyPrint=false", testProject, testRegion, testDisk),
+			fmt.Sprintf("/%s/regions/%s/disks?alt=json&prettyPrint=false", testProject, testRegion),
+			&compute.Disk{Name: testDisk},
+			rd,
+		},
 		{
 			"forwardingRules",
 			func() error { return c.CreateForwardingRule(testProject, testRegion, fr) },
@@ -165,6 +187,46 @@ func TestCreates(t *testing.T) {
 			&compute.Firewall{Name: testFirewallRule},
 			fir,
 		},
+		{
+			"addresses",
+			func() error { return c.CreateAddress(testProject, testRegion, ad) },
+			fmt.Sprintf("/%s/regions/%s/addresses/%s?alt=json&prettyPrint=false", testProject, testRegion, testAddress),
+			fmt.Sprintf("/%s/regions/%s/addresses?alt=json&prettyPrint=false", testProject, testRegion),
+			&compute.Address{Name: testAddress},
+			ad,
+		},
+		{
+			"routes",
+			func() error { return c.CreateRoute(testProject, ro) },
+			fmt.Sprintf("/%s/global/routes/%s?alt=json&prettyPrint=false", testProject, testRoute),
+			fmt.Sprintf("/%s/global/routes?alt=json&prettyPrint=false", testProject),
+			&compute.Route{Name: testRoute},
+			ro,
+		},
+		{
+			"routers",
+			func() error { return c.CreateRouter(testProject, testRegion, rt) },
+			fmt.Sprintf("/%s/regions/%s/routers/%s?alt=json&prettyPrint=false", testProject, testRegion, testRouter),
+			fmt.Sprintf("/%s/regions/%s/routers?alt=json&prettyPrint=false", testProject, testRegion),
+			&compute.Router{Name: testRouter},
+			rt,
+		},
+		{
+			"instanceTemplates",
+			func() error { return c.CreateInstanceTemplate(testProject, it) },
+			fmt.Sprintf("/%s/global/instanceTemplates/%s?alt=json&prettyPrint=false", testProject, testInstanceTemplate),
+			fmt.Sprintf("/%s/global/instanceTemplates?alt=json&prettyPrint=false", testProject),
+			&compute.InstanceTemplate{Name: testInstanceTemplate},
+			it,
+		},
+		{
+			"instanceGroupManagers",
+			func() error { return c.CreateInstanceGroupManager(testProject, testZone, igm) },
+			fmt.Sprintf("/%s/zones/%s/instanceGroupManagers/%s?alt=json&prettyPrint=false", testProject, testZone, testInstanceGroupManager),
+			fmt.Sprintf("/%s/zones/%s/instanceGroupManagers?alt=json&prettyPrint=false", testProject, testZone),
+			&compute.InstanceGroupManager{Name: testInstanceGroupManager},
+			igm,
+		},
 		{
 			"images",
 			func() error { return c.CreateImage(testProject, im) },
@@ -316,6 +378,107 @@ func TestStops(t *testing.T) {
 	}
 }
 
+func TestSuspendsAndResumes(t *testing.T) {
+	var actionURL, opGetURL string
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == actionURL {
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == opGetURL {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	opGetURL = fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone)
+	actionURL = fmt.Sprintf("/projects/%s/zones/%s/instances/%s/suspend?alt=json&prettyPrint=false", testProject, testZone, testInstance)
+	if err := c.SuspendInstance(testProject, testZone, testInstance); err != nil {
+		t.Errorf("error running Suspend: %v", err)
+	}
+	actionURL = fmt.Sprintf("/projects/%s/zones/%s/instances/%s/resume?alt=json&prettyPrint=false", testProject, testZone, testInstance)
+	if err := c.ResumeInstance(testProject, testZone, testInstance); err != nil {
+		t.Errorf("error running Resume: %v", err)
+	}
+}
+
+func TestSetLabels(t *testing.T) {
+	var setURL, opGetURL string
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.String() == setURL {
+			fmt.Fprint(w, `{}`)
+		} else if r.Method == "POST" && r.URL.String() == opGetURL {
+			fmt.Fprint(w, `{"Status":"DONE"}`)
+		} else {
+			w.WriteHeader(500)
+			fmt.Fprintln(w, "URL and Method not recognized:", r.Method, r.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+
+	labels := map[string]string{"foo": "bar"}
+	tests := []struct {
+		desc          string
+		setFn         func() error
+		setURL, opURL string
+	}{
+		{
+			"disk",
+			func() error {
+				return c.SetDiskLabels(testProject, testZone, testDisk, &compute.ZoneSetLabelsRequest{Labels: labels})
+			},
+			fmt.Sprintf("/projects/%s/zones/%s/disks/%s/setLabels?alt=json&prettyPrint=false", testProject, testZone, testDisk),
+			fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone),
+		},
+		{
+			"region disk",
+			func() error {
+				return c.SetRegionDiskLabels(testProject, testRegion, testDisk, &compute.RegionSetLabelsRequest{Labels: labels})
+			},
+			fmt.Sprintf("/projects/%s/regions/%s/disks/%s/setLabels?alt=json&prettyPrint=false", testProject, testRegion, testDisk),
+			fmt.Sprintf("/projects/%s/regions/%s/operations//wait?alt=json&prettyPrint=false", testProject, testRegion),
+		},
+		{
+			"image",
+			func() error {
+				return c.SetImageLabels(testProject, testImage, &compute.GlobalSetLabelsRequest{Labels: labels})
+			},
+			fmt.Sprintf("/projects/%s/global/images/%s/setLabels?alt=json&prettyPrint=false", testProject, testImage),
+			fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject),
+		},
+		{
+			"instance",
+			func() error {
+				return c.SetInstanceLabels(testProject, testZone, testInstance, &compute.InstancesSetLabelsRequest{Labels: labels})
+			},
+			fmt.Sprintf("/projects/%s/zones/%s/instances/%s/setLabels?alt=json&prettyPrint=false", testProject, testZone, testInstance),
+			fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone),
+		},
+		{
+			"snapshot",
+			func() error {
+				return c.SetSnapshotLabels(testProject, testSnapshot, &compute.GlobalSetLabelsRequest{Labels: labels})
+			},
+			fmt.Sprintf("/projects/%s/global/snapshots/%s/setLabels?alt=json&prettyPrint=false", testProject, testSnapshot),
+			fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject),
+		},
+	}
+
+	for _, tt := range tests {
+		setURL, opGetURL = tt.setURL, tt.opURL
+		if err := tt.setFn(); err != nil {
+			t.Errorf("%s: error running SetLabels: %v", tt.desc, err)
+		}
+	}
+}
+
 func TestDeletes(t *testing.T) {
 	var deleteURL, opGetURL *string
 	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -344,6 +507,12 @@ func TestDeletes(t *testing.T) {
 			fmt.Sprintf("/projects/%s/zones/%s/disks/%s?alt=json&prettyPrint=false", testProject, testZone, testDisk),
 			fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone),
 		},
+		{
+			"regionDisks",
+			func() error { return c.DeleteRegionDisk(testProject, testRegion, testDisk) },
+			fmt.Sprintf("/projects/%s/regions/%s/disks/%s?alt=json&prettyPrint=false", testProject, testRegion, testDisk),
+			fmt.Sprintf("/projects/%s/regions/%s/operations//wait?alt=json&prettyPrint=false", testProject, testRegion),
+		},
 		{
 			"forwardingRules",
 			func() error { return c.DeleteForwardingRule(testProject, testRegion, testForwardingRule) },
@@ -356,6 +525,36 @@ func TestDeletes(t *testing.T) {
 			fmt.Sprintf("/projects/%s/global/firewalls/%s?alt=json&prettyPrint=false", testProject, testFirewallRule),
 			fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject),
 		},
+		{
+			"addresses",
+			func() error { return c.DeleteAddress(testProject, testRegion, testAddress) },
+			fmt.Sprintf("/projects/%s/regions/%s/addresses/%s?alt=json&prettyPrint=false", testProject, testRegion, testAddress),
+			fmt.Sprintf("/projects/%s/regions/%s/operations//wait?alt=json&prettyPrint=false", testProject, testRegion),
+		},
+		{
+			"routes",
+			func() error { return c.DeleteRoute(testProject, testRoute) },
+			fmt.Sprintf("/projects/%s/global/routes/%s?alt=json&prettyPrint=false", testProject, testRoute),
+			fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject),
+		},
+		{
+			"routers",
+			func() error { return c.DeleteRouter(testProject, testRegion, testRouter) },
+			fmt.Sprintf("/projects/%s/regions/%s/routers/%s?alt=json&prettyPrint=false", testProject, testRegion, testRouter),
+			fmt.Sprintf("/projects/%s/regions/%s/operations//wait?alt=json&prettyPrint=false", testProject, testRegion),
+		},
+		{
+			"instanceTemplates",
+			func() error { return c.DeleteInstanceTemplate(testProject, testInstanceTemplate) },
+			fmt.Sprintf("/projects/%s/global/instanceTemplates/%s?alt=json&prettyPrint=false", testProject, testInstanceTemplate),
+			fmt.Sprintf("/projects/%s/global/operations//wait?alt=json&prettyPrint=false", testProject),
+		},
+		{
+			"instanceGroupManagers",
+			func() error { return c.DeleteInstanceGroupManager(testProject, testZone, testInstanceGroupManager) },
+			fmt.Sprintf("/projects/%s/zones/%s/instanceGroupManagers/%s?alt=json&prettyPrint=false", testProject, testZone, testInstanceGroupManager),
+			fmt.Sprintf("/projects/%s/zones/%s/operations//wait?alt=json&prettyPrint=false", testProject, testZone),
+		},
 		{
 			"images",
 			func() error { return c.DeleteImage(testProject, testImage) },
@@ -485,3 +684,56 @@ func TestDetachDisk(t *testing.T) {
 		t.Fatalf("error running DetachDisk: %v", err)
 	}
 }
+
+func TestWithContext(t *testing.T) {
+	block := make(chan struct{})
+	svr, c, err := NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			fmt.Fprint(w, `{}`)
+		} else if r.URL.Path == fmt.Sprintf("/projects/%s/zones/%s/disks/%s", testProject, testZone, testDisk) {
+			<-block
+		} else {
+			fmt.Fprint(w, `{"Status":"RUNNING"}`)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svr.Close()
+	// Unblock the handler before the server is closed.
+	defer close(block)
+
+	// Overrides are kept.
+	c.GetProjectFn = func(project string) (*compute.Project, error) { return &compute.Project{Name: project}, nil }
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := WithContext(ctx, c)
+	if p, err := cc.GetProject(testProject); err != nil || p.Name != testProject {
+		t.Errorf("override not used, got %v, %v", p, err)
+	}
+
+	tests := []struct {
+		desc string
+		do   func() error
+	}{
+		{"in-flight call", func() error { _, err := cc.GetDisk(testProject, testZone, testDisk); return err }},
+		{"operation wait", func() error { return cc.DeleteDisk(testProject, testZone, testDisk) }},
+	}
+	time.AfterFunc(100*time.Millisecond, cancel)
+	for _, tt := range tests {
+		start := time.Now()
+		if err := tt.do(); err == nil {
+			t.Errorf("%s: expected an error", tt.desc)
+		}
+		if d := time.Since(start); d > 2*time.Second {
+			t.Errorf("%s: took %v to return after cancellation", tt.desc, d)
+		}
+	}
+}
+
+func TestWithContextWithoutContextClient(t *testing.T) {
+	// Clients that aren't ContextClients are used as they are.
+	c := struct{ Client }{}
+	if got := WithContext(context.Background(), c); got != c {
+		t.Errorf("want the client unchanged, got %v", got)
+	}
+}
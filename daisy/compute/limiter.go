@@ -0,0 +1,213 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter limits the number of in-flight Compute operations and the rate of
+// Compute API requests per API method. A single Limiter can be shared by any
+// number of clients, e.g. by all workflows running in a process.
+//
+// API methods are named as in the Compute Engine API reference, without the
+// "compute." prefix and version, e.g. "disks.insert", "instances.get" or
+// "zoneOperations.wait".
+type Limiter struct {
+	ops        chan struct{}
+	defaultRPS float64
+	methodRPS  map[string]float64
+
+	mx   sync.Mutex
+	next map[string]time.Time
+}
+
+// NewLimiter creates a Limiter that allows up to maxOperations mutating calls
+// (from request until their operation is done) at a time and defaultRPS
+// requests per second for each API method, overridden per method by
+// methodRPS. Zero or negative values mean no limit.
+func NewLimiter(maxOperations int, defaultRPS float64, methodRPS map[string]float64) *Limiter {
+	l := &Limiter{defaultRPS: defaultRPS, methodRPS: methodRPS, next: map[string]time.Time{}}
+	if maxOperations > 0 {
+		l.ops = make(chan struct{}, maxOperations)
+	}
+	return l
+}
+
+// ParseMethodRPS parses a comma separated list of "method=rps" pairs, e.g.
+// "disks.insert=2,instances.get=20".
+func ParseMethodRPS(s string) (map[string]float64, error) {
+	m := map[string]float64{}
+	if s == "" {
+		return m, nil
+	}
+	for _, kv := range strings.Split(s, ",") {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid API method rate %q, expected method=rps", kv)
+		}
+		rps, err := strconv.ParseFloat(kv[i+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API method rate %q: %v", kv, err)
+		}
+		m[strings.TrimSpace(kv[:i])] = rps
+	}
+	return m, nil
+}
+
+// startOperation blocks until an operation slot is free or ctx is done, and
+// returns the function that releases the slot. Safe to call on a nil Limiter.
+func (l *Limiter) startOperation(ctx context.Context) (func(), error) {
+	if l == nil || l.ops == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.ops <- struct{}{}:
+		return func() { <-l.ops }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// wait blocks until a request to method is allowed or ctx is done.
+func (l *Limiter) wait(ctx context.Context, method string) error {
+	rps, ok := l.methodRPS[method]
+	if !ok {
+		rps = l.defaultRPS
+	}
+	if rps <= 0 {
+		return nil
+	}
+
+	// Requests are spaced evenly: each one reserves the next free slot.
+	l.mx.Lock()
+	now := time.Now()
+	at := l.next[method]
+	if at.Before(now) {
+		at = now
+	}
+	end := at.Add(time.Duration(float64(time.Second) / rps))
+	l.next[method] = end
+	l.mx.Unlock()
+
+	d := at.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// Give the slot back unless later requests reserved slots after it.
+		l.mx.Lock()
+		if l.next[method].Equal(end) {
+			l.next[method] = at
+		}
+		l.mx.Unlock()
+		return ctx.Err()
+	}
+}
+
+// operationCollections name the operations collection of each scope as in
+// the API reference.
+var operationCollections = map[string]string{
+	"zones":   "zoneOperations",
+	"regions": "regionOperations",
+	"global":  "globalOperations",
+}
+
+// customMethodNames maps URL verbs that differ from their API method name.
+var customMethodNames = map[string]string{
+	"serialPort":      "getSerialPortOutput",
+	"guestAttributes": "getGuestAttributes",
+}
+
+// apiMethod derives the API method name of a Compute API request from its
+// HTTP method and URL path.
+func apiMethod(r *http.Request) string {
+	p := r.URL.Path
+	i := strings.Index(p, "/projects/")
+	if i < 0 {
+		return r.Method + " " + p
+	}
+	// Drop "projects/<project>".
+	segs := strings.Split(strings.Trim(p[i+1:], "/"), "/")[2:]
+	if len(segs) == 0 {
+		return "projects.get"
+	}
+
+	scope := "global"
+	switch {
+	case segs[0] == "aggregated" && len(segs) == 2:
+		return segs[1] + ".aggregatedList"
+	case segs[0] == "global":
+		segs = segs[1:]
+	case (segs[0] == "zones" || segs[0] == "regions") && len(segs) > 2:
+		scope = segs[0]
+		segs = segs[2:]
+	case len(segs) == 1 && r.Method == http.MethodPost:
+		// Project level custom method, e.g. setCommonInstanceMetadata.
+		return "projects." + segs[0]
+	}
+
+	collection := segs[0]
+	if collection == "operations" {
+		collection = operationCollections[scope]
+	}
+	switch {
+	case len(segs) == 1 && r.Method == http.MethodPost:
+		return collection + ".insert"
+	case len(segs) == 1:
+		return collection + ".list"
+	case len(segs) == 3 && collection == "images" && segs[1] == "family":
+		return "images.getFromFamily"
+	case len(segs) >= 3:
+		verb := segs[len(segs)-1]
+		if name, ok := customMethodNames[verb]; ok {
+			verb = name
+		}
+		return collection + "." + verb
+	}
+	switch r.Method {
+	case http.MethodDelete:
+		return collection + ".delete"
+	case http.MethodPatch:
+		return collection + ".patch"
+	case http.MethodPut:
+		return collection + ".update"
+	}
+	return collection + ".get"
+}
+
+// limitedTransport applies a Limiter's request rates to every request.
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *limitedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(r.Context(), apiMethod(r)); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(r)
+}
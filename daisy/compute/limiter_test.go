@@ -0,0 +1,221 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package compute
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+func TestAPIMethod(t *testing.T) {
+	base := "https://compute.googleapis.com/compute/v1/projects/p"
+	tests := []struct {
+		method, url, want string
+	}{
+		{"GET", base, "projects.get"},
+		{"POST", base + "/setCommonInstanceMetadata", "projects.setCommonInstanceMetadata"},
+		{"GET", base + "/zones", "zones.list"},
+		{"GET", base + "/zones/z", "zones.get"},
+		{"GET", base + "/regions", "regions.list"},
+		{"POST", base + "/zones/z/disks", "disks.insert"},
+		{"GET", base + "/zones/z/disks", "disks.list"},
+		{"GET", base + "/zones/z/disks/d", "disks.get"},
+		{"DELETE", base + "/zones/z/disks/d", "disks.delete"},
+		{"POST", base + "/zones/z/disks/d/resize", "disks.resize"},
+		{"POST", base + "/zones/z/instances/i/attachDisk", "instances.attachDisk"},
+		{"GET", base + "/zones/z/instances/i/serialPort", "instances.getSerialPortOutput"},
+		{"POST", base + "/zones/z/operations/op/wait", "zoneOperations.wait"},
+		{"POST", base + "/regions/r/operations/op/wait", "regionOperations.wait"},
+		{"POST", base + "/global/operations/op/wait", "globalOperations.wait"},
+		{"GET", base + "/aggregated/disks", "disks.aggregatedList"},
+		{"POST", base + "/global/images", "images.insert"},
+		{"GET", base + "/global/images/family/f", "images.getFromFamily"},
+		{"POST", base + "/global/images/i/deprecate", "images.deprecate"},
+		{"GET", base + "/regions/r/subnetworks/s", "subnetworks.get"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.url, nil)
+		got := apiMethod(r)
+		if got != tt.want {
+			t.Errorf("apiMethod(%s %s) = %q, want %q", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestParseMethodRPS(t *testing.T) {
+	got, err := ParseMethodRPS("disks.insert=2, instances.get=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"disks.insert": 2, "instances.get": 0.5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, s := range []string{"disks.insert", "=2", "disks.insert=fast"} {
+		if _, err := ParseMethodRPS(s); err == nil {
+			t.Errorf("ParseMethodRPS(%q) should have returned an error", s)
+		}
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := NewLimiter(0, 0, map[string]float64{"disks.insert": 20})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := l.wait(ctx, "disks.insert"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request is immediate, the next four are spaced by 50ms.
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("5 requests at 20 rps took %v, want at least 200ms", d)
+	}
+
+	// Unlimited methods do not wait.
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		l.wait(ctx, "disks.get")
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("unlimited requests took %v", d)
+	}
+
+	// Waiting respects the context.
+	l = NewLimiter(0, 0.1, nil)
+	start = time.Now()
+	l.wait(ctx, "disks.get")
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.wait(cctx, "disks.get"); err == nil {
+		t.Error("wait should have returned the context's error")
+	}
+	next := func() time.Time {
+		l.mx.Lock()
+		defer l.mx.Unlock()
+		return l.next["disks.get"]
+	}
+	// The canceled request gives its slot back to later ones.
+	if next := next(); next.After(start.Add(11 * time.Second)) {
+		t.Errorf("next request at %v after the start, want the slot of the canceled request", next.Sub(start))
+	}
+
+	// Unless a later request already reserved the slot after it.
+	cctx, cancel = context.WithCancel(ctx)
+	errc := make(chan error)
+	go func() { errc <- l.wait(cctx, "disks.get") }()
+	time.Sleep(10 * time.Millisecond)
+	lctx, lcancel := context.WithCancel(ctx)
+	defer lcancel()
+	go l.wait(lctx, "disks.get")
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-errc
+	if next := next(); next.Before(start.Add(29 * time.Second)) {
+		t.Errorf("next request at %v after the start, want after the two reserved slots", next.Sub(start))
+	}
+}
+
+func TestLimiterOperations(t *testing.T) {
+	ctx := context.Background()
+	var nilLimiter *Limiter
+	release, err := nilLimiter.startOperation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	l := NewLimiter(1, 0, nil)
+	release, err = l.startOperation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan struct{})
+	go func() {
+		if release, err := l.startOperation(ctx); err == nil {
+			release()
+		}
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second operation started while the first was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second operation did not start after the first finished")
+	}
+}
+
+func TestLimiterOperationsCanceled(t *testing.T) {
+	l := NewLimiter(1, 0, nil)
+	release, err := l.startOperation(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := l.startOperation(ctx)
+		errc <- err
+	}()
+	cancel()
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Errorf("want %v, got %v", context.Canceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("startOperation did not return when its context was canceled")
+	}
+}
+
+func TestNewLimitedClient(t *testing.T) {
+	var methods []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, apiMethod(r))
+		w.Write([]byte(`{"Status":"DONE"}`))
+	}))
+	defer svr.Close()
+
+	l := NewLimiter(1, 1000, nil)
+	c, err := NewLimitedClient(context.Background(), l, option.WithEndpoint(svr.URL), option.WithHTTPClient(http.DefaultClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteDisk(testProject, testZone, testDisk); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"disks.delete", "zoneOperations.wait"}
+	if !reflect.DeepEqual(methods, want) {
+		t.Errorf("got requests %v, want %v", methods, want)
+	}
+	if len(l.ops) != 0 {
+		t.Error("operation slot not released")
+	}
+}
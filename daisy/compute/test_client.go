@@ -49,65 +49,100 @@ func NewTestClient(handleFunc http.HandlerFunc) (*httptest.Server, *TestClient,
 type TestClient struct {
 	client
 
-	AttachDiskFn                func(project, zone, instance string, d *compute.AttachedDisk) error
-	DetachDiskFn                func(project, zone, instance, disk string) error
-	CreateDiskFn                func(project, zone string, d *compute.Disk) error
-	CreateForwardingRuleFn      func(project, region string, fr *compute.ForwardingRule) error
-	CreateFirewallRuleFn        func(project string, i *compute.Firewall) error
-	CreateImageFn               func(project string, i *compute.Image) error
-	CreateInstanceFn            func(project, zone string, i *compute.Instance) error
-	CreateNetworkFn             func(project string, n *compute.Network) error
-	CreateSnapshotFn            func(project, zone, disk string, s *compute.Snapshot) error
-	CreateSubnetworkFn          func(project, region string, n *compute.Subnetwork) error
-	CreateTargetInstanceFn      func(project, zone string, ti *compute.TargetInstance) error
-	StartInstanceFn             func(project, zone, name string) error
-	StopInstanceFn              func(project, zone, name string) error
-	DeleteDiskFn                func(project, zone, name string) error
-	DeleteForwardingRuleFn      func(project, region, name string) error
-	DeleteFirewallRuleFn        func(project, name string) error
-	DeleteImageFn               func(project, name string) error
-	DeleteInstanceFn            func(project, zone, name string) error
-	DeleteNetworkFn             func(project, name string) error
-	DeleteSubnetworkFn          func(project, region, name string) error
-	DeleteTargetInstanceFn      func(project, zone, name string) error
-	DeprecateImageFn            func(project, name string, deprecationstatus *compute.DeprecationStatus) error
-	GetMachineTypeFn            func(project, zone, machineType string) (*compute.MachineType, error)
-	ListMachineTypesFn          func(project, zone string, opts ...ListCallOption) ([]*compute.MachineType, error)
-	GetProjectFn                func(project string) (*compute.Project, error)
-	GetSerialPortOutputFn       func(project, zone, name string, port, start int64) (*compute.SerialPortOutput, error)
-	GetZoneFn                   func(project, zone string) (*compute.Zone, error)
-	ListZonesFn                 func(project string, opts ...ListCallOption) ([]*compute.Zone, error)
-	GetInstanceFn               func(project, zone, name string) (*compute.Instance, error)
-	AggregatedListInstancesFn   func(project string, opts ...ListCallOption) ([]*compute.Instance, error)
-	ListInstancesFn             func(project, zone string, opts ...ListCallOption) ([]*compute.Instance, error)
-	ListSnapshotsFn             func(project string, opts ...ListCallOption) ([]*compute.Snapshot, error)
-	GetSnapshotFn               func(project, name string) (*compute.Snapshot, error)
-	DeleteSnapshotFn            func(project, name string) error
-	GetDiskFn                   func(project, zone, name string) (*compute.Disk, error)
-	AggregatedListDisksFn       func(project string, opts ...ListCallOption) ([]*compute.Disk, error)
-	ListDisksFn                 func(project, zone string, opts ...ListCallOption) ([]*compute.Disk, error)
-	GetForwardingRuleFn         func(project, region, name string) (*compute.ForwardingRule, error)
-	ListForwardingRulesFn       func(project, region string, opts ...ListCallOption) ([]*compute.ForwardingRule, error)
-	GetFirewallRuleFn           func(project, name string) (*compute.Firewall, error)
-	ListFirewallRulesFn         func(project string, opts ...ListCallOption) ([]*compute.Firewall, error)
-	GetImageFn                  func(project, name string) (*compute.Image, error)
-	GetImageFromFamilyFn        func(project, family string) (*compute.Image, error)
-	ListImagesFn                func(project string, opts ...ListCallOption) ([]*compute.Image, error)
-	GetLicenseFn                func(project, name string) (*compute.License, error)
-	ListLicensesFn              func(project string, opts ...ListCallOption) ([]*compute.License, error)
-	GetNetworkFn                func(project, name string) (*compute.Network, error)
-	AggregatedListSubnetworksFn func(project string, opts ...ListCallOption) ([]*compute.Subnetwork, error)
-	ListNetworksFn              func(project string, opts ...ListCallOption) ([]*compute.Network, error)
-	GetSubnetworkFn             func(project, region, name string) (*compute.Subnetwork, error)
-	ListSubnetworksFn           func(project, region string, opts ...ListCallOption) ([]*compute.Subnetwork, error)
-	GetTargetInstanceFn         func(project, zone, name string) (*compute.TargetInstance, error)
-	ListTargetInstancesFn       func(project, zone string, opts ...ListCallOption) ([]*compute.TargetInstance, error)
-	InstanceStatusFn            func(project, zone, name string) (string, error)
-	InstanceStoppedFn           func(project, zone, name string) (bool, error)
-	ResizeDiskFn                func(project, zone, disk string, drr *compute.DisksResizeRequest) error
-	SetInstanceMetadataFn       func(project, zone, name string, md *compute.Metadata) error
-	SetCommonInstanceMetadataFn func(project string, md *compute.Metadata) error
-	RetryFn                     func(f func(opts ...googleapi.CallOption) (*compute.Operation, error), opts ...googleapi.CallOption) (op *compute.Operation, err error)
+	AttachDiskFn                 func(project, zone, instance string, d *compute.AttachedDisk) error
+	DetachDiskFn                 func(project, zone, instance, disk string) error
+	CreateDiskFn                 func(project, zone string, d *compute.Disk) error
+	CreateForwardingRuleFn       func(project, region string, fr *compute.ForwardingRule) error
+	CreateFirewallRuleFn         func(project string, i *compute.Firewall) error
+	CreateImageFn                func(project string, i *compute.Image) error
+	CreateInstanceFn             func(project, zone string, i *compute.Instance) error
+	CreateNetworkFn              func(project string, n *compute.Network) error
+	CreateSnapshotFn             func(project, zone, disk string, s *compute.Snapshot) error
+	CreateSubnetworkFn           func(project, region string, n *compute.Subnetwork) error
+	CreateTargetInstanceFn       func(project, zone string, ti *compute.TargetInstance) error
+	StartInstanceFn              func(project, zone, name string) error
+	StopInstanceFn               func(project, zone, name string) error
+	SuspendInstanceFn            func(project, zone, name string) error
+	ResumeInstanceFn             func(project, zone, name string) error
+	DeleteDiskFn                 func(project, zone, name string) error
+	DeleteForwardingRuleFn       func(project, region, name string) error
+	DeleteFirewallRuleFn         func(project, name string) error
+	DeleteImageFn                func(project, name string) error
+	DeleteInstanceFn             func(project, zone, name string) error
+	DeleteNetworkFn              func(project, name string) error
+	DeleteSubnetworkFn           func(project, region, name string) error
+	DeleteTargetInstanceFn       func(project, zone, name string) error
+	DeprecateImageFn             func(project, name string, deprecationstatus *compute.DeprecationStatus) error
+	GetMachineTypeFn             func(project, zone, machineType string) (*compute.MachineType, error)
+	ListMachineTypesFn           func(project, zone string, opts ...ListCallOption) ([]*compute.MachineType, error)
+	GetProjectFn                 func(project string) (*compute.Project, error)
+	GetSerialPortOutputFn        func(project, zone, name string, port, start int64) (*compute.SerialPortOutput, error)
+	GetZoneFn                    func(project, zone string) (*compute.Zone, error)
+	ListZonesFn                  func(project string, opts ...ListCallOption) ([]*compute.Zone, error)
+	ListRegionsFn                func(project string, opts ...ListCallOption) ([]*compute.Region, error)
+	GetInstanceFn                func(project, zone, name string) (*compute.Instance, error)
+	AggregatedListInstancesFn    func(project string, opts ...ListCallOption) ([]*compute.Instance, error)
+	ListInstancesFn              func(project, zone string, opts ...ListCallOption) ([]*compute.Instance, error)
+	ListSnapshotsFn              func(project string, opts ...ListCallOption) ([]*compute.Snapshot, error)
+	GetSnapshotFn                func(project, name string) (*compute.Snapshot, error)
+	DeleteSnapshotFn             func(project, name string) error
+	GetDiskFn                    func(project, zone, name string) (*compute.Disk, error)
+	AggregatedListDisksFn        func(project string, opts ...ListCallOption) ([]*compute.Disk, error)
+	ListDisksFn                  func(project, zone string, opts ...ListCallOption) ([]*compute.Disk, error)
+	GetForwardingRuleFn          func(project, region, name string) (*compute.ForwardingRule, error)
+	ListForwardingRulesFn        func(project, region string, opts ...ListCallOption) ([]*compute.ForwardingRule, error)
+	GetFirewallRuleFn            func(project, name string) (*compute.Firewall, error)
+	ListFirewallRulesFn          func(project string, opts ...ListCallOption) ([]*compute.Firewall, error)
+	GetImageFn                   func(project, name string) (*compute.Image, error)
+	GetImageFromFamilyFn         func(project, family string) (*compute.Image, error)
+	ListImagesFn                 func(project string, opts ...ListCallOption) ([]*compute.Image, error)
+	GetLicenseFn                 func(project, name string) (*compute.License, error)
+	ListLicensesFn               func(project string, opts ...ListCallOption) ([]*compute.License, error)
+	GetNetworkFn                 func(project, name string) (*compute.Network, error)
+	AggregatedListSubnetworksFn  func(project string, opts ...ListCallOption) ([]*compute.Subnetwork, error)
+	ListNetworksFn               func(project string, opts ...ListCallOption) ([]*compute.Network, error)
+	GetSubnetworkFn              func(project, region, name string) (*compute.Subnetwork, error)
+	ListSubnetworksFn            func(project, region string, opts ...ListCallOption) ([]*compute.Subnetwork, error)
+	GetTargetInstanceFn          func(project, zone, name string) (*compute.TargetInstance, error)
+	ListTargetInstancesFn        func(project, zone string, opts ...ListCallOption) ([]*compute.TargetInstance, error)
+	InstanceStatusFn             func(project, zone, name string) (string, error)
+	InstanceStoppedFn            func(project, zone, name string) (bool, error)
+	InstanceSuspendedFn          func(project, zone, name string) (bool, error)
+	ResizeDiskFn                 func(project, zone, disk string, drr *compute.DisksResizeRequest) error
+	CreateRegionDiskFn           func(project, region string, d *compute.Disk) error
+	DeleteRegionDiskFn           func(project, region, name string) error
+	GetRegionDiskFn              func(project, region, name string) (*compute.Disk, error)
+	ListRegionDisksFn            func(project, region string, opts ...ListCallOption) ([]*compute.Disk, error)
+	ResizeRegionDiskFn           func(project, region, disk string, drr *compute.RegionDisksResizeRequest) error
+	SetInstanceMetadataFn        func(project, zone, name string, md *compute.Metadata) error
+	SetCommonInstanceMetadataFn  func(project string, md *compute.Metadata) error
+	SetDiskLabelsFn              func(project, zone, name string, req *compute.ZoneSetLabelsRequest) error
+	SetRegionDiskLabelsFn        func(project, region, name string, req *compute.RegionSetLabelsRequest) error
+	SetImageLabelsFn             func(project, name string, req *compute.GlobalSetLabelsRequest) error
+	SetInstanceLabelsFn          func(project, zone, name string, req *compute.InstancesSetLabelsRequest) error
+	SetSnapshotLabelsFn          func(project, name string, req *compute.GlobalSetLabelsRequest) error
+	RetryFn                      func(f func(opts ...googleapi.CallOption) (*compute.Operation, error), opts ...googleapi.CallOption) (op *compute.Operation, err error)
+	CreateAddressFn              func(project, region string, a *compute.Address) error
+	DeleteAddressFn              func(project, region, name string) error
+	GetAddressFn                 func(project, region, name string) (*compute.Address, error)
+	ListAddressesFn              func(project, region string, opts ...ListCallOption) ([]*compute.Address, error)
+	CreateRouteFn                func(project string, r *compute.Route) error
+	DeleteRouteFn                func(project, name string) error
+	GetRouteFn                   func(project, name string) (*compute.Route, error)
+	ListRoutesFn                 func(project string, opts ...ListCallOption) ([]*compute.Route, error)
+	CreateRouterFn               func(project, region string, r *compute.Router) error
+	DeleteRouterFn               func(project, region, name string) error
+	GetRouterFn                  func(project, region, name string) (*compute.Router, error)
+	ListRoutersFn                func(project, region string, opts ...ListCallOption) ([]*compute.Router, error)
+	CreateInstanceTemplateFn     func(project string, t *compute.InstanceTemplate) error
+	DeleteInstanceTemplateFn     func(project, name string) error
+	GetInstanceTemplateFn        func(project, name string) (*compute.InstanceTemplate, error)
+	ListInstanceTemplatesFn      func(project string, opts ...ListCallOption) ([]*compute.InstanceTemplate, error)
+	CreateInstanceGroupManagerFn func(project, zone string, m *compute.InstanceGroupManager) error
+	DeleteInstanceGroupManagerFn func(project, zone, name string) error
+	GetInstanceGroupManagerFn    func(project, zone, name string) (*compute.InstanceGroupManager, error)
+	ListInstanceGroupManagersFn  func(project, zone string, opts ...ListCallOption) ([]*compute.InstanceGroupManager, error)
+	PatchInstanceGroupManagerFn  func(project, zone, name string, m *compute.InstanceGroupManager) error
 
 	// Alpha API calls
 	CreateInstanceAlphaFn func(project, zone string, i *computeAlpha.Instance) error
@@ -125,6 +160,15 @@ type TestClient struct {
 	globalOperationsWaitFn func(project, name string) error
 }
 
+// WithContext returns a copy of this TestClient, with the same overrides,
+// whose real implementations make their calls with ctx.
+func (c *TestClient) WithContext(ctx context.Context) Client {
+	nc := *c
+	nc.client.ctx = ctx
+	nc.client.i = &nc
+	return &nc
+}
+
 // Retry uses the override method RetryFn or the real implementation.
 func (c *TestClient) Retry(f func(opts ...googleapi.CallOption) (*compute.Operation, error), opts ...googleapi.CallOption) (op *compute.Operation, err error) {
 	if c.RetryFn != nil {
@@ -213,6 +257,22 @@ func (c *TestClient) CreateTargetInstance(project, zone string, ti *compute.Targ
 	return c.client.CreateTargetInstance(project, zone, ti)
 }
 
+// SuspendInstance uses the override method SuspendInstanceFn or the real implementation.
+func (c *TestClient) SuspendInstance(project, zone, name string) error {
+	if c.SuspendInstanceFn != nil {
+		return c.SuspendInstanceFn(project, zone, name)
+	}
+	return c.client.SuspendInstance(project, zone, name)
+}
+
+// ResumeInstance uses the override method ResumeInstanceFn or the real implementation.
+func (c *TestClient) ResumeInstance(project, zone, name string) error {
+	if c.ResumeInstanceFn != nil {
+		return c.ResumeInstanceFn(project, zone, name)
+	}
+	return c.client.ResumeInstance(project, zone, name)
+}
+
 // StartInstance uses the override method StartInstanceFn or the real implementation.
 func (c *TestClient) StartInstance(project, zone, name string) error {
 	if c.StartInstanceFn != nil {
@@ -341,6 +401,14 @@ func (c *TestClient) ListZones(project string, opts ...ListCallOption) ([]*compu
 	return c.client.ListZones(project, opts...)
 }
 
+// ListRegions uses the override method ListRegionsFn or the real implementation.
+func (c *TestClient) ListRegions(project string, opts ...ListCallOption) ([]*compute.Region, error) {
+	if c.ListRegionsFn != nil {
+		return c.ListRegionsFn(project, opts...)
+	}
+	return c.client.ListRegions(project, opts...)
+}
+
 // CreateSnapshot uses the override method CreateSnapshotFn or the real implementation.
 func (c *TestClient) CreateSnapshot(project, zone, disk string, s *compute.Snapshot) error {
 	if c.CreateSnapshotFn != nil {
@@ -581,6 +649,14 @@ func (c *TestClient) InstanceStopped(project, zone, name string) (bool, error) {
 	return c.client.InstanceStopped(project, zone, name)
 }
 
+// InstanceSuspended uses the override method InstanceSuspendedFn or the real implementation.
+func (c *TestClient) InstanceSuspended(project, zone, name string) (bool, error) {
+	if c.InstanceSuspendedFn != nil {
+		return c.InstanceSuspendedFn(project, zone, name)
+	}
+	return c.client.InstanceSuspended(project, zone, name)
+}
+
 // ResizeDisk uses the override method ResizeDiskFn or the real implementation.
 func (c *TestClient) ResizeDisk(project, zone, disk string, drr *compute.DisksResizeRequest) error {
 	if c.ResizeDiskFn != nil {
@@ -589,6 +665,214 @@ func (c *TestClient) ResizeDisk(project, zone, disk string, drr *compute.DisksRe
 	return c.client.ResizeDisk(project, zone, disk, drr)
 }
 
+// CreateRegionDisk uses the override method CreateRegionDiskFn or the real implementation.
+func (c *TestClient) CreateRegionDisk(project, region string, d *compute.Disk) error {
+	if c.CreateRegionDiskFn != nil {
+		return c.CreateRegionDiskFn(project, region, d)
+	}
+	return c.client.CreateRegionDisk(project, region, d)
+}
+
+// DeleteRegionDisk uses the override method DeleteRegionDiskFn or the real implementation.
+func (c *TestClient) DeleteRegionDisk(project, region, name string) error {
+	if c.DeleteRegionDiskFn != nil {
+		return c.DeleteRegionDiskFn(project, region, name)
+	}
+	return c.client.DeleteRegionDisk(project, region, name)
+}
+
+// GetRegionDisk uses the override method GetRegionDiskFn or the real implementation.
+func (c *TestClient) GetRegionDisk(project, region, name string) (*compute.Disk, error) {
+	if c.GetRegionDiskFn != nil {
+		return c.GetRegionDiskFn(project, region, name)
+	}
+	return c.client.GetRegionDisk(project, region, name)
+}
+
+// ListRegionDisks uses the override method ListRegionDisksFn or the real implementation.
+func (c *TestClient) ListRegionDisks(project, region string, opts ...ListCallOption) ([]*compute.Disk, error) {
+	if c.ListRegionDisksFn != nil {
+		return c.ListRegionDisksFn(project, region, opts...)
+	}
+	return c.client.ListRegionDisks(project, region, opts...)
+}
+
+// ResizeRegionDisk uses the override method ResizeRegionDiskFn or the real implementation.
+func (c *TestClient) ResizeRegionDisk(project, region, disk string, drr *compute.RegionDisksResizeRequest) error {
+	if c.ResizeRegionDiskFn != nil {
+		return c.ResizeRegionDiskFn(project, region, disk, drr)
+	}
+	return c.client.ResizeRegionDisk(project, region, disk, drr)
+}
+
+// CreateAddress uses the override method CreateAddressFn or the real implementation.
+func (c *TestClient) CreateAddress(project, region string, a *compute.Address) error {
+	if c.CreateAddressFn != nil {
+		return c.CreateAddressFn(project, region, a)
+	}
+	return c.client.CreateAddress(project, region, a)
+}
+
+// DeleteAddress uses the override method DeleteAddressFn or the real implementation.
+func (c *TestClient) DeleteAddress(project, region, name string) error {
+	if c.DeleteAddressFn != nil {
+		return c.DeleteAddressFn(project, region, name)
+	}
+	return c.client.DeleteAddress(project, region, name)
+}
+
+// GetAddress uses the override method GetAddressFn or the real implementation.
+func (c *TestClient) GetAddress(project, region, name string) (*compute.Address, error) {
+	if c.GetAddressFn != nil {
+		return c.GetAddressFn(project, region, name)
+	}
+	return c.client.GetAddress(project, region, name)
+}
+
+// ListAddresses uses the override method ListAddressesFn or the real implementation.
+func (c *TestClient) ListAddresses(project, region string, opts ...ListCallOption) ([]*compute.Address, error) {
+	if c.ListAddressesFn != nil {
+		return c.ListAddressesFn(project, region, opts...)
+	}
+	return c.client.ListAddresses(project, region, opts...)
+}
+
+// CreateRoute uses the override method CreateRouteFn or the real implementation.
+func (c *TestClient) CreateRoute(project string, r *compute.Route) error {
+	if c.CreateRouteFn != nil {
+		return c.CreateRouteFn(project, r)
+	}
+	return c.client.CreateRoute(project, r)
+}
+
+// DeleteRoute uses the override method DeleteRouteFn or the real implementation.
+func (c *TestClient) DeleteRoute(project, name string) error {
+	if c.DeleteRouteFn != nil {
+		return c.DeleteRouteFn(project, name)
+	}
+	return c.client.DeleteRoute(project, name)
+}
+
+// GetRoute uses the override method GetRouteFn or the real implementation.
+func (c *TestClient) GetRoute(project, name string) (*compute.Route, error) {
+	if c.GetRouteFn != nil {
+		return c.GetRouteFn(project, name)
+	}
+	return c.client.GetRoute(project, name)
+}
+
+// ListRoutes uses the override method ListRoutesFn or the real implementation.
+func (c *TestClient) ListRoutes(project string, opts ...ListCallOption) ([]*compute.Route, error) {
+	if c.ListRoutesFn != nil {
+		return c.ListRoutesFn(project, opts...)
+	}
+	return c.client.ListRoutes(project, opts...)
+}
+
+// CreateRouter uses the override method CreateRouterFn or the real implementation.
+func (c *TestClient) CreateRouter(project, region string, r *compute.Router) error {
+	if c.CreateRouterFn != nil {
+		return c.CreateRouterFn(project, region, r)
+	}
+	return c.client.CreateRouter(project, region, r)
+}
+
+// DeleteRouter uses the override method DeleteRouterFn or the real implementation.
+func (c *TestClient) DeleteRouter(project, region, name string) error {
+	if c.DeleteRouterFn != nil {
+		return c.DeleteRouterFn(project, region, name)
+	}
+	return c.client.DeleteRouter(project, region, name)
+}
+
+// GetRouter uses the override method GetRouterFn or the real implementation.
+func (c *TestClient) GetRouter(project, region, name string) (*compute.Router, error) {
+	if c.GetRouterFn != nil {
+		return c.GetRouterFn(project, region, name)
+	}
+	return c.client.GetRouter(project, region, name)
+}
+
+// ListRouters uses the override method ListRoutersFn or the real implementation.
+func (c *TestClient) ListRouters(project, region string, opts ...ListCallOption) ([]*compute.Router, error) {
+	if c.ListRoutersFn != nil {
+		return c.ListRoutersFn(project, region, opts...)
+	}
+	return c.client.ListRouters(project, region, opts...)
+}
+
+// CreateInstanceTemplate uses the override method CreateInstanceTemplateFn or the real implementation.
+func (c *TestClient) CreateInstanceTemplate(project string, t *compute.InstanceTemplate) error {
+	if c.CreateInstanceTemplateFn != nil {
+		return c.CreateInstanceTemplateFn(project, t)
+	}
+	return c.client.CreateInstanceTemplate(project, t)
+}
+
+// DeleteInstanceTemplate uses the override method DeleteInstanceTemplateFn or the real implementation.
+func (c *TestClient) DeleteInstanceTemplate(project, name string) error {
+	if c.DeleteInstanceTemplateFn != nil {
+		return c.DeleteInstanceTemplateFn(project, name)
+	}
+	return c.client.DeleteInstanceTemplate(project, name)
+}
+
+// GetInstanceTemplate uses the override method GetInstanceTemplateFn or the real implementation.
+func (c *TestClient) GetInstanceTemplate(project, name string) (*compute.InstanceTemplate, error) {
+	if c.GetInstanceTemplateFn != nil {
+		return c.GetInstanceTemplateFn(project, name)
+	}
+	return c.client.GetInstanceTemplate(project, name)
+}
+
+// ListInstanceTemplates uses the override method ListInstanceTemplatesFn or the real implementation.
+func (c *TestClient) ListInstanceTemplates(project string, opts ...ListCallOption) ([]*compute.InstanceTemplate, error) {
+	if c.ListInstanceTemplatesFn != nil {
+		return c.ListInstanceTemplatesFn(project, opts...)
+	}
+	return c.client.ListInstanceTemplates(project, opts...)
+}
+
+// CreateInstanceGroupManager uses the override method CreateInstanceGroupManagerFn or the real implementation.
+func (c *TestClient) CreateInstanceGroupManager(project, zone string, m *compute.InstanceGroupManager) error {
+	if c.CreateInstanceGroupManagerFn != nil {
+		return c.CreateInstanceGroupManagerFn(project, zone, m)
+	}
+	return c.client.CreateInstanceGroupManager(project, zone, m)
+}
+
+// DeleteInstanceGroupManager uses the override method DeleteInstanceGroupManagerFn or the real implementation.
+func (c *TestClient) DeleteInstanceGroupManager(project, zone, name string) error {
+	if c.DeleteInstanceGroupManagerFn != nil {
+		return c.DeleteInstanceGroupManagerFn(project, zone, name)
+	}
+	return c.client.DeleteInstanceGroupManager(project, zone, name)
+}
+
+// GetInstanceGroupManager uses the override method GetInstanceGroupManagerFn or the real implementation.
+func (c *TestClient) GetInstanceGroupManager(project, zone, name string) (*compute.InstanceGroupManager, error) {
+	if c.GetInstanceGroupManagerFn != nil {
+		return c.GetInstanceGroupManagerFn(project, zone, name)
+	}
+	return c.client.GetInstanceGroupManager(project, zone, name)
+}
+
+// ListInstanceGroupManagers uses the override method ListInstanceGroupManagersFn or the real implementation.
+func (c *TestClient) ListInstanceGroupManagers(project, zone string, opts ...ListCallOption) ([]*compute.InstanceGroupManager, error) {
+	if c.ListInstanceGroupManagersFn != nil {
+		return c.ListInstanceGroupManagersFn(project, zone, opts...)
+	}
+	return c.client.ListInstanceGroupManagers(project, zone, opts...)
+}
+
+// PatchInstanceGroupManager uses the override method PatchInstanceGroupManagerFn or the real implementation.
+func (c *TestClient) PatchInstanceGroupManager(project, zone, name string, m *compute.InstanceGroupManager) error {
+	if c.PatchInstanceGroupManagerFn != nil {
+		return c.PatchInstanceGroupManagerFn(project, zone, name, m)
+	}
+	return c.client.PatchInstanceGroupManager(project, zone, name, m)
+}
+
 // SetInstanceMetadata uses the override method SetInstancemetadataFn or the real implementation.
 func (c *TestClient) SetInstanceMetadata(project, zone, name string, md *compute.Metadata) error {
 	if c.SetInstanceMetadataFn != nil {
@@ -605,6 +889,46 @@ func (c *TestClient) SetCommonInstanceMetadata(project string, md *compute.Metad
 	return c.client.SetCommonInstanceMetadata(project, md)
 }
 
+// SetDiskLabels uses the override method SetDiskLabelsFn or the real implementation.
+func (c *TestClient) SetDiskLabels(project, zone, name string, req *compute.ZoneSetLabelsRequest) error {
+	if c.SetDiskLabelsFn != nil {
+		return c.SetDiskLabelsFn(project, zone, name, req)
+	}
+	return c.client.SetDiskLabels(project, zone, name, req)
+}
+
+// SetRegionDiskLabels uses the override method SetRegionDiskLabelsFn or the real implementation.
+func (c *TestClient) SetRegionDiskLabels(project, region, name string, req *compute.RegionSetLabelsRequest) error {
+	if c.SetRegionDiskLabelsFn != nil {
+		return c.SetRegionDiskLabelsFn(project, region, name, req)
+	}
+	return c.client.SetRegionDiskLabels(project, region, name, req)
+}
+
+// SetImageLabels uses the override method SetImageLabelsFn or the real implementation.
+func (c *TestClient) SetImageLabels(project, name string, req *compute.GlobalSetLabelsRequest) error {
+	if c.SetImageLabelsFn != nil {
+		return c.SetImageLabelsFn(project, name, req)
+	}
+	return c.client.SetImageLabels(project, name, req)
+}
+
+// SetInstanceLabels uses the override method SetInstanceLabelsFn or the real implementation.
+func (c *TestClient) SetInstanceLabels(project, zone, name string, req *compute.InstancesSetLabelsRequest) error {
+	if c.SetInstanceLabelsFn != nil {
+		return c.SetInstanceLabelsFn(project, zone, name, req)
+	}
+	return c.client.SetInstanceLabels(project, zone, name, req)
+}
+
+// SetSnapshotLabels uses the override method SetSnapshotLabelsFn or the real implementation.
+func (c *TestClient) SetSnapshotLabels(project, name string, req *compute.GlobalSetLabelsRequest) error {
+	if c.SetSnapshotLabelsFn != nil {
+		return c.SetSnapshotLabelsFn(project, name, req)
+	}
+	return c.client.SetSnapshotLabels(project, name, req)
+}
+
 // zoneOperationsWait uses the override method zoneOperationsWaitFn or the real implementation.
 func (c *TestClient) zoneOperationsWait(project, zone, name string) error {
 	if c.zoneOperationsWaitFn != nil {
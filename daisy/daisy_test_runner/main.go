@@ -37,6 +37,7 @@ import (
 
 	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
 	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy/vars"
 	"github.com/google/uuid"
 	"google.golang.org/api/compute/v1"
 )
@@ -57,6 +58,7 @@ var (
 	filter        = flag.String("filter", "", "test name filter")
 	outPath       = flag.String("out_path", "junit.xml", "junit xml path")
 	parallelCount = flag.Int("parallel_count", 0, "TestParallelCount")
+	varFlags      = vars.RegisterFlags(flag.CommandLine)
 
 	funcMap = map[string]interface{}{
 		"randItem": randItem,
@@ -156,12 +158,16 @@ func (l *logger) ReadSerialPortLogs() []string {
 
 func (l *logger) Flush() { return }
 
-func createTestCase(ctx context.Context, testLogger *logger, path, project, zone, oauthPath, ce string, varMap map[string]string) (*daisy.Workflow, error) {
+func createTestCase(ctx context.Context, testLogger *logger, path, project, zone, oauthPath, ce string, vs vars.Vars) (*daisy.Workflow, error) {
 	w, err := daisy.NewFromFile(path)
 	if err != nil {
 		return nil, err
 	}
-	for k, v := range varMap {
+	declared := func(name string) bool {
+		_, ok := w.Vars[name]
+		return ok
+	}
+	for k, v := range vs.MapFor(declared) {
 		w.AddVar(k, v)
 	}
 
@@ -190,7 +196,7 @@ func createTestCase(ctx context.Context, testLogger *logger, path, project, zone
 	return w, nil
 }
 
-func createTestSuite(ctx context.Context, path string, varMap map[string]string, regex *regexp.Regexp) (*TestSuite, error) {
+func createTestSuite(ctx context.Context, path string, vs vars.Vars, regex *regexp.Regexp) (*TestSuite, error) {
 	var t TestSuite
 
 	b, err := ioutil.ReadFile(path)
@@ -204,7 +210,7 @@ func createTestSuite(ctx context.Context, path string, varMap map[string]string,
 	}
 
 	var buf bytes.Buffer
-	if err := templ.Execute(&buf, varMap); err != nil {
+	if err := templ.Execute(&buf, vs.Map()); err != nil {
 		return nil, fmt.Errorf("%s: %v", path, err)
 	}
 
@@ -264,7 +270,7 @@ func createTestSuite(ctx context.Context, path string, varMap map[string]string,
 
 		wfPath := filepath.Join(filepath.Dir(path), test.Path)
 		for k, v := range test.Vars {
-			varMap[k] = v
+			vs.Set(k, v, "test "+name)
 		}
 
 		zone := t.Zone
@@ -282,7 +288,7 @@ func createTestSuite(ctx context.Context, path string, varMap map[string]string,
 
 		rand.Seed(time.Now().UnixNano())
 		test.logger = &logger{}
-		w, err := createTestCase(ctx, test.logger, wfPath, t.Projects[rand.Intn(len(t.Projects))], zone, oauthPath, computeEndpoint, varMap)
+		w, err := createTestCase(ctx, test.logger, wfPath, t.Projects[rand.Intn(len(t.Projects))], zone, oauthPath, computeEndpoint, vs)
 		if err != nil {
 			return nil, err
 		}
@@ -294,7 +300,7 @@ func createTestSuite(ctx context.Context, path string, varMap map[string]string,
 
 const (
 	flgDefValue   = "flag generated for workflow variable"
-	varFlagPrefix = "var:"
+	varFlagPrefix = vars.FlagPrefix
 )
 
 func addFlags(args []string) {
@@ -606,12 +612,15 @@ func main() {
 	addFlags(os.Args[1:])
 	flag.Parse()
 
-	varMap := map[string]string{}
-	flag.Visit(func(flg *flag.Flag) {
-		if strings.HasPrefix(flg.Name, varFlagPrefix) {
-			varMap[strings.TrimPrefix(flg.Name, varFlagPrefix)] = flg.Value.String()
-		}
-	})
+	vs, err := varFlags.Load(os.Environ())
+	if err != nil {
+		log.Fatalln(err)
+	}
+	vs.AddFlags(flag.CommandLine)
+	if *print && len(vs) > 0 {
+		fmt.Println("[TestRunner] Workflow variables and where they were set:")
+		vs.Report(os.Stdout)
+	}
 
 	if len(flag.Args()) == 0 {
 		fmt.Println("Not enough args, first arg needs to be the path to a test template.")
@@ -629,7 +638,7 @@ func main() {
 
 	ctx := context.Background()
 
-	ts, err := createTestSuite(ctx, flag.Arg(0), varMap, regex)
+	ts, err := createTestSuite(ctx, flag.Arg(0), vs, regex)
 	if err != nil {
 		log.Fatalln("test case creation error:", err)
 	}
@@ -0,0 +1,464 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DiffKind is the kind of a difference between two workflows.
+type DiffKind string
+
+// The kinds of differences.
+const (
+	DiffAdded   DiffKind = "+"
+	DiffRemoved DiffKind = "-"
+	DiffChanged DiffKind = "~"
+)
+
+// WorkflowDiff are the differences between two expanded workflows.
+type WorkflowDiff struct {
+	// Fields are the differences of the workflow fields other than its steps
+	// and dependencies.
+	Fields []FieldDiff
+	// Steps are the added, removed and changed steps, including the steps of
+	// included workflows and subworkflows, named <step>.<nested step>.
+	Steps []StepDiff
+	// Dependencies are the added and removed dependencies.
+	Dependencies []DependencyDiff
+	// Resources are the added and removed resources created by the steps.
+	Resources []ResourceDiff
+}
+
+// FieldDiff is a difference of a field, at a path like
+// CreateDisks[disk-name-${ID}].sizeGb. Old is unset for added fields and New
+// for removed ones.
+type FieldDiff struct {
+	Kind     DiffKind
+	Path     string
+	Old, New interface{}
+}
+
+// StepDiff is an added, removed or changed step. Fields are the differences
+// of a changed step.
+type StepDiff struct {
+	Kind   DiffKind
+	Name   string
+	Fields []FieldDiff
+}
+
+// DependencyDiff is an added or removed dependency of Step on Dependency.
+type DependencyDiff struct {
+	Kind       DiffKind
+	Step       string
+	Dependency string
+}
+
+// ResourceDiff is an added or removed resource, by the name it is created
+// with.
+type ResourceDiff struct {
+	Kind DiffKind
+	Type string
+	Name string
+}
+
+// Empty reports whether the workflows are the same.
+func (d *WorkflowDiff) Empty() bool {
+	return len(d.Fields) == 0 && len(d.Steps) == 0 && len(d.Dependencies) == 0 && len(d.Resources) == 0
+}
+
+// diffIDPlaceholder replaces the generated IDs of workflows, which would
+// otherwise differ in every name derived from them.
+const diffIDPlaceholder = "${ID}"
+
+// DiffWorkflows populates a and b like Run does, without API calls, and
+// returns the differences from a to b. Included workflows and subworkflows are
+// expanded and variables substituted, so the differences are the effect of
+// the change on what would run. Workflows reading ${SOURCE:...} or included
+// files from GCS can't be compared.
+func DiffWorkflows(ctx context.Context, a, b *Workflow) (*WorkflowDiff, error) {
+	now := time.Now()
+	var expanded []interface{}
+	for _, w := range []*Workflow{a, b} {
+		v, err := w.expand(ctx, now)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, v)
+	}
+
+	var flat [2]*flatWorkflow
+	for i, v := range expanded {
+		flat[i] = &flatWorkflow{steps: map[string]map[string]interface{}{}, deps: map[[2]string]bool{}, resources: map[[2]string]bool{}}
+		flat[i].fields = flat[i].add(v.(map[string]interface{}), "")
+	}
+	return flat[0].diff(flat[1]), nil
+}
+
+// expand populates w offline and returns it as decoded JSON, with the IDs of
+// w and its subworkflows replaced by diffIDPlaceholder.
+func (w *Workflow) expand(ctx context.Context, now time.Time) (interface{}, DError) {
+	w.populateTime = now
+	if w.GCSPath == "" {
+		// The bucket Run would use, without checking it exists.
+		w.GCSPath = "gs://" + strings.Replace(w.Project, ":", "-", -1) + "-daisy-bkt"
+	}
+	w.externalLogging = false
+	w.Logger = newDaisyLogger(false)
+	if err := w.populate(ctx); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		return nil, newErr("failed to marshal workflow", err)
+	}
+	var ids []string
+	for _, id := range w.ids() {
+		ids = append(ids, id, diffIDPlaceholder)
+	}
+	data = []byte(strings.NewReplacer(ids...).Replace(string(data)))
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, newErr("failed to unmarshal workflow", err)
+	}
+	return v, nil
+}
+
+// ids returns the IDs of w and its subworkflows.
+func (w *Workflow) ids() []string {
+	ids := []string{w.id}
+	for _, s := range w.Steps {
+		if s.IncludeWorkflow != nil && s.IncludeWorkflow.Workflow != nil {
+			ids = append(ids, s.IncludeWorkflow.Workflow.ids()...)
+		}
+		if s.SubWorkflow != nil && s.SubWorkflow.Workflow != nil {
+			ids = append(ids, s.SubWorkflow.Workflow.ids()...)
+		}
+	}
+	return ids
+}
+
+// flatWorkflow holds the steps of a workflow and its nested workflows by
+// their prefixed names.
+type flatWorkflow struct {
+	fields    map[string]interface{}
+	steps     map[string]map[string]interface{}
+	deps      map[[2]string]bool
+	resources map[[2]string]bool
+}
+
+// add adds the steps and dependencies of the workflow w, naming its steps
+// prefix<step>, and returns its other fields.
+func (f *flatWorkflow) add(w map[string]interface{}, prefix string) map[string]interface{} {
+	steps, _ := w["Steps"].(map[string]interface{})
+	for name, v := range steps {
+		s, _ := v.(map[string]interface{})
+		if s == nil {
+			s = map[string]interface{}{}
+		}
+		for _, nested := range []string{"IncludeWorkflow", "SubWorkflow"} {
+			if n, ok := s[nested].(map[string]interface{}); ok {
+				if nw, ok := n["Workflow"].(map[string]interface{}); ok {
+					n["Workflow"] = f.add(nw, prefix+name+".")
+				}
+			}
+		}
+		for key, v := range s {
+			if strings.HasPrefix(key, "Create") {
+				f.addResources(key, v)
+			}
+		}
+		f.steps[prefix+name] = s
+	}
+	deps, _ := w["Dependencies"].(map[string]interface{})
+	for name, v := range deps {
+		ds, _ := v.([]interface{})
+		for _, d := range ds {
+			f.deps[[2]string{prefix + name, prefix + fmt.Sprint(d)}] = true
+		}
+	}
+
+	fields := map[string]interface{}{}
+	for k, v := range w {
+		if k != "Steps" && k != "Dependencies" {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// addResources adds the resources of a Create step field, typed by the field
+// name, e.g. CreateFirewallRules creates firewallRule resources.
+func (f *flatWorkflow) addResources(key string, v interface{}) {
+	t := strings.TrimPrefix(key, "Create")
+	if strings.HasSuffix(t, "sses") {
+		t = strings.TrimSuffix(t, "es")
+	} else {
+		t = strings.TrimSuffix(t, "s")
+	}
+	if t == "" {
+		return
+	}
+	t = strings.ToLower(t[:1]) + t[1:]
+	rs, _ := v.([]interface{})
+	for _, r := range rs {
+		if r, ok := r.(map[string]interface{}); ok {
+			if name, ok := itemName(r); ok {
+				f.resources[[2]string{t, name}] = true
+			}
+		}
+	}
+}
+
+func (f *flatWorkflow) diff(o *flatWorkflow) *WorkflowDiff {
+	d := &WorkflowDiff{Fields: diffValues("", f.fields, o.fields)}
+
+	for _, name := range unionKeys(f.steps, o.steps) {
+		s, inF := f.steps[name]
+		other, inO := o.steps[name]
+		switch {
+		case !inO:
+			d.Steps = append(d.Steps, StepDiff{Kind: DiffRemoved, Name: name})
+		case !inF:
+			d.Steps = append(d.Steps, StepDiff{Kind: DiffAdded, Name: name})
+		default:
+			if fds := diffValues("", s, other); len(fds) > 0 {
+				d.Steps = append(d.Steps, StepDiff{Kind: DiffChanged, Name: name, Fields: fds})
+			}
+		}
+	}
+
+	for _, k := range diffSets(f.deps, o.deps) {
+		d.Dependencies = append(d.Dependencies, DependencyDiff{Kind: k.kind, Step: k.key[0], Dependency: k.key[1]})
+	}
+	for _, k := range diffSets(f.resources, o.resources) {
+		d.Resources = append(d.Resources, ResourceDiff{Kind: k.kind, Type: k.key[0], Name: k.key[1]})
+	}
+	return d
+}
+
+type setDiff struct {
+	kind DiffKind
+	key  [2]string
+}
+
+// diffSets returns the keys removed from a and added to b, ordered by key.
+func diffSets(a, b map[[2]string]bool) []setDiff {
+	var ds []setDiff
+	for k := range a {
+		if !b[k] {
+			ds = append(ds, setDiff{DiffRemoved, k})
+		}
+	}
+	for k := range b {
+		if !a[k] {
+			ds = append(ds, setDiff{DiffAdded, k})
+		}
+	}
+	sort.Slice(ds, func(i, j int) bool {
+		if ds[i].key != ds[j].key {
+			return ds[i].key[0] < ds[j].key[0] || ds[i].key[0] == ds[j].key[0] && ds[i].key[1] < ds[j].key[1]
+		}
+		return ds[i].kind == DiffRemoved
+	})
+	return ds
+}
+
+func unionKeys(a, b map[string]map[string]interface{}) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffValues returns the differences between the decoded JSON values a and b.
+// Lists of objects with unique names are compared by name, other lists by
+// index.
+func diffValues(path string, a, b interface{}) []FieldDiff {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		var keys []string
+		for k := range a {
+			keys = append(keys, k)
+		}
+		for k := range b {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var ds []FieldDiff
+		for _, k := range keys {
+			av, inA := a[k]
+			bv, inB := b[k]
+			switch {
+			case !inB:
+				ds = append(ds, FieldDiff{Kind: DiffRemoved, Path: join(k), Old: av})
+			case !inA:
+				ds = append(ds, FieldDiff{Kind: DiffAdded, Path: join(k), New: bv})
+			default:
+				ds = append(ds, diffValues(join(k), av, bv)...)
+			}
+		}
+		return ds
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		an, bn := namedItems(a), namedItems(b)
+		if an != nil && bn != nil {
+			var ds []FieldDiff
+			for _, name := range unionKeys(an, bn) {
+				p := fmt.Sprintf("%s[%s]", path, name)
+				av, inA := an[name]
+				bv, inB := bn[name]
+				switch {
+				case !inB:
+					ds = append(ds, FieldDiff{Kind: DiffRemoved, Path: p, Old: av})
+				case !inA:
+					ds = append(ds, FieldDiff{Kind: DiffAdded, Path: p, New: bv})
+				default:
+					ds = append(ds, diffValues(p, av, bv)...)
+				}
+			}
+			return ds
+		}
+		var ds []FieldDiff
+		for i := 0; i < len(a) || i < len(b); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(b):
+				ds = append(ds, FieldDiff{Kind: DiffRemoved, Path: p, Old: a[i]})
+			case i >= len(a):
+				ds = append(ds, FieldDiff{Kind: DiffAdded, Path: p, New: b[i]})
+			default:
+				ds = append(ds, diffValues(p, a[i], b[i])...)
+			}
+		}
+		return ds
+	default:
+		if a == b {
+			return nil
+		}
+	}
+	return []FieldDiff{{Kind: DiffChanged, Path: path, Old: a, New: b}}
+}
+
+// namedItems returns the objects of l by their Name, or nil if they are not
+// all objects with a unique name.
+func namedItems(l []interface{}) map[string]map[string]interface{} {
+	items := map[string]map[string]interface{}{}
+	for _, v := range l {
+		o, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		name, ok := itemName(o)
+		if _, dup := items[name]; !ok || dup {
+			return nil
+		}
+		items[name] = o
+	}
+	return items
+}
+
+// itemName returns the name of a resource, the generated one once populated.
+func itemName(o map[string]interface{}) (string, bool) {
+	if name, ok := o["name"].(string); ok {
+		return name, true
+	}
+	name, ok := o["Name"].(string)
+	return name, ok
+}
+
+// maxDiffValueLen truncates values in Write, such as inlined scripts.
+const maxDiffValueLen = 120
+
+// Write writes the differences as text, in sections for the workflow fields,
+// steps, dependencies and resources.
+func (d *WorkflowDiff) Write(w io.Writer) {
+	if len(d.Fields) > 0 {
+		fmt.Fprintln(w, "Workflow fields:")
+		writeFieldDiffs(w, "  ", d.Fields)
+	}
+	if len(d.Steps) > 0 {
+		fmt.Fprintln(w, "Steps:")
+		for _, s := range d.Steps {
+			fmt.Fprintf(w, "  %s %s\n", s.Kind, s.Name)
+			writeFieldDiffs(w, "      ", s.Fields)
+		}
+	}
+	if len(d.Dependencies) > 0 {
+		fmt.Fprintln(w, "Dependencies:")
+		for _, dep := range d.Dependencies {
+			fmt.Fprintf(w, "  %s %s -> %s\n", dep.Kind, dep.Step, dep.Dependency)
+		}
+	}
+	if len(d.Resources) > 0 {
+		fmt.Fprintln(w, "Resources:")
+		for _, r := range d.Resources {
+			fmt.Fprintf(w, "  %s %s %s\n", r.Kind, r.Type, r.Name)
+		}
+	}
+}
+
+func writeFieldDiffs(w io.Writer, indent string, fds []FieldDiff) {
+	for _, fd := range fds {
+		switch fd.Kind {
+		case DiffAdded:
+			fmt.Fprintf(w, "%s+ %s: %s\n", indent, fd.Path, formatDiffValue(fd.New))
+		case DiffRemoved:
+			fmt.Fprintf(w, "%s- %s: %s\n", indent, fd.Path, formatDiffValue(fd.Old))
+		default:
+			fmt.Fprintf(w, "%s~ %s: %s -> %s\n", indent, fd.Path, formatDiffValue(fd.Old), formatDiffValue(fd.New))
+		}
+	}
+}
+
+func formatDiffValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if s := string(b); len(s) > maxDiffValueLen {
+		return fmt.Sprintf("%s... (%d bytes)", s[:maxDiffValueLen], len(s))
+	}
+	return string(b)
+}
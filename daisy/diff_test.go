@@ -0,0 +1,102 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffWorkflows(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daisy-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) string {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write("inc.wf.json", `{"Vars": {"size": {"Required": true}}, "Steps": {"disks": {"CreateDisks": [{"Name": "inc-disk", "SizeGb": "${size}"}]}}}`)
+	old := write("old.wf.json", `{
+  "Name": "wf", "Project": "p", "Zone": "z",
+  "Vars": {"size": "10"},
+  "Steps": {
+    "create": {"CreateDisks": [{"Name": "a", "SizeGb": "${size}"}, {"Name": "b"}]},
+    "include": {"IncludeWorkflow": {"Path": "inc.wf.json", "Vars": {"size": "${size}"}}},
+    "delete": {"DeleteResources": {"Disks": ["a"]}}
+  },
+  "Dependencies": {"include": ["create"], "delete": ["include"]}
+}`)
+	new := write("new.wf.json", `{
+  "Name": "wf", "Project": "p", "Zone": "z", "DefaultTimeout": "20m",
+  "Vars": {"size": "20"},
+  "Steps": {
+    "create": {"CreateDisks": [{"Name": "c"}, {"Name": "a", "SizeGb": "${size}"}]},
+    "include": {"IncludeWorkflow": {"Path": "inc.wf.json", "Vars": {"size": "${size}"}}},
+    "stop": {"StopInstances": {"Instances": ["i"]}}
+  },
+  "Dependencies": {"include": ["create"], "stop": ["include"]}
+}`)
+	read := func(p string) *Workflow {
+		w, err := NewFromFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	d, err := DiffWorkflows(context.Background(), read(old), read(old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, d.Empty(), "want no differences, got %+v", d)
+
+	d, err = DiffWorkflows(context.Background(), read(old), read(new))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	d.Write(&b)
+	got := b.String()
+	for _, want := range []string{
+		"Workflow fields:\n  ~ DefaultTimeout: \"10m\" -> \"20m\"\n",
+		`  ~ Vars.size.Value: "10" -> "20"`,
+		"  ~ create\n",
+		`      - CreateDisks[b-wf-${ID}]: {`,
+		`      + CreateDisks[c-wf-${ID}]: {`,
+		`      ~ CreateDisks[a-wf-${ID}].sizeGb: "10" -> "20"`,
+		"  - delete\n",
+		"  ~ include.disks\n",
+		`      ~ CreateDisks[inc-disk-wf-include-${ID}].sizeGb: "10" -> "20"`,
+		"  + stop\n",
+		"Dependencies:\n  - delete -> include\n  + stop -> include\n",
+		"Resources:\n  - disk b-wf-${ID}\n  + disk c-wf-${ID}\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want diff containing %q, got\n%s", want, got)
+		}
+	}
+}
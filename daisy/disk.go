@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -29,7 +30,7 @@ import (
 )
 
 var (
-	diskURLRgx       = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/disks/(?P<disk>%[2]s)(/resize)?$`, projectRgxStr, rfc1035))
+	diskURLRgx       = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?(zones/(?P<zone>%[2]s)|regions/(?P<region>%[2]s))/disks/(?P<disk>%[2]s)(/resize)?$`, projectRgxStr, rfc1035))
 	deviceNameURLRgx = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/devices/(?P<disk>%[2]s)$`, projectRgxStr, rfc1035))
 )
 
@@ -41,6 +42,33 @@ func (w *Workflow) diskExists(project, zone, disk string) (bool, DError) {
 	}, project, zone, disk)
 }
 
+// regionDiskExists should only be used during validation for existing GCE
+// regional disks and should not be relied or populated for daisy created
+// resources.
+func (w *Workflow) regionDiskExists(project, region, disk string) (bool, DError) {
+	return w.regionDiskCache.resourceExists(func(project, region string, opts ...daisyCompute.ListCallOption) (interface{}, error) {
+		return w.ComputeClient.ListRegionDisks(project, region)
+	}, project, region, disk)
+}
+
+// diskInZone reports whether a disk, as parsed by diskURLRgx, can be used by
+// an instance in zone. Zonal disks must be in the same zone, regional disks
+// in the zone's region.
+func diskInZone(disk map[string]string, zone string) bool {
+	if disk["region"] != "" {
+		return disk["region"] == getRegionFromZone(zone)
+	}
+	return disk["zone"] == zone
+}
+
+// diskLocation describes where a disk, as parsed by diskURLRgx, lives.
+func diskLocation(disk map[string]string) string {
+	if disk["region"] != "" {
+		return fmt.Sprintf("region %q", disk["region"])
+	}
+	return fmt.Sprintf("zone %q", disk["zone"])
+}
+
 // isDiskAttached should only be used during validation for existing attached GCE disks
 // and should not be relied or populated for daisy created resources.
 func isDiskAttached(client daisyCompute.Client, deviceName, project, zone, instance string) (bool, DError) {
@@ -77,6 +105,12 @@ type Disk struct {
 	FallbackToPdStandard bool `json:"fallbackToPdStandard,omitempty"`
 }
 
+// isRegional reports whether d is a regional persistent disk, that is
+// whether it has ReplicaZones or a Region.
+func (d *Disk) isRegional() bool {
+	return len(d.ReplicaZones) > 0 || d.Region != ""
+}
+
 // MarshalJSON is a hacky workaround to prevent Disk from using compute.Disk's implementation.
 func (d *Disk) MarshalJSON() ([]byte, error) {
 	return json.Marshal(*d)
@@ -84,7 +118,23 @@ func (d *Disk) MarshalJSON() ([]byte, error) {
 
 func (d *Disk) populate(ctx context.Context, s *Step) DError {
 	var errs DError
-	d.Name, d.Zone, errs = d.Resource.populateWithZone(ctx, s, d.Name, d.Zone)
+	if d.isRegional() {
+		region := d.Region
+		if region == "" {
+			region = getRegionFromZone(path.Base(d.ReplicaZones[0]))
+		}
+		d.Name, d.Region, errs = d.Resource.populateWithRegion(ctx, s, d.Name, region)
+		d.Zone = ""
+		for i, z := range d.ReplicaZones {
+			if strings.Contains(z, "/") {
+				d.ReplicaZones[i] = extendPartialURL(z, d.Project)
+			} else {
+				d.ReplicaZones[i] = fmt.Sprintf("projects/%s/zones/%s", d.Project, z)
+			}
+		}
+	} else {
+		d.Name, d.Zone, errs = d.Resource.populateWithZone(ctx, s, d.Name, d.Zone)
+	}
 
 	d.Description = strOr(d.Description, fmt.Sprintf("Disk created by Daisy in workflow %q on behalf of %s.", s.w.Name, s.w.username))
 	if d.SizeGb != "" {
@@ -108,23 +158,50 @@ func (d *Disk) populate(ctx context.Context, s *Step) DError {
 	if imageURLRgx.MatchString(d.SourceImage) {
 		d.SourceImage = extendPartialURL(d.SourceImage, d.Project)
 	}
+	location := "zones/" + d.Zone
+	if d.isRegional() {
+		location = "regions/" + d.Region
+	}
 	if d.Type == "" {
-		d.Type = fmt.Sprintf("projects/%s/zones/%s/diskTypes/pd-standard", d.Project, d.Zone)
-	} else if diskTypeURLRgx.MatchString(d.Type) {
+		d.Type = fmt.Sprintf("projects/%s/%s/diskTypes/pd-standard", d.Project, location)
+	} else if diskTypeURLRgx.MatchString(d.Type) || regionDiskTypeURLRgx.MatchString(d.Type) {
 		d.Type = extendPartialURL(d.Type, d.Project)
 	} else {
-		d.Type = fmt.Sprintf("projects/%s/zones/%s/diskTypes/%s", d.Project, d.Zone, d.Type)
+		d.Type = fmt.Sprintf("projects/%s/%s/diskTypes/%s", d.Project, location, d.Type)
 	}
-	d.link = fmt.Sprintf("projects/%s/zones/%s/disks/%s", d.Project, d.Zone, d.Name)
+	d.link = fmt.Sprintf("projects/%s/%s/disks/%s", d.Project, location, d.Name)
 	return errs
 }
 
+// create creates d as a zonal or regional disk.
+func (d *Disk) create(client daisyCompute.Client) error {
+	if d.isRegional() {
+		return client.CreateRegionDisk(d.Project, d.Region, &d.Disk)
+	}
+	return client.CreateDisk(d.Project, d.Zone, &d.Disk)
+}
+
 func (d *Disk) validate(ctx context.Context, s *Step) DError {
 	pre := fmt.Sprintf("cannot create disk %q", d.daisyName)
-	errs := d.Resource.validateWithZone(ctx, s, d.Zone, pre)
-
-	if !diskTypeURLRgx.MatchString(d.Type) {
-		errs = addErrs(errs, Errf("%s: bad disk type: %q", pre, d.Type))
+	var errs DError
+	if d.isRegional() {
+		errs = d.Resource.validateWithRegion(ctx, s, d.Region, pre)
+		if len(d.ReplicaZones) != 2 {
+			errs = addErrs(errs, Errf("%s: regional disks need exactly 2 ReplicaZones, got %d", pre, len(d.ReplicaZones)))
+		}
+		for _, z := range d.ReplicaZones {
+			if getRegionFromZone(path.Base(z)) != d.Region {
+				errs = addErrs(errs, Errf("%s: replica zone %q is not in region %q", pre, z, d.Region))
+			}
+		}
+		if !regionDiskTypeURLRgx.MatchString(d.Type) {
+			errs = addErrs(errs, Errf("%s: bad disk type: %q", pre, d.Type))
+		}
+	} else {
+		errs = d.Resource.validateWithZone(ctx, s, d.Zone, pre)
+		if !diskTypeURLRgx.MatchString(d.Type) {
+			errs = addErrs(errs, Errf("%s: bad disk type: %q", pre, d.Type))
+		}
 	}
 
 	if d.SourceImage != "" {
@@ -168,9 +245,14 @@ func (dr *diskRegistry) init() {
 	dr.attachments = map[string]map[string]*diskAttachment{}
 }
 
-func (dr *diskRegistry) deleteFn(res *Resource) DError {
+func (dr *diskRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(diskURLRgx, res.link)
-	err := dr.w.ComputeClient.DeleteDisk(m["project"], m["zone"], m["disk"])
+	var err error
+	if m["region"] != "" {
+		err = daisyCompute.WithContext(ctx, dr.w.ComputeClient).DeleteRegionDisk(m["project"], m["region"], m["disk"])
+	} else {
+		err = daisyCompute.WithContext(ctx, dr.w.ComputeClient).DeleteDisk(m["project"], m["zone"], m["disk"])
+	}
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete disk", err)
 	}
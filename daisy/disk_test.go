@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -75,6 +76,23 @@ func TestDiskPopulate(t *testing.T) {
 			nil,
 			true,
 		},
+		{
+			"regional disk case",
+			&Disk{Disk: compute.Disk{Name: name, ReplicaZones: []string{w.Zone, "zones/test-zonf"}}},
+			&Disk{Disk: compute.Disk{
+				Name:         genName,
+				Type:         fmt.Sprintf("projects/%s/regions/%s/diskTypes/pd-standard", w.Project, testRegion),
+				Region:       testRegion,
+				ReplicaZones: []string{fmt.Sprintf("projects/%s/zones/%s", w.Project, w.Zone), fmt.Sprintf("projects/%s/zones/test-zonf", w.Project)},
+			}},
+			false,
+		},
+		{
+			"regional disk Type case",
+			&Disk{Disk: compute.Disk{Name: name, Region: testRegion, Type: "pd-ssd"}},
+			&Disk{Disk: compute.Disk{Name: genName, Type: fmt.Sprintf("projects/%s/regions/%s/diskTypes/pd-ssd", w.Project, testRegion), Region: testRegion}},
+			false,
+		},
 		{
 			"bad SizeGb case",
 			&Disk{Disk: compute.Disk{Name: "foo"}, SizeGb: "ten"},
@@ -444,3 +462,80 @@ func TestDiskValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestDiskValidateRegional(t *testing.T) {
+	w := testWorkflow()
+	w.ComputeClient.(*daisyCompute.TestClient).ListRegionsFn = func(_ string, _ ...daisyCompute.ListCallOption) ([]*compute.Region, error) {
+		return []*compute.Region{{Name: testRegion}}, nil
+	}
+	s, err := w.NewStep("s")
+	if err != nil {
+		t.Fatalf("test set up error: %v", err)
+	}
+
+	ty := fmt.Sprintf("projects/%s/regions/%s/diskTypes/pd-standard", w.Project, testRegion)
+	zone := func(z string) string { return fmt.Sprintf("projects/%s/zones/%s", w.Project, z) }
+	tests := []struct {
+		desc      string
+		d         *Disk
+		shouldErr bool
+	}{
+		{
+			"normal case",
+			&Disk{Disk: compute.Disk{Name: "d1", SizeGb: 1, Type: ty, Region: testRegion, ReplicaZones: []string{zone(testZone), zone("test-zonf")}}},
+			false,
+		},
+		{
+			"one replica zone case",
+			&Disk{Disk: compute.Disk{Name: "d2", SizeGb: 1, Type: ty, Region: testRegion, ReplicaZones: []string{zone(testZone)}}},
+			true,
+		},
+		{
+			"replica zone in other region case",
+			&Disk{Disk: compute.Disk{Name: "d3", SizeGb: 1, Type: ty, Region: testRegion, ReplicaZones: []string{zone(testZone), zone("other-zone")}}},
+			true,
+		},
+		{
+			"zonal type case",
+			&Disk{Disk: compute.Disk{Name: "d4", SizeGb: 1, Type: fmt.Sprintf("projects/%s/zones/%s/diskTypes/pd-standard", w.Project, testZone), Region: testRegion, ReplicaZones: []string{zone(testZone), zone("test-zonf")}}},
+			true,
+		},
+		{
+			"region dne case",
+			&Disk{Disk: compute.Disk{Name: "d5", SizeGb: 1, Type: ty, Region: DNE, ReplicaZones: []string{zone(testZone), zone("test-zonf")}}},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.d.daisyName = tt.d.Name
+		tt.d.RealName = tt.d.Name
+		tt.d.link = fmt.Sprintf("projects/%s/regions/%s/disks/%s", w.Project, tt.d.Region, tt.d.Name)
+		tt.d.Project = w.Project
+
+		s.CreateDisks = &CreateDisks{tt.d}
+		err := s.validate(context.Background())
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: did not return an error as expected", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestDiskInZone(t *testing.T) {
+	tests := []struct {
+		disk, zone string
+		want       bool
+	}{
+		{"projects/p/zones/us-central1-a/disks/d", "us-central1-a", true},
+		{"projects/p/zones/us-central1-a/disks/d", "us-central1-b", false},
+		{"projects/p/regions/us-central1/disks/d", "us-central1-b", true},
+		{"projects/p/regions/us-central1/disks/d", "us-east1-b", false},
+	}
+	for _, tt := range tests {
+		if got := diskInZone(NamedSubexp(diskURLRgx, tt.disk), tt.zone); got != tt.want {
+			t.Errorf("diskInZone(%q, %q) = %v, want %v", tt.disk, tt.zone, got, tt.want)
+		}
+	}
+}
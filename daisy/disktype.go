@@ -20,3 +20,5 @@ import (
 )
 
 var diskTypeURLRgx = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/diskTypes/(?P<disktype>%[2]s)$`, projectRgxStr, rfc1035))
+
+var regionDiskTypeURLRgx = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?regions/(?P<region>%[2]s)/diskTypes/(?P<disktype>%[2]s)$`, projectRgxStr, rfc1035))
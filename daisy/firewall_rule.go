@@ -91,9 +91,9 @@ func newFirewallRuleRegistry(w *Workflow) *firewallRuleRegistry {
 	return frr
 }
 
-func (frr *firewallRuleRegistry) deleteFn(res *Resource) DError {
+func (frr *firewallRuleRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(firewallRuleURLRegex, res.link)
-	err := frr.w.ComputeClient.DeleteFirewallRule(m["project"], m["firewallRule"])
+	err := daisyCompute.WithContext(ctx, frr.w.ComputeClient).DeleteFirewallRule(m["project"], m["firewallRule"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete firewall", err)
 	}
@@ -99,9 +99,9 @@ func newForwardingRuleRegistry(w *Workflow) *forwardingRuleRegistry {
 	return tir
 }
 
-func (tir *forwardingRuleRegistry) deleteFn(res *Resource) DError {
+func (tir *forwardingRuleRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(forwardingRuleURLRegex, res.link)
-	err := tir.w.ComputeClient.DeleteForwardingRule(m["project"], m["region"], m["forwardingRule"])
+	err := daisyCompute.WithContext(ctx, tir.w.ComputeClient).DeleteForwardingRule(m["project"], m["region"], m["forwardingRule"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete forwarding rule", err)
 	}
@@ -476,9 +476,9 @@ func newImageRegistry(w *Workflow) *imageRegistry {
 	return ir
 }
 
-func (ir *imageRegistry) deleteFn(res *Resource) DError {
+func (ir *imageRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(imageURLRgx, res.link)
-	err := ir.w.ComputeClient.DeleteImage(m["project"], m["image"])
+	err := daisyCompute.WithContext(ctx, ir.w.ComputeClient).DeleteImage(m["project"], m["image"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete image", err)
 	}
@@ -728,8 +728,8 @@ func (ib *InstanceBase) validateDiskSource(diskSource string, ii InstanceInterfa
 	if result["project"] != ib.Project {
 		errs = addErrs(errs, Errf("cannot create instance in project %q with disk in project %q: %q", ib.Project, result["project"], diskSource))
 	}
-	if result["zone"] != ii.getZone() {
-		errs = addErrs(errs, Errf("cannot create instance in project %q with disk in zone %q: %q", ii.getZone(), result["zone"], diskSource))
+	if !diskInZone(result, ii.getZone()) {
+		errs = addErrs(errs, Errf("cannot create instance in zone %q with disk in %s: %q", ii.getZone(), diskLocation(result), diskSource))
 	}
 	return errs
 }
@@ -816,10 +816,11 @@ func newInstanceRegistry(w *Workflow) *instanceRegistry {
 // SleepFn function is mocked on testing.
 var SleepFn = time.Sleep
 
-func (ir *instanceRegistry) deleteFn(res *Resource) DError {
+func (ir *instanceRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(instanceURLRgx, res.link)
+	client := daisyCompute.WithContext(ctx, ir.w.ComputeClient)
 	for i := 1; i < 4; i++ {
-		if _, err := ir.w.ComputeClient.GetInstance(m["project"], m["zone"], m["instance"]); err != nil {
+		if _, err := client.GetInstance(m["project"], m["zone"], m["instance"]); err != nil {
 			// Can't remove an instance that was not even yet created!
 			// However as the command was already submitted, wait.
 			SleepFn((time.Duration(rand.Intn(1000))*time.Millisecond + 1*time.Second) * time.Duration(i))
@@ -827,31 +828,71 @@ func (ir *instanceRegistry) deleteFn(res *Resource) DError {
 		}
 	}
 	// Proceed to instance deletion
-	err := ir.w.ComputeClient.DeleteInstance(m["project"], m["zone"], m["instance"])
+	err := client.DeleteInstance(m["project"], m["zone"], m["instance"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete instance", err)
 	}
 	return newErr("failed to delete instance", err)
 }
 
-func (ir *instanceRegistry) startFn(res *Resource) DError {
+func (ir *instanceRegistry) startFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(instanceURLRgx, res.link)
-	err := ir.w.ComputeClient.StartInstance(m["project"], m["zone"], m["instance"])
+	err := daisyCompute.WithContext(ctx, ir.w.ComputeClient).StartInstance(m["project"], m["zone"], m["instance"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to start instance", err)
 	}
 	return newErr("failed to start instance", err)
 }
 
-func (ir *instanceRegistry) stopFn(res *Resource) DError {
+func (ir *instanceRegistry) stopFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(instanceURLRgx, res.link)
-	err := ir.w.ComputeClient.StopInstance(m["project"], m["zone"], m["instance"])
+	err := daisyCompute.WithContext(ctx, ir.w.ComputeClient).StopInstance(m["project"], m["zone"], m["instance"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to stop instance", err)
 	}
 	return newErr("failed to stop instance", err)
 }
 
+// suspend suspends an instance, it can't be suspended again until it's
+// resumed.
+func (ir *instanceRegistry) suspend(ctx context.Context, name string) DError {
+	res, ok := ir.get(name)
+	if !ok {
+		return Errf("cannot suspend instance %q; does not exist in registry", name)
+	}
+	if res.suspendedByWf {
+		return Errf("cannot suspend %q; already suspended", name)
+	}
+
+	m := NamedSubexp(instanceURLRgx, res.link)
+	err := daisyCompute.WithContext(ctx, ir.w.ComputeClient).SuspendInstance(m["project"], m["zone"], m["instance"])
+	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+		return typedErr(resourceDNEError, "failed to suspend instance", err)
+	} else if err != nil {
+		return newErr("failed to suspend instance", err)
+	}
+	res.suspendedByWf = true
+	return nil
+}
+
+// resume resumes a suspended instance.
+func (ir *instanceRegistry) resume(ctx context.Context, name string) DError {
+	res, ok := ir.get(name)
+	if !ok {
+		return Errf("cannot resume instance %q; does not exist in registry", name)
+	}
+
+	m := NamedSubexp(instanceURLRgx, res.link)
+	err := daisyCompute.WithContext(ctx, ir.w.ComputeClient).ResumeInstance(m["project"], m["zone"], m["instance"])
+	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+		return typedErr(resourceDNEError, "failed to resume instance", err)
+	} else if err != nil {
+		return newErr("failed to resume instance", err)
+	}
+	res.suspendedByWf = false
+	return nil
+}
+
 func (ir *instanceRegistry) regCreate(name string, res *Resource, overWrite bool, s *Step) DError {
 	// Base creation logic.
 	errs := ir.baseResourceRegistry.regCreate(name, res, s, overWrite)
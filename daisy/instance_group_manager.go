@@ -0,0 +1,139 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	instanceGroupManagerURLRgx = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?zones/(?P<zone>%[2]s)/instanceGroupManagers/(?P<instanceGroupManager>%[2]s)$`, projectRgxStr, rfc1035))
+)
+
+func (w *Workflow) instanceGroupManagerExists(project, zone, instanceGroupManager string) (bool, DError) {
+	return w.instanceGroupManagerCache.resourceExists(func(project, zone string, opts ...daisyCompute.ListCallOption) (interface{}, error) {
+		return w.ComputeClient.ListInstanceGroupManagers(project, zone)
+	}, project, zone, instanceGroupManager)
+}
+
+// InstanceGroupManager is used to create a GCE managed instance group.
+type InstanceGroupManager struct {
+	compute.InstanceGroupManager
+	Resource
+}
+
+// MarshalJSON is a hacky workaround to compute.InstanceGroupManager's implementation.
+func (igm *InstanceGroupManager) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*igm)
+}
+
+// extendInstanceTemplateURL extends a partial instance template URL, names of
+// templates created in the workflow are left as they are.
+func extendInstanceTemplateURL(template, project string) string {
+	if instanceTemplateURLRgx.MatchString(template) {
+		return extendPartialURL(template, project)
+	}
+	return template
+}
+
+// resolveInstanceTemplate returns the link of a template created in the
+// workflow, or template itself if it's a URL.
+func resolveInstanceTemplate(w *Workflow, template string) string {
+	if res, ok := w.instanceTemplates.get(template); ok {
+		return res.link
+	}
+	return template
+}
+
+// validateVersions registers s as a user of the instance templates used by
+// versions.
+func validateVersions(versions []*compute.InstanceGroupManagerVersion, s *Step, pre string) DError {
+	var errs DError
+	for _, v := range versions {
+		if v.InstanceTemplate == "" {
+			errs = addErrs(errs, Errf("%s: version %q: InstanceTemplate not set", pre, v.Name))
+			continue
+		}
+		if _, err := s.w.instanceTemplates.regUse(v.InstanceTemplate, s); err != nil {
+			errs = addErrs(errs, err)
+		}
+	}
+	return errs
+}
+
+func (igm *InstanceGroupManager) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	igm.Name, igm.Zone, errs = igm.Resource.populateWithZone(ctx, s, igm.Name, igm.Zone)
+
+	igm.BaseInstanceName = strOr(igm.BaseInstanceName, igm.Name)
+	igm.InstanceTemplate = extendInstanceTemplateURL(igm.InstanceTemplate, igm.Project)
+	for _, v := range igm.Versions {
+		v.InstanceTemplate = extendInstanceTemplateURL(v.InstanceTemplate, igm.Project)
+	}
+
+	igm.Description = strOr(igm.Description, defaultDescription("InstanceGroupManager", s.w.Name, s.w.username))
+	igm.link = fmt.Sprintf("projects/%s/zones/%s/instanceGroupManagers/%s", igm.Project, igm.Zone, igm.Name)
+	return errs
+}
+
+func (igm *InstanceGroupManager) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot create instance group manager %q", igm.daisyName)
+	errs := igm.Resource.validateWithZone(ctx, s, igm.Zone, pre)
+
+	if igm.InstanceTemplate == "" && len(igm.Versions) == 0 {
+		errs = addErrs(errs, Errf("%s: one of InstanceTemplate or Versions must be set", pre))
+	}
+	if igm.InstanceTemplate != "" {
+		if _, err := s.w.instanceTemplates.regUse(igm.InstanceTemplate, s); err != nil {
+			errs = addErrs(errs, err)
+		}
+	}
+	errs = addErrs(errs, validateVersions(igm.Versions, s, pre))
+	if igm.TargetSize < 0 {
+		errs = addErrs(errs, Errf("%s: TargetSize can't be negative: %d", pre, igm.TargetSize))
+	}
+
+	// Register creation.
+	errs = addErrs(errs, s.w.instanceGroupManagers.regCreate(igm.daisyName, &igm.Resource, s, false))
+	return errs
+}
+
+type instanceGroupManagerRegistry struct {
+	baseResourceRegistry
+}
+
+func newInstanceGroupManagerRegistry(w *Workflow) *instanceGroupManagerRegistry {
+	igmr := &instanceGroupManagerRegistry{baseResourceRegistry: baseResourceRegistry{w: w, typeName: "instanceGroupManager", urlRgx: instanceGroupManagerURLRgx}}
+	igmr.baseResourceRegistry.deleteFn = igmr.deleteFn
+	igmr.init()
+	return igmr
+}
+
+func (igmr *instanceGroupManagerRegistry) deleteFn(ctx context.Context, res *Resource) DError {
+	m := NamedSubexp(instanceGroupManagerURLRgx, res.link)
+	err := daisyCompute.WithContext(ctx, igmr.w.ComputeClient).DeleteInstanceGroupManager(m["project"], m["zone"], m["instanceGroupManager"])
+	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+		return typedErr(resourceDNEError, "failed to delete instance group manager", err)
+	}
+	return newErr("failed to delete instance group manager", err)
+}
@@ -0,0 +1,172 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	instanceTemplateURLRgx = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?global/instanceTemplates/(?P<instanceTemplate>%[2]s)$`, projectRgxStr, rfc1035))
+)
+
+func (w *Workflow) instanceTemplateExists(project, instanceTemplate string) (bool, DError) {
+	return w.instanceTemplateCache.resourceExists(func(project string, opts ...daisyCompute.ListCallOption) (interface{}, error) {
+		return w.ComputeClient.ListInstanceTemplates(project)
+	}, project, instanceTemplate)
+}
+
+// InstanceTemplate is used to create a GCE instance template.
+type InstanceTemplate struct {
+	compute.InstanceTemplate
+	Resource
+}
+
+// MarshalJSON is a hacky workaround to compute.InstanceTemplate's implementation.
+func (it *InstanceTemplate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*it)
+}
+
+func (it *InstanceTemplate) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	it.Name, errs = it.Resource.populateWithGlobal(ctx, s, it.Name)
+
+	if it.Properties == nil {
+		it.Properties = &compute.InstanceProperties{}
+	}
+	p := it.Properties
+	// Unlike instances, templates take a machine type name rather than a URL.
+	p.MachineType = strOr(p.MachineType, "n1-standard-1")
+	for di, d := range p.Disks {
+		d.Boot = di == 0
+		d.Mode = strOr(d.Mode, defaultDiskMode)
+		if d.InitializeParams != nil {
+			// Disks created for managed instances go away with the instance.
+			d.AutoDelete = true
+			if imageURLRgx.MatchString(d.InitializeParams.SourceImage) {
+				d.InitializeParams.SourceImage = extendPartialURL(d.InitializeParams.SourceImage, it.Project)
+			}
+		}
+	}
+
+	if p.NetworkInterfaces == nil {
+		p.NetworkInterfaces = []*compute.NetworkInterface{{}}
+	}
+	for _, n := range p.NetworkInterfaces {
+		if n.AccessConfigs == nil {
+			n.AccessConfigs = []*compute.AccessConfig{{Type: defaultAccessConfigType}}
+		}
+		// Only set default if no subnetwork or network set.
+		if n.Subnetwork == "" {
+			n.Network = strOr(n.Network, "global/networks/default")
+		}
+		if networkURLRegex.MatchString(n.Network) {
+			n.Network = extendPartialURL(n.Network, it.Project)
+		}
+		if subnetworkURLRegex.MatchString(n.Subnetwork) {
+			n.Subnetwork = extendPartialURL(n.Subnetwork, it.Project)
+		}
+	}
+
+	it.Description = strOr(it.Description, defaultDescription("InstanceTemplate", s.w.Name, s.w.username))
+	it.link = fmt.Sprintf("projects/%s/global/instanceTemplates/%s", it.Project, it.Name)
+	return errs
+}
+
+func (it *InstanceTemplate) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot create instance template %q", it.daisyName)
+	errs := it.Resource.validate(ctx, s, pre)
+
+	p := it.Properties
+	if len(p.Disks) == 0 {
+		errs = addErrs(errs, Errf("%s: no disks provided", pre))
+	}
+	for _, d := range p.Disks {
+		if d.Source != "" {
+			errs = addErrs(errs, Errf("%s: disk Source can't be shared between managed instances, use InitializeParams", pre))
+		}
+		if d.InitializeParams == nil || d.InitializeParams.SourceImage == "" {
+			continue
+		}
+		if _, err := s.w.images.regUse(d.InitializeParams.SourceImage, s); err != nil {
+			errs = addErrs(errs, Errf("%s: can't use InitializeParams.SourceImage %q: %v", pre, d.InitializeParams.SourceImage, err))
+		}
+	}
+	for _, n := range p.NetworkInterfaces {
+		if n.Subnetwork != "" {
+			if _, err := s.w.subnetworks.regUse(n.Subnetwork, s); err != nil {
+				errs = addErrs(errs, err)
+			}
+		}
+		if n.Network != "" {
+			if _, err := s.w.networks.regUse(n.Network, s); err != nil {
+				errs = addErrs(errs, err)
+			}
+		}
+	}
+
+	// Register creation.
+	errs = addErrs(errs, s.w.instanceTemplates.regCreate(it.daisyName, &it.Resource, s, false))
+	return errs
+}
+
+// updateLinksBeforeCreate replaces workflow-internal image and network names
+// with the links of the resources created for them.
+func (it *InstanceTemplate) updateLinksBeforeCreate(w *Workflow) {
+	for _, d := range it.Properties.Disks {
+		if d.InitializeParams == nil || d.InitializeParams.SourceImage == "" {
+			continue
+		}
+		if image, ok := w.images.get(d.InitializeParams.SourceImage); ok {
+			d.InitializeParams.SourceImage = image.link
+		}
+	}
+	for _, n := range it.Properties.NetworkInterfaces {
+		if netRes, ok := w.networks.get(n.Network); ok {
+			n.Network = netRes.link
+		}
+		if subnetRes, ok := w.subnetworks.get(n.Subnetwork); ok {
+			n.Subnetwork = subnetRes.link
+		}
+	}
+}
+
+type instanceTemplateRegistry struct {
+	baseResourceRegistry
+}
+
+func newInstanceTemplateRegistry(w *Workflow) *instanceTemplateRegistry {
+	itr := &instanceTemplateRegistry{baseResourceRegistry: baseResourceRegistry{w: w, typeName: "instanceTemplate", urlRgx: instanceTemplateURLRgx}}
+	itr.baseResourceRegistry.deleteFn = itr.deleteFn
+	itr.init()
+	return itr
+}
+
+func (itr *instanceTemplateRegistry) deleteFn(ctx context.Context, res *Resource) DError {
+	m := NamedSubexp(instanceTemplateURLRgx, res.link)
+	err := daisyCompute.WithContext(ctx, itr.w.ComputeClient).DeleteInstanceTemplate(m["project"], m["instanceTemplate"])
+	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+		return typedErr(resourceDNEError, "failed to delete instance template", err)
+	}
+	return newErr("failed to delete instance template", err)
+}
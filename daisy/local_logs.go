@@ -0,0 +1,104 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	localDaisyLogFile    = "daisy.log"
+	localSummaryFile     = "summary.json"
+	localSummaryHTMLFile = "summary.html"
+	localSerialLogsDir   = "serial"
+	localLogsPermission  = 0755
+)
+
+// SetLocalLogsDir mirrors the daisy log, all serial port output and the run
+// Report into a subdirectory of dir, independently of GCS and Cloud Logging.
+func (w *Workflow) SetLocalLogsDir(dir string) {
+	w.localLogsDir = dir
+}
+
+// LocalLogsPath returns the directory this workflow run's local logs are
+// written to, or "" if local logging is not enabled.
+func (w *Workflow) LocalLogsPath() string {
+	root := w.root()
+	if root.localLogsDir == "" {
+		return ""
+	}
+	return filepath.Join(root.localLogsDir, fmt.Sprintf("daisy-%s-%s", root.Name, root.id))
+}
+
+// openLocalSerialLog opens the local mirror of an instance's serial port
+// output for appending. Returns nil if local logging is not enabled.
+func (w *Workflow) openLocalSerialLog(instance string, port int64) (*os.File, error) {
+	dir := w.LocalLogsPath()
+	if dir == "" {
+		return nil, nil
+	}
+	dir = filepath.Join(dir, localSerialLogsDir)
+	if err := os.MkdirAll(dir, localLogsPermission); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, fmt.Sprintf("%s-serial-port%d.log", instance, port)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// openLocalDaisyLog opens the local daisy log for appending. Returns nil if
+// local logging is not enabled.
+func (w *Workflow) openLocalDaisyLog() (*os.File, error) {
+	dir := w.LocalLogsPath()
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, localLogsPermission); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, localDaisyLogFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// writeLocalRunSummary writes the report of a finished workflow run as JSON
+// and HTML.
+func (w *Workflow) writeLocalRunSummary() {
+	dir := w.LocalLogsPath()
+	if dir == "" {
+		return
+	}
+
+	r := w.Report()
+	err := os.MkdirAll(dir, localLogsPermission)
+	for _, f := range []struct {
+		name  string
+		write func(io.Writer) error
+	}{{localSummaryFile, r.WriteJSON}, {localSummaryHTMLFile, r.WriteHTML}} {
+		if err != nil {
+			break
+		}
+		var out *os.File
+		if out, err = os.Create(filepath.Join(dir, f.name)); err != nil {
+			break
+		}
+		err = f.write(out)
+		if cErr := out.Close(); err == nil {
+			err = cErr
+		}
+	}
+	if err != nil && w.Logger != nil {
+		w.LogWorkflowInfo("Error writing local run summary: %v", err)
+	}
+}
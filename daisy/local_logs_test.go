@@ -0,0 +1,160 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestLocalLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daisy-local-logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	w := testWorkflow()
+	w.SetLocalLogsDir(dir)
+	w.DisableGCSLogging()
+	w.DisableStdoutLogging()
+	w.Logger = nil
+	w.createLogger(ctx)
+	runDir := filepath.Join(dir, "daisy-"+testWf+"-abcdef")
+	assert.Equal(t, runDir, w.LocalLogsPath())
+
+	// Subworkflows write into the top level workflow's directory.
+	sw := w.NewSubWorkflow()
+	sw.Name = "sub"
+	sw.Logger = w.Logger
+	sw.ComputeClient = w.ComputeClient
+	sw.StorageClient = w.StorageClient
+	assert.Equal(t, runDir, sw.LocalLogsPath())
+
+	w.LogWorkflowInfo("hello %s", "local")
+	w.Logger.Flush()
+	b, err := ioutil.ReadFile(filepath.Join(runDir, localDaisyLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(b), "hello local")
+
+	responses := []string{"serial ", "output"}
+	w.ComputeClient.(*daisyCompute.TestClient).GetSerialPortOutputFn = func(_, _, _ string, _, next int64) (*compute.SerialPortOutput, error) {
+		if len(responses) == 0 {
+			return nil, assert.AnError
+		}
+		r := responses[0]
+		responses = responses[1:]
+		return &compute.SerialPortOutput{Contents: r, Next: next + int64(len(r))}, nil
+	}
+	w.ComputeClient.(*daisyCompute.TestClient).InstanceStatusFn = func(_, _, _ string) (string, error) {
+		return "STOPPED", nil
+	}
+	i := &Instance{Instance: compute.Instance{Name: "i1"}}
+	logSerialOutput(ctx, &Step{name: "foo", w: sw}, i, &i.InstanceBase, 1, time.Microsecond)
+	b, err = ioutil.ReadFile(filepath.Join(runDir, localSerialLogsDir, "i1-serial-port1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "serial output", string(b))
+
+	w.AddSerialConsoleOutputValue("key", "value")
+	w.recordStepTime("step", time.Now(), time.Now())
+	w.runStartTime = time.Now()
+	w.runEndTime = time.Now()
+	w.runErr = Errf("step failed")
+	w.writeLocalRunSummary()
+	if _, err := os.Stat(filepath.Join(runDir, localSummaryHTMLFile)); err != nil {
+		t.Errorf("HTML summary not written: %v", err)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(runDir, localSummaryFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testWf, got.Workflow)
+	assert.False(t, got.Success)
+	assert.Equal(t, "step failed", got.Error)
+	assert.Equal(t, map[string]string{"key": "value"}, got.SerialOutputValues)
+	assert.Equal(t, 1, len(got.Steps))
+}
+
+func TestLocalLogsClosedOnCleanup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daisy-local-logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := testWorkflow()
+	w.SetLocalLogsDir(dir)
+	w.DisableGCSLogging()
+	w.DisableStdoutLogging()
+	w.Logger = nil
+	w.createLogger(context.Background())
+	l := w.Logger.(*daisyLog)
+
+	// A subworkflow's cleanup leaves the shared logger open.
+	sw := w.NewSubWorkflow()
+	sw.Logger = w.Logger
+	sw.cleanup()
+	assert.NotNil(t, l.localLogFile)
+	assert.NotEmpty(t, l.stopFlushes)
+
+	// The run summary is written, and its errors logged, before the logger is
+	// closed.
+	w.runStartTime = time.Now()
+	if err := os.Mkdir(filepath.Join(w.LocalLogsPath(), localSummaryFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w.cleanup()
+	assert.Nil(t, l.localLogFile)
+	assert.Empty(t, l.stopFlushes)
+	assert.False(t, w.runEndTime.IsZero())
+	b, err := ioutil.ReadFile(filepath.Join(w.LocalLogsPath(), localDaisyLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(b), "finished cleanup")
+	assert.Contains(t, string(b), "Error writing local run summary")
+}
+
+func TestLocalLogsDisabled(t *testing.T) {
+	w := testWorkflow()
+	assert.Equal(t, "", w.LocalLogsPath())
+	f, err := w.openLocalSerialLog("i1", 1)
+	assert.Nil(t, f)
+	assert.Nil(t, err)
+	// Must not panic or create anything.
+	w.writeLocalRunSummary()
+	if strings.Contains(w.LocalLogsPath(), "daisy-") {
+		t.Error("unexpected local logs path")
+	}
+}
@@ -0,0 +1,229 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+// Locks are kept as project common instance metadata items, keyed
+// "daisy-<mode>-lock_<name>_<workflow id>", with their expiry time as value.
+// Lock names follow RFC 1035 so they can't contain the "_" separators.
+
+const (
+	lockModeRead   = "READ"
+	lockModeWrite  = "WRITE"
+	defaultLockTTL = "2h"
+)
+
+var lockNameRgx = regexp.MustCompile(fmt.Sprintf(`^%s$`, rfc1035))
+
+// lockKeyPrefix is the metadata key prefix of all mode locks on name.
+func lockKeyPrefix(mode, name string) string {
+	return fmt.Sprintf("daisy-%s-lock_%s_", strings.ToLower(mode), name)
+}
+
+// lockState looks for locks on name in md. It reports whether a lock of the
+// given mode is held by anyone but owner, and returns md's items without the
+// expired locks on name.
+func lockState(md *compute.Metadata, name, mode, owner string) (bool, []*compute.MetadataItems) {
+	var held bool
+	var items []*compute.MetadataItems
+	for _, item := range md.Items {
+		isLock := strings.HasPrefix(item.Key, lockKeyPrefix(lockModeRead, name)) || strings.HasPrefix(item.Key, lockKeyPrefix(lockModeWrite, name))
+		if isLock && item.Value != nil {
+			if expiry, err := time.Parse(time.RFC3339, *item.Value); err == nil && time.Now().After(expiry) {
+				continue
+			}
+		}
+		if isLock && item.Key != owner && strings.HasPrefix(item.Key, lockKeyPrefix(mode, name)) {
+			held = true
+		}
+		items = append(items, item)
+	}
+	return held, items
+}
+
+// Lock is a named read/write lock shared by all workflows using the same
+// project.
+type Lock struct {
+	// Name of the lock, e.g. the image family or network it guards.
+	Name string
+	// Project keeping the lock, defaults to the workflow's project.
+	Project string `json:",omitempty"`
+	// Mode is READ or WRITE (default). Any number of workflows can hold a
+	// READ lock, a WRITE lock is exclusive.
+	Mode string `json:",omitempty"`
+	// TTL after which a lock that wasn't released expires (default is 2h).
+	TTL string `json:",omitempty"`
+	// Interval to check whether the lock is free (default is 10s).
+	Interval string `json:",omitempty"`
+
+	ttl, interval time.Duration
+	key           string
+}
+
+func (l *Lock) populate(ctx context.Context, s *Step) DError {
+	l.Project = strOr(l.Project, s.w.Project)
+	l.Mode = strings.ToUpper(strOr(l.Mode, lockModeWrite))
+	l.TTL = strOr(l.TTL, defaultLockTTL)
+	l.Interval = strOr(l.Interval, defaultInterval)
+
+	var err error
+	if l.ttl, err = time.ParseDuration(l.TTL); err != nil {
+		return newErr(fmt.Sprintf("failed to parse TTL for step %v", s.name), err)
+	}
+	if l.interval, err = time.ParseDuration(l.Interval); err != nil {
+		return newErr(fmt.Sprintf("failed to parse Interval for step %v", s.name), err)
+	}
+	l.key = lockKeyPrefix(l.Mode, l.Name) + s.w.id
+	return nil
+}
+
+func (l *Lock) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot acquire lock %q", l.Name)
+	var errs DError
+	if !lockNameRgx.MatchString(l.Name) {
+		errs = addErrs(errs, Errf("%s: bad name, must match %q", pre, lockNameRgx))
+	}
+	if l.Mode != lockModeRead && l.Mode != lockModeWrite {
+		errs = addErrs(errs, Errf("%s: Mode %q not one of %v", pre, l.Mode, []string{lockModeRead, lockModeWrite}))
+	}
+	if l.ttl <= 0 {
+		errs = addErrs(errs, Errf("%s: TTL must be positive: %q", pre, l.TTL))
+	}
+	if exists, err := projectExists(s.w.ComputeClient, l.Project); err != nil {
+		errs = addErrs(errs, Errf("%s: bad project lookup: %v", pre, err))
+	} else if !exists {
+		errs = addErrs(errs, Errf("%s: project %q does not exist", pre, l.Project))
+	}
+
+	// Register creation, there's no resource to look up.
+	res := &Resource{RealName: l.key, Project: l.Project, link: fmt.Sprintf("projects/%s/locks/%s", l.Project, l.Name)}
+	errs = addErrs(errs, s.w.locks.regCreate(l.Name, res, s, true))
+	return errs
+}
+
+// acquire takes l, waiting for conflicting locks held by other workflows to
+// be released or to expire. It reports false if the workflow was canceled.
+func (l *Lock) acquire(ctx context.Context, s *Step) (bool, DError) {
+	// Nobody can join while a WRITE lock is held, or waiting for readers.
+	if ok, err := l.waitAndSet(ctx, s, lockModeWrite, true); !ok || err != nil {
+		return ok, err
+	}
+	if l.Mode == lockModeRead {
+		return true, nil
+	}
+	// Our WRITE lock keeps new readers out, wait for the current ones to leave.
+	ok, err := l.waitAndSet(ctx, s, lockModeRead, false)
+	if !ok || err != nil {
+		if err := releaseLock(context.Background(), s.w, l.Project, l.key); err != nil {
+			s.w.LogStepInfo(s.name, "AcquireLock", "Failed to release lock %q: %v", l.Name, err)
+		}
+	}
+	return ok, err
+}
+
+// waitAndSet waits until nobody else holds a lock of the given mode on l's
+// name, then sets l's metadata item if set is true.
+func (l *Lock) waitAndSet(ctx context.Context, s *Step, mode string, set bool) (bool, DError) {
+	w := s.w
+	client := daisyCompute.WithContext(ctx, w.ComputeClient)
+	tick := time.Tick(l.interval)
+	for {
+		p, err := client.GetProject(l.Project)
+		if err != nil {
+			return false, typedErr(apiError, "failed to get project metadata", err)
+		}
+		md := p.CommonInstanceMetadata
+		if md == nil {
+			md = &compute.Metadata{}
+		}
+		held, items := lockState(md, l.Name, mode, l.key)
+		if !held {
+			if !set {
+				return true, nil
+			}
+			expiry := time.Now().Add(l.ttl).Format(time.RFC3339)
+			items = append(items, &compute.MetadataItems{Key: l.key, Value: &expiry})
+			err := client.SetCommonInstanceMetadata(l.Project, &compute.Metadata{Fingerprint: md.Fingerprint, Items: items})
+			if err == nil {
+				return true, nil
+			}
+			if !isFingerprintConflict(err) {
+				return false, typedErr(apiError, "failed to set lock", err)
+			}
+			// Someone else changed the metadata, look again straight away.
+			continue
+		}
+
+		w.LogStepInfo(s.name, "AcquireLock", "Waiting for %s lock %q.", strings.ToLower(mode), l.Name)
+		select {
+		case <-w.Cancel:
+			return false, nil
+		case <-ctx.Done():
+			return false, Errf("stopped waiting for lock %q: %v", l.Name, ctx.Err())
+		case <-tick:
+		}
+	}
+}
+
+// releaseLock removes the lock metadata item key from project.
+func releaseLock(ctx context.Context, w *Workflow, project, key string) error {
+	client := daisyCompute.WithContext(ctx, w.ComputeClient)
+	return retryOnFingerprintConflict(func() error {
+		p, err := client.GetProject(project)
+		if err != nil {
+			return err
+		}
+		md := p.CommonInstanceMetadata
+		if md == nil {
+			return nil
+		}
+		var items []*compute.MetadataItems
+		for _, item := range md.Items {
+			if item.Key != key {
+				items = append(items, item)
+			}
+		}
+		if len(items) == len(md.Items) {
+			// Already gone, e.g. expired and cleaned up by someone else.
+			return nil
+		}
+		return client.SetCommonInstanceMetadata(project, &compute.Metadata{Fingerprint: md.Fingerprint, Items: items})
+	})
+}
+
+type lockRegistry struct {
+	baseResourceRegistry
+}
+
+func newLockRegistry(w *Workflow) *lockRegistry {
+	lr := &lockRegistry{baseResourceRegistry: baseResourceRegistry{w: w, typeName: "lock"}}
+	lr.baseResourceRegistry.deleteFn = lr.deleteFn
+	lr.init()
+	return lr
+}
+
+func (lr *lockRegistry) deleteFn(ctx context.Context, res *Resource) DError {
+	return newErr("failed to release lock", releaseLock(ctx, lr.w, res.Project, res.RealName))
+}
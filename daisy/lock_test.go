@@ -0,0 +1,130 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// fakeProjectMetadata keeps common instance metadata the way GCE does,
+// rejecting writes with a stale fingerprint.
+type fakeProjectMetadata struct {
+	mx          sync.Mutex
+	items       map[string]string
+	fingerprint int
+	sets        int
+}
+
+func newFakeProjectMetadata(c *daisyCompute.TestClient, items map[string]string) *fakeProjectMetadata {
+	f := &fakeProjectMetadata{items: items}
+	if f.items == nil {
+		f.items = map[string]string{}
+	}
+	c.GetProjectFn = func(project string) (*compute.Project, error) {
+		f.mx.Lock()
+		defer f.mx.Unlock()
+		md := &compute.Metadata{Fingerprint: fmt.Sprint(f.fingerprint)}
+		for k, v := range f.items {
+			vCopy := v
+			md.Items = append(md.Items, &compute.MetadataItems{Key: k, Value: &vCopy})
+		}
+		return &compute.Project{CommonInstanceMetadata: md}, nil
+	}
+	c.SetCommonInstanceMetadataFn = func(project string, md *compute.Metadata) error {
+		f.mx.Lock()
+		defer f.mx.Unlock()
+		if md.Fingerprint != fmt.Sprint(f.fingerprint) {
+			return &googleapi.Error{Code: http.StatusPreconditionFailed}
+		}
+		f.items = map[string]string{}
+		for _, item := range md.Items {
+			f.items[item.Key] = *item.Value
+		}
+		f.fingerprint++
+		f.sets++
+		return nil
+	}
+	return f
+}
+
+func (f *fakeProjectMetadata) has(key string) bool {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	_, ok := f.items[key]
+	return ok
+}
+
+func TestLockState(t *testing.T) {
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	md := &compute.Metadata{Items: []*compute.MetadataItems{
+		{Key: "unrelated", Value: &past},
+		{Key: "daisy-write-lock_foo_mine", Value: &future},
+		{Key: "daisy-read-lock_foo_other", Value: &future},
+		{Key: "daisy-read-lock_foo_expired", Value: &past},
+		{Key: "daisy-write-lock_foo-bar_other", Value: &future},
+	}}
+
+	tests := []struct {
+		desc, mode, owner string
+		wantHeld          bool
+	}{
+		{"own write lock", lockModeWrite, "daisy-write-lock_foo_mine", false},
+		{"other's write lock", lockModeWrite, "daisy-write-lock_foo_theirs", true},
+		{"other's read lock", lockModeRead, "daisy-write-lock_foo_mine", true},
+	}
+	for _, tt := range tests {
+		held, items := lockState(md, "foo", tt.mode, tt.owner)
+		if held != tt.wantHeld {
+			t.Errorf("%s: want held %v, got %v", tt.desc, tt.wantHeld, held)
+		}
+		var keys []string
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+		want := []string{"unrelated", "daisy-write-lock_foo_mine", "daisy-read-lock_foo_other", "daisy-write-lock_foo-bar_other"}
+		if diffRes := diff(keys, want, 0); diffRes != "" {
+			t.Errorf("%s: items not as expected: (-got,+want)\n%s", tt.desc, diffRes)
+		}
+	}
+}
+
+func TestReleaseLockMetadata(t *testing.T) {
+	w := testWorkflow()
+	f := newFakeProjectMetadata(w.ComputeClient.(*daisyCompute.TestClient), map[string]string{"keep": "v", "lock": "v"})
+
+	if err := releaseLock(context.Background(), w, testProject, "lock"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.has("lock") || !f.has("keep") {
+		t.Errorf("unexpected metadata after release: %v", f.items)
+	}
+	// Releasing a lock that's gone is a no-op.
+	if err := releaseLock(context.Background(), w, testProject, "lock"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.sets != 1 {
+		t.Errorf("want 1 metadata update, got %d", f.sets)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"path"
 	"regexp"
 	"sync"
@@ -44,11 +45,16 @@ type cloudLogWriter interface {
 // daisyLog wraps the different logging mechanisms that can be used.
 type daisyLog struct {
 	gcsLogWriter    *syncedWriter
+	localLogWriter  *syncedWriter
 	cloudLogger     cloudLogWriter
 	stdoutLogging   bool
 	logCleanupRegex *regexp.Regexp
 	// A map of instance name to its serial logs.
 	serialLogs map[string][]byte
+	// localLogFile is the file behind localLogWriter.
+	localLogFile io.Closer
+	// stopFlushes stops the periodic flushes of the writers above.
+	stopFlushes []func()
 }
 
 // createLogger builds a Logger.
@@ -58,7 +64,19 @@ func (w *Workflow) createLogger(ctx context.Context) {
 	if !w.gcsLoggingDisabled {
 		gcsLogger := NewGCSLogger(ctx, w.StorageClient, w.bucket, path.Join(w.logsPath, "daisy.log"))
 		l.gcsLogWriter = &syncedWriter{buf: bufio.NewWriter(gcsLogger)}
-		periodicFlush(func() { l.gcsLogWriter.Flush() })
+		l.stopFlushes = append(l.stopFlushes, periodicFlush(func() { l.gcsLogWriter.Flush() }))
+	}
+
+	if f, err := w.openLocalDaisyLog(); err != nil {
+		l.WriteLogEntry(&LogEntry{
+			LocalTimestamp: time.Now(),
+			WorkflowName:   getAbsoluteName(w),
+			Message:        fmt.Sprintf("Unable to write logs to local directory %q: %v", w.LocalLogsPath(), err),
+		})
+	} else if f != nil {
+		l.localLogWriter = &syncedWriter{buf: bufio.NewWriter(f)}
+		l.localLogFile = f
+		l.stopFlushes = append(l.stopFlushes, periodicFlush(func() { l.localLogWriter.Flush() }))
 	}
 
 	if !w.cloudLoggingDisabled && w.cloudLoggingClient != nil {
@@ -73,11 +91,12 @@ func (w *Workflow) createLogger(ctx context.Context) {
 		} else {
 			cloudLogName := fmt.Sprintf("daisy-%s-%s", w.Name, w.id)
 			l.cloudLogger = w.cloudLoggingClient.Logger(cloudLogName)
-			periodicFlush(func() { l.cloudLogger.Flush() })
+			l.stopFlushes = append(l.stopFlushes, periodicFlush(func() { l.cloudLogger.Flush() }))
 		}
 	}
 
 	w.Logger = l
+	w.closeLogger = l.close
 
 	w.addCleanupHook(func() DError {
 		w.Logger.Flush()
@@ -182,11 +201,29 @@ func (l *daisyLog) Flush() {
 		l.gcsLogWriter.Flush()
 	}
 
+	if l.localLogWriter != nil {
+		l.localLogWriter.Flush()
+	}
+
 	if l.cloudLogger != nil {
 		l.cloudLogger.Flush()
 	}
 }
 
+// close stops the periodic flushes, flushes the logs and closes the local
+// log file. Nothing is written to GCS or the local file after close.
+func (l *daisyLog) close() {
+	for _, stop := range l.stopFlushes {
+		stop()
+	}
+	l.stopFlushes = nil
+	l.Flush()
+	if l.localLogFile != nil {
+		l.localLogFile.Close()
+		l.localLogFile = nil
+	}
+}
+
 // LogEntry encapsulates a single log entry.
 type LogEntry struct {
 	LocalTimestamp time.Time `json:"localTimestamp"`
@@ -207,6 +244,10 @@ func (l *daisyLog) WriteLogEntry(e *LogEntry) {
 		l.gcsLogWriter.Write([]byte(e.String()))
 	}
 
+	if l.localLogWriter != nil {
+		l.localLogWriter.Write([]byte(e.String()))
+	}
+
 	if l.stdoutLogging {
 		fmt.Print(e)
 	}
@@ -258,13 +299,28 @@ func (l *GCSLogger) Write(b []byte) (int, error) {
 	return len(b), nil
 }
 
-func periodicFlush(f func()) {
+// periodicFlush calls f every 5 seconds until the returned func is called.
+// The returned func waits for a running f to finish.
+func periodicFlush(f func()) func() {
+	ticker := time.NewTicker(5 * time.Second)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		for {
-			time.Sleep(5 * time.Second)
-			f()
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				f()
+			}
 		}
 	}()
+	return func() {
+		ticker.Stop()
+		close(stop)
+		<-stopped
+	}
 }
 
 func getAbsoluteName(w *Workflow) string {
@@ -100,9 +100,9 @@ func newMachineImageRegistry(w *Workflow) *machineImageRegistry {
 	return ir
 }
 
-func (ir *machineImageRegistry) deleteFn(res *Resource) DError {
+func (ir *machineImageRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(machineImageURLRgx, res.link)
-	err := ir.w.ComputeClient.DeleteMachineImage(m["project"], m["machineImage"])
+	err := daisyCompute.WithContext(ctx, ir.w.ComputeClient).DeleteMachineImage(m["project"], m["machineImage"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete machine image", err)
 	}
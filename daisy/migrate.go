@@ -0,0 +1,351 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CurrentSchemaVersion is the workflow file schema version written by Migrate.
+// Files without a SchemaVersion are version 0.
+const CurrentSchemaVersion = 3
+
+// migration is a rule updating a deprecated form in workflow files older than
+// version.
+type migration struct {
+	version int
+	desc    string
+	// apply returns the new value of the object field or array element at path
+	// (key is empty for elements) and whether it changed it, or an error if
+	// the value can't be updated without losing data.
+	apply func(path, key string, v interface{}) (interface{}, bool, error)
+}
+
+var migrations = []migration{
+	{1, "list of resources instead of the Go fields split by API version", migrateSplitFields},
+	{2, "list instead of a single failureMatch string", migrateFailureMatch},
+	{3, "feature names instead of Compute API GuestOsFeature objects", migrateGuestOsFeatures},
+}
+
+// imageGuestOsFeaturesRgx matches the path of the GuestOsFeatures of a
+// CreateImages entry. Disks keep the Compute API objects.
+var imageGuestOsFeaturesRgx = regexp.MustCompile(`(?i)\.CreateImages\[\d+\]\.guestOsFeatures$`)
+
+// splitFields are the fields older versions of Daisy printed CreateImages and
+// CreateInstances steps with, in the order they are used.
+var splitFields = map[string][]string{
+	"CreateImages":    {"Images", "ImagesBeta", "ImagesAlpha"},
+	"CreateInstances": {"Instances", "InstancesBeta"},
+}
+
+// migrateSplitFields replaces the split fields with the list of the one that
+// is set. It fails if several are, rather than dropping the others.
+func migrateSplitFields(_, key string, v interface{}) (interface{}, bool, error) {
+	o, ok := v.(jsonObject)
+	if !ok {
+		return v, false, nil
+	}
+	for step, fields := range splitFields {
+		if !strings.EqualFold(key, step) {
+			continue
+		}
+		var set []string
+		list := []interface{}{}
+		for _, f := range fields {
+			if l, ok := o.get(f).([]interface{}); ok && len(l) > 0 {
+				set = append(set, f)
+				list = l
+			}
+		}
+		if len(set) > 1 {
+			return nil, false, fmt.Errorf("only one of %s can be set", strings.Join(set, ", "))
+		}
+		return list, true, nil
+	}
+	return v, false, nil
+}
+
+func migrateFailureMatch(_, key string, v interface{}) (interface{}, bool, error) {
+	if s, ok := v.(string); ok && strings.EqualFold(key, "failureMatch") {
+		return []interface{}{s}, true, nil
+	}
+	return v, false, nil
+}
+
+func migrateGuestOsFeatures(path, _ string, v interface{}) (interface{}, bool, error) {
+	l, ok := v.([]interface{})
+	if !ok || len(l) == 0 || !imageGuestOsFeaturesRgx.MatchString(path) {
+		return v, false, nil
+	}
+	var features []interface{}
+	for _, f := range l {
+		o, ok := f.(jsonObject)
+		if !ok {
+			return v, false, nil
+		}
+		t, ok := o.get("type").(string)
+		if !ok {
+			return v, false, nil
+		}
+		features = append(features, t)
+	}
+	return features, true, nil
+}
+
+// Migrate applies the migration rules newer than the SchemaVersion of the
+// workflow file data. It returns the file with the deprecated forms updated
+// and SchemaVersion set to CurrentSchemaVersion, and the changes made. The
+// whole file is rewritten: fields keep their order, but the formatting is the
+// one of writeOrdered. Data of an up to date file is returned as is.
+func Migrate(data []byte) ([]byte, []string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, nil, errors.New("invalid data after the workflow")
+	}
+	wf, ok := v.(jsonObject)
+	if !ok {
+		return nil, nil, errors.New("workflow isn't a JSON object")
+	}
+
+	version := 0
+	if n, ok := wf.get("SchemaVersion").(json.Number); ok {
+		i, err := strconv.Atoi(string(n))
+		if err != nil {
+			return nil, nil, fmt.Errorf("bad SchemaVersion %q", n)
+		}
+		version = i
+	}
+	if version > CurrentSchemaVersion {
+		return nil, nil, fmt.Errorf("schema version %d is newer than %d, the latest this version of Daisy supports", version, CurrentSchemaVersion)
+	}
+	if version == CurrentSchemaVersion {
+		return data, nil, nil
+	}
+
+	var changes []string
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		nwf, err := migrateValue(wf, "", "", m, &changes)
+		if err != nil {
+			return nil, nil, err
+		}
+		wf = nwf.(jsonObject)
+	}
+	wf = wf.set("SchemaVersion", json.Number(strconv.Itoa(CurrentSchemaVersion)), "Name")
+
+	var buf bytes.Buffer
+	writeOrdered(&buf, wf, "")
+	buf.WriteByte('\n')
+	return buf.Bytes(), changes, nil
+}
+
+// migrateValue applies m to the fields and elements of v, recording the
+// changes made by their path.
+func migrateValue(v interface{}, path, key string, m migration, changes *[]string) (interface{}, error) {
+	if path != "" {
+		nv, ok, err := m.apply(path, key, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if ok {
+			*changes = append(*changes, fmt.Sprintf("%s: %s", path, m.desc))
+			v = nv
+		}
+	}
+	var err error
+	switch v := v.(type) {
+	case jsonObject:
+		for i, f := range v {
+			p := f.key
+			if path != "" {
+				p = path + "." + f.key
+			}
+			if v[i].value, err = migrateValue(f.value, p, f.key, m, changes); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			if v[i], err = migrateValue(e, fmt.Sprintf("%s[%d]", path, i), "", m, changes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+// jsonObject is a JSON object keeping the order of its fields.
+type jsonObject []jsonField
+
+// get returns the value of the field key, matched case insensitively like
+// encoding/json does.
+func (o jsonObject) get(key string) interface{} {
+	for _, f := range o {
+		if strings.EqualFold(f.key, key) {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// set sets the field key to value, adding it after the field named after or
+// first if there is none.
+func (o jsonObject) set(key string, value interface{}, after string) jsonObject {
+	for i, f := range o {
+		if strings.EqualFold(f.key, key) {
+			o[i].value = value
+			return o
+		}
+	}
+	i := 0
+	for j, f := range o {
+		if strings.EqualFold(f.key, after) {
+			i = j + 1
+			break
+		}
+	}
+	o = append(o, jsonField{})
+	copy(o[i+1:], o[i:])
+	o[i] = jsonField{key, value}
+	return o
+}
+
+// decodeOrdered decodes the next JSON value, objects as jsonObject.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		o := jsonObject{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, jsonField{k.(string), v})
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		l := []interface{}{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		_, err := dec.Token()
+		return l, err
+	}
+	return t, nil
+}
+
+// writeOrdered writes v indented by two spaces, lists of plain values on one
+// line. Migrate writes whole files with it, so the formatting of migrated
+// files changes where it differs from this one.
+func writeOrdered(buf *bytes.Buffer, v interface{}, indent string) {
+	switch v := v.(type) {
+	case jsonObject:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{\n")
+		for i, f := range v {
+			buf.WriteString(indent + "  ")
+			writeString(buf, f.key)
+			buf.WriteString(": ")
+			writeOrdered(buf, f.value, indent+"  ")
+			if i < len(v)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		inline := true
+		for _, e := range v {
+			switch e.(type) {
+			case jsonObject, []interface{}:
+				inline = false
+			}
+		}
+		if inline {
+			buf.WriteByte('[')
+			for i, e := range v {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				writeOrdered(buf, e, indent)
+			}
+			buf.WriteByte(']')
+			return
+		}
+		buf.WriteString("[\n")
+		for i, e := range v {
+			buf.WriteString(indent + "  ")
+			writeOrdered(buf, e, indent+"  ")
+			if i < len(v)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	case string:
+		writeString(buf, v)
+	case json.Number:
+		buf.WriteString(string(v))
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	}
+}
+
+// writeString writes s quoted, without escaping HTML characters which are
+// common in scripts.
+func writeString(buf *bytes.Buffer, s string) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,238 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		desc, data, want string
+		wantChanges      []string
+	}{
+		{
+			"no changes",
+			`{"Name": "wf", "Steps": {"s": {"StartInstances": {"Instances": ["i"]}}}}`,
+			`{
+  "Name": "wf",
+  "SchemaVersion": 3,
+  "Steps": {
+    "s": {
+      "StartInstances": {
+        "Instances": ["i"]
+      }
+    }
+  }
+}
+`,
+			nil,
+		},
+		{
+			"split fields",
+			`{"Steps": {"a": {"CreateImages": {"Images": null, "ImagesAlpha": null, "ImagesBeta": [{"Name": "i"}]}}, "b": {"CreateInstances": {"Instances": [{"Name": "vm"}], "InstancesBeta": null}}}}`,
+			`{
+  "SchemaVersion": 3,
+  "Steps": {
+    "a": {
+      "CreateImages": [
+        {
+          "Name": "i"
+        }
+      ]
+    },
+    "b": {
+      "CreateInstances": [
+        {
+          "Name": "vm"
+        }
+      ]
+    }
+  }
+}
+`,
+			[]string{
+				"Steps.a.CreateImages: list of resources instead of the Go fields split by API version",
+				"Steps.b.CreateInstances: list of resources instead of the Go fields split by API version",
+			},
+		},
+		{
+			"failureMatch and guestOsFeatures",
+			`{"Steps": {"wait": {"WaitForInstancesSignal": [{"Name": "vm", "SerialOutput": {"Port": 1, "FailureMatch": "<fail>"}}]}, "img": {"CreateImages": [{"Name": "i", "GuestOsFeatures": [{"Type": "UEFI_COMPATIBLE"}]}]}}}`,
+			`{
+  "SchemaVersion": 3,
+  "Steps": {
+    "wait": {
+      "WaitForInstancesSignal": [
+        {
+          "Name": "vm",
+          "SerialOutput": {
+            "Port": 1,
+            "FailureMatch": ["<fail>"]
+          }
+        }
+      ]
+    },
+    "img": {
+      "CreateImages": [
+        {
+          "Name": "i",
+          "GuestOsFeatures": ["UEFI_COMPATIBLE"]
+        }
+      ]
+    }
+  }
+}
+`,
+			[]string{
+				"Steps.wait.WaitForInstancesSignal[0].SerialOutput.FailureMatch: list instead of a single failureMatch string",
+				"Steps.img.CreateImages[0].GuestOsFeatures: feature names instead of Compute API GuestOsFeature objects",
+			},
+		},
+		{
+			"disk guestOsFeatures and newer rules only",
+			`{"Name": "wf", "SchemaVersion": 2, "Steps": {"cd": {"CreateDisks": [{"Name": "d", "GuestOsFeatures": [{"Type": "UEFI_COMPATIBLE"}]}]}, "wait": {"WaitForInstancesSignal": [{"Name": "vm", "SerialOutput": {"FailureMatch": "fail"}}]}}}`,
+			`{
+  "Name": "wf",
+  "SchemaVersion": 3,
+  "Steps": {
+    "cd": {
+      "CreateDisks": [
+        {
+          "Name": "d",
+          "GuestOsFeatures": [
+            {
+              "Type": "UEFI_COMPATIBLE"
+            }
+          ]
+        }
+      ]
+    },
+    "wait": {
+      "WaitForInstancesSignal": [
+        {
+          "Name": "vm",
+          "SerialOutput": {
+            "FailureMatch": "fail"
+          }
+        }
+      ]
+    }
+  }
+}
+`,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		got, changes, err := Migrate([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: want\n%s\ngot\n%s", tt.desc, tt.want, got)
+		}
+		if !reflect.DeepEqual(changes, tt.wantChanges) {
+			t.Errorf("%s: want changes %q, got %q", tt.desc, tt.wantChanges, changes)
+		}
+	}
+}
+
+func TestMigrateUpToDate(t *testing.T) {
+	data := `{"SchemaVersion": 3, "Steps": {"wait": {"WaitForInstancesSignal": [{"Name": "vm", "SerialOutput": {"FailureMatch": "fail"}}]}}}`
+	got, changes, err := Migrate([]byte(data))
+	if err != nil || string(got) != data || changes != nil {
+		t.Errorf("want data unchanged, got %s, %q, %v", got, changes, err)
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct{ data, wantErr string }{
+		{`{"Name": "wf",}`, "invalid character"},
+		{`["wf"]`, "workflow isn't a JSON object"},
+		{`{"Name": "wf"} {}`, "invalid data after the workflow"},
+		{`{"SchemaVersion": 1.5}`, `bad SchemaVersion "1.5"`},
+		{`{"SchemaVersion": 4}`, "schema version 4 is newer than 3"},
+		{
+			`{"Steps": {"ci": {"CreateImages": {"Images": [{"Name": "a"}], "ImagesBeta": [{"Name": "b"}]}}}}`,
+			"Steps.ci.CreateImages: only one of Images, ImagesBeta can be set",
+		},
+		{
+			`{"Steps": {"ci": {"CreateInstances": {"Instances": [{"Name": "a"}], "InstancesBeta": [{"Name": "b"}]}}}}`,
+			"Steps.ci.CreateInstances: only one of Instances, InstancesBeta can be set",
+		},
+	}
+	for _, tt := range tests {
+		if _, _, err := Migrate([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: want error containing %q, got %v", tt.data, tt.wantErr, err)
+		}
+	}
+}
+
+func TestNewFromFileReportsMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daisy-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		data        string
+		wantErr     string
+		wantChanges int
+	}{
+		{`{"Steps": {"s": {"CreateInstances": [{"Name": "vm"}]}, "wait": {"WaitForInstancesSignal": [{"Name": "vm", "SerialOutput": {"FailureMatch": "fail"}}]}}}`, "", 1},
+		{`{"Steps": {"s": {"CreateInstances": {"Instances": [{"Name": "vm"}], "InstancesBeta": null}}}}`, "run \"daisy migrate", 0},
+		{`{"SchemaVersion": 3, "Steps": {"s": {"CreateInstances": [{"Name": "vm"}]}}}`, "", 0},
+		// Disks keep the Compute API GuestOsFeature objects.
+		{`{"Steps": {"s": {"CreateInstances": [{"Name": "vm"}]}, "cd": {"CreateDisks": [{"Name": "d", "GuestOsFeatures": [{"Type": "UEFI_COMPATIBLE"}]}]}}}`, "", 0},
+		{`{"SchemaVersion": 4}`, "newer than 3", 0},
+		// Errors are reported against the file as it is.
+		{"{\n  \"Steps\": {\"s\": {\"CreateInstances\": {\"Instances\": [{\"Name\": \"vm\"}]}}},\n}", "line 3", 0},
+	}
+	for i, tt := range tests {
+		file := filepath.Join(dir, "test.wf.json")
+		if err := ioutil.WriteFile(file, []byte(tt.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w, err := NewFromFile(file)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%d: want error containing %q, got %v", i, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if got := w.Steps["s"].CreateInstances.Instances[0].Name; got != "vm" {
+			t.Errorf("%d: want instance vm, got %q", i, got)
+		}
+		if len(w.PendingMigrations()) != tt.wantChanges {
+			t.Errorf("%d: want %d pending migrations, got %q", i, tt.wantChanges, w.PendingMigrations())
+		}
+		// The file isn't rewritten when it's read.
+		if data, err := ioutil.ReadFile(file); err != nil || string(data) != tt.data {
+			t.Errorf("%d: want file unchanged, got %s, %v", i, data, err)
+		}
+	}
+}
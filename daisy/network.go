@@ -101,9 +101,9 @@ func newNetworkRegistry(w *Workflow) *networkRegistry {
 	return nr
 }
 
-func (nr *networkRegistry) deleteFn(res *Resource) DError {
+func (nr *networkRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(networkURLRegex, res.link)
-	err := nr.w.ComputeClient.DeleteNetwork(m["project"], m["network"])
+	err := daisyCompute.WithContext(ctx, nr.w.ComputeClient).DeleteNetwork(m["project"], m["network"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete network", err)
 	}
@@ -0,0 +1,206 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StepState is the state of a step in a running workflow.
+type StepState string
+
+// The states of a step.
+const (
+	StepPending StepState = "pending"
+	StepRunning StepState = "running"
+	StepDone    StepState = "done"
+	StepFailed  StepState = "failed"
+)
+
+// Progress is a snapshot of a running workflow, for progress displays.
+type Progress struct {
+	// Steps are the steps of the workflow in dependency order. The steps of
+	// IncludeWorkflow and SubWorkflow steps follow their parent step once
+	// they have started, named <workflow>.<step>.
+	Steps []StepProgress
+	// Resources are the resources created by the workflow and not deleted yet.
+	Resources []ResourceReport
+}
+
+// StepProgress describes the state of a step.
+type StepProgress struct {
+	Name      string
+	State     StepState
+	StartTime time.Time
+	EndTime   time.Time
+	// Timeout is zero for the steps of included workflows and sub workflows.
+	Timeout time.Duration
+	// Instances is the latest StatusMatch line of each instance the step
+	// waits for, ordered by instance name.
+	Instances []InstanceStatus
+}
+
+// InstanceStatus is the latest StatusMatch line of an instance.
+type InstanceStatus struct {
+	Instance string
+	Step     string
+	Line     string
+	Time     time.Time
+}
+
+// Elapsed returns how long the step ran, or has been running at now.
+func (s StepProgress) Elapsed(now time.Time) time.Duration {
+	switch {
+	case s.StartTime.IsZero():
+		return 0
+	case s.EndTime.IsZero():
+		return now.Sub(s.StartTime)
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+func (w *Workflow) recordStepFailure(stepName string) {
+	if w.parent == nil {
+		w.recordTimeMx.Lock()
+		if w.failedSteps == nil {
+			w.failedSteps = map[string]bool{}
+		}
+		w.failedSteps[stepName] = true
+		w.recordTimeMx.Unlock()
+	} else {
+		w.parent.recordStepFailure(fmt.Sprintf("%s.%s", w.Name, stepName))
+	}
+}
+
+func (w *Workflow) recordInstanceStatus(stepName, instance, line string) {
+	if w.parent == nil {
+		w.recordTimeMx.Lock()
+		if w.instanceStatus == nil {
+			w.instanceStatus = map[string]InstanceStatus{}
+		}
+		w.instanceStatus[instance] = InstanceStatus{Instance: instance, Step: stepName, Line: line, Time: time.Now()}
+		w.recordTimeMx.Unlock()
+	} else {
+		w.parent.recordInstanceStatus(fmt.Sprintf("%s.%s", w.Name, stepName), instance, line)
+	}
+}
+
+// Progress returns the state of each step of the workflow and the resources
+// it has in flight. It is safe to call while the workflow runs.
+func (w *Workflow) Progress() *Progress {
+	w.recordTimeMx.Lock()
+	steps := map[string]*StepProgress{}
+	for _, tr := range w.stepTimeRecords {
+		steps[tr.Name] = &StepProgress{Name: tr.Name, State: StepDone, StartTime: tr.StartTime, EndTime: tr.EndTime}
+	}
+	for name, start := range w.runningSteps {
+		steps[name] = &StepProgress{Name: name, State: StepRunning, StartTime: start}
+	}
+	for name := range w.failedSteps {
+		if s, ok := steps[name]; ok {
+			s.State = StepFailed
+		}
+	}
+	for _, is := range w.instanceStatus {
+		if s, ok := steps[is.Step]; ok {
+			s.Instances = append(s.Instances, is)
+		}
+	}
+	w.recordTimeMx.Unlock()
+
+	p := &Progress{}
+	for _, name := range w.stepOrder() {
+		sp, ok := steps[name]
+		if !ok {
+			sp = &StepProgress{Name: name, State: StepPending}
+		}
+		sp.Timeout = w.Steps[name].timeout
+		p.Steps = append(p.Steps, *sp)
+
+		// Nested steps are recorded under the name of their workflow.
+		var prefix string
+		if iw := w.Steps[name].IncludeWorkflow; iw != nil && iw.Workflow != nil {
+			prefix = iw.Workflow.Name + "."
+		} else if sw := w.Steps[name].SubWorkflow; sw != nil && sw.Workflow != nil {
+			prefix = sw.Workflow.Name + "."
+		}
+		if prefix == "" || sp.StartTime.IsZero() {
+			continue
+		}
+		var nested []StepProgress
+		for n, s := range steps {
+			if strings.HasPrefix(n, prefix) && !s.StartTime.Before(sp.StartTime) {
+				nested = append(nested, *s)
+			}
+		}
+		sort.Slice(nested, func(i, j int) bool { return nested[i].StartTime.Before(nested[j].StartTime) })
+		p.Steps = append(p.Steps, nested...)
+	}
+	for i := range p.Steps {
+		is := p.Steps[i].Instances
+		sort.Slice(is, func(i, j int) bool { return is[i].Instance < is[j].Instance })
+	}
+
+	for _, r := range w.resourceReports() {
+		if r.Created && !r.Deleted {
+			p.Resources = append(p.Resources, r)
+		}
+	}
+	return p
+}
+
+// stepOrder returns the names of the steps in dependency order, breaking ties
+// by name.
+func (w *Workflow) stepOrder() []string {
+	var names []string
+	for name := range w.Steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var order []string
+	done := map[string]bool{}
+	for len(order) < len(names) {
+		n := len(order)
+		for _, name := range names {
+			if done[name] {
+				continue
+			}
+			ready := true
+			for _, d := range w.Dependencies[name] {
+				if _, ok := w.Steps[d]; ok && !done[d] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				order = append(order, name)
+				done[name] = true
+			}
+		}
+		if len(order) == n {
+			// A dependency cycle, which Validate reports; list the rest by name.
+			for _, name := range names {
+				if !done[name] {
+					order = append(order, name)
+				}
+			}
+		}
+	}
+	return order
+}
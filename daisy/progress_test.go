@@ -0,0 +1,110 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	w := testWorkflow()
+	iw := New()
+	iw.Name = "inc"
+	w.includeWorkflow(iw)
+	for _, name := range []string{"create", "wait", "include", "delete"} {
+		w.Steps[name] = &Step{name: name, w: w, timeout: time.Minute}
+	}
+	w.Steps["include"].IncludeWorkflow = &IncludeWorkflow{Workflow: iw}
+	w.Dependencies = map[string][]string{"wait": {"create"}, "include": {"create"}, "delete": {"wait", "include"}}
+	s := w.Steps["create"]
+	w.disks.m = map[string]*Resource{
+		"d":       {link: "projects/p/zones/z/disks/d", creator: s, createdInWorkflow: true},
+		"deleted": {link: "projects/p/zones/z/disks/deleted", creator: s, createdInWorkflow: true, deleted: true},
+	}
+
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.recordStepStart("create", start)
+	w.recordStepTime("create", start, start.Add(time.Second))
+	w.recordStepStart("wait", start.Add(time.Second))
+	w.recordInstanceStatus("wait", "i2", "Status: two")
+	w.recordInstanceStatus("wait", "i1", "Status: old")
+	w.recordInstanceStatus("wait", "i1", "Status: one")
+	w.recordStepStart("include", start.Add(time.Second))
+	iw.recordStepStart("first", start.Add(2*time.Second))
+	iw.recordStepTime("first", start.Add(2*time.Second), start.Add(3*time.Second))
+	iw.recordStepStart("second", start.Add(3*time.Second))
+	iw.recordStepFailure("second")
+	iw.recordStepTime("second", start.Add(3*time.Second), start.Add(4*time.Second))
+
+	p := w.Progress()
+	var got []string
+	for _, s := range p.Steps {
+		got = append(got, s.Name+" "+string(s.State))
+	}
+	want := []string{"create done", "include running", "inc.first done", "inc.second failed", "wait running", "delete pending"}
+	assert.Equal(t, want, got)
+
+	wait := p.Steps[4]
+	assert.Equal(t, time.Minute, wait.Timeout)
+	assert.Equal(t, time.Minute, wait.Elapsed(start.Add(61*time.Second)))
+	assert.Len(t, wait.Instances, 2)
+	assert.Equal(t, "i1", wait.Instances[0].Instance)
+	assert.Equal(t, "Status: one", wait.Instances[0].Line)
+	assert.Equal(t, time.Second, p.Steps[0].Elapsed(time.Time{}))
+	assert.Equal(t, time.Duration(0), p.Steps[5].Elapsed(start))
+
+	assert.Len(t, p.Resources, 1)
+	assert.Equal(t, "d", p.Resources[0].Name)
+}
+
+func TestProgressWhileResourcesChange(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{name: "s", w: w}
+	var names []string
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("d%d", i)
+		names = append(names, name)
+		w.disks.m[name] = &Resource{link: "projects/p/zones/z/disks/" + name, creator: s}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				w.Progress()
+			}
+		}
+	}()
+	for _, name := range names {
+		res, _ := w.disks.get(name)
+		w.disks.setCreated(res)
+		if err := w.disks.delete(context.Background(), name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	<-done
+	assert.Empty(t, w.Progress().Resources)
+}
@@ -0,0 +1,401 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	gitPathPrefix = "git::"
+	httpsPrefix   = "https://"
+
+	// Remote workflows larger than this are rejected.
+	maxRemoteWorkflowSize = 10 << 20
+)
+
+var (
+	sha256Rgx = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	// git::<repository>//<path in repository>?ref=<ref>
+	gitPathRgx = regexp.MustCompile(`^git::(.+?://[^/]+/.+?)//(.+)\?ref=(.+)$`)
+	// gitRepoSchemes are the transports git workflows can be fetched with.
+	gitRepoSchemes = []string{"https://", "ssh://"}
+
+	// workflowHTTPClient is used to fetch https:// workflows. Swapped out in tests.
+	workflowHTTPClient = http.DefaultClient
+	// gitCmd runs git with the given args in dir and returns stdout. Swapped out in tests.
+	gitCmd = func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		// Also keep git from using other transports, e.g. for submodules.
+		cmd.Env = append(os.Environ(), "GIT_ALLOW_PROTOCOL=https:ssh")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+)
+
+// parseGitPath splits a git::<repository>//<path>?ref=<ref> workflow path.
+// Only https:// and ssh:// repositories are allowed, and neither the
+// repository nor the ref can look like a git option.
+func parseGitPath(p string) (repo, file, ref string, dErr DError) {
+	m := gitPathRgx.FindStringSubmatch(p)
+	if m == nil {
+		return "", "", "", Errf("invalid git workflow path %q, expected git::<repository>//<path>?ref=<ref>", p)
+	}
+	repo, file, ref = m[1], m[2], m[3]
+	allowed := false
+	for _, scheme := range gitRepoSchemes {
+		if strings.HasPrefix(repo, scheme) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", "", "", Errf("invalid git workflow path %q: the repository has to be an https:// or ssh:// URL", p)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return "", "", "", Errf("invalid git workflow path %q: ref %q can't start with '-'", p, ref)
+	}
+	return repo, file, ref, nil
+}
+
+// isRemoteWorkflowPath reports whether p refers to a workflow that must be
+// fetched before it can be read: gs://, https:// or git:: paths.
+func isRemoteWorkflowPath(p string) bool {
+	return strings.HasPrefix(p, "gs://") || strings.HasPrefix(p, httpsPrefix) || strings.HasPrefix(p, gitPathPrefix)
+}
+
+// SetIncludeCacheDir sets the local directory remote IncludeWorkflow and
+// SubWorkflow files are cached in. Defaults to <user cache dir>/daisy/workflows.
+func (w *Workflow) SetIncludeCacheDir(dir string) {
+	w.includeCacheDir = dir
+}
+
+// EnableOfflineIncludes prevents daisy from fetching remote IncludeWorkflow and
+// SubWorkflow files; they must already be present in the include cache.
+func (w *Workflow) EnableOfflineIncludes() {
+	w.offlineIncludes = true
+}
+
+// SetPathCheck sets a check of every local file and workflow path used by
+// this workflow and the workflows it includes, run before they're read or
+// written. Paths check returns an error for can't be used, which lets servers
+// restrict what submitted workflows can access.
+func (w *Workflow) SetPathCheck(check func(p string) error) {
+	w.pathCheck = check
+}
+
+// checkPath runs the path check set on the top level workflow on p.
+func (w *Workflow) checkPath(p string) DError {
+	check := w.root().pathCheck
+	if check == nil || p == "" {
+		return nil
+	}
+	if err := check(p); err != nil {
+		return Errf("%q can't be used by workflow %q: %v", p, w.Name, err)
+	}
+	return nil
+}
+
+// remoteIncludeSettings returns the include cache dir and offline mode
+// configured on the top level workflow.
+func (w *Workflow) remoteIncludeSettings() (string, bool) {
+	root := w.root()
+	dir := root.includeCacheDir
+	if dir == "" {
+		if d, err := os.UserCacheDir(); err == nil {
+			dir = filepath.Join(d, "daisy", "workflows")
+		} else {
+			dir = filepath.Join(os.TempDir(), "daisy", "workflows")
+		}
+	}
+	return dir, root.offlineIncludes
+}
+
+// resolveWorkflowPath resolves p, relative to this workflow's location, into a
+// local file path or a remote workflow path.
+func (w *Workflow) resolveWorkflowPath(p string) (string, DError) {
+	if isRemoteWorkflowPath(p) {
+		return p, nil
+	}
+	if filepath.IsAbs(p) || w.remoteDir == "" {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(w.workflowDir, p)
+		}
+		return p, nil
+	}
+
+	// Relative paths in a remote workflow are relative to its remote location.
+	p = filepath.ToSlash(p)
+	switch {
+	case strings.HasPrefix(w.remoteDir, httpsPrefix):
+		base, err := url.Parse(w.remoteDir)
+		if err != nil {
+			return "", Errf("invalid workflow URL %q: %v", w.remoteDir, err)
+		}
+		ref, err := url.Parse(p)
+		if err != nil {
+			return "", Errf("invalid workflow path %q: %v", p, err)
+		}
+		return base.ResolveReference(ref).String(), nil
+	case strings.HasPrefix(w.remoteDir, gitPathPrefix):
+		repo, dir, ref, err := parseGitPath(w.remoteDir)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s%s//%s?ref=%s", gitPathPrefix, repo, path.Join(dir, p), ref), nil
+	default:
+		bkt, obj, err := splitGCSPath(w.remoteDir)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("gs://%s/%s", bkt, path.Join(obj, p)), nil
+	}
+}
+
+// resolveLocalPath resolves p, a file path used by this workflow such as a
+// Source, relative to the workflow's location. Files next to a remote workflow
+// can only be read if it's in GCS; other remote workflows must not use
+// relative file paths.
+func (w *Workflow) resolveLocalPath(p string) (string, DError) {
+	if p == "" || isGCSPath(p) {
+		return p, nil
+	}
+	if filepath.IsAbs(p) {
+		return p, w.checkPath(p)
+	}
+	if w.remoteDir == "" {
+		p = filepath.Join(w.workflowDir, p)
+		return p, w.checkPath(p)
+	}
+	r, err := w.resolveWorkflowPath(p)
+	if err != nil {
+		return "", err
+	}
+	if !isGCSPath(r) {
+		return "", Errf("relative path %q can't be used in workflow %q fetched from %q, only workflows in GCS can refer to files next to them; use an absolute or gs:// path", p, w.Name, w.remoteDir)
+	}
+	if strings.HasSuffix(p, "/") && !strings.HasSuffix(r, "/") {
+		r += "/"
+	}
+	return r, nil
+}
+
+// remoteParentDir returns the remote "directory" containing the workflow at p.
+func remoteParentDir(p string) string {
+	if m := gitPathRgx.FindStringSubmatch(p); m != nil {
+		return fmt.Sprintf("%s%s//%s?ref=%s", gitPathPrefix, m[1], path.Dir(m[2]), m[3])
+	}
+	return p[:strings.LastIndex(p, "/")+1]
+}
+
+// fetchWorkflow returns the path to a local copy of the workflow at p. Remote
+// workflows are fetched into the include cache unless a copy matching digest
+// is already cached. If digest is set, the content is verified against it.
+func (w *Workflow) fetchWorkflow(ctx context.Context, p, digest string) (string, DError) {
+	if digest != "" && !sha256Rgx.MatchString(digest) {
+		return "", Errf("invalid SHA256 %q for workflow %q: must be 64 lowercase hex characters", digest, p)
+	}
+	if !isRemoteWorkflowPath(p) {
+		if digest != "" {
+			data, err := ioutil.ReadFile(p)
+			if err != nil {
+				return "", typedErr(fileIOError, "failed to read workflow file", err)
+			}
+			if err := verifyWorkflowDigest(p, data, digest); err != nil {
+				return "", err
+			}
+		}
+		return p, nil
+	}
+
+	cacheDir, offline := w.remoteIncludeSettings()
+	key := digest
+	if key == "" {
+		sum := sha256.Sum256([]byte(p))
+		key = "path-" + hex.EncodeToString(sum[:])
+	}
+	cached := filepath.Join(cacheDir, key+".wf.json")
+
+	// Content pinned by digest never changes, so a verified cache hit is final.
+	if data, err := ioutil.ReadFile(cached); err == nil {
+		if digest != "" && verifyWorkflowDigest(p, data, digest) == nil {
+			return cached, nil
+		}
+		if offline {
+			if digest != "" {
+				return "", Errf("cached copy of workflow %q does not match SHA256 %s and offline mode is enabled", p, digest)
+			}
+			return cached, nil
+		}
+	} else if offline {
+		return "", Errf("workflow %q is not in the include cache %q and offline mode is enabled", p, cacheDir)
+	}
+
+	data, dErr := w.downloadWorkflow(ctx, p)
+	if dErr != nil {
+		return "", dErr
+	}
+	if digest != "" {
+		if err := verifyWorkflowDigest(p, data, digest); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", typedErr(fileIOError, "failed to create include cache directory", err)
+	}
+	tmp, err := ioutil.TempFile(cacheDir, key+".tmp")
+	if err != nil {
+		return "", typedErr(fileIOError, "failed to create include cache file", err)
+	}
+	_, err = tmp.Write(data)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), cached)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", typedErr(fileIOError, "failed to write include cache file", err)
+	}
+	return cached, nil
+}
+
+func verifyWorkflowDigest(p string, data []byte, digest string) DError {
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != digest {
+		return Errf("workflow %q SHA256 mismatch: got %s, want %s", p, got, digest)
+	}
+	return nil
+}
+
+func (w *Workflow) downloadWorkflow(ctx context.Context, p string) ([]byte, DError) {
+	switch {
+	case strings.HasPrefix(p, "gs://"):
+		bkt, obj, err := splitGCSPath(p)
+		if err != nil {
+			return nil, err
+		}
+		if w.StorageClient == nil {
+			return nil, Errf("cannot read workflow %s without a storage client", p)
+		}
+		r, rErr := w.StorageClient.Bucket(bkt).Object(obj).NewReader(ctx)
+		if rErr != nil {
+			return nil, typedErr(apiError, "failed to read workflow from GCS", rErr)
+		}
+		defer r.Close()
+		return readRemoteWorkflow(p, r)
+	case strings.HasPrefix(p, httpsPrefix):
+		req, err := http.NewRequest(http.MethodGet, p, nil)
+		if err != nil {
+			return nil, Errf("invalid workflow URL %q: %v", p, err)
+		}
+		resp, err := workflowHTTPClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, newErr("failed to fetch workflow over HTTPS", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, Errf("failed to fetch workflow %q: %s", p, resp.Status)
+		}
+		return readRemoteWorkflow(p, resp.Body)
+	default:
+		repo, file, ref, dErr := parseGitPath(p)
+		if dErr != nil {
+			return nil, dErr
+		}
+		dir, err := ioutil.TempDir("", "daisy-git-")
+		if err != nil {
+			return nil, typedErr(fileIOError, "failed to create temporary git directory", err)
+		}
+		defer os.RemoveAll(dir)
+		for _, args := range [][]string{{"init", "-q"}, {"fetch", "-q", "--depth", "1", "--", repo, ref}} {
+			if _, err := gitCmd(ctx, dir, args...); err != nil {
+				return nil, newErr("failed to fetch workflow from git", err)
+			}
+		}
+		data, err := gitCmd(ctx, dir, "show", "FETCH_HEAD:"+file)
+		if err != nil {
+			return nil, newErr("failed to read workflow from git", err)
+		}
+		return data, nil
+	}
+}
+
+func readRemoteWorkflow(p string, r io.Reader) ([]byte, DError) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxRemoteWorkflowSize+1))
+	if err != nil {
+		return nil, newErr("failed to read remote workflow", err)
+	}
+	if len(data) > maxRemoteWorkflowSize {
+		return nil, Errf("workflow %q is larger than %d bytes", p, maxRemoteWorkflowSize)
+	}
+	return data, nil
+}
+
+// readWorkflowFromPath resolves, fetches and reads the workflow at p into wf.
+// Relative paths in remote workflows are resolved against their remote
+// location, not the include cache.
+func (w *Workflow) readWorkflowFromPath(ctx context.Context, p, digest string, wf *Workflow) DError {
+	p, err := w.resolveWorkflowPath(p)
+	if err != nil {
+		return err
+	}
+	if err := w.checkPath(p); err != nil {
+		return err
+	}
+	local, err := w.fetchWorkflow(ctx, p, digest)
+	if err != nil {
+		return err
+	}
+	if !isRemoteWorkflowPath(p) {
+		return readWorkflow(local, wf)
+	}
+
+	// readWorkflow rejects a relative OAuthPath in remote workflows.
+	wf.remoteDir = remoteParentDir(p)
+	if err := readWorkflow(local, wf); err != nil {
+		return err
+	}
+	data, rErr := ioutil.ReadFile(local)
+	if rErr != nil {
+		return typedErr(fileIOError, "failed to read workflow file", rErr)
+	}
+	if bytes.Contains(data, []byte("${WFDIR}")) {
+		return Errf("workflow %q uses ${WFDIR}, which remote workflows can't use; use a path relative to the workflow instead", p)
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const remoteTestWf = `{"Name": "remote", "Steps": {"s": {"IncludeWorkflow": {"Path": "child.wf.json"}}}}`
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestResolveWorkflowPath(t *testing.T) {
+	tests := []struct {
+		desc, remoteDir, workflowDir, p, want string
+	}{
+		{"local relative", "", "/wf", "child.wf.json", "/wf/child.wf.json"},
+		{"local absolute", "", "/wf", "/other/child.wf.json", "/other/child.wf.json"},
+		{"remote path", "", "/wf", "gs://bkt/child.wf.json", "gs://bkt/child.wf.json"},
+		{"relative to gcs", "gs://bkt/dir/", "/cache", "../child.wf.json", "gs://bkt/child.wf.json"},
+		{"relative to https", "https://example.com/wf/", "/cache", "sub/child.wf.json", "https://example.com/wf/sub/child.wf.json"},
+		{"relative to git", "git::https://example.com/repo.git//wf?ref=abc", "/cache", "child.wf.json", "git::https://example.com/repo.git//wf/child.wf.json?ref=abc"},
+	}
+
+	for _, tt := range tests {
+		w := &Workflow{remoteDir: tt.remoteDir, workflowDir: tt.workflowDir}
+		got, err := w.resolveWorkflowPath(tt.p)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestRemoteParentDir(t *testing.T) {
+	tests := []struct{ p, want string }{
+		{"gs://bkt/dir/wf.json", "gs://bkt/dir/"},
+		{"https://example.com/a/b.wf.json", "https://example.com/a/"},
+		{"git::https://example.com/repo.git//a/b.wf.json?ref=main", "git::https://example.com/repo.git//a?ref=main"},
+	}
+	for _, tt := range tests {
+		if got := remoteParentDir(tt.p); got != tt.want {
+			t.Errorf("remoteParentDir(%q) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestFetchWorkflowHTTPS(t *testing.T) {
+	var requests int
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/wf/remote.wf.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, remoteTestWf)
+	}))
+	defer ts.Close()
+	defer func(c *http.Client) { workflowHTTPClient = c }(workflowHTTPClient)
+	workflowHTTPClient = ts.Client()
+
+	ctx := context.Background()
+	cacheDir, err := ioutil.TempDir("", "daisy-include-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	w := testWorkflow()
+	w.SetIncludeCacheDir(cacheDir)
+	p := ts.URL + "/wf/remote.wf.json"
+	digest := sha256Hex(remoteTestWf)
+
+	// Wrong digest is rejected and nothing is cached.
+	if _, err := w.fetchWorkflow(ctx, p, sha256Hex("other")); err == nil || !strings.Contains(err.Error(), "SHA256 mismatch") {
+		t.Errorf("expected SHA256 mismatch error, got: %v", err)
+	}
+	if _, err := w.fetchWorkflow(ctx, p, "not-a-digest"); err == nil {
+		t.Error("expected error for malformed digest")
+	}
+
+	got, err := w.fetchWorkflow(ctx, p, digest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(cacheDir, digest+".wf.json"); got != want {
+		t.Errorf("got cache path %q, want %q", got, want)
+	}
+
+	// A pinned cache hit must not touch the network, even offline.
+	w.EnableOfflineIncludes()
+	before := requests
+	if _, err := w.fetchWorkflow(ctx, p, digest); err != nil {
+		t.Errorf("unexpected error on cache hit: %v", err)
+	}
+	if requests != before {
+		t.Errorf("cache hit made %d requests", requests-before)
+	}
+
+	// Offline without a cached copy fails.
+	if _, err := w.fetchWorkflow(ctx, ts.URL+"/wf/uncached.wf.json", ""); err == nil || !strings.Contains(err.Error(), "offline") {
+		t.Errorf("expected offline error, got: %v", err)
+	}
+
+	// Subworkflows share the top level workflow's cache settings.
+	sw := w.NewSubWorkflow()
+	if dir, offline := sw.remoteIncludeSettings(); dir != cacheDir || !offline {
+		t.Errorf("subworkflow include settings = (%q, %t), want (%q, true)", dir, offline, cacheDir)
+	}
+}
+
+func TestNewIncludedWorkflowFromRemotePath(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, remoteTestWf)
+	}))
+	defer ts.Close()
+	defer func(c *http.Client) { workflowHTTPClient = c }(workflowHTTPClient)
+	workflowHTTPClient = ts.Client()
+
+	cacheDir, err := ioutil.TempDir("", "daisy-include-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	w := testWorkflow()
+	w.SetIncludeCacheDir(cacheDir)
+
+	iw, err := w.newIncludedWorkflowFromPath(context.Background(), ts.URL+"/wf/remote.wf.json", sha256Hex(remoteTestWf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if iw.Name != "remote" {
+		t.Errorf("got workflow name %q, want %q", iw.Name, "remote")
+	}
+	if want := ts.URL + "/wf/"; iw.remoteDir != want {
+		t.Errorf("got remoteDir %q, want %q", iw.remoteDir, want)
+	}
+	child, _ := iw.resolveWorkflowPath(iw.Steps["s"].IncludeWorkflow.Path)
+	if want := ts.URL + "/wf/child.wf.json"; child != want {
+		t.Errorf("nested include resolved to %q, want %q", child, want)
+	}
+}
+
+func TestResolveLocalPath(t *testing.T) {
+	tests := []struct {
+		desc, remoteDir, p, want string
+	}{
+		{"local relative", "", "script.sh", "/wf/script.sh"},
+		{"local absolute", "", "/other/script.sh", "/other/script.sh"},
+		{"gcs path", "", "gs://bkt/script.sh", "gs://bkt/script.sh"},
+		{"empty", "gs://bkt/dir/", "", ""},
+		{"relative to gcs", "gs://bkt/dir/", "script.sh", "gs://bkt/dir/script.sh"},
+		{"directory relative to gcs", "gs://bkt/dir/", "scripts/", "gs://bkt/dir/scripts/"},
+		{"absolute in remote", "gs://bkt/dir/", "/other/script.sh", "/other/script.sh"},
+		{"relative to gcs over https", "https://storage.googleapis.com/bkt/dir/", "script.sh", "https://storage.googleapis.com/bkt/dir/script.sh"},
+	}
+	for _, tt := range tests {
+		w := &Workflow{remoteDir: tt.remoteDir, workflowDir: "/wf"}
+		got, err := w.resolveLocalPath(tt.p)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+
+	for _, remoteDir := range []string{"https://example.com/wf/", "git::https://example.com/repo.git//wf?ref=abc"} {
+		w := &Workflow{remoteDir: remoteDir, workflowDir: "/cache"}
+		if got, err := w.resolveLocalPath("script.sh"); err == nil {
+			t.Errorf("%s: want error for a relative path, got %q", remoteDir, got)
+		}
+	}
+}
+
+func TestPathCheck(t *testing.T) {
+	parent := &Workflow{Name: "parent", workflowDir: "/wf"}
+	var checked []string
+	parent.SetPathCheck(func(p string) error {
+		checked = append(checked, p)
+		if strings.HasPrefix(p, "/secret") || strings.HasPrefix(p, "git::") {
+			return errors.New("not allowed")
+		}
+		return nil
+	})
+	// Included workflows use the check of the top level workflow.
+	w := &Workflow{Name: "child", workflowDir: "/wf", parent: parent}
+
+	for _, p := range []string{"script.sh", "/other/script.sh", "gs://bkt/script.sh"} {
+		if _, err := w.resolveLocalPath(p); err != nil {
+			t.Errorf("%s: unexpected error: %v", p, err)
+		}
+	}
+	if want := []string{"/wf/script.sh", "/other/script.sh"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("checked %q, want %q", checked, want)
+	}
+	if _, err := w.resolveLocalPath("/secret/key"); err == nil {
+		t.Error("want error for a path failing the check")
+	}
+	if err := w.readWorkflowFromPath(context.Background(), "git::https://example.com/repo.git//wf.json?ref=v1", "", &Workflow{}); err == nil {
+		t.Error("want error for a workflow path failing the check")
+	}
+}
+
+func TestRemoteWorkflowRelativePaths(t *testing.T) {
+	workflows := map[string]string{
+		"/wf/source.wf.json": `{"Name": "source", "Sources": {"script": "script.sh", "abs": "/abs/script.sh"}}`,
+		"/wf/oauth.wf.json":  `{"Name": "oauth", "OAuthPath": "creds.json"}`,
+		"/wf/wfdir.wf.json":  `{"Name": "wfdir", "Sources": {"script": "${WFDIR}/script.sh"}}`,
+	}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, workflows[r.URL.Path])
+	}))
+	defer ts.Close()
+	defer func(c *http.Client) { workflowHTTPClient = c }(workflowHTTPClient)
+	workflowHTTPClient = ts.Client()
+
+	cacheDir, err := ioutil.TempDir("", "daisy-include-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	w := testWorkflow()
+	w.SetIncludeCacheDir(cacheDir)
+	ctx := context.Background()
+
+	// Relative Sources are not read from the include cache.
+	iw, err := w.newIncludedWorkflowFromPath(ctx, ts.URL+"/wf/source.wf.json", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := iw.resolveLocalPath(iw.Sources["script"]); err == nil || !strings.Contains(err.Error(), "only workflows in GCS") {
+		t.Errorf("want error for a relative Source in an HTTPS workflow, got %v", err)
+	}
+	if got, err := iw.resolveLocalPath(iw.Sources["abs"]); err != nil || got != "/abs/script.sh" {
+		t.Errorf("absolute Source resolved to (%q, %v), want /abs/script.sh", got, err)
+	}
+	if _, err := iw.sourceContent(ctx, "script"); err == nil || strings.Contains(err.Error(), cacheDir) {
+		t.Errorf("want error for a relative Source that doesn't refer to the include cache, got %v", err)
+	}
+	// The same workflow in GCS reads its Sources from next to it.
+	iw.remoteDir = "gs://bkt/wf/"
+	if got, err := iw.resolveLocalPath(iw.Sources["script"]); err != nil || got != "gs://bkt/wf/script.sh" {
+		t.Errorf("relative Source resolved to (%q, %v), want gs://bkt/wf/script.sh", got, err)
+	}
+
+	for _, name := range []string{"oauth", "wfdir"} {
+		if _, err := w.newIncludedWorkflowFromPath(ctx, ts.URL+"/wf/"+name+".wf.json", ""); err == nil {
+			t.Errorf("%s: want error reading remote workflow, got none", name)
+		}
+	}
+}
+
+func TestFetchWorkflowGit(t *testing.T) {
+	var calls [][]string
+	defer func(f func(context.Context, string, ...string) ([]byte, error)) { gitCmd = f }(gitCmd)
+	gitCmd = func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if args[0] == "show" {
+			return []byte(remoteTestWf), nil
+		}
+		return nil, nil
+	}
+
+	cacheDir, err := ioutil.TempDir("", "daisy-include-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	w := testWorkflow()
+	w.SetIncludeCacheDir(cacheDir)
+
+	p := "git::https://example.com/repo.git//wf/remote.wf.json?ref=v1.0"
+	if _, err := w.fetchWorkflow(context.Background(), p, sha256Hex(remoteTestWf)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][]string{
+		{"init", "-q"},
+		{"fetch", "-q", "--depth", "1", "--", "https://example.com/repo.git", "v1.0"},
+		{"show", "FETCH_HEAD:wf/remote.wf.json"},
+	}
+	if diffRes := diff(calls, want, 0); diffRes != "" {
+		t.Errorf("git calls do not match expectation: (-got +want)\n%s", diffRes)
+	}
+
+	if _, err := w.fetchWorkflow(context.Background(), "git::https://example.com/repo.git", ""); err == nil {
+		t.Error("expected error for git path without a ref")
+	}
+
+	calls = nil
+	for _, bad := range []string{
+		"git::file:///x//wf.json?ref=v1.0",
+		"git::ext::sh -c touch% /tmp/pwned//wf.json?ref=v1.0",
+		"git::https://example.com/repo.git//wf.json?ref=--upload-pack=touch /tmp/pwned",
+	} {
+		if _, err := w.fetchWorkflow(context.Background(), bad, ""); err == nil {
+			t.Errorf("expected error for git path %q", bad)
+		}
+	}
+	if len(calls) != 0 {
+		t.Errorf("git was run for rejected paths: %v", calls)
+	}
+}
+
+func TestResolveWorkflowPathInvalidGitDir(t *testing.T) {
+	w := &Workflow{remoteDir: "git::https://example.com/repo.git"}
+	if _, err := w.resolveWorkflowPath("child.wf.json"); err == nil {
+		t.Error("expected error for malformed git remoteDir")
+	}
+}
+
+func TestFetchWorkflowLocalDigest(t *testing.T) {
+	f, err := ioutil.TempFile("", "local.wf.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(remoteTestWf)
+	f.Close()
+
+	w := testWorkflow()
+	if _, err := w.fetchWorkflow(context.Background(), f.Name(), sha256Hex(remoteTestWf)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := w.fetchWorkflow(context.Background(), f.Name(), sha256Hex("other")); err == nil {
+		t.Error("expected SHA256 mismatch for local workflow")
+	}
+}
@@ -0,0 +1,294 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+)
+
+// Report describes the outcome of a workflow run.
+type Report struct {
+	Workflow  string
+	ID        string
+	Project   string `json:",omitempty"`
+	Zone      string `json:",omitempty"`
+	StartTime time.Time
+	EndTime   time.Time
+	Success   bool
+	// Error is the error the run failed with.
+	Error string `json:",omitempty"`
+	// AnonymizedErrors are the error messages with user data removed.
+	AnonymizedErrors   []string          `json:",omitempty"`
+	Steps              []StepReport      `json:",omitempty"`
+	Resources          []ResourceReport  `json:",omitempty"`
+	SerialOutputValues map[string]string `json:",omitempty"`
+	Logs               LogLinks
+}
+
+// StepReport holds the timing of a single step, as recorded by the workflow.
+type StepReport struct {
+	Name      string
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  string
+}
+
+// ResourceReport describes a GCE resource created or deleted by the workflow.
+type ResourceReport struct {
+	Type string
+	// Name is the name of the resource in the workflow, or its URL if the
+	// workflow did not create it.
+	Name string
+	Link string
+	// Created is set if the workflow created the resource.
+	Created bool
+	// Deleted is set if the resource was deleted by a step or during cleanup.
+	Deleted   bool
+	NoCleanup bool `json:",omitempty"`
+	// LeftBehind is set for resources the workflow created and did not delete.
+	LeftBehind bool `json:",omitempty"`
+}
+
+// LogLinks points to the logs of a workflow run.
+type LogLinks struct {
+	GCS          string `json:",omitempty"`
+	Console      string `json:",omitempty"`
+	CloudLogging string `json:",omitempty"`
+	Local        string `json:",omitempty"`
+}
+
+// Report returns the report for this workflow. After Run returns it describes
+// the finished run; before that it reflects the run's progress so far.
+func (w *Workflow) Report() *Report {
+	w.runMx.Lock()
+	start, end, runErr := w.runStartTime, w.runEndTime, w.runErr
+	w.runMx.Unlock()
+
+	r := &Report{
+		Workflow:  w.Name,
+		ID:        w.id,
+		Project:   w.Project,
+		Zone:      w.Zone,
+		StartTime: start,
+		EndTime:   end,
+		Success:   !end.IsZero() && runErr == nil,
+		Resources: w.resourceReports(),
+	}
+	if runErr != nil {
+		r.Error = runErr.Error()
+		r.AnonymizedErrors = runErr.AnonymizedErrs()
+	}
+
+	for _, tr := range w.GetStepTimeRecords() {
+		r.Steps = append(r.Steps, StepReport{
+			Name:      tr.Name,
+			StartTime: tr.StartTime,
+			EndTime:   tr.EndTime,
+			Duration:  tr.EndTime.Sub(tr.StartTime).Round(time.Second).String(),
+		})
+	}
+	sort.SliceStable(r.Steps, func(i, j int) bool { return r.Steps[i].StartTime.Before(r.Steps[j].StartTime) })
+
+	w.serialControlOutputValuesMx.Lock()
+	if len(w.serialControlOutputValues) > 0 {
+		r.SerialOutputValues = map[string]string{}
+		for k, v := range w.serialControlOutputValues {
+			r.SerialOutputValues[k] = v
+		}
+	}
+	w.serialControlOutputValuesMx.Unlock()
+
+	if w.bucket != "" {
+		r.Logs.GCS = fmt.Sprintf("gs://%s/%s", w.bucket, w.logsPath)
+		r.Logs.Console = "https://console.cloud.google.com/storage/browser/" + path.Join(w.bucket, w.logsPath)
+	}
+	if w.Project != "" && !w.cloudLoggingDisabled {
+		query := fmt.Sprintf(`logName="projects/%s/logs/daisy-%s-%s"`, w.Project, w.Name, w.id)
+		r.Logs.CloudLogging = fmt.Sprintf("https://console.cloud.google.com/logs/query;query=%s?project=%s", url.PathEscape(query), w.Project)
+	}
+	r.Logs.Local = w.LocalLogsPath()
+	return r
+}
+
+func (w *Workflow) resourceReports() []ResourceReport {
+	var rs []ResourceReport
+	for _, r := range []*baseResourceRegistry{
+		&w.instanceGroupManagers.baseResourceRegistry,
+		&w.instanceTemplates.baseResourceRegistry,
+		&w.instances.baseResourceRegistry,
+		&w.images.baseResourceRegistry,
+		&w.machineImages.baseResourceRegistry,
+		&w.disks.baseResourceRegistry,
+		&w.forwardingRules.baseResourceRegistry,
+		&w.targetInstances.baseResourceRegistry,
+		&w.firewallRules.baseResourceRegistry,
+		&w.routes.baseResourceRegistry,
+		&w.routers.baseResourceRegistry,
+		&w.addresses.baseResourceRegistry,
+		&w.subnetworks.baseResourceRegistry,
+		&w.networks.baseResourceRegistry,
+		&w.snapshots.baseResourceRegistry,
+		&w.locks.baseResourceRegistry,
+	} {
+		rs = append(rs, r.report()...)
+	}
+	return append(rs, subWorkflowReports(w.Steps)...)
+}
+
+// subWorkflowReports returns the resources of the subworkflows run by steps,
+// which have their own registries. Included workflows use their parent's
+// registries, but can run subworkflows too.
+func subWorkflowReports(steps map[string]*Step) []ResourceReport {
+	var rs []ResourceReport
+	for _, s := range steps {
+		switch {
+		case s.SubWorkflow != nil && s.SubWorkflow.Workflow != nil:
+			rs = append(rs, s.SubWorkflow.Workflow.resourceReports()...)
+		case s.IncludeWorkflow != nil && s.IncludeWorkflow.Workflow != nil:
+			rs = append(rs, subWorkflowReports(s.IncludeWorkflow.Workflow.Steps)...)
+		}
+	}
+	return rs
+}
+
+func (r *baseResourceRegistry) report() []ResourceReport {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	var rs []ResourceReport
+	for name, res := range r.m {
+		created := res.creator != nil && res.createdInWorkflow
+		if !created && !res.deleted {
+			// Only referenced by the workflow.
+			continue
+		}
+		rs = append(rs, ResourceReport{
+			Type:       r.typeName,
+			Name:       name,
+			Link:       res.link,
+			Created:    created,
+			Deleted:    res.deleted,
+			NoCleanup:  res.NoCleanup,
+			LeftBehind: created && !res.deleted,
+		})
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Name < rs[j].Name })
+	return rs
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(out io.Writer) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(b, '\n'))
+	return err
+}
+
+type ganttBar struct {
+	StepReport
+	Left, Width float64
+}
+
+// WriteHTML writes the report as a standalone HTML page with a timeline of
+// the workflow's steps.
+func (r *Report) WriteHTML(out io.Writer) error {
+	start, end := r.StartTime, r.EndTime
+	for _, s := range r.Steps {
+		if start.IsZero() || s.StartTime.Before(start) {
+			start = s.StartTime
+		}
+		if s.EndTime.After(end) {
+			end = s.EndTime
+		}
+	}
+	total := end.Sub(start).Seconds()
+
+	var bars []ganttBar
+	for _, s := range r.Steps {
+		b := ganttBar{StepReport: s, Width: 100}
+		if total > 0 {
+			b.Left = 100 * s.StartTime.Sub(start).Seconds() / total
+			b.Width = 100 * s.EndTime.Sub(s.StartTime).Seconds() / total
+		}
+		bars = append(bars, b)
+	}
+
+	return reportTmpl.Execute(out, struct {
+		*Report
+		Bars     []ganttBar
+		Duration string
+	}{r, bars, end.Sub(start).Round(time.Second).String()})
+}
+
+var reportTmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct": func(f float64) string { return fmt.Sprintf("%.2f%%", f) },
+	"ts":  func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Daisy workflow {{.Workflow}} ({{.ID}})</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.ok { color: #188038; } .failed { color: #d93025; }
+.gantt td.bar { width: 60em; position: relative; }
+.gantt .fill { position: absolute; top: 4px; bottom: 4px; background: #4285f4; min-width: 2px; }
+pre { white-space: pre-wrap; margin: 0; }
+</style>
+</head>
+<body>
+<h1>Workflow {{.Workflow}} ({{.ID}})</h1>
+<table>
+<tr><th>Outcome</th><td>{{if .Success}}<span class="ok">Succeeded</span>{{else}}<span class="failed">Failed</span>{{end}}</td></tr>
+{{if .Error}}<tr><th>Error</th><td><pre>{{.Error}}</pre></td></tr>{{end}}
+<tr><th>Project</th><td>{{.Project}}</td></tr>
+<tr><th>Zone</th><td>{{.Zone}}</td></tr>
+<tr><th>Started</th><td>{{ts .StartTime}}</td></tr>
+<tr><th>Finished</th><td>{{ts .EndTime}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+{{with .Logs}}{{if .Console}}<tr><th>GCS logs</th><td><a href="{{.Console}}">{{.GCS}}</a></td></tr>{{end}}
+{{if .CloudLogging}}<tr><th>Cloud Logging</th><td><a href="{{.CloudLogging}}">query</a></td></tr>{{end}}
+{{if .Local}}<tr><th>Local logs</th><td>{{.Local}}</td></tr>{{end}}{{end}}
+</table>
+
+<h2>Steps</h2>
+<table class="gantt">
+<tr><th>Step</th><th>Duration</th><th>Timeline</th></tr>
+{{range .Bars}}<tr><td>{{.Name}}</td><td>{{.Duration}}</td><td class="bar"><div class="fill" style="left: {{pct .Left}}; width: {{pct .Width}}" title="{{ts .StartTime}} - {{ts .EndTime}}"></div></td></tr>
+{{end}}</table>
+
+{{if .Resources}}<h2>Resources</h2>
+<table>
+<tr><th>Type</th><th>Name</th><th>Link</th><th>Created</th><th>Deleted</th><th>Left behind</th></tr>
+{{range .Resources}}<tr><td>{{.Type}}</td><td>{{.Name}}</td><td>{{.Link}}</td><td>{{.Created}}</td><td>{{.Deleted}}</td><td>{{if .LeftBehind}}yes{{if .NoCleanup}} (NoCleanup){{end}}{{end}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .SerialOutputValues}}<h2>Serial output values</h2>
+<table>
+{{range $k, $v := .SerialOutputValues}}<tr><th>{{$k}}</th><td><pre>{{$v}}</pre></td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
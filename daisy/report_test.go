@@ -0,0 +1,149 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	w := testWorkflow()
+	w.bucket = "bkt"
+	w.logsPath = "scratch/logs"
+	s := &Step{name: "s", w: w}
+
+	w.disks.m = map[string]*Resource{
+		"kept":                              {link: "projects/p/zones/z/disks/kept", creator: s, createdInWorkflow: true, NoCleanup: true},
+		"deleted":                           {link: "projects/p/zones/z/disks/deleted", creator: s, createdInWorkflow: true, deleted: true},
+		"failed":                            {link: "projects/p/zones/z/disks/failed", creator: s},
+		"projects/p/zones/z/disks/existing": {link: "projects/p/zones/z/disks/existing", deleted: true},
+		"projects/p/zones/z/disks/used":     {link: "projects/p/zones/z/disks/used"},
+	}
+	sw := w.NewSubWorkflow()
+	sw.images.m = map[string]*Resource{
+		"sub-image": {link: "projects/p/global/images/sub-image", creator: s, createdInWorkflow: true},
+	}
+	w.Steps["sub"] = &Step{SubWorkflow: &SubWorkflow{Workflow: sw}}
+	// Subworkflows of included workflows are reported too.
+	iw := New()
+	w.includeWorkflow(iw)
+	isw := iw.NewSubWorkflow()
+	isw.snapshots.m = map[string]*Resource{
+		"included-sub-snapshot": {link: "projects/p/global/snapshots/included-sub-snapshot", creator: s, createdInWorkflow: true, deleted: true},
+	}
+	iw.Steps = map[string]*Step{"sub": {SubWorkflow: &SubWorkflow{Workflow: isw}}}
+	w.Steps["include"] = &Step{IncludeWorkflow: &IncludeWorkflow{Workflow: iw}}
+
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.runStartTime = start
+	w.runEndTime = start.Add(time.Minute)
+	w.recordStepTime("second", start.Add(30*time.Second), start.Add(time.Minute))
+	w.recordStepTime("first", start, start.Add(30*time.Second))
+	w.AddSerialConsoleOutputValue("key", "value")
+
+	r := w.Report()
+	assert.True(t, r.Success)
+	assert.Equal(t, []string{"first", "second"}, []string{r.Steps[0].Name, r.Steps[1].Name})
+	assert.Equal(t, "30s", r.Steps[0].Duration)
+	assert.Equal(t, map[string]string{"key": "value"}, r.SerialOutputValues)
+	assert.Equal(t, "gs://bkt/scratch/logs", r.Logs.GCS)
+	assert.Contains(t, r.Logs.CloudLogging, "daisy-"+testWf+"-abcdef")
+
+	want := []ResourceReport{
+		{Type: "disk", Name: "deleted", Link: "projects/p/zones/z/disks/deleted", Created: true, Deleted: true},
+		{Type: "disk", Name: "kept", Link: "projects/p/zones/z/disks/kept", Created: true, NoCleanup: true, LeftBehind: true},
+		{Type: "disk", Name: "projects/p/zones/z/disks/existing", Link: "projects/p/zones/z/disks/existing", Deleted: true},
+		{Type: "image", Name: "sub-image", Link: "projects/p/global/images/sub-image", Created: true, LeftBehind: true},
+		{Type: "snapshot", Name: "included-sub-snapshot", Link: "projects/p/global/snapshots/included-sub-snapshot", Created: true, Deleted: true},
+	}
+	assert.Equal(t, want, r.Resources)
+
+	var b bytes.Buffer
+	if err := r.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, r.Resources, got.Resources)
+
+	b.Reset()
+	if err := r.WriteHTML(&b); err != nil {
+		t.Fatal(err)
+	}
+	html := b.String()
+	assert.Contains(t, html, "Succeeded")
+	assert.Contains(t, html, `style="left: 50.00%; width: 50.00%"`)
+	assert.Contains(t, html, "(NoCleanup)")
+}
+
+func TestReportFailedRun(t *testing.T) {
+	w := testWorkflow()
+	w.runStartTime = time.Now()
+	w.runEndTime = time.Now()
+	w.runErr = Errf("bad %s", "thing")
+
+	r := w.Report()
+	assert.False(t, r.Success)
+	assert.Equal(t, "bad thing", r.Error)
+	assert.Equal(t, []string{"bad %s"}, r.AnonymizedErrors)
+
+	var b bytes.Buffer
+	if err := r.WriteHTML(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "Failed") {
+		t.Error("HTML report does not show failure")
+	}
+
+	// A workflow that has not run has not succeeded.
+	assert.False(t, testWorkflow().Report().Success)
+}
+
+func TestReportWhileRunning(t *testing.T) {
+	w := testWorkflow()
+	started := make(chan struct{})
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Report()
+		close(started)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				w.Report()
+			}
+		}
+	}()
+	<-started
+	// The workflow has no steps, so Run fails; it still records the run.
+	err := w.Run(context.Background())
+	close(stop)
+	<-done
+	r := w.Report()
+	assert.False(t, r.Success)
+	assert.Equal(t, err.Error(), r.Error)
+}
@@ -39,11 +39,12 @@ type Resource struct {
 	// The name of the disk as known to Daisy and the Daisy user.
 	daisyName string
 
-	link        string
-	deleted     bool
-	stoppedByWf bool
-	startedByWf bool
-	deleteMx    *sync.Mutex
+	link          string
+	deleted       bool
+	stoppedByWf   bool
+	startedByWf   bool
+	suspendedByWf bool
+	deleteMx      *sync.Mutex
 
 	creator, deleter  *Step
 	createdInWorkflow bool
@@ -144,6 +145,9 @@ func (w *Workflow) resourceExists(url string) (bool, DError) {
 		return w.instanceExists(result["project"], result["zone"], result["instance"])
 	case diskURLRgx.MatchString(url):
 		result := NamedSubexp(diskURLRgx, url)
+		if result["region"] != "" {
+			return w.regionDiskExists(result["project"], result["region"], result["disk"])
+		}
 		return w.diskExists(result["project"], result["zone"], result["disk"])
 	case imageURLRgx.MatchString(url):
 		result := NamedSubexp(imageURLRgx, url)
@@ -166,6 +170,21 @@ func (w *Workflow) resourceExists(url string) (bool, DError) {
 	case firewallRuleURLRegex.MatchString(url):
 		result := NamedSubexp(firewallRuleURLRegex, url)
 		return w.firewallRuleExists(result["project"], result["firewallRule"])
+	case addressURLRegex.MatchString(url):
+		result := NamedSubexp(addressURLRegex, url)
+		return w.addressExists(result["project"], result["region"], result["address"])
+	case routeURLRegex.MatchString(url):
+		result := NamedSubexp(routeURLRegex, url)
+		return w.routeExists(result["project"], result["route"])
+	case routerURLRegex.MatchString(url):
+		result := NamedSubexp(routerURLRegex, url)
+		return w.routerExists(result["project"], result["region"], result["router"])
+	case instanceTemplateURLRgx.MatchString(url):
+		result := NamedSubexp(instanceTemplateURLRgx, url)
+		return w.instanceTemplateExists(result["project"], result["instanceTemplate"])
+	case instanceGroupManagerURLRgx.MatchString(url):
+		result := NamedSubexp(instanceGroupManagerURLRgx, url)
+		return w.instanceGroupManagerExists(result["project"], result["zone"], result["instanceGroupManager"])
 	case snapshotURLRgx.MatchString(url):
 		result := NamedSubexp(snapshotURLRgx, url)
 		return w.snapshotExists(result["project"], result["snapshot"])
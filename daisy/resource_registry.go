@@ -15,6 +15,7 @@
 package daisy
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -26,9 +27,9 @@ type baseResourceRegistry struct {
 	m  map[string]*Resource
 	mx sync.Mutex
 
-	deleteFn func(res *Resource) DError
-	startFn  func(res *Resource) DError
-	stopFn   func(res *Resource) DError
+	deleteFn func(ctx context.Context, res *Resource) DError
+	startFn  func(ctx context.Context, res *Resource) DError
+	stopFn   func(ctx context.Context, res *Resource) DError
 	typeName string
 	urlRgx   *regexp.Regexp
 }
@@ -38,7 +39,8 @@ func (r *baseResourceRegistry) init() {
 }
 
 func (r *baseResourceRegistry) cleanup() {
-	var wg sync.WaitGroup
+	var names []string
+	r.mx.Lock()
 	for name, res := range r.m {
 		if res.creator == nil || // placeholder resource
 			(res.creator != nil && !res.createdInWorkflow) || // resource isn‘t created successfully
@@ -46,10 +48,18 @@ func (r *baseResourceRegistry) cleanup() {
 			res.deleted { // resource has been deleted
 			continue
 		}
+		names = append(names, name)
+	}
+	r.mx.Unlock()
+
+	var wg sync.WaitGroup
+	for _, name := range names {
 		wg.Add(1)
 		go func(name string) {
 			defer wg.Done()
-			if err := r.delete(name); err != nil && err.etype() != resourceDNEError {
+			// Cleanup runs after the workflow is canceled, so it must not use
+			// the workflow's step contexts.
+			if err := r.delete(context.Background(), name); err != nil && err.etype() != resourceDNEError {
 				fmt.Println(err)
 			}
 		}(name)
@@ -57,7 +67,7 @@ func (r *baseResourceRegistry) cleanup() {
 	wg.Wait()
 }
 
-func (r *baseResourceRegistry) delete(name string) DError {
+func (r *baseResourceRegistry) delete(ctx context.Context, name string) DError {
 	res, ok := r.get(name)
 	if !ok {
 		return Errf("cannot delete %s %q; does not exist in registry", r.typeName, name)
@@ -76,14 +86,16 @@ func (r *baseResourceRegistry) delete(name string) DError {
 	if res.deleted {
 		return Errf("cannot delete %q; already deleted", name)
 	}
-	if err := r.deleteFn(res); err != nil {
+	if err := r.deleteFn(ctx, res); err != nil {
 		return err
 	}
+	r.mx.Lock()
 	res.deleted = true
+	r.mx.Unlock()
 	return nil
 }
 
-func (r *baseResourceRegistry) start(name string) DError {
+func (r *baseResourceRegistry) start(ctx context.Context, name string) DError {
 	res, ok := r.get(name)
 	if !ok {
 		return Errf("cannot start %s %q; does not exist in registry", r.typeName, name)
@@ -92,7 +104,7 @@ func (r *baseResourceRegistry) start(name string) DError {
 	if res.startedByWf {
 		return Errf("cannot start %q; already started", name)
 	}
-	if err := r.startFn(res); err != nil {
+	if err := r.startFn(ctx, res); err != nil {
 		return err
 	}
 	res.stoppedByWf = false
@@ -100,7 +112,7 @@ func (r *baseResourceRegistry) start(name string) DError {
 	return nil
 }
 
-func (r *baseResourceRegistry) stop(name string) DError {
+func (r *baseResourceRegistry) stop(ctx context.Context, name string) DError {
 	res, ok := r.get(name)
 	if !ok {
 		return Errf("cannot stop %s %q; does not exist in registry", r.typeName, name)
@@ -109,7 +121,7 @@ func (r *baseResourceRegistry) stop(name string) DError {
 	if res.stoppedByWf {
 		return Errf("cannot stop %q; already stopped", name)
 	}
-	if err := r.stopFn(res); err != nil {
+	if err := r.stopFn(ctx, res); err != nil {
 		return err
 	}
 	res.startedByWf = false
@@ -117,6 +129,15 @@ func (r *baseResourceRegistry) stop(name string) DError {
 	return nil
 }
 
+// setCreated records that res was created by the workflow. createdInWorkflow
+// and deleted are written under r.mx, as progress reports read them while the
+// workflow runs.
+func (r *baseResourceRegistry) setCreated(res *Resource) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	res.createdInWorkflow = true
+}
+
 func (r *baseResourceRegistry) get(name string) (*Resource, bool) {
 	r.mx.Lock()
 	defer r.mx.Unlock()
@@ -15,6 +15,7 @@
 package daisy
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
@@ -171,7 +172,7 @@ func TestResourceRegistryConcurrency(t *testing.T) {
 func TestResourceRegistryDelete(t *testing.T) {
 	var deleteFnErr DError
 	r := &baseResourceRegistry{m: map[string]*Resource{}}
-	r.deleteFn = func(r *Resource) DError {
+	r.deleteFn = func(_ context.Context, r *Resource) DError {
 		return deleteFnErr
 	}
 
@@ -191,7 +192,7 @@ func TestResourceRegistryDelete(t *testing.T) {
 
 	for _, tt := range tests {
 		deleteFnErr = tt.deleteFnErr
-		err := r.delete(tt.input)
+		err := r.delete(context.Background(), tt.input)
 		if tt.shouldErr && err == nil {
 			t.Errorf("%s: should have erred but didn't", tt.desc)
 		} else if !tt.shouldErr && err != nil {
@@ -212,19 +213,19 @@ func TestResourceRegistryStart(t *testing.T) {
 	var startFnErr DError
 	var stopFnErr DError
 	r := &baseResourceRegistry{m: map[string]*Resource{}}
-	r.startFn = func(r *Resource) DError {
+	r.startFn = func(_ context.Context, r *Resource) DError {
 		return startFnErr
 	}
-	r.stopFn = func(r *Resource) DError {
+	r.stopFn = func(_ context.Context, r *Resource) DError {
 		return stopFnErr
 	}
 
 	r.m["foo"] = &Resource{}
 	r.m["baz"] = &Resource{}
 	r.m["stopped"] = &Resource{}
-	r.stop("stopped")
+	r.stop(context.Background(), "stopped")
 	r.m["keep_stopped"] = &Resource{}
-	r.stop("keep_stopped")
+	r.stop(context.Background(), "keep_stopped")
 
 	tests := []struct {
 		desc, input string
@@ -240,7 +241,7 @@ func TestResourceRegistryStart(t *testing.T) {
 
 	for _, tt := range tests {
 		startFnErr = tt.startFnErr
-		err := r.start(tt.input)
+		err := r.start(context.Background(), tt.input)
 		if tt.shouldErr && err == nil {
 			t.Errorf("%s: should have erred but didn't", tt.desc)
 		} else if !tt.shouldErr && err != nil {
@@ -262,7 +263,7 @@ func TestResourceRegistryStart(t *testing.T) {
 func TestResourceRegistryStop(t *testing.T) {
 	var stopFnErr DError
 	r := &baseResourceRegistry{m: map[string]*Resource{}}
-	r.stopFn = func(r *Resource) DError {
+	r.stopFn = func(_ context.Context, r *Resource) DError {
 		return stopFnErr
 	}
 
@@ -282,7 +283,7 @@ func TestResourceRegistryStop(t *testing.T) {
 
 	for _, tt := range tests {
 		stopFnErr = tt.stopFnErr
-		err := r.stop(tt.input)
+		err := r.stop(context.Background(), tt.input)
 		if tt.shouldErr && err == nil {
 			t.Errorf("%s: should have erred but didn't", tt.desc)
 		} else if !tt.shouldErr && err != nil {
@@ -0,0 +1,123 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	routeURLRegex = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?global/routes/(?P<route>%[2]s)$`, projectRgxStr, rfc1035))
+)
+
+func (w *Workflow) routeExists(project, route string) (bool, DError) {
+	return w.routeCache.resourceExists(func(project string, opts ...daisyCompute.ListCallOption) (interface{}, error) {
+		return w.ComputeClient.ListRoutes(project)
+	}, project, route)
+}
+
+// Route is used to create a GCE route.
+type Route struct {
+	compute.Route
+	Resource
+}
+
+// MarshalJSON is a hacky workaround to compute.Route's implementation.
+func (r *Route) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*r)
+}
+
+func (r *Route) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	r.Name, errs = r.Resource.populateWithGlobal(ctx, s, r.Name)
+
+	if networkURLRegex.MatchString(r.Network) {
+		r.Network = extendPartialURL(r.Network, r.Project)
+	}
+	if instanceURLRgx.MatchString(r.NextHopInstance) {
+		r.NextHopInstance = extendPartialURL(r.NextHopInstance, r.Project)
+	}
+	// Gateways are referenced by name, e.g. "default-internet-gateway".
+	if r.NextHopGateway != "" && !strings.Contains(r.NextHopGateway, "/") {
+		r.NextHopGateway = fmt.Sprintf("projects/%s/global/gateways/%s", r.Project, r.NextHopGateway)
+	}
+
+	r.Description = strOr(r.Description, defaultDescription("Route", s.w.Name, s.w.username))
+	r.link = fmt.Sprintf("projects/%s/global/routes/%s", r.Project, r.Name)
+	return errs
+}
+
+func (r *Route) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot create route %q", r.daisyName)
+	errs := r.Resource.validate(ctx, s, pre)
+
+	if r.Network == "" {
+		errs = addErrs(errs, Errf("%s: Network not set", pre))
+	} else if _, err := s.w.networks.regUse(r.Network, s); err != nil {
+		errs = addErrs(errs, err)
+	}
+	if _, _, err := net.ParseCIDR(r.DestRange); err != nil {
+		errs = addErrs(errs, Errf("%s: bad DestRange: %q, error: %v", pre, r.DestRange, err))
+	}
+
+	var hops int
+	for _, h := range []string{r.NextHopGateway, r.NextHopIlb, r.NextHopInstance, r.NextHopIp, r.NextHopVpnTunnel} {
+		if h != "" {
+			hops++
+		}
+	}
+	if hops != 1 {
+		errs = addErrs(errs, Errf("%s: exactly one of NextHopGateway, NextHopIlb, NextHopInstance, NextHopIp or NextHopVpnTunnel must be set", pre))
+	}
+	if r.NextHopInstance != "" {
+		if _, err := s.w.instances.regUse(r.NextHopInstance, s); err != nil {
+			errs = addErrs(errs, err)
+		}
+	}
+
+	// Register creation.
+	errs = addErrs(errs, s.w.routes.regCreate(r.daisyName, &r.Resource, s, false))
+	return errs
+}
+
+type routeRegistry struct {
+	baseResourceRegistry
+}
+
+func newRouteRegistry(w *Workflow) *routeRegistry {
+	rr := &routeRegistry{baseResourceRegistry: baseResourceRegistry{w: w, typeName: "route", urlRgx: routeURLRegex}}
+	rr.baseResourceRegistry.deleteFn = rr.deleteFn
+	rr.init()
+	return rr
+}
+
+func (rr *routeRegistry) deleteFn(ctx context.Context, res *Resource) DError {
+	m := NamedSubexp(routeURLRegex, res.link)
+	err := daisyCompute.WithContext(ctx, rr.w.ComputeClient).DeleteRoute(m["project"], m["route"])
+	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+		return typedErr(resourceDNEError, "failed to delete route", err)
+	}
+	return newErr("failed to delete route", err)
+}
@@ -0,0 +1,144 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+var (
+	routerURLRegex = regexp.MustCompile(fmt.Sprintf(`^(projects/(?P<project>%[1]s)/)?regions/(?P<region>%[2]s)/routers/(?P<router>%[2]s)$`, projectRgxStr, rfc1035))
+)
+
+const (
+	natAutoOnly   = "AUTO_ONLY"
+	natManualOnly = "MANUAL_ONLY"
+)
+
+func (w *Workflow) routerExists(project, region, router string) (bool, DError) {
+	return w.routerCache.resourceExists(func(project, region string, opts ...daisyCompute.ListCallOption) (interface{}, error) {
+		return w.ComputeClient.ListRouters(project, region)
+	}, project, region, router)
+}
+
+// Router is used to create a GCE Cloud Router, including its Cloud NAT
+// configs.
+type Router struct {
+	compute.Router
+	Resource
+}
+
+// MarshalJSON is a hacky workaround to compute.Router's implementation.
+func (r *Router) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*r)
+}
+
+func (r *Router) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	r.Name, r.Region, errs = r.Resource.populateWithRegion(ctx, s, r.Name, r.Region)
+
+	if networkURLRegex.MatchString(r.Network) {
+		r.Network = extendPartialURL(r.Network, r.Project)
+	}
+	for _, nat := range r.Nats {
+		nat.NatIpAllocateOption = strOr(nat.NatIpAllocateOption, natAutoOnly)
+		nat.SourceSubnetworkIpRangesToNat = strOr(nat.SourceSubnetworkIpRangesToNat, "ALL_SUBNETWORKS_ALL_IP_RANGES")
+		for i, ip := range nat.NatIps {
+			if addressURLRegex.MatchString(ip) {
+				nat.NatIps[i] = extendPartialURL(ip, r.Project)
+			}
+		}
+		for _, sn := range nat.Subnetworks {
+			if subnetworkURLRegex.MatchString(sn.Name) {
+				sn.Name = extendPartialURL(sn.Name, r.Project)
+			}
+		}
+	}
+
+	r.Description = strOr(r.Description, defaultDescription("Router", s.w.Name, s.w.username))
+	r.link = fmt.Sprintf("projects/%s/regions/%s/routers/%s", r.Project, r.Region, r.Name)
+	return errs
+}
+
+func (r *Router) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot create router %q", r.daisyName)
+	errs := r.Resource.validateWithRegion(ctx, s, r.Region, pre)
+
+	if r.Network == "" {
+		errs = addErrs(errs, Errf("%s: Network not set", pre))
+	} else if _, err := s.w.networks.regUse(r.Network, s); err != nil {
+		errs = addErrs(errs, err)
+	}
+
+	for _, nat := range r.Nats {
+		if nat.Name == "" {
+			errs = addErrs(errs, Errf("%s: NAT config name not set", pre))
+		}
+		switch nat.NatIpAllocateOption {
+		case natAutoOnly:
+			if len(nat.NatIps) > 0 {
+				errs = addErrs(errs, Errf("%s: NAT config %q: NatIps can only be used with NatIpAllocateOption %q", pre, nat.Name, natManualOnly))
+			}
+		case natManualOnly:
+			if len(nat.NatIps) == 0 {
+				errs = addErrs(errs, Errf("%s: NAT config %q: NatIpAllocateOption %q needs NatIps", pre, nat.Name, natManualOnly))
+			}
+		default:
+			errs = addErrs(errs, Errf("%s: NAT config %q: NatIpAllocateOption %q not one of %v", pre, nat.Name, nat.NatIpAllocateOption, []string{natAutoOnly, natManualOnly}))
+		}
+		for _, ip := range nat.NatIps {
+			if _, err := s.w.addresses.regUse(ip, s); err != nil {
+				errs = addErrs(errs, err)
+			}
+		}
+		for _, sn := range nat.Subnetworks {
+			if _, err := s.w.subnetworks.regUse(sn.Name, s); err != nil {
+				errs = addErrs(errs, err)
+			}
+		}
+	}
+
+	// Register creation.
+	errs = addErrs(errs, s.w.routers.regCreate(r.daisyName, &r.Resource, s, false))
+	return errs
+}
+
+type routerRegistry struct {
+	baseResourceRegistry
+}
+
+func newRouterRegistry(w *Workflow) *routerRegistry {
+	rr := &routerRegistry{baseResourceRegistry: baseResourceRegistry{w: w, typeName: "router", urlRgx: routerURLRegex}}
+	rr.baseResourceRegistry.deleteFn = rr.deleteFn
+	rr.init()
+	return rr
+}
+
+func (rr *routerRegistry) deleteFn(ctx context.Context, res *Resource) DError {
+	m := NamedSubexp(routerURLRegex, res.link)
+	err := daisyCompute.WithContext(ctx, rr.w.ComputeClient).DeleteRouter(m["project"], m["region"], m["router"])
+	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
+		return typedErr(resourceDNEError, "failed to delete router", err)
+	}
+	return newErr("failed to delete router", err)
+}
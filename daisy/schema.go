@@ -0,0 +1,346 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// schema is a JSON Schema.
+type schema map[string]interface{}
+
+// ignoredFields are fields, by definition name, that Daisy doesn't read but
+// that existing workflow files set. They're accepted with any value so the
+// files check cleanly without changing what they do.
+var ignoredFields = map[string][]string{
+	"InstanceSignal": {"Timeout"},
+	"Var":            {"Default"},
+}
+
+// customSchema returns the schema of types with their own UnmarshalJSON.
+func (g *schemaGen) customSchema(t reflect.Type) (schema, bool) {
+	switch t {
+	case reflect.TypeOf(Var{}):
+		return schema{"anyOf": []interface{}{schema{"type": "string"}, g.structSchema(t)}}, true
+	case reflect.TypeOf(FailureMatches{}):
+		return schema{"anyOf": []interface{}{schema{"type": "string"}, schema{"type": "array", "items": schema{"type": "string"}}}}, true
+	case reflect.TypeOf(guestOsFeatures{}):
+		return schema{"type": "array", "items": schema{"anyOf": []interface{}{schema{"type": "string"}, g.schema(reflect.TypeOf(compute.GuestOsFeature{}))}}}, true
+	// The lists are read with every API version, use the one with the most
+	// fields.
+	case reflect.TypeOf(CreateImages{}):
+		return schema{"type": "array", "items": g.schema(reflect.TypeOf(ImageAlpha{}))}, true
+	case reflect.TypeOf(CreateInstances{}):
+		return schema{"type": "array", "items": g.schema(reflect.TypeOf(InstanceBeta{}))}, true
+	}
+	return nil, false
+}
+
+// WorkflowSchema returns a JSON Schema (draft-07) of the workflow file format,
+// derived from the Workflow and Step types. Field names are the ones used in
+// the documentation although Daisy matches them case insensitively.
+func WorkflowSchema() map[string]interface{} {
+	g := &schemaGen{defs: map[string]interface{}{}}
+	ref := g.schema(reflect.TypeOf(Workflow{}))
+	// Let files refer to the schema for editors.
+	g.defs["Workflow"].(schema)["properties"].(schema)["$schema"] = schema{"type": "string"}
+	return map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "Daisy workflow",
+		"$ref":        ref["$ref"],
+		"definitions": g.defs,
+	}
+}
+
+type schemaGen struct {
+	defs map[string]interface{}
+}
+
+func (g *schemaGen) schema(t reflect.Type) schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, ok := g.customSchema(t); ok {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Base64 encoded.
+			return schema{"type": "string"}
+		}
+		return schema{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.defs[name]; !ok {
+			// Placeholder for recursive types.
+			g.defs[name] = schema{}
+			g.defs[name] = g.structSchema(t)
+		}
+		return schema{"$ref": "#/definitions/" + name}
+	}
+	return schema{}
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) schema {
+	props := schema{}
+	for _, f := range schemaFields(t) {
+		s := g.schema(f.typ)
+		if f.quoted {
+			s = schema{"type": "string"}
+		}
+		props[f.name] = s
+	}
+	for _, name := range ignoredFields[schemaName(t)] {
+		props[name] = schema{"description": "Ignored by Daisy."}
+	}
+	return schema{"type": "object", "properties": props, "additionalProperties": false}
+}
+
+// schemaName names the definition of t, by its name for Daisy types and
+// qualified by its package path otherwise, e.g. compute.v1.Disk.
+func schemaName(t reflect.Type) string {
+	if t.PkgPath() == reflect.TypeOf(Workflow{}).PkgPath() {
+		return t.Name()
+	}
+	p := strings.Split(t.PkgPath(), "/")
+	if len(p) > 2 {
+		p = p[len(p)-2:]
+	}
+	return strings.Join(append(p, t.Name()), ".")
+}
+
+type schemaField struct {
+	name   string
+	typ    reflect.Type
+	quoted bool
+	depth  int
+	tagged bool
+}
+
+// schemaFields returns the fields encoding/json reads into a t, following its
+// rules for embedded structs.
+func schemaFields(t reflect.Type) []schemaField {
+	var all []schemaField
+	var walk func(t reflect.Type, depth int)
+	walk = func(t reflect.Type, depth int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			opts := strings.Split(tag, ",")
+			name := opts[0]
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				walk(ft, depth+1)
+				continue
+			}
+			if f.PkgPath != "" || ft.Kind() == reflect.Chan || ft.Kind() == reflect.Func {
+				continue
+			}
+			sf := schemaField{name: name, typ: f.Type, depth: depth, tagged: name != ""}
+			if name == "" {
+				sf.name = f.Name
+			}
+			for _, o := range opts[1:] {
+				if o == "string" {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+						reflect.Float32, reflect.Float64, reflect.String:
+						sf.quoted = true
+					}
+				}
+			}
+			all = append(all, sf)
+		}
+	}
+	walk(t, 0)
+
+	// The shallowest field of a name wins, the tagged one if there are several.
+	var fields []schemaField
+	done := map[string]bool{}
+	for _, f := range all {
+		if done[f.name] {
+			continue
+		}
+		done[f.name] = true
+		var shallowest, tagged []schemaField
+		for _, o := range all {
+			switch {
+			case o.name != f.name:
+			case len(shallowest) == 0 || o.depth < shallowest[0].depth:
+				shallowest = []schemaField{o}
+			case o.depth == shallowest[0].depth:
+				shallowest = append(shallowest, o)
+			}
+		}
+		for _, o := range shallowest {
+			if o.tagged {
+				tagged = append(tagged, o)
+			}
+		}
+		if len(shallowest) == 1 {
+			fields = append(fields, shallowest[0])
+		} else if len(tagged) == 1 {
+			fields = append(fields, tagged[0])
+		}
+	}
+	return fields
+}
+
+// ValidateWorkflowSchema checks the workflow file data against WorkflowSchema
+// and returns the mismatches. Field names are matched case insensitively, as
+// Daisy reads them. No GCP access is needed, unlike Workflow.Validate.
+func ValidateWorkflowSchema(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after the workflow")
+	}
+	s := WorkflowSchema()
+	sv := &schemaValidator{defs: s["definitions"].(map[string]interface{})}
+	return sv.validate(schema{"$ref": s["$ref"]}, v, ""), nil
+}
+
+type schemaValidator struct {
+	defs map[string]interface{}
+}
+
+func (sv *schemaValidator) validate(s schema, v interface{}, path string) []string {
+	if ref, ok := s["$ref"].(string); ok {
+		s = sv.defs[strings.TrimPrefix(ref, "#/definitions/")].(schema)
+	}
+	// encoding/json accepts null for any field.
+	if v == nil {
+		return nil
+	}
+	errorf := func(format string, a ...interface{}) []string {
+		msg := fmt.Sprintf(format, a...)
+		if path != "" {
+			msg = path + ": " + msg
+		}
+		return []string{msg}
+	}
+
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		for _, alt := range anyOf {
+			if len(sv.validate(alt.(schema), v, path)) == 0 {
+				return nil
+			}
+		}
+		return errorf("%s doesn't match any of the accepted forms", jsonType(v))
+	}
+
+	t, _ := s["type"].(string)
+	if got := jsonType(v); t != "" && got != t && !(t == "number" && got == "integer") {
+		return errorf("want %s, got %s", t, got)
+	}
+	var errs []string
+	switch v := v.(type) {
+	case jsonObject:
+		props, _ := s["properties"].(schema)
+		for _, f := range v {
+			p := f.key
+			if path != "" {
+				p = path + "." + f.key
+			}
+			if ps := lookupProperty(props, f.key); ps != nil {
+				errs = append(errs, sv.validate(ps, f.value, p)...)
+				continue
+			}
+			switch ap := s["additionalProperties"].(type) {
+			case schema:
+				errs = append(errs, sv.validate(ap, f.value, p)...)
+			case bool:
+				if !ap {
+					errs = append(errs, errorf("unknown field %q", f.key)...)
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"].(schema); ok {
+			for i, e := range v {
+				errs = append(errs, sv.validate(items, e, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+// lookupProperty returns the schema of the property key, preferring an exact
+// match to a case insensitive one like encoding/json.
+func lookupProperty(props schema, key string) schema {
+	if s, ok := props[key].(schema); ok {
+		return s
+	}
+	for k, s := range props {
+		if strings.EqualFold(k, key) {
+			return s.(schema)
+		}
+	}
+	return nil
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case jsonObject:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return "null"
+}
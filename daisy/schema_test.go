@@ -0,0 +1,151 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowSchema(t *testing.T) {
+	s := WorkflowSchema()
+	if _, err := json.Marshal(s); err != nil {
+		t.Fatal(err)
+	}
+	defs := s["definitions"].(map[string]interface{})
+	prop := func(def, name string) interface{} {
+		d, ok := defs[def].(schema)
+		if !ok {
+			t.Fatalf("no definition %q", def)
+		}
+		return d["properties"].(schema)[name]
+	}
+
+	tests := []struct {
+		desc, def, prop string
+		want            interface{}
+	}{
+		{"workflow field", "Workflow", "Name", schema{"type": "string"}},
+		{"ignored field", "Workflow", "Cancel", nil},
+		{"unexported field", "Workflow", "id", nil},
+		{"recursive type", "IncludeWorkflow", "Workflow", schema{"$ref": "#/definitions/Workflow"}},
+		{"custom UnmarshalJSON", "SerialOutput", "failureMatch", schema{"anyOf": []interface{}{schema{"type": "string"}, schema{"type": "array", "items": schema{"type": "string"}}}}},
+		{"custom list", "Step", "CreateInstances", schema{"type": "array", "items": schema{"$ref": "#/definitions/InstanceBeta"}}},
+		{"shadowed embedded field", "Disk", "sizeGb", schema{"type": "string"}},
+		{"promoted embedded field", "Disk", "name", schema{"type": "string"}},
+		{"quoted number", "compute.v1.AttachedDiskInitializeParams", "diskSizeGb", schema{"type": "string"}},
+		{"number", "compute.v1.AttachedDisk", "index", schema{"type": "integer"}},
+	}
+	for _, tt := range tests {
+		if got := prop(tt.def, tt.prop); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: want %s.%s %v, got %v", tt.desc, tt.def, tt.prop, tt.want, got)
+		}
+	}
+}
+
+func TestValidateWorkflowSchema(t *testing.T) {
+	tests := []struct {
+		desc, data string
+		want       []string
+	}{
+		{"empty", `{}`, nil},
+		{
+			"valid",
+			`{"$schema": "daisy.schema.json", "name": "wf", "Vars": {"a": "a", "b": {"Value": "b", "Required": true}}, "Steps": {"s": {"Timeout": "1m", "createDisks": [{"Name": "d", "SizeGb": "10"}]}}, "Dependencies": {"s": []}}`,
+			nil,
+		},
+		{
+			"custom shapes",
+			`{"Steps": {"w": {"WaitForInstancesSignal": [{"Name": "i", "SerialOutput": {"FailureMatch": "f"}}, {"Name": "j", "SerialOutput": {"FailureMatch": ["f", "g"]}}]}, "i": {"CreateImages": [{"Name": "i", "GuestOsFeatures": ["UEFI_COMPATIBLE", {"Type": "WINDOWS"}]}]}}}`,
+			nil,
+		},
+		{
+			"ignored fields",
+			`{"Vars": {"v": {"Default": "x"}}, "Steps": {"w": {"WaitForInstancesSignal": [{"Name": "i", "Timeout": "15m"}]}}}`,
+			nil,
+		},
+		{"nulls", `{"Name": null, "Steps": {"s": null}}`, nil},
+		{
+			"wrong types",
+			`{"Name": 1, "Steps": {"s": {"CreateInstances": [{"Name": "i", "Disks": [{"Source": "d", "Index": "0"}]}], "StopInstances": {"Instances": "i"}}}}`,
+			[]string{
+				"Name: want string, got integer",
+				"Steps.s.CreateInstances[0].Disks[0].Index: want integer, got string",
+				"Steps.s.StopInstances.Instances: want array, got string",
+			},
+		},
+		{
+			"unknown fields",
+			`{"Steps": {"s": {"WaitForInstancesSignal": [{"Name": "i", "Interval": "1m", "Signal": "x"}]}}, "Var": {}}`,
+			[]string{
+				`Steps.s.WaitForInstancesSignal[0]: unknown field "Signal"`,
+				`unknown field "Var"`,
+			},
+		},
+		{
+			"no accepted form",
+			`{"Vars": {"v": {"Value": 1}}, "Steps": {"s": {"WaitForInstancesSignal": [{"SerialOutput": {"FailureMatch": 1}}]}}}`,
+			[]string{
+				"Vars.v: object doesn't match any of the accepted forms",
+				"Steps.s.WaitForInstancesSignal[0].SerialOutput.FailureMatch: integer doesn't match any of the accepted forms",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ValidateWorkflowSchema([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: want %q, got %q", tt.desc, tt.want, got)
+		}
+	}
+
+	if _, err := ValidateWorkflowSchema([]byte(`{"Name": "wf",}`)); err == nil {
+		t.Error("expected error for invalid JSON, got none")
+	}
+}
+
+// TestWorkflowFilesSchema checks the workflow files under daisy_workflows.
+func TestWorkflowFilesSchema(t *testing.T) {
+	dir := "../daisy_workflows"
+	if _, err := os.Stat(dir); err != nil {
+		t.Skipf("no workflows to check: %v", err)
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !strings.HasSuffix(path, ".wf.json") {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		errs, err := ValidateWorkflowSchema(data)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+		for _, e := range errs {
+			t.Errorf("%s: %s", path, e)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,604 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package server runs Daisy workflows submitted through an HTTP/JSON API.
+//
+// The API is:
+//
+//	POST /runs               submit a workflow, see SubmitRequest
+//	GET  /runs               list runs, most recent first, ?state= filters
+//	GET  /runs/<id>          get a run, including its step states
+//	GET  /runs/<id>/logs     get the run's daisy log, ?follow=true streams it
+//	POST /runs/<id>/cancel   cancel a queued or running run, see CancelRequest
+//
+// Run history is kept in the server's data directory, which also holds each
+// run's local logs.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+)
+
+// Run states.
+const (
+	StateQueued   = "QUEUED"
+	StateRunning  = "RUNNING"
+	StateDone     = "DONE"
+	StateFailed   = "FAILED"
+	StateCanceled = "CANCELED"
+)
+
+// Step states.
+const (
+	StepRunning  = "RUNNING"
+	StepFinished = "FINISHED"
+)
+
+const (
+	defaultMaxConcurrentRuns = 4
+	defaultCancelReason      = "canceled through the daisy server API"
+	daisyLogFile             = "daisy.log"
+)
+
+// logPollInterval is how often followed logs are checked for new output.
+var logPollInterval = 500 * time.Millisecond
+
+// SubmitRequest is the body of a POST /runs request. One of Workflow and Path
+// must be set.
+type SubmitRequest struct {
+	// Workflow is an inline workflow. Local files it uses, such as Sources,
+	// included workflows and OAuthPath, have to be in the server's workflow
+	// directory; relative paths are relative to the run's directory, so use
+	// absolute ones. It can include workflows from Cloud Storage and HTTPS,
+	// but not from git.
+	Workflow json.RawMessage `json:",omitempty"`
+	// Path is the path of a workflow file in the server's workflow
+	// directory, relative to it.
+	Path string `json:",omitempty"`
+	// Vars are the workflow's variables.
+	Vars map[string]string `json:",omitempty"`
+}
+
+// CancelRequest is the optional body of a POST /runs/<id>/cancel request.
+type CancelRequest struct {
+	Reason string `json:",omitempty"`
+}
+
+// StepState is the state of a workflow step that started running. Steps of
+// included and sub workflows are prefixed with their workflow's name.
+type StepState struct {
+	Name      string
+	State     string
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  string
+}
+
+// Run is a workflow run, as persisted and returned by the server.
+type Run struct {
+	ID string
+	// Workflow is the workflow's name and WorkflowID its Daisy ID.
+	Workflow   string
+	WorkflowID string
+	// Path is the workflow file that was run.
+	Path string
+	Vars map[string]string `json:",omitempty"`
+	// LogsPath is the directory with the run's local logs.
+	LogsPath     string
+	State        string
+	Error        string `json:",omitempty"`
+	CancelReason string `json:",omitempty"`
+	SubmitTime   time.Time
+	StartTime    time.Time
+	EndTime      time.Time
+	Steps        []StepState            `json:",omitempty"`
+	Resources    []daisy.ResourceReport `json:",omitempty"`
+}
+
+// Config configures a Server.
+type Config struct {
+	// DataDir keeps the run history and logs.
+	DataDir string
+	// WorkflowDir is the directory workflow files submitted by Path are read
+	// from. Paths can't leave it. If it's empty, only inline workflows are
+	// accepted.
+	WorkflowDir string
+	// MaxConcurrentRuns is the number of workflows run at the same time,
+	// further runs are queued. Defaults to 4.
+	MaxConcurrentRuns int
+	// Load reads the workflow file at path and sets its vars. It's the place
+	// to apply server wide settings, such as project overrides or clients.
+	// Defaults to reading the file with daisy.NewFromFile.
+	Load func(path string, vars map[string]string) (*daisy.Workflow, error)
+}
+
+// Server runs workflows and serves the API.
+type Server struct {
+	cfg   Config
+	store *store
+	sem   chan struct{}
+
+	mx     sync.Mutex
+	active map[string]*daisy.Workflow
+	wg     sync.WaitGroup
+}
+
+// New creates a Server, loading the run history kept in cfg.DataDir.
+func New(cfg Config) (*Server, error) {
+	if cfg.DataDir == "" {
+		return nil, fmt.Errorf("no data directory set")
+	}
+	if cfg.MaxConcurrentRuns <= 0 {
+		cfg.MaxConcurrentRuns = defaultMaxConcurrentRuns
+	}
+	if cfg.Load == nil {
+		cfg.Load = loadWorkflow
+	}
+	st, err := openStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("error loading run history: %v", err)
+	}
+	return &Server{
+		cfg:    cfg,
+		store:  st,
+		sem:    make(chan struct{}, cfg.MaxConcurrentRuns),
+		active: map[string]*daisy.Workflow{},
+	}, nil
+}
+
+// loadWorkflow reads a workflow file and sets its vars, which have to be
+// declared by the workflow.
+func loadWorkflow(path string, vars map[string]string) (*daisy.Workflow, error) {
+	w, err := daisy.NewFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range vars {
+		if _, ok := w.Vars[k]; !ok {
+			return nil, fmt.Errorf("unknown workflow Var %q passed to Workflow %q", k, w.Name)
+		}
+		w.AddVar(k, v)
+	}
+	w.DisableStdoutLogging()
+	return w, nil
+}
+
+// Submit queues a workflow run.
+func (s *Server) Submit(req *SubmitRequest) (*Run, error) {
+	if (len(req.Workflow) == 0) == (req.Path == "") {
+		return nil, fmt.Errorf("exactly one of Workflow and Path must be set")
+	}
+	var path string
+	if req.Path != "" {
+		var err error
+		if path, err = s.workflowPath(req.Path); err != nil {
+			return nil, err
+		}
+	}
+	id, err := s.store.newID()
+	if err != nil {
+		return nil, err
+	}
+	dir := s.store.runDir(id)
+	inline := len(req.Workflow) != 0
+	if inline {
+		// Load reads the credentials, so they're checked first.
+		if err := s.checkInlineOAuthPath(req.Workflow, dir); err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		// Keep inline workflows with the run, they're read like any other.
+		path = filepath.Join(dir, workflowFile)
+		if err := ioutil.WriteFile(path, req.Workflow, 0644); err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+	}
+	w, err := s.cfg.Load(path, req.Vars)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if inline {
+		w.SetPathCheck(s.checkInlinePath)
+	}
+	w.SetLocalLogsDir(dir)
+
+	r := Run{
+		ID:         id,
+		Workflow:   w.Name,
+		WorkflowID: w.ID(),
+		Path:       path,
+		Vars:       req.Vars,
+		LogsPath:   w.LocalLogsPath(),
+		State:      StateQueued,
+		SubmitTime: time.Now(),
+	}
+	if err := s.store.put(r); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	s.mx.Lock()
+	s.active[id] = w
+	s.mx.Unlock()
+	s.wg.Add(1)
+	go s.execute(id, w)
+	return &r, nil
+}
+
+// workflowPath returns the path of the workflow file p in the workflow
+// directory. It fails if p, after following symlinks, is outside of it.
+func (s *Server) workflowPath(p string) (string, error) {
+	if s.cfg.WorkflowDir == "" {
+		return "", fmt.Errorf("this server only runs inline workflows, set Workflow instead of Path")
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(s.cfg.WorkflowDir, p)
+	}
+	if _, err := os.Stat(p); err != nil {
+		return "", err
+	}
+	if err := s.inWorkflowDir(p); err != nil {
+		return "", err
+	}
+	return filepath.Clean(p), nil
+}
+
+// checkInlinePath is the path check of inline workflows: local files have to
+// be in the workflow directory, and git workflows, which are fetched by
+// running git on the server, can't be included.
+func (s *Server) checkInlinePath(p string) error {
+	switch {
+	case strings.HasPrefix(p, "git::"):
+		return fmt.Errorf("inline workflows can't include git workflows")
+	case strings.HasPrefix(p, "gs://"), strings.HasPrefix(p, "https://"):
+		return nil
+	}
+	return s.inWorkflowDir(p)
+}
+
+// checkInlineOAuthPath checks the OAuthPath set by the inline workflow data,
+// whose relative paths are relative to dir.
+func (s *Server) checkInlineOAuthPath(data json.RawMessage, dir string) error {
+	var wf struct{ OAuthPath string }
+	// Invalid workflows are reported by Load.
+	if err := json.Unmarshal(data, &wf); err != nil || wf.OAuthPath == "" {
+		return nil
+	}
+	p := wf.OAuthPath
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	if err := s.inWorkflowDir(p); err != nil {
+		return fmt.Errorf("OAuthPath %q can't be used: %v", wf.OAuthPath, err)
+	}
+	return nil
+}
+
+// inWorkflowDir fails if the local path p, after following symlinks, is
+// outside of the workflow directory. A missing file, such as a copy
+// destination, is checked by its closest existing parent.
+func (s *Server) inWorkflowDir(p string) error {
+	if s.cfg.WorkflowDir == "" {
+		return fmt.Errorf("the server has no workflow directory for local files")
+	}
+	root, err := filepath.EvalSymlinks(s.cfg.WorkflowDir)
+	if err != nil {
+		return fmt.Errorf("error reading workflow directory: %v", err)
+	}
+	resolved, err := resolvePath(p)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%q is not in the server's workflow directory", p)
+	}
+	return nil
+}
+
+// resolvePath returns the absolute path p with symlinks followed, keeping the
+// parts of p that don't exist as they are.
+func resolvePath(p string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(p)
+	if err == nil || !os.IsNotExist(err) {
+		return resolved, err
+	}
+	// Without its missing parts, ".." in p could refer to a symlink's parent.
+	if !filepath.IsAbs(p) || filepath.Clean(p) != p {
+		return "", err
+	}
+	parent := filepath.Dir(p)
+	if parent == p {
+		return "", err
+	}
+	dir, err := resolvePath(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(p)), nil
+}
+
+// execute runs w once a run slot is free.
+func (s *Server) execute(id string, w *daisy.Workflow) {
+	defer s.wg.Done()
+	select {
+	case s.sem <- struct{}{}:
+	case <-w.Cancel:
+		s.finish(id, w, false, nil)
+		return
+	}
+	defer func() { <-s.sem }()
+	select {
+	case <-w.Cancel:
+		s.finish(id, w, false, nil)
+		return
+	default:
+	}
+
+	if _, err := s.store.update(id, func(r *Run) {
+		r.State = StateRunning
+		r.StartTime = time.Now()
+	}); err != nil {
+		log.Printf("Error saving run %q: %v", id, err)
+	}
+	err := w.Run(context.Background())
+	s.finish(id, w, true, err)
+}
+
+// finish records the outcome of a run.
+func (s *Server) finish(id string, w *daisy.Workflow, started bool, runErr error) {
+	s.mx.Lock()
+	delete(s.active, id)
+	s.mx.Unlock()
+
+	var rep *daisy.Report
+	if started {
+		rep = w.Report()
+	}
+	if _, err := s.store.update(id, func(r *Run) {
+		r.EndTime = time.Now()
+		switch {
+		case r.CancelReason != "":
+			r.State = StateCanceled
+		case runErr != nil:
+			r.State = StateFailed
+		default:
+			r.State = StateDone
+		}
+		if runErr != nil {
+			r.Error = runErr.Error()
+		}
+		if rep != nil {
+			r.Steps = stepStates(w)
+			r.Resources = rep.Resources
+		}
+	}); err != nil {
+		log.Printf("Error saving run %q: %v", id, err)
+	}
+}
+
+// Cancel cancels a queued or running run.
+func (s *Server) Cancel(id, reason string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	w, ok := s.active[id]
+	if !ok {
+		return fmt.Errorf("run %q is not queued or running", id)
+	}
+	if reason == "" {
+		reason = defaultCancelReason
+	}
+	if _, err := s.store.update(id, func(r *Run) {
+		if r.CancelReason == "" {
+			r.CancelReason = reason
+		}
+	}); err != nil {
+		return err
+	}
+	w.CancelWithReason(reason)
+	return nil
+}
+
+// Get returns a run, with the live step states of active runs.
+func (s *Server) Get(id string) (*Run, bool) {
+	r, ok := s.store.get(id)
+	if !ok {
+		return nil, false
+	}
+	s.mx.Lock()
+	w, active := s.active[id]
+	s.mx.Unlock()
+	if active {
+		r.Steps = stepStates(w)
+	}
+	return &r, true
+}
+
+// List returns the runs in the given state, or all runs if state is empty,
+// most recently submitted first.
+func (s *Server) List(state string) []Run {
+	var rs []Run
+	for _, r := range s.store.list() {
+		if state == "" || strings.EqualFold(r.State, state) {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// Wait waits for all queued and running runs to finish.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Server) isActive(id string) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	_, ok := s.active[id]
+	return ok
+}
+
+// stepStates lists the finished and the running steps of w.
+func stepStates(w *daisy.Workflow) []StepState {
+	var ss []StepState
+	for _, tr := range w.GetStepTimeRecords() {
+		ss = append(ss, StepState{
+			Name:      tr.Name,
+			State:     StepFinished,
+			StartTime: tr.StartTime,
+			EndTime:   tr.EndTime,
+			Duration:  tr.EndTime.Sub(tr.StartTime).Round(time.Second).String(),
+		})
+	}
+	for _, tr := range w.GetRunningSteps() {
+		ss = append(ss, StepState{
+			Name:      tr.Name,
+			State:     StepRunning,
+			StartTime: tr.StartTime,
+			Duration:  time.Since(tr.StartTime).Round(time.Second).String(),
+		})
+	}
+	return ss
+}
+
+// ServeHTTP implements the API.
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if parts[0] != "runs" || len(parts) > 3 {
+		writeError(rw, http.StatusNotFound, fmt.Errorf("no such API: %s", req.URL.Path))
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && req.Method == http.MethodGet:
+		writeJSON(rw, http.StatusOK, s.List(req.URL.Query().Get("state")))
+	case len(parts) == 1 && req.Method == http.MethodPost:
+		var sr SubmitRequest
+		if err := json.NewDecoder(req.Body).Decode(&sr); err != nil {
+			writeError(rw, http.StatusBadRequest, fmt.Errorf("error decoding request: %v", err))
+			return
+		}
+		r, err := s.Submit(&sr)
+		if err != nil {
+			writeError(rw, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(rw, http.StatusCreated, r)
+	case len(parts) == 1:
+		writeError(rw, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+	default:
+		r, ok := s.Get(parts[1])
+		if !ok {
+			writeError(rw, http.StatusNotFound, fmt.Errorf("run %q not found", parts[1]))
+			return
+		}
+		s.serveRun(rw, req, r, parts[2:])
+	}
+}
+
+func (s *Server) serveRun(rw http.ResponseWriter, req *http.Request, r *Run, parts []string) {
+	action := ""
+	if len(parts) > 0 {
+		action = parts[0]
+	}
+	switch {
+	case action == "" && req.Method == http.MethodGet:
+		writeJSON(rw, http.StatusOK, r)
+	case action == "logs" && req.Method == http.MethodGet:
+		s.serveLogs(rw, req, r, req.URL.Query().Get("follow") == "true")
+	case action == "cancel" && req.Method == http.MethodPost:
+		var cr CancelRequest
+		if err := json.NewDecoder(req.Body).Decode(&cr); err != nil && err != io.EOF {
+			writeError(rw, http.StatusBadRequest, fmt.Errorf("error decoding request: %v", err))
+			return
+		}
+		if err := s.Cancel(r.ID, cr.Reason); err != nil {
+			writeError(rw, http.StatusConflict, err)
+			return
+		}
+		r, _ = s.Get(r.ID)
+		writeJSON(rw, http.StatusOK, r)
+	case action == "" || action == "logs" || action == "cancel":
+		writeError(rw, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+	default:
+		writeError(rw, http.StatusNotFound, fmt.Errorf("no such API: %s", req.URL.Path))
+	}
+}
+
+// serveLogs writes the daisy log of r. If follow is set, it keeps writing new
+// output until the run finishes or the client goes away.
+func (s *Server) serveLogs(rw http.ResponseWriter, req *http.Request, r *Run, follow bool) {
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	flusher, _ := rw.(http.Flusher)
+	path := filepath.Join(r.LogsPath, daisyLogFile)
+	var f *os.File
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	for {
+		// Check before reading so the last read sees all of a finished run's logs.
+		done := !s.isActive(r.ID)
+		if f == nil {
+			var err error
+			if f, err = os.Open(path); err != nil && !os.IsNotExist(err) {
+				writeError(rw, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		if f != nil {
+			if _, err := io.Copy(rw, f); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if !follow || done {
+			return
+		}
+		select {
+		case <-req.Context().Done():
+			return
+		case <-time.After(logPollInterval):
+		}
+	}
+}
+
+func writeJSON(rw http.ResponseWriter, code int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	enc := json.NewEncoder(rw)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(rw http.ResponseWriter, code int, err error) {
+	writeJSON(rw, code, struct{ Error string }{err.Error()})
+}
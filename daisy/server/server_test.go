@@ -0,0 +1,469 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"cloud.google.com/go/storage"
+	"github.com/GoogleCloudPlatform/compute-image-tools/daisy"
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+const testProject = "test-project"
+
+// fakeProject keeps the common instance metadata the lock steps use.
+type fakeProject struct {
+	mx    sync.Mutex
+	items []*compute.MetadataItems
+}
+
+func (p *fakeProject) client(t *testing.T) daisyCompute.Client {
+	_, c, err := daisyCompute.NewTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"Status":"DONE"}`)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.GetProjectFn = func(project string) (*compute.Project, error) {
+		p.mx.Lock()
+		defer p.mx.Unlock()
+		return &compute.Project{Name: project, CommonInstanceMetadata: &compute.Metadata{Items: append([]*compute.MetadataItems(nil), p.items...)}}, nil
+	}
+	c.SetCommonInstanceMetadataFn = func(_ string, md *compute.Metadata) error {
+		p.mx.Lock()
+		defer p.mx.Unlock()
+		p.items = md.Items
+		return nil
+	}
+	return c
+}
+
+// hold sets a write lock on name that's held by another workflow.
+func (p *fakeProject) hold(name string) {
+	expiry := time.Now().Add(time.Hour).Format(time.RFC3339)
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.items = append(p.items, &compute.MetadataItems{Key: "daisy-write-lock_" + name + "_other", Value: &expiry})
+}
+
+// tempDir creates a directory that's removed by the returned func.
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "daisy-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// newTestServer creates a Server and serves it. The caller closes the
+// returned httptest.Server.
+func newTestServer(t *testing.T, dir string, maxRuns int) (*Server, *httptest.Server, *fakeProject) {
+	p := &fakeProject{}
+	cc := p.client(t)
+	ctx := context.Background()
+	sc, err := storage.NewClient(ctx, option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cloud Logging is disabled, the client is never used.
+	conn, err := grpc.Dial("localhost:0", grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc, err := logging.NewClient(ctx, testProject, option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(Config{
+		DataDir:           dir,
+		MaxConcurrentRuns: maxRuns,
+		Load: func(path string, vars map[string]string) (*daisy.Workflow, error) {
+			w, err := loadWorkflow(path, vars)
+			if err != nil {
+				return nil, err
+			}
+			w.ComputeClient = cc
+			w.StorageClient = sc
+			w.SetCloudLoggingClient(lc)
+			w.DisableGCSLogging()
+			w.DisableCloudLogging()
+			return w, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, httptest.NewServer(s), p
+}
+
+// lockWorkflow acquires and then releases lock.
+func lockWorkflow(lock string) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+  "Name": "lock",
+  "Project": %q,
+  "GCSPath": "gs://bucket",
+  "Steps": {
+    "acquire": {"AcquireLock": [{"Name": %q, "Interval": "10ms"}]},
+    "release": {"ReleaseLock": [%[2]q]}
+  },
+  "Dependencies": {"release": ["acquire"]}
+}`, testProject, lock))
+}
+
+func do(t *testing.T, method, url string, body interface{}, wantCode int, out interface{}) {
+	var b bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&b).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != wantCode {
+		t.Fatalf("%s %s: want status %d, got %d: %s", method, url, wantCode, resp.StatusCode, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("%s %s: %v: %s", method, url, err, data)
+		}
+	}
+}
+
+// waitFor polls the run until cond holds.
+func waitFor(t *testing.T, s *Server, id string, cond func(*Run) bool) *Run {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		r, ok := s.Get(id)
+		if ok && cond(r) {
+			return r
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for run %q, last seen: %+v", id, r)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubmitAndGet(t *testing.T) {
+	dir, rm := tempDir(t)
+	defer rm()
+	s, ts, _ := newTestServer(t, dir, 0)
+	defer ts.Close()
+
+	var r Run
+	do(t, http.MethodPost, ts.URL+"/runs", &SubmitRequest{Workflow: lockWorkflow("free")}, http.StatusCreated, &r)
+	if r.Workflow != "lock" || r.State != StateQueued {
+		t.Errorf("unexpected submitted run: %+v", r)
+	}
+	s.Wait()
+
+	var got Run
+	do(t, http.MethodGet, ts.URL+"/runs/"+r.ID, nil, http.StatusOK, &got)
+	if got.State != StateDone {
+		t.Fatalf("want state %s, got %s: %s", StateDone, got.State, got.Error)
+	}
+	var steps []string
+	for _, st := range got.Steps {
+		if st.State != StepFinished {
+			t.Errorf("step %q: want state %s, got %s", st.Name, StepFinished, st.State)
+		}
+		steps = append(steps, st.Name)
+	}
+	if strings.Join(steps, ",") != "acquire,release,workflow cleanup" {
+		t.Errorf("want steps acquire, release and cleanup, got %v", steps)
+	}
+	if got.StartTime.IsZero() || got.EndTime.IsZero() {
+		t.Errorf("run times not set: %+v", got)
+	}
+
+	resp, err := http.Get(ts.URL + "/runs/" + r.ID + "/logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(logs), `Running step "acquire"`) {
+		t.Errorf("logs don't mention the acquire step:\n%s", logs)
+	}
+
+	var runs []Run
+	do(t, http.MethodGet, ts.URL+"/runs?state=done", nil, http.StatusOK, &runs)
+	if len(runs) != 1 || runs[0].ID != r.ID {
+		t.Errorf("want run %q listed, got %+v", r.ID, runs)
+	}
+	do(t, http.MethodGet, ts.URL+"/runs?state=running", nil, http.StatusOK, &runs)
+	if len(runs) != 0 {
+		t.Errorf("want no running runs, got %+v", runs)
+	}
+}
+
+func TestSubmitPath(t *testing.T) {
+	dir, rm := tempDir(t)
+	defer rm()
+	wfDir, rmWfDir := tempDir(t)
+	defer rmWfDir()
+	otherDir, rmOtherDir := tempDir(t)
+	defer rmOtherDir()
+	s, ts, _ := newTestServer(t, dir, 0)
+	defer ts.Close()
+
+	wf := strings.Replace(string(lockWorkflow("${lock}")), `"Steps"`, `"Vars": {"lock": {"Required": true}}, "Steps"`, 1)
+	path := filepath.Join(wfDir, "wf.json")
+	outside := filepath.Join(otherDir, "wf.json")
+	for _, p := range []string{path, outside} {
+		if err := ioutil.WriteFile(p, []byte(wf), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(wfDir, "link.json")); err != nil {
+		t.Fatal(err)
+	}
+	vars := map[string]string{"lock": "free"}
+
+	// Without a workflow directory, only inline workflows are run.
+	if _, err := s.Submit(&SubmitRequest{Path: path, Vars: vars}); err == nil {
+		t.Error("expected error submitting a path without a workflow directory, got none")
+	}
+
+	s.cfg.WorkflowDir = wfDir
+	var ids []string
+	for _, p := range []string{"wf.json", path} {
+		r, err := s.Submit(&SubmitRequest{Path: p, Vars: vars})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Path != path {
+			t.Errorf("want run path %q, got %q", path, r.Path)
+		}
+		ids = append(ids, r.ID)
+	}
+	s.Wait()
+	for _, id := range ids {
+		if got, _ := s.Get(id); got.State != StateDone {
+			t.Errorf("want state %s, got %s: %s", StateDone, got.State, got.Error)
+		}
+	}
+
+	for desc, req := range map[string]*SubmitRequest{
+		"no workflow":   {},
+		"both":          {Path: path, Workflow: lockWorkflow("free")},
+		"unknown var":   {Path: path, Vars: map[string]string{"dne": "v"}},
+		"missing file":  {Path: "dne.json"},
+		"outside":       {Path: outside, Vars: vars},
+		"parent dir":    {Path: filepath.Join("..", filepath.Base(otherDir), "wf.json"), Vars: vars},
+		"symlink":       {Path: "link.json", Vars: vars},
+		"bad workflow":  {Workflow: json.RawMessage(`{"Steps": 1}`)},
+		"not an object": {Workflow: json.RawMessage(`"wf"`)},
+	} {
+		if _, err := s.Submit(req); err == nil {
+			t.Errorf("%s: expected error, got none", desc)
+		}
+	}
+	if runs := s.List(""); len(runs) != len(ids) {
+		t.Errorf("rejected runs were kept: %+v", runs)
+	}
+}
+
+func TestSubmitInlinePaths(t *testing.T) {
+	dir, rm := tempDir(t)
+	defer rm()
+	wfDir, rmWfDir := tempDir(t)
+	defer rmWfDir()
+	otherDir, rmOtherDir := tempDir(t)
+	defer rmOtherDir()
+	s, ts, _ := newTestServer(t, dir, 0)
+	defer ts.Close()
+	s.cfg.WorkflowDir = wfDir
+
+	inside := filepath.Join(wfDir, "file")
+	outside := filepath.Join(otherDir, "file")
+	for _, p := range []string{inside, outside} {
+		if err := ioutil.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(wfDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{inside, filepath.Join(wfDir, "sub", "new"), "gs://bucket/file", "https://example.com/wf.json"} {
+		if err := s.checkInlinePath(p); err != nil {
+			t.Errorf("%s: unexpected error: %v", p, err)
+		}
+	}
+	for _, p := range []string{
+		outside,
+		filepath.Join(wfDir, "link"),
+		filepath.Join(wfDir, "..", filepath.Base(otherDir), "file"),
+		wfDir + "/link/../new",
+		"git::https://example.com/repo.git//wf.json?ref=v1",
+	} {
+		if err := s.checkInlinePath(p); err == nil {
+			t.Errorf("%s: expected error, got none", p)
+		}
+	}
+
+	// The check is used by the runs of inline workflows.
+	wf := strings.Replace(string(lockWorkflow("free")), `"Steps"`, fmt.Sprintf(`"Sources": {"file": %q}, "Steps"`, outside), 1)
+	r, err := s.Submit(&SubmitRequest{Workflow: json.RawMessage(wf)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+	if got, _ := s.Get(r.ID); got.State != StateFailed || !strings.Contains(got.Error, "not in the server's workflow directory") {
+		t.Errorf("want the run to fail on the source outside the workflow directory, got %s: %s", got.State, got.Error)
+	}
+
+	wf = strings.Replace(string(lockWorkflow("free")), `"Steps"`, fmt.Sprintf(`"OAuthPath": %q, "Steps"`, outside), 1)
+	if _, err := s.Submit(&SubmitRequest{Workflow: json.RawMessage(wf)}); err == nil {
+		t.Error("expected error for an OAuthPath outside the workflow directory, got none")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	dir, rm := tempDir(t)
+	defer rm()
+	s, ts, p := newTestServer(t, dir, 1)
+	defer ts.Close()
+	p.hold("busy")
+
+	var running, queued Run
+	do(t, http.MethodPost, ts.URL+"/runs", &SubmitRequest{Workflow: lockWorkflow("busy")}, http.StatusCreated, &running)
+	do(t, http.MethodPost, ts.URL+"/runs", &SubmitRequest{Workflow: lockWorkflow("busy")}, http.StatusCreated, &queued)
+
+	got := waitFor(t, s, running.ID, func(r *Run) bool { return len(r.Steps) > 0 })
+	if got.State != StateRunning || got.Steps[0].Name != "acquire" || got.Steps[0].State != StepRunning {
+		t.Errorf("want step acquire running, got %+v", got)
+	}
+	// Only one run at a time.
+	if got, _ := s.Get(queued.ID); got.State != StateQueued {
+		t.Errorf("want second run %s, got %s", StateQueued, got.State)
+	}
+
+	do(t, http.MethodPost, ts.URL+"/runs/"+queued.ID+"/cancel", nil, http.StatusOK, nil)
+	do(t, http.MethodPost, ts.URL+"/runs/"+running.ID+"/cancel", &CancelRequest{Reason: "test over"}, http.StatusOK, nil)
+	s.Wait()
+
+	for _, id := range []string{running.ID, queued.ID} {
+		if got, _ := s.Get(id); got.State != StateCanceled {
+			t.Errorf("run %q: want state %s, got %s", id, StateCanceled, got.State)
+		}
+	}
+	if got, _ := s.Get(running.ID); got.CancelReason != "test over" || !strings.Contains(got.Error, "test over") {
+		t.Errorf("want cancel reason in run, got %+v", got)
+	}
+	if got, _ := s.Get(queued.ID); !got.StartTime.IsZero() || got.CancelReason != defaultCancelReason {
+		t.Errorf("queued run shouldn't have started: %+v", got)
+	}
+
+	do(t, http.MethodPost, ts.URL+"/runs/"+running.ID+"/cancel", nil, http.StatusConflict, nil)
+	do(t, http.MethodPost, ts.URL+"/runs/dne/cancel", nil, http.StatusNotFound, nil)
+	do(t, http.MethodGet, ts.URL+"/runs/"+running.ID+"/cancel", nil, http.StatusMethodNotAllowed, nil)
+	do(t, http.MethodGet, ts.URL+"/dne", nil, http.StatusNotFound, nil)
+}
+
+func TestFollowLogs(t *testing.T) {
+	logPollInterval = 10 * time.Millisecond
+	dir, rm := tempDir(t)
+	defer rm()
+	s, ts, p := newTestServer(t, dir, 0)
+	defer ts.Close()
+	p.hold("busy")
+
+	r, err := s.Submit(&SubmitRequest{Workflow: lockWorkflow("busy")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, s, r.ID, func(r *Run) bool { return len(r.Steps) > 0 })
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.Cancel(r.ID, "")
+	}()
+
+	// Returns once the run is over.
+	resp, err := http.Get(ts.URL + "/runs/" + r.ID + "/logs?follow=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{`Waiting for write lock "busy"`, "cleaning up"} {
+		if !strings.Contains(string(logs), want) {
+			t.Errorf("followed logs don't contain %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	dir, rm := tempDir(t)
+	defer rm()
+	s, ts, _ := newTestServer(t, dir, 0)
+	ts.Close()
+	r, err := s.Submit(&SubmitRequest{Workflow: lockWorkflow("free")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+
+	// A run left behind by a server that went away.
+	stale := Run{ID: "stale", State: StateRunning, SubmitTime: time.Now()}
+	if err := os.Mkdir(s.store.runDir(stale.ID), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.write(stale); err != nil {
+		t.Fatal(err)
+	}
+
+	s, ts, _ = newTestServer(t, dir, 0)
+	ts.Close()
+	runs := s.List("")
+	if len(runs) != 2 || runs[0].ID != stale.ID || runs[1].ID != r.ID {
+		t.Fatalf("want runs %q and %q, got %+v", stale.ID, r.ID, runs)
+	}
+	if runs[0].State != StateFailed || runs[0].Error == "" {
+		t.Errorf("want stale run failed, got %+v", runs[0])
+	}
+	if runs[1].State != StateDone || len(runs[1].Steps) != 3 {
+		t.Errorf("finished run not kept as is: %+v", runs[1])
+	}
+}
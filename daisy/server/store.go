@@ -0,0 +1,167 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	runsDir      = "runs"
+	runFile      = "run.json"
+	workflowFile = "workflow.json"
+)
+
+// store keeps the run history in memory and persists every run as a JSON
+// file in its own directory: <dir>/runs/<id>/run.json.
+type store struct {
+	dir  string
+	mx   sync.Mutex
+	runs map[string]Run
+	gen  *rand.Rand
+}
+
+// openStore loads the runs persisted under dir. Runs that were still queued
+// or running are marked failed, the server that ran them is gone.
+func openStore(dir string) (*store, error) {
+	s := &store{dir: dir, runs: map[string]Run{}, gen: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	if err := os.MkdirAll(filepath.Join(dir, runsDir), 0755); err != nil {
+		return nil, err
+	}
+	fis, err := ioutil.ReadDir(filepath.Join(dir, runsDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(s.runDir(fi.Name()), runFile))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var r Run
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("error reading run %q: %v", fi.Name(), err)
+		}
+		if r.State == StateQueued || r.State == StateRunning {
+			r.State = StateFailed
+			r.Error = "the server stopped before the run finished"
+			if err := s.write(r); err != nil {
+				return nil, err
+			}
+		}
+		s.runs[r.ID] = r
+	}
+	return s, nil
+}
+
+func (s *store) runDir(id string) string {
+	return filepath.Join(s.dir, runsDir, id)
+}
+
+// newID reserves a new run ID and creates its directory. IDs sort by
+// submission time.
+func (s *store) newID() (string, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	letters := "bdghjlmnpqrstvwxyz0123456789"
+	for {
+		b := make([]byte, 4)
+		for i := range b {
+			b[i] = letters[s.gen.Intn(len(letters))]
+		}
+		id := fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102-150405"), b)
+		if _, ok := s.runs[id]; ok {
+			continue
+		}
+		if err := os.Mkdir(s.runDir(id), 0755); os.IsExist(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		return id, nil
+	}
+}
+
+// put saves r, replacing any run with the same ID.
+func (s *store) put(r Run) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.runs[r.ID] = r
+	return s.write(r)
+}
+
+// update applies f to the run with the given ID and saves it.
+func (s *store) update(id string, f func(*Run)) (Run, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	r, ok := s.runs[id]
+	if !ok {
+		return r, fmt.Errorf("run %q not found", id)
+	}
+	f(&r)
+	s.runs[id] = r
+	return r, s.write(r)
+}
+
+func (s *store) get(id string) (Run, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	r, ok := s.runs[id]
+	return r, ok
+}
+
+// list returns all runs, most recently submitted first.
+func (s *store) list() []Run {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	var rs []Run
+	for _, r := range s.runs {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		if !rs[i].SubmitTime.Equal(rs[j].SubmitTime) {
+			return rs[i].SubmitTime.After(rs[j].SubmitTime)
+		}
+		return rs[i].ID > rs[j].ID
+	})
+	return rs
+}
+
+// write persists r. The file is replaced atomically so a crash never leaves
+// a partial record behind.
+func (s *store) write(r Run) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.runDir(r.ID), runFile)
+	if err := ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
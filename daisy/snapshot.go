@@ -75,8 +75,10 @@ func (ss *Snapshot) validate(ctx context.Context, s *Step) DError {
 	// Source disk checking.
 	if ss.SourceDisk == "" {
 		errs = addErrs(errs, Errf("%s: must provide SourceDisk", pre))
-	} else if _, err := s.w.disks.regUse(ss.SourceDisk, s); err != nil {
+	} else if dr, err := s.w.disks.regUse(ss.SourceDisk, s); err != nil {
 		errs = addErrs(errs, newErr("failed to get source disk", err))
+	} else if dr != nil && NamedSubexp(diskURLRgx, dr.link)["region"] != "" {
+		errs = addErrs(errs, Errf("%s: snapshots of regional disks are not supported: %q", pre, ss.SourceDisk))
 	}
 
 	// Register creation.
@@ -95,9 +97,9 @@ func newSnapshotRegistry(w *Workflow) *snapshotRegistry {
 	return sr
 }
 
-func (sr *snapshotRegistry) deleteFn(res *Resource) DError {
+func (sr *snapshotRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(snapshotURLRgx, res.link)
-	err := sr.w.ComputeClient.DeleteSnapshot(m["project"], m["snapshot"])
+	err := daisyCompute.WithContext(ctx, sr.w.ComputeClient).DeleteSnapshot(m["project"], m["snapshot"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete snapshot", err)
 	}
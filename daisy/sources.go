@@ -84,12 +84,19 @@ func (w *Workflow) sourceContent(ctx context.Context, s string) (string, error)
 	if !ok {
 		return "", Errf("source not found: %s", s)
 	}
+	src, dErr := w.resolveLocalPath(src)
+	if dErr != nil {
+		return "", dErr
+	}
 	// Try GCS file first.
 	if bkt, objPath, err := splitGCSPath(src); err == nil {
 		if objPath == "" || strings.HasSuffix(objPath, "/") {
 			return "", Errf("source %s appears to be a GCS 'bucket'", src)
 
 		}
+		if w.StorageClient == nil {
+			return "", Errf("cannot read source %s without a storage client", src)
+		}
 		src := w.StorageClient.Bucket(bkt).Object(objPath)
 		r, err := src.NewReader(ctx)
 		if err != nil {
@@ -109,9 +116,6 @@ func (w *Workflow) sourceContent(ctx context.Context, s string) (string, error)
 		return buf.String(), nil
 	}
 	// Fall back to local read.
-	if !filepath.IsAbs(src) {
-		src = filepath.Join(w.workflowDir, src)
-	}
 	if _, err := os.Stat(src); err != nil {
 		return "", typedErr(fileIOError, "failed to find local file", err)
 	}
@@ -142,6 +146,10 @@ func (w *Workflow) uploadSources(ctx context.Context) DError {
 		if origPath == "" {
 			continue
 		}
+		origPath, dErr := w.resolveLocalPath(origPath)
+		if dErr != nil {
+			return dErr
+		}
 		// GCS to GCS.
 		if bkt, objPath, err := splitGCSPath(origPath); err == nil {
 			if objPath == "" || strings.HasSuffix(objPath, "/") {
@@ -162,9 +170,6 @@ func (w *Workflow) uploadSources(ctx context.Context) DError {
 		}
 
 		// Local to GCS.
-		if !filepath.IsAbs(origPath) {
-			origPath = filepath.Join(w.workflowDir, origPath)
-		}
 		fi, err := os.Stat(origPath)
 		if err != nil {
 			return typedErr(fileIOError, "failed to open local file", err)
@@ -184,6 +189,10 @@ func (w *Workflow) uploadSources(ctx context.Context) DError {
 				return typedErr(fileIOError, "failed to walk file path", err)
 			}
 			for _, file := range files {
+				// Files in the directory can be symlinks to other places.
+				if err := w.checkPath(file); err != nil {
+					return err
+				}
 				obj := path.Join(dst, strings.TrimPrefix(file, filepath.Clean(origPath)))
 				if err := w.uploadFile(ctx, file, obj); err != nil {
 					return err
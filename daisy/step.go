@@ -45,29 +45,40 @@ type Step struct {
 	Timeout string `json:",omitempty"`
 	timeout time.Duration
 	// Only one of the below fields should exist for each instance of Step.
-	AttachDisks               *AttachDisks               `json:",omitempty"`
-	DetachDisks               *DetachDisks               `json:",omitempty"`
-	CreateDisks               *CreateDisks               `json:",omitempty"`
-	CreateForwardingRules     *CreateForwardingRules     `json:",omitempty"`
-	CreateFirewallRules       *CreateFirewallRules       `json:",omitempty"`
-	CreateImages              *CreateImages              `json:",omitempty"`
-	CreateMachineImages       *CreateMachineImages       `json:",omitempty"`
-	CreateInstances           *CreateInstances           `json:",omitempty"`
-	CreateNetworks            *CreateNetworks            `json:",omitempty"`
-	CreateSnapshots           *CreateSnapshots           `json:",omitempty"`
-	CreateSubnetworks         *CreateSubnetworks         `json:",omitempty"`
-	CreateTargetInstances     *CreateTargetInstances     `json:",omitempty"`
-	CopyGCSObjects            *CopyGCSObjects            `json:",omitempty"`
-	ResizeDisks               *ResizeDisks               `json:",omitempty"`
-	StartInstances            *StartInstances            `json:",omitempty"`
-	StopInstances             *StopInstances             `json:",omitempty"`
-	DeleteResources           *DeleteResources           `json:",omitempty"`
-	DeprecateImages           *DeprecateImages           `json:",omitempty"`
-	IncludeWorkflow           *IncludeWorkflow           `json:",omitempty"`
-	SubWorkflow               *SubWorkflow               `json:",omitempty"`
-	WaitForInstancesSignal    *WaitForInstancesSignal    `json:",omitempty"`
-	WaitForAnyInstancesSignal *WaitForAnyInstancesSignal `json:",omitempty"`
-	UpdateInstancesMetadata   *UpdateInstancesMetadata   `json:",omitempty"`
+	AttachDisks                 *AttachDisks                 `json:",omitempty"`
+	DetachDisks                 *DetachDisks                 `json:",omitempty"`
+	CreateAddresses             *CreateAddresses             `json:",omitempty"`
+	CreateDisks                 *CreateDisks                 `json:",omitempty"`
+	CreateForwardingRules       *CreateForwardingRules       `json:",omitempty"`
+	CreateFirewallRules         *CreateFirewallRules         `json:",omitempty"`
+	CreateImages                *CreateImages                `json:",omitempty"`
+	CreateMachineImages         *CreateMachineImages         `json:",omitempty"`
+	CreateInstances             *CreateInstances             `json:",omitempty"`
+	CreateInstanceTemplates     *CreateInstanceTemplates     `json:",omitempty"`
+	CreateInstanceGroupManagers *CreateInstanceGroupManagers `json:",omitempty"`
+	CreateNetworks              *CreateNetworks              `json:",omitempty"`
+	CreateRoutes                *CreateRoutes                `json:",omitempty"`
+	CreateRouters               *CreateRouters               `json:",omitempty"`
+	CreateSnapshots             *CreateSnapshots             `json:",omitempty"`
+	CreateSubnetworks           *CreateSubnetworks           `json:",omitempty"`
+	CreateTargetInstances       *CreateTargetInstances       `json:",omitempty"`
+	CopyGCSObjects              *CopyGCSObjects              `json:",omitempty"`
+	ResizeDisks                 *ResizeDisks                 `json:",omitempty"`
+	RollingUpdate               *RollingUpdate               `json:",omitempty"`
+	StartInstances              *StartInstances              `json:",omitempty"`
+	StopInstances               *StopInstances               `json:",omitempty"`
+	SuspendInstances            *SuspendInstances            `json:",omitempty"`
+	ResumeInstances             *ResumeInstances             `json:",omitempty"`
+	DeleteResources             *DeleteResources             `json:",omitempty"`
+	DeprecateImages             *DeprecateImages             `json:",omitempty"`
+	IncludeWorkflow             *IncludeWorkflow             `json:",omitempty"`
+	SubWorkflow                 *SubWorkflow                 `json:",omitempty"`
+	WaitForInstancesSignal      *WaitForInstancesSignal      `json:",omitempty"`
+	WaitForAnyInstancesSignal   *WaitForAnyInstancesSignal   `json:",omitempty"`
+	UpdateInstancesMetadata     *UpdateInstancesMetadata     `json:",omitempty"`
+	UpdateResources             *UpdateResources             `json:",omitempty"`
+	AcquireLock                 *AcquireLock                 `json:",omitempty"`
+	ReleaseLock                 *ReleaseLock                 `json:",omitempty"`
 	// Used for unit tests.
 	testType stepImpl
 }
@@ -97,6 +108,10 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.DetachDisks
 	}
+	if s.CreateAddresses != nil {
+		matchCount++
+		result = s.CreateAddresses
+	}
 	if s.CreateDisks != nil {
 		matchCount++
 		result = s.CreateDisks
@@ -121,10 +136,26 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.CreateInstances
 	}
+	if s.CreateInstanceTemplates != nil {
+		matchCount++
+		result = s.CreateInstanceTemplates
+	}
+	if s.CreateInstanceGroupManagers != nil {
+		matchCount++
+		result = s.CreateInstanceGroupManagers
+	}
 	if s.CreateNetworks != nil {
 		matchCount++
 		result = s.CreateNetworks
 	}
+	if s.CreateRoutes != nil {
+		matchCount++
+		result = s.CreateRoutes
+	}
+	if s.CreateRouters != nil {
+		matchCount++
+		result = s.CreateRouters
+	}
 	if s.CreateSnapshots != nil {
 		matchCount++
 		result = s.CreateSnapshots
@@ -145,6 +176,10 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.ResizeDisks
 	}
+	if s.RollingUpdate != nil {
+		matchCount++
+		result = s.RollingUpdate
+	}
 	if s.StartInstances != nil {
 		matchCount++
 		result = s.StartInstances
@@ -153,6 +188,14 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.StopInstances
 	}
+	if s.SuspendInstances != nil {
+		matchCount++
+		result = s.SuspendInstances
+	}
+	if s.ResumeInstances != nil {
+		matchCount++
+		result = s.ResumeInstances
+	}
 	if s.DeleteResources != nil {
 		matchCount++
 		result = s.DeleteResources
@@ -181,6 +224,18 @@ func (s *Step) stepImpl() (stepImpl, DError) {
 		matchCount++
 		result = s.UpdateInstancesMetadata
 	}
+	if s.UpdateResources != nil {
+		matchCount++
+		result = s.UpdateResources
+	}
+	if s.AcquireLock != nil {
+		matchCount++
+		result = s.AcquireLock
+	}
+	if s.ReleaseLock != nil {
+		matchCount++
+		result = s.ReleaseLock
+	}
 	if s.testType != nil {
 		matchCount++
 		result = s.testType
@@ -289,6 +344,7 @@ func (s *Step) recordStepTime(startTime time.Time) {
 
 func (s *Step) run(ctx context.Context) DError {
 	startTime := time.Now()
+	s.w.recordStepStart(s.name, startTime)
 	defer s.recordStepTime(startTime)
 	impl, err := s.stepImpl()
 	if err != nil {
@@ -302,6 +358,12 @@ func (s *Step) run(ctx context.Context) DError {
 	}
 	s.w.LogWorkflowInfo("Running step %q (%s)", s.name, st)
 	if err = impl.run(ctx, s); err != nil {
+		select {
+		case <-s.w.Cancel:
+			// The error is most likely the interrupted API call.
+			return s.w.onStepCancel(s, st)
+		default:
+		}
 		return s.wrapRunError(err)
 	}
 	select {
@@ -0,0 +1,78 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+)
+
+// AcquireLock is a Daisy AcquireLock workflow step. Locks that aren't
+// released by a ReleaseLock step are released when the workflow cleans up.
+type AcquireLock []*Lock
+
+func (al *AcquireLock) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, l := range *al {
+		errs = addErrs(errs, l.populate(ctx, s))
+	}
+	return errs
+}
+
+func (al *AcquireLock) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, l := range *al {
+		errs = addErrs(errs, l.validate(ctx, s))
+	}
+	return errs
+}
+
+func (al *AcquireLock) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError, len(*al)+1)
+	for _, l := range *al {
+		wg.Add(1)
+		go func(l *Lock) {
+			defer wg.Done()
+			w.LogStepInfo(s.name, "AcquireLock", "Acquiring %s lock %q in project %q.", l.Mode, l.Name, l.Project)
+			ok, err := l.acquire(ctx, s)
+			if err != nil {
+				e <- err
+				return
+			}
+			if !ok {
+				return
+			}
+			if res, ok := w.locks.get(l.Name); ok {
+				w.locks.setCreated(res)
+			}
+			w.LogStepInfo(s.name, "AcquireLock", "Acquired %s lock %q.", l.Mode, l.Name)
+		}(l)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,191 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+)
+
+func TestAcquireLockPopulate(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	s.AcquireLock = &AcquireLock{{Name: "family"}, {Name: "net", Project: "p", Mode: "read", TTL: "5m", Interval: "1s"}}
+
+	if err := s.AcquireLock.populate(context.Background(), s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &AcquireLock{
+		{Name: "family", Project: w.Project, Mode: lockModeWrite, TTL: defaultLockTTL, Interval: defaultInterval, ttl: 2 * time.Hour, interval: 10 * time.Second, key: "daisy-write-lock_family_" + w.id},
+		{Name: "net", Project: "p", Mode: lockModeRead, TTL: "5m", Interval: "1s", ttl: 5 * time.Minute, interval: time.Second, key: "daisy-read-lock_net_" + w.id},
+	}
+	if diffRes := diff(s.AcquireLock, want, 0); diffRes != "" {
+		t.Errorf("AcquireLock not populated as expected: (-got,+want)\n%s", diffRes)
+	}
+
+	if err := (&AcquireLock{{Name: "l", TTL: "forever"}}).populate(context.Background(), s); err == nil {
+		t.Error("expected error for bad TTL, got none")
+	}
+}
+
+func TestAcquireLockValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	tests := []struct {
+		desc    string
+		l       *Lock
+		wantErr bool
+	}{
+		{"write lock", &Lock{Name: "l1", Project: testProject, Mode: lockModeWrite, ttl: time.Hour}, false},
+		{"read lock", &Lock{Name: "l2", Project: testProject, Mode: lockModeRead, ttl: time.Hour}, false},
+		{"duplicate lock", &Lock{Name: "l1", Project: testProject, Mode: lockModeWrite, ttl: time.Hour}, true},
+		{"bad name", &Lock{Name: "Bad_Name", Project: testProject, Mode: lockModeWrite, ttl: time.Hour}, true},
+		{"bad mode", &Lock{Name: "l3", Project: testProject, Mode: "SHARED", ttl: time.Hour}, true},
+		{"bad TTL", &Lock{Name: "l4", Project: testProject, Mode: lockModeWrite}, true},
+		{"missing project", &Lock{Name: "l5", Project: DNE, Mode: lockModeWrite, ttl: time.Hour}, true},
+	}
+	for _, tt := range tests {
+		err := (&AcquireLock{tt.l}).validate(ctx, s)
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		} else if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestAcquireLockRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	f := newFakeProjectMetadata(w.ComputeClient.(*daisyCompute.TestClient), map[string]string{
+		"daisy-read-lock_shared_other":   future,
+		"daisy-write-lock_stale_other":   past,
+		"daisy-write-lock_blocked_other": future,
+	})
+
+	al := &AcquireLock{
+		{Name: "shared", Mode: "READ"},
+		{Name: "stale"},
+	}
+	if err := al.populate(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.validate(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := al.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"daisy-read-lock_shared_" + w.id, "daisy-write-lock_stale_" + w.id, "daisy-read-lock_shared_other"} {
+		if !f.has(key) {
+			t.Errorf("lock %q not in metadata: %v", key, f.items)
+		}
+	}
+	if f.has("daisy-write-lock_stale_other") {
+		t.Error("expired lock wasn't removed")
+	}
+	for _, name := range []string{"shared", "stale"} {
+		if res, _ := w.locks.get(name); !res.createdInWorkflow {
+			t.Errorf("lock %q not marked as acquired", name)
+		}
+	}
+	var reported []string
+	for _, r := range w.resourceReports() {
+		if r.Type == "lock" && r.Created {
+			reported = append(reported, r.Name)
+		}
+	}
+	if want := []string{"shared", "stale"}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("want locks %q in the resource reports, got %q", want, reported)
+	}
+
+	// Cleanup releases locks that are still held.
+	w.locks.cleanup()
+	if f.has("daisy-read-lock_shared_"+w.id) || f.has("daisy-write-lock_stale_"+w.id) {
+		t.Errorf("locks not released by cleanup: %v", f.items)
+	}
+
+	// A held WRITE lock blocks until the step times out.
+	blocked := &AcquireLock{{Name: "blocked", Interval: "10ms"}}
+	if err := blocked.populate(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := blocked.run(tctx, s); err == nil {
+		t.Error("expected error waiting for a held lock, got none")
+	}
+	if f.has("daisy-write-lock_blocked_" + w.id) {
+		t.Error("lock was taken while held by someone else")
+	}
+}
+
+func TestAcquireLockRunWaitsForReaders(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	f := newFakeProjectMetadata(w.ComputeClient.(*daisyCompute.TestClient), map[string]string{"daisy-read-lock_l_other": future})
+
+	al := &AcquireLock{{Name: "l", Interval: "10ms"}}
+	if err := al.populate(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := al.run(tctx, s); err == nil {
+		t.Error("expected error waiting for readers, got none")
+	}
+	// The write lock taken while waiting for readers is given up.
+	if f.has("daisy-write-lock_l_" + w.id) {
+		t.Errorf("write lock not given up: %v", f.items)
+	}
+}
+
+func TestAcquireLockRunCanceled(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	newFakeProjectMetadata(w.ComputeClient.(*daisyCompute.TestClient), map[string]string{
+		"daisy-write-lock_a_other": future,
+		"daisy-write-lock_b_other": future,
+	})
+
+	al := &AcquireLock{{Name: "a", Interval: "10ms"}, {Name: "b", Interval: "10ms"}}
+	if err := al.populate(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+	// Canceling the workflow cancels the context of its running steps.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan DError)
+	go func() { done <- al.run(ctx, s) }()
+	w.CancelWorkflow()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("AcquireLock did not return after the workflow was canceled")
+	}
+}
@@ -16,9 +16,12 @@ package daisy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -101,3 +104,30 @@ func TestAttachDisksRun(t *testing.T) {
 		}
 	}
 }
+
+func TestAttachDisksRunCanceled(t *testing.T) {
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.instances.m = map[string]*Resource{testInstance: {Project: testProject, RealName: testInstance}}
+	w.ComputeClient.(*daisyCompute.TestClient).AttachDiskFn = func(_, _, _ string, _ *compute.AttachedDisk) error {
+		// Like an API call interrupted by the canceled context.
+		<-w.Cancel
+		return errors.New("context canceled")
+	}
+	ads := &AttachDisks{
+		{Instance: testInstance, AttachedDisk: compute.AttachedDisk{Source: "d1"}},
+		{Instance: testInstance, AttachedDisk: compute.AttachedDisk{Source: "d2"}},
+	}
+
+	done := make(chan DError)
+	go func() { done <- ads.run(context.Background(), s) }()
+	w.CancelWorkflow()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("AttachDisks did not return after the workflow was canceled")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"path"
 	"sync"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -71,18 +72,18 @@ func (a *AttachDisks) validate(ctx context.Context, s *Step) (errs DError) {
 		}
 		addErrs(errs, err)
 
-		// Ensure disk is in the same project and zone.
+		// Ensure disk is in the same project and zone, or region for regional disks.
 		disk := NamedSubexp(diskURLRgx, dr.link)
 		instance := NamedSubexp(instanceURLRgx, ir.link)
 		if disk["project"] != instance["project"] {
 			errs = addErrs(errs, Errf("cannot attach disk in project %q to instance in project %q: %q", disk["project"], instance["project"], ad.Source))
 		}
-		if disk["zone"] != instance["zone"] {
-			errs = addErrs(errs, Errf("cannot attach disk in zone %q to instance in zone %q: %q", disk["zone"], instance["zone"], ad.Source))
+		if !diskInZone(disk, instance["zone"]) {
+			errs = addErrs(errs, Errf("cannot attach disk in %s to instance in zone %q: %q", diskLocation(disk), instance["zone"], ad.Source))
 		}
 
 		ad.project = disk["project"]
-		ad.zone = disk["zone"]
+		ad.zone = instance["zone"]
 
 		// Register disk attachments.
 		errs = addErrs(errs, s.w.instances.w.disks.regAttach(ad.DeviceName, ad.Source, ad.Instance, ad.Mode, s))
@@ -93,7 +94,7 @@ func (a *AttachDisks) validate(ctx context.Context, s *Step) (errs DError) {
 func (a *AttachDisks) run(ctx context.Context, s *Step) DError {
 	var wg sync.WaitGroup
 	w := s.w
-	e := make(chan DError)
+	e := make(chan DError, len(*a)+1)
 	for _, ad := range *a {
 		wg.Add(1)
 		go func(ad *AttachDisk) {
@@ -110,7 +111,7 @@ func (a *AttachDisks) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "AttachDisks", "Attaching disk %q to instance %q.", ad.AttachedDisk.Source, inst)
-			if err := w.ComputeClient.AttachDisk(ad.project, ad.zone, ad.Instance, &ad.AttachedDisk); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).AttachDisk(ad.project, ad.zone, ad.Instance, &ad.AttachedDisk); err != nil {
 				e <- newErr("failed to attach disk", err)
 				return
 			}
@@ -15,9 +15,15 @@
 package daisy
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
 	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -25,72 +31,150 @@ import (
 	"google.golang.org/api/iterator"
 )
 
+const defaultCopyParallelism = 10
+
 // CopyGCSObjects is a Daisy CopyGCSObject workflow step.
 type CopyGCSObjects []CopyGCSObject
 
 // CopyGCSObject copies a GCS object from Source to Destination.
+//
+// Source can be a single object, a prefix ending in "/" or a bucket, which are
+// copied recursively, or a glob pattern such as gs://bucket/dir/*.tar.gz.
+// Either Source or Destination, but not both, can be a path on the machine
+// running daisy, which is copied recursively if it is a directory.
 type CopyGCSObject struct {
 	Source, Destination string
 	ACLRules            []*storage.ACLRule `json:",omitempty"`
+	// Don't copy objects whose destination already has the same content.
+	SkipIdentical bool `json:",omitempty"`
+	// Maximum number of objects to copy at once (default is 10).
+	Parallelism int `json:",omitempty"`
+}
+
+// isGCSPath reports whether p is meant as a GCS path rather than a local one.
+func isGCSPath(p string) bool {
+	if strings.HasPrefix(p, "gs://") {
+		return true
+	}
+	_, _, err := splitGCSPath(p)
+	return err == nil
+}
+
+// globIndex returns the index of the first glob meta character in p, or -1.
+func globIndex(p string) int {
+	return strings.IndexAny(p, "*?[")
 }
 
 func (c *CopyGCSObjects) populate(ctx context.Context, s *Step) DError {
-	for _, co := range *c {
+	for i := range *c {
+		co := &(*c)[i]
 		for _, acl := range co.ACLRules {
 			acl.Role = storage.ACLRole(strings.ToUpper(string(acl.Role)))
 		}
+		for _, p := range []*string{&co.Source, &co.Destination} {
+			var err DError
+			if *p, err = s.w.resolveLocalPath(*p); err != nil {
+				return err
+			}
+		}
+		if co.Parallelism == 0 {
+			co.Parallelism = defaultCopyParallelism
+		}
+	}
+	return nil
+}
+
+// validateReadableBkt checks that bkt exists and can be read, once per bucket.
+func validateReadableBkt(ctx context.Context, s *Step, bkt string) DError {
+	readableBkts.mx.Lock()
+	defer readableBkts.mx.Unlock()
+	if strIn(bkt, readableBkts.bkts) {
+		return nil
+	}
+	if _, err := s.w.StorageClient.Bucket(bkt).Attrs(ctx); err != nil {
+		return Errf("error reading bucket %q: %v", bkt, err)
+	}
+	readableBkts.bkts = append(readableBkts.bkts, bkt)
+	return nil
+}
+
+// validateWritableBkt checks that bkt exists and can be written to, once per
+// bucket.
+func validateWritableBkt(ctx context.Context, s *Step, bkt string) DError {
+	writableBkts.mx.Lock()
+	defer writableBkts.mx.Unlock()
+	if strIn(bkt, writableBkts.bkts) {
+		return nil
+	}
+	if _, err := s.w.StorageClient.Bucket(bkt).Attrs(ctx); err != nil {
+		return Errf("error reading bucket %q: %v", bkt, err)
+	}
+
+	// Check if destination bucket is writable.
+	tObj := s.w.StorageClient.Bucket(bkt).Object(fmt.Sprintf("daisy-validate-%s-%s", s.name, s.w.id))
+	w := tObj.NewWriter(ctx)
+	if _, err := w.Write(nil); err != nil {
+		return newErr("failed to ", err)
+	}
+	if err := w.Close(); err != nil {
+		return Errf("error writing to bucket %q: %v", bkt, err)
+	}
+	if err := tObj.Delete(ctx); err != nil {
+		return Errf("error deleting file %+v after write validation: %v", tObj, err)
 	}
+	writableBkts.bkts = append(writableBkts.bkts, bkt)
 	return nil
 }
 
 func (c *CopyGCSObjects) validate(ctx context.Context, s *Step) DError {
 	for _, co := range *c {
-		sBkt, _, err := splitGCSPath(co.Source)
-		if err != nil {
-			return err
+		srcGCS, dstGCS := isGCSPath(co.Source), isGCSPath(co.Destination)
+		if co.Source == "" || co.Destination == "" {
+			return Errf("CopyGCSObject needs both Source and Destination: %+v", co)
+		}
+		if !srcGCS && !dstGCS {
+			return Errf("one of Source or Destination must be a GCS path: %q, %q", co.Source, co.Destination)
+		}
+		if co.Parallelism < 0 {
+			return Errf("Parallelism can't be negative: %d", co.Parallelism)
+		}
+
+		if srcGCS {
+			sBkt, _, err := splitGCSPath(co.Source)
+			if err != nil {
+				return err
+			}
+			// Check if source bucket exists and is readable.
+			if err := validateReadableBkt(ctx, s, sBkt); err != nil {
+				return err
+			}
+		} else if _, err := os.Stat(co.Source); err != nil {
+			return typedErr(fileIOError, "failed to find local file", err)
+		}
+
+		if !dstGCS {
+			if len(co.ACLRules) > 0 {
+				return Errf("ACLRules can only be used with a GCS Destination: %q", co.Destination)
+			}
+			continue
 		}
 		dBkt, dObj, err := splitGCSPath(co.Destination)
 		if err != nil {
 			return err
 		}
+		if globIndex(dObj) != -1 {
+			return Errf("Destination can't be a glob pattern: %q", co.Destination)
+		}
 
 		// Add object to object list.
 		if err := s.w.objects.regCreate(path.Join(dBkt, dObj)); err != nil {
 			return err
 		}
 
-		// Check if source bucket exists and is readable.
-		readableBkts.mx.Lock()
-		if !strIn(sBkt, readableBkts.bkts) {
-			if _, err := s.w.StorageClient.Bucket(sBkt).Attrs(ctx); err != nil {
-				return Errf("error reading bucket %q: %v", sBkt, err)
-			}
-			readableBkts.bkts = append(readableBkts.bkts, sBkt)
-		}
-		readableBkts.mx.Unlock()
-
-		// Check if destination bucket exists and is readable.
-		writableBkts.mx.Lock()
-		if !strIn(dBkt, writableBkts.bkts) {
-			if _, err := s.w.StorageClient.Bucket(dBkt).Attrs(ctx); err != nil {
-				return Errf("error reading bucket %q: %v", dBkt, err)
-			}
-
-			// Check if destination bucket is writable.
-			tObj := s.w.StorageClient.Bucket(dBkt).Object(fmt.Sprintf("daisy-validate-%s-%s", s.name, s.w.id))
-			w := tObj.NewWriter(ctx)
-			if _, err := w.Write(nil); err != nil {
-				return newErr("failed to ", err)
-			}
-			if err := w.Close(); err != nil {
-				return Errf("error writing to bucket %q: %v", dBkt, err)
-			}
-			if err := tObj.Delete(ctx); err != nil {
-				return Errf("error deleting file %+v after write validation: %v", tObj, err)
-			}
-			writableBkts.bkts = append(writableBkts.bkts, dBkt)
+		// Check if destination bucket exists and is writable.
+		if err := validateWritableBkt(ctx, s, dBkt); err != nil {
+			return err
 		}
-		writableBkts.mx.Unlock()
 
 		// Check each ACLRule
 		for _, acl := range co.ACLRules {
@@ -122,31 +206,340 @@ func (c *CopyGCSObjects) validate(ctx context.Context, s *Step) DError {
 	return nil
 }
 
-func recursiveGCS(ctx context.Context, w *Workflow, sBkt, sPrefix, dBkt, dPrefix string, acls []*storage.ACLRule) DError {
-	it := w.StorageClient.Bucket(sBkt).Objects(ctx, &storage.Query{Prefix: sPrefix})
+// copyLocation is a GCS object, or a file if bkt is empty.
+type copyLocation struct {
+	bkt, obj, file string
+}
+
+func (l copyLocation) String() string {
+	if l.bkt == "" {
+		return l.file
+	}
+	return fmt.Sprintf("gs://%s/%s", l.bkt, l.obj)
+}
+
+// copyTransfer copies one object or file. srcAttrs are the source object's
+// attributes if they're already known.
+type copyTransfer struct {
+	src, dst copyLocation
+	srcAttrs *storage.ObjectAttrs
+}
+
+// listGCSSources lists the objects matching a source prefix or glob pattern,
+// along with their names relative to the directory the copy starts from.
+func listGCSSources(ctx context.Context, w *Workflow, bkt, obj string) ([]*storage.ObjectAttrs, []string, DError) {
+	prefix, pattern := obj, ""
+	if i := globIndex(obj); i != -1 {
+		prefix, pattern = obj[:i], obj
+	}
+	// Relative names start after the last "/" before any glob.
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+
+	var attrs []*storage.ObjectAttrs
+	var rels []string
+	it := w.StorageClient.Bucket(bkt).Objects(ctx, &storage.Query{Prefix: prefix})
 	for objAttr, err := it.Next(); err != iterator.Done; objAttr, err = it.Next() {
 		if err != nil {
-			return typedErr(apiError, "failed to iterate GCS objects for copying", err)
+			return nil, nil, typedErr(apiError, "failed to iterate GCS objects for copying", err)
 		}
 		if objAttr.Size == 0 {
 			continue
 		}
-		srcPath := w.StorageClient.Bucket(sBkt).Object(objAttr.Name)
-		o := path.Join(dPrefix, strings.TrimPrefix(objAttr.Name, sPrefix))
-		dstPath := w.StorageClient.Bucket(dBkt).Object(o)
-		if _, err := dstPath.CopierFrom(srcPath).Run(ctx); err != nil {
-			return typedErr(apiError, "failed to copy GCS object", err)
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, objAttr.Name); !ok {
+				continue
+			}
+		}
+		attrs = append(attrs, objAttr)
+		rels = append(rels, strings.TrimPrefix(objAttr.Name, dir))
+	}
+	return attrs, rels, nil
+}
+
+// listLocalSources lists the files under dir, along with their slash
+// separated names relative to dir.
+func listLocalSources(dir string) ([]string, []string, DError) {
+	var files, rels []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
 		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, p)
+		rels = append(rels, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, typedErr(fileIOError, "failed to list local files for copying", err)
+	}
+	return files, rels, nil
+}
 
-		for _, acl := range acls {
-			if err := dstPath.ACL().Set(ctx, acl.Entity, acl.Role); err != nil {
-				return typedErr(apiError, "failed to set ACL for GCS object", err)
+// transfers expands co into the single object copies it's made of.
+func (co *CopyGCSObject) transfers(ctx context.Context, w *Workflow) ([]*copyTransfer, DError) {
+	var srcs []copyLocation
+	var srcAttrs []*storage.ObjectAttrs
+	// rels are the source names relative to the copy's root, nil if Source
+	// is a single object or file.
+	var rels []string
+	if isGCSPath(co.Source) {
+		sBkt, sObj, err := splitGCSPath(co.Source)
+		if err != nil {
+			return nil, err
+		}
+		if sObj == "" || strings.HasSuffix(sObj, "/") || globIndex(sObj) != -1 {
+			if srcAttrs, rels, err = listGCSSources(ctx, w, sBkt, sObj); err != nil {
+				return nil, err
+			}
+			for _, a := range srcAttrs {
+				srcs = append(srcs, copyLocation{bkt: sBkt, obj: a.Name})
 			}
+		} else {
+			srcs = []copyLocation{{bkt: sBkt, obj: sObj}}
+			srcAttrs = []*storage.ObjectAttrs{nil}
 		}
+	} else {
+		info, err := os.Stat(co.Source)
+		if err != nil {
+			return nil, typedErr(fileIOError, "failed to find local file", err)
+		}
+		if info.IsDir() {
+			files, fileRels, err := listLocalSources(co.Source)
+			if err != nil {
+				return nil, err
+			}
+			rels = fileRels
+			for _, f := range files {
+				// Files in the directory can be symlinks to other places.
+				if err := w.checkPath(f); err != nil {
+					return nil, err
+				}
+				srcs = append(srcs, copyLocation{file: f})
+				srcAttrs = append(srcAttrs, nil)
+			}
+		} else {
+			srcs = []copyLocation{{file: co.Source}}
+			srcAttrs = []*storage.ObjectAttrs{nil}
+		}
+	}
+
+	var ts []*copyTransfer
+	for i, src := range srcs {
+		// A single object copied into a bucket or directory keeps its name.
+		rel := path.Base(filepath.ToSlash(src.file))
+		if src.bkt != "" {
+			rel = path.Base(src.obj)
+		}
+		var dst copyLocation
+		if isGCSPath(co.Destination) {
+			dBkt, dObj, err := splitGCSPath(co.Destination)
+			if err != nil {
+				return nil, err
+			}
+			if rels != nil {
+				dObj = path.Join(dObj, rels[i])
+			} else if dObj == "" || strings.HasSuffix(dObj, "/") {
+				dObj += rel
+			}
+			dst = copyLocation{bkt: dBkt, obj: dObj}
+		} else if rels != nil {
+			dst = copyLocation{file: filepath.Join(co.Destination, filepath.FromSlash(rels[i]))}
+		} else if info, err := os.Stat(co.Destination); strings.HasSuffix(co.Destination, string(filepath.Separator)) || (err == nil && info.IsDir()) {
+			dst = copyLocation{file: filepath.Join(co.Destination, rel)}
+		} else {
+			dst = copyLocation{file: co.Destination}
+		}
+		if dst.file != "" {
+			if err := w.checkPath(dst.file); err != nil {
+				return nil, err
+			}
+		}
+		ts = append(ts, &copyTransfer{src: src, dst: dst, srcAttrs: srcAttrs[i]})
+	}
+	return ts, nil
+}
+
+// fileChecksums returns the size, CRC32C and MD5 of file.
+func fileChecksums(file string) (int64, uint32, []byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer f.Close()
+	crc := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	md := md5.New()
+	n, err := io.Copy(io.MultiWriter(crc, md), f)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return n, crc.Sum32(), md.Sum(nil), nil
+}
+
+// verifyChecksums compares a copy's checksums to the original's. MD5s are
+// only compared if both are known, composite objects don't have one.
+func verifyChecksums(src, dst copyLocation, srcCRC, dstCRC uint32, srcMD5, dstMD5 []byte) DError {
+	if srcCRC != dstCRC {
+		return Errf("CRC32C of %s (%d) doesn't match %s (%d)", dst, dstCRC, src, srcCRC)
+	}
+	if len(srcMD5) > 0 && len(dstMD5) > 0 && !bytes.Equal(srcMD5, dstMD5) {
+		return Errf("MD5 of %s (%x) doesn't match %s (%x)", dst, dstMD5, src, srcMD5)
+	}
+	return nil
+}
+
+// identical reports whether dst exists and has the size and CRC32C given.
+func (t *copyTransfer) identical(ctx context.Context, w *Workflow, size int64, crc uint32) bool {
+	if t.dst.bkt == "" {
+		dSize, dCRC, _, err := fileChecksums(t.dst.file)
+		return err == nil && dSize == size && dCRC == crc
+	}
+	attrs, err := w.StorageClient.Bucket(t.dst.bkt).Object(t.dst.obj).Attrs(ctx)
+	return err == nil && attrs.Size == size && attrs.CRC32C == crc
+}
+
+// run copies t.src to t.dst and verifies the copy's checksums.
+func (t *copyTransfer) run(ctx context.Context, s *Step, co *CopyGCSObject) DError {
+	w := s.w
+	if t.src.bkt == "" {
+		return t.upload(ctx, s, co)
+	}
+
+	src := w.StorageClient.Bucket(t.src.bkt).Object(t.src.obj)
+	if t.srcAttrs == nil {
+		var err error
+		if t.srcAttrs, err = src.Attrs(ctx); err != nil {
+			return Errf("error reading %s: %v", t.src, err)
+		}
+	}
+	if co.SkipIdentical && t.identical(ctx, w, t.srcAttrs.Size, t.srcAttrs.CRC32C) {
+		w.LogStepInfo(s.name, "CopyGCSObjects", "Skipping %s, %s is identical.", t.src, t.dst)
+		return nil
+	}
+	if t.dst.bkt == "" {
+		return t.download(ctx, src)
+	}
+
+	dst := w.StorageClient.Bucket(t.dst.bkt).Object(t.dst.obj)
+	attrs, err := dst.CopierFrom(src).Run(ctx)
+	if err != nil {
+		return Errf("error copying from %s to %s: %v", t.src, t.dst, err)
+	}
+	return verifyChecksums(t.src, t.dst, t.srcAttrs.CRC32C, attrs.CRC32C, t.srcAttrs.MD5, attrs.MD5)
+}
+
+// upload copies a local file to GCS, letting GCS reject corrupted uploads.
+func (t *copyTransfer) upload(ctx context.Context, s *Step, co *CopyGCSObject) DError {
+	size, crc, md, err := fileChecksums(t.src.file)
+	if err != nil {
+		return typedErr(fileIOError, "failed to read local file", err)
+	}
+	if co.SkipIdentical && t.identical(ctx, s.w, size, crc) {
+		s.w.LogStepInfo(s.name, "CopyGCSObjects", "Skipping %s, %s is identical.", t.src, t.dst)
+		return nil
+	}
+
+	f, err := os.Open(t.src.file)
+	if err != nil {
+		return typedErr(fileIOError, "failed to open local file", err)
+	}
+	defer f.Close()
+	gcs := s.w.StorageClient.Bucket(t.dst.bkt).Object(t.dst.obj).NewWriter(ctx)
+	gcs.CRC32C, gcs.SendCRC32C, gcs.MD5 = crc, true, md
+	if _, err := io.Copy(gcs, f); err != nil {
+		gcs.Close()
+		return Errf("error uploading %s to %s: %v", t.src, t.dst, err)
+	}
+	if err := gcs.Close(); err != nil {
+		return Errf("error uploading %s to %s: %v", t.src, t.dst, err)
+	}
+	attrs := gcs.Attrs()
+	return verifyChecksums(t.src, t.dst, crc, attrs.CRC32C, md, attrs.MD5)
+}
+
+// download copies src to a local file. The file only replaces t.dst once its
+// checksums are verified.
+func (t *copyTransfer) download(ctx context.Context, src *storage.ObjectHandle) DError {
+	if err := os.MkdirAll(filepath.Dir(t.dst.file), 0755); err != nil {
+		return typedErr(fileIOError, "failed to create local directory", err)
+	}
+	// Read what's stored, checksums are of the stored bytes even if GCS would
+	// decompress them on the fly.
+	r, err := src.ReadCompressed(true).NewReader(ctx)
+	if err != nil {
+		return Errf("error reading %s: %v", t.src, err)
+	}
+	defer r.Close()
+
+	tmp := t.dst.file + ".daisy-download"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return typedErr(fileIOError, "failed to create local file", err)
+	}
+	defer os.Remove(tmp)
+
+	crc := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	md := md5.New()
+	_, err = io.Copy(io.MultiWriter(f, crc, md), r)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return Errf("error downloading %s to %s: %v", t.src, t.dst, err)
+	}
+	if err := verifyChecksums(t.src, t.dst, t.srcAttrs.CRC32C, crc.Sum32(), t.srcAttrs.MD5, md.Sum(nil)); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, t.dst.file); err != nil {
+		return typedErr(fileIOError, "failed to move downloaded file into place", err)
 	}
 	return nil
 }
 
+// copyObjects runs co's transfers, at most co.Parallelism at once.
+func (co *CopyGCSObject) copyObjects(ctx context.Context, s *Step) DError {
+	ts, err := co.transfers(ctx, s.w)
+	if err != nil {
+		return Errf("error copying from %s to %s: %v", co.Source, co.Destination, err)
+	}
+
+	parallelism := co.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultCopyParallelism
+	}
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	var mx sync.Mutex
+	var errs DError
+	for _, t := range ts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t *copyTransfer) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := t.run(ctx, s, co)
+			if err == nil && t.dst.bkt != "" {
+				dst := s.w.StorageClient.Bucket(t.dst.bkt).Object(t.dst.obj)
+				for _, acl := range co.ACLRules {
+					if aclErr := dst.ACL().Set(ctx, acl.Entity, acl.Role); aclErr != nil {
+						err = Errf("error setting ACLRule on %s: %v", t.dst, aclErr)
+						break
+					}
+				}
+			}
+			if err != nil {
+				mx.Lock()
+				errs = addErrs(errs, err)
+				mx.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+	return errs
+}
+
 func (c *CopyGCSObjects) run(ctx context.Context, s *Step) DError {
 	var wg sync.WaitGroup
 	w := s.w
@@ -155,36 +548,8 @@ func (c *CopyGCSObjects) run(ctx context.Context, s *Step) DError {
 		wg.Add(1)
 		go func(co CopyGCSObject) {
 			defer wg.Done()
-			sBkt, sObj, err := splitGCSPath(co.Source)
-			if err != nil {
-				e <- err
-				return
-			}
-			dBkt, dObj, err := splitGCSPath(co.Destination)
-			if err != nil {
+			if err := co.copyObjects(ctx, s); err != nil {
 				e <- err
-				return
-			}
-
-			if sObj == "" || strings.HasSuffix(sObj, "/") {
-				if err := recursiveGCS(ctx, s.w, sBkt, sObj, dBkt, dObj, co.ACLRules); err != nil {
-					e <- Errf("error copying from %s to %s: %v", co.Source, co.Destination, err)
-					return
-				}
-				return
-			}
-
-			src := s.w.StorageClient.Bucket(sBkt).Object(sObj)
-			dstPath := s.w.StorageClient.Bucket(dBkt).Object(dObj)
-			if _, err := dstPath.CopierFrom(src).Run(ctx); err != nil {
-				e <- Errf("error copying from %s to %s: %v", co.Source, co.Destination, err)
-				return
-			}
-			for _, acl := range co.ACLRules {
-				if err := dstPath.ACL().Set(ctx, acl.Entity, acl.Role); err != nil {
-					e <- Errf("error setting ACLRule on %s: %v", co.Destination, err)
-					return
-				}
 			}
 		}(co)
 	}
@@ -16,9 +16,23 @@ package daisy
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 
 	"cloud.google.com/go/storage"
@@ -38,8 +52,8 @@ func TestCopyGCSObjectsPopulate(t *testing.T) {
 		t.Errorf("error running CopyGCSObjects.populate(): %v", err)
 	}
 	want := &CopyGCSObjects{
-		{Source: "gs://bucket/object", Destination: "gs://bucket/object", ACLRules: []*storage.ACLRule{{Entity: "allUsers", Role: "OWNER"}}},
-		{Source: "gs://bucket/object", Destination: "gs://bucket/object", ACLRules: []*storage.ACLRule{{Entity: "allAuthenticatedUsers", Role: "WRITER"}}},
+		{Source: "gs://bucket/object", Destination: "gs://bucket/object", ACLRules: []*storage.ACLRule{{Entity: "allUsers", Role: "OWNER"}}, Parallelism: defaultCopyParallelism},
+		{Source: "gs://bucket/object", Destination: "gs://bucket/object", ACLRules: []*storage.ACLRule{{Entity: "allAuthenticatedUsers", Role: "WRITER"}}, Parallelism: defaultCopyParallelism},
 	}
 	if diffRes := diff(ws, want, 0); diffRes != "" {
 		t.Errorf("populated CopyGCSObjects does not match expectation: (-got +want)\n%s", diffRes)
@@ -139,3 +153,250 @@ func TestCopyGCSObjectsRun(t *testing.T) {
 		}
 	}
 }
+
+// fakeGCS is an in-memory GCS serving the JSON API calls and media downloads
+// CopyGCSObjects makes.
+type fakeGCS struct {
+	server  *httptest.Server
+	mx      sync.Mutex
+	objs    map[string][]byte
+	crcs    map[string]uint32 // overrides the CRC32C reported for an object
+	uploads int
+}
+
+func (f *fakeGCS) attrs(bkt, obj string) string {
+	data := f.objs[bkt+"/"+obj]
+	crc, ok := f.crcs[bkt+"/"+obj]
+	if !ok {
+		crc = crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+	}
+	crcBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(crcBytes, crc)
+	md := md5.Sum(data)
+	return fmt.Sprintf(`{"bucket":%q,"name":%q,"size":"%d","crc32c":%q,"md5Hash":%q}`, bkt, obj, len(data),
+		base64.StdEncoding.EncodeToString(crcBytes), base64.StdEncoding.EncodeToString(md[:]))
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	p := r.URL.EscapedPath()
+	unescape := func(s string) string {
+		u, _ := url.PathUnescape(s)
+		return u
+	}
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	switch {
+	case r.Method == "POST" && strings.HasPrefix(p, "/upload/storage/v1/b/"):
+		bkt := parts[4]
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		mdPart, _ := mr.NextPart()
+		var md struct{ Name string }
+		json.NewDecoder(mdPart).Decode(&md)
+		dataPart, _ := mr.NextPart()
+		data, _ := ioutil.ReadAll(dataPart)
+		f.objs[bkt+"/"+md.Name] = data
+		f.uploads++
+		fmt.Fprint(w, f.attrs(bkt, md.Name))
+	case r.Method == "POST" && len(parts) == 9 && parts[4] == "rewriteTo":
+		// /b/bucket/o/object/rewriteTo/b/bucket/o/object
+		src, dst := parts[1]+"/"+unescape(parts[3]), parts[6]+"/"+unescape(parts[8])
+		f.objs[dst] = f.objs[src]
+		fmt.Fprintf(w, `{"done":true,"resource":%s}`, f.attrs(parts[6], unescape(parts[8])))
+	case r.Method == "GET" && len(parts) == 3 && parts[0] == "b" && parts[2] == "o":
+		var items []string
+		for k := range f.objs {
+			bkt := strings.SplitN(k, "/", 2)
+			if bkt[0] == parts[1] && strings.HasPrefix(bkt[1], r.URL.Query().Get("prefix")) {
+				items = append(items, f.attrs(bkt[0], bkt[1]))
+			}
+		}
+		sort.Strings(items)
+		fmt.Fprintf(w, `{"items":[%s]}`, strings.Join(items, ","))
+	case r.Method == "GET" && len(parts) == 4 && parts[0] == "b" && parts[2] == "o":
+		if _, ok := f.objs[parts[1]+"/"+unescape(parts[3])]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, f.attrs(parts[1], unescape(parts[3])))
+	case r.Method == "GET":
+		// Media download, /bucket/object.
+		data, ok := f.objs[unescape(strings.TrimPrefix(p, "/"))]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "fakeGCS unknown request: %s %s", r.Method, r.URL)
+	}
+}
+
+// newFakeGCSWorkflow returns a workflow whose storage client is served by a
+// fakeGCS. The caller closes the fakeGCS server.
+func newFakeGCSWorkflow(t *testing.T, objs map[string][]byte) (*Workflow, *fakeGCS) {
+	f := &fakeGCS{objs: objs, crcs: map[string]uint32{}}
+	f.server = httptest.NewTLSServer(f)
+	sc, err := storage.NewClient(context.Background(), option.WithEndpoint(f.server.URL), option.WithHTTPClient(f.server.Client()))
+	if err != nil {
+		f.server.Close()
+		t.Fatal(err)
+	}
+	w := testWorkflow()
+	w.StorageClient = sc
+	return w, f
+}
+
+func readDir(t *testing.T, dir string) map[string]string {
+	got := map[string]string{}
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		data, _ := ioutil.ReadFile(p)
+		got[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	return got
+}
+
+func TestCopyGCSObjectsRunDownload(t *testing.T) {
+	ctx := context.Background()
+	w, f := newFakeGCSWorkflow(t, map[string][]byte{
+		"bkt/dir/a.txt":     []byte("a"),
+		"bkt/dir/b.log":     []byte("b"),
+		"bkt/dir/sub/c.txt": []byte("c"),
+		"bkt/other":         []byte("o"),
+	})
+	defer f.server.Close()
+	s := &Step{w: w}
+
+	tests := []struct {
+		desc, src string
+		want      map[string]string
+	}{
+		{"glob", "gs://bkt/dir/*.txt", map[string]string{"a.txt": "a"}},
+		{"nested glob", "gs://bkt/dir/*/*.txt", map[string]string{"sub/c.txt": "c"}},
+		{"prefix", "gs://bkt/dir/", map[string]string{"a.txt": "a", "b.log": "b", "sub/c.txt": "c"}},
+		{"single object into directory", "gs://bkt/dir/b.log", map[string]string{"b.log": "b"}},
+	}
+	root, err := ioutil.TempDir("", "daisy-copy-gcs-objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for _, tt := range tests {
+		dir, err := ioutil.TempDir(root, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &CopyGCSObjects{{Source: tt.src, Destination: dir}}
+		if err := c.run(ctx, s); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+			continue
+		}
+		if diffRes := diff(readDir(t, dir), tt.want, 0); diffRes != "" {
+			t.Errorf("%s: downloaded files not as expected: (-got +want)\n%s", tt.desc, diffRes)
+		}
+	}
+}
+
+func TestCopyGCSObjectsRunDownloadChecksumMismatch(t *testing.T) {
+	w, f := newFakeGCSWorkflow(t, map[string][]byte{"bkt/obj": []byte("data")})
+	defer f.server.Close()
+	f.crcs["bkt/obj"] = 1
+	dir, err := ioutil.TempDir("", "daisy-copy-gcs-objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "obj")
+
+	if err := (&CopyGCSObjects{{Source: "gs://bkt/obj", Destination: dst}}).run(context.Background(), &Step{w: w}); err == nil {
+		t.Error("expected error, got none")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("corrupt download left at %s", dst)
+	}
+}
+
+func TestCopyGCSObjectsRunUpload(t *testing.T) {
+	ctx := context.Background()
+	w, f := newFakeGCSWorkflow(t, map[string][]byte{})
+	defer f.server.Close()
+	s := &Step{w: w}
+	dir, err := ioutil.TempDir("", "daisy-copy-gcs-objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "x"), []byte("x"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "sub", "y"), []byte("y"), 0644)
+
+	c := &CopyGCSObjects{
+		{Source: dir, Destination: "gs://bkt/up/"},
+		{Source: filepath.Join(dir, "x"), Destination: "gs://bkt/"},
+	}
+	if err := c.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string][]byte{"bkt/up/x": []byte("x"), "bkt/up/sub/y": []byte("y"), "bkt/x": []byte("x")}
+	if diffRes := diff(f.objs, want, 0); diffRes != "" {
+		t.Errorf("uploaded objects not as expected: (-got +want)\n%s", diffRes)
+	}
+
+	// Unchanged files aren't uploaded again.
+	f.uploads = 0
+	ioutil.WriteFile(filepath.Join(dir, "x"), []byte("changed"), 0644)
+	c = &CopyGCSObjects{{Source: dir, Destination: "gs://bkt/up", SkipIdentical: true, Parallelism: 1}}
+	if err := c.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.uploads != 1 || string(f.objs["bkt/up/x"]) != "changed" {
+		t.Errorf("want only the changed file uploaded, got %d uploads", f.uploads)
+	}
+}
+
+func TestCopyGCSObjectsRunCopy(t *testing.T) {
+	w, f := newFakeGCSWorkflow(t, map[string][]byte{"bkt/dir/a": []byte("a"), "bkt/dir/b": []byte("b")})
+	defer f.server.Close()
+	c := &CopyGCSObjects{
+		{Source: "gs://bkt/dir/a", Destination: "gs://bkt2"},
+		{Source: "gs://bkt/dir/*", Destination: "gs://bkt3/copy"},
+	}
+	if err := c.run(context.Background(), &Step{w: w}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, obj := range []string{"bkt2/a", "bkt3/copy/a", "bkt3/copy/b"} {
+		if _, ok := f.objs[obj]; !ok {
+			t.Errorf("object %q not copied, objects: %v", obj, f.objs)
+		}
+	}
+}
+
+func TestCopyGCSObjectsValidateLocal(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	dir, err := ioutil.TempDir("", "daisy-copy-gcs-objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, c := range []*CopyGCSObjects{
+		{{Source: dir, Destination: dir}},
+		{{Source: filepath.Join(dir, "dne"), Destination: "gs://bucket"}},
+		{{Source: "gs://bucket/object", Destination: dir, ACLRules: []*storage.ACLRule{{Entity: "allUsers", Role: "OWNER"}}}},
+		{{Source: dir, Destination: "gs://bucket/*"}},
+		{{Source: dir, Destination: "gs://bucket", Parallelism: -1}},
+	} {
+		if err := c.validate(ctx, s); err == nil {
+			t.Errorf("expected error for %+v", (*c)[0])
+		}
+	}
+}
@@ -0,0 +1,66 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"github.com/kylelemons/godebug/pretty"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestCreateAddressesRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.subnetworks.m = map[string]*Resource{"sn": {RealName: "sn", link: "snlink"}}
+
+	e := Errf("error")
+
+	wantAddress := compute.Address{}
+	wantAddress.Description = "Address created by Daisy in workflow \"test-wf\" on behalf of ."
+	wantAddress.Name = "test-wf-abcdef"
+	wantAddress.Region = "test-zo"
+	wantInternal := wantAddress
+	wantInternal.AddressType = "INTERNAL"
+	wantInternal.Subnetwork = "snlink"
+
+	tests := []struct {
+		desc      string
+		a, wantA  compute.Address
+		clientErr error
+		wantErr   DError
+	}{
+		{"good case", compute.Address{}, wantAddress, nil, nil},
+		{"resolve subnetwork case", compute.Address{AddressType: "INTERNAL", Subnetwork: "sn"}, wantInternal, nil, nil},
+		{"client error case", compute.Address{}, wantAddress, e, e},
+	}
+
+	for _, tt := range tests {
+		var gotA compute.Address
+		fake := func(_, _ string, a *compute.Address) error { gotA = *a; return tt.clientErr }
+		w.ComputeClient = &daisyCompute.TestClient{CreateAddressFn: fake}
+		cas := &CreateAddresses{{Address: tt.a}}
+		cas.populate(ctx, s)
+		if err := cas.run(ctx, s); err != tt.wantErr {
+			t.Errorf("%s: unexpected error returned, got: %v, want: %v", tt.desc, err, tt.wantErr)
+		}
+		if diff := pretty.Compare(gotA, tt.wantA); diff != "" {
+			t.Errorf("%s: client got incorrect Address, diff: %s", tt.desc, diff)
+		}
+	}
+}
@@ -72,14 +72,14 @@ func (c *CreateDisks) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "CreateDisks", "Creating disk %q.", cd.Name)
-			if err := w.ComputeClient.CreateDisk(cd.Project, cd.Zone, &cd.Disk); err != nil {
+			if err := cd.create(compute.WithContext(ctx, w.ComputeClient)); err != nil {
 				// Fallback to pd-standard to avoid quota issue.
 				if cd.FallbackToPdStandard && strings.HasSuffix(cd.Type, pdSsd) && isQuotaExceeded(err) {
 					w.LogStepInfo(s.name, "CreateDisks", "Falling back to pd-standard for disk %v. "+
 						"It may be caused by insufficient pd-ssd quota. Consider increasing pd-ssd quota to "+
 						"avoid using ps-standard for better performance.", cd.Name)
 					cd.Type = strings.TrimRight(cd.Type, pdSsd) + pdStandard
-					err = w.ComputeClient.CreateDisk(cd.Project, cd.Zone, &cd.Disk)
+					err = cd.create(compute.WithContext(ctx, w.ComputeClient))
 				}
 
 				if err != nil {
@@ -87,7 +87,7 @@ func (c *CreateDisks) run(ctx context.Context, s *Step) DError {
 					return
 				}
 			}
-			cd.createdInWorkflow = true
+			w.disks.setCreated(&cd.Resource)
 		}(d)
 	}
 
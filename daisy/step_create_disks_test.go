@@ -68,3 +68,28 @@ func TestCreateDisksRun(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateDisksRunRegional(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+
+	var gotRegion string
+	w.ComputeClient = &daisyCompute.TestClient{
+		CreateDiskFn: func(_, _ string, _ *compute.Disk) error {
+			t.Error("regional disk created with CreateDisk")
+			return nil
+		},
+		CreateRegionDiskFn: func(_, region string, _ *compute.Disk) error {
+			gotRegion = region
+			return nil
+		},
+	}
+	cds := &CreateDisks{{Disk: compute.Disk{Region: testRegion, ReplicaZones: []string{"z1", "z2"}}}}
+	if err := cds.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotRegion != testRegion {
+		t.Errorf("disk created in region %q, want %q", gotRegion, testRegion)
+	}
+}
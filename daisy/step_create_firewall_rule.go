@@ -17,6 +17,8 @@ package daisy
 import (
 	"context"
 	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 // CreateFirewallRules is a Daisy CreateFirewallRules workflow step.
@@ -52,11 +54,11 @@ func (c *CreateFirewallRules) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "CreateFirewallRules", "Creating firewall rule %q.", fir.Name)
-			if err := w.ComputeClient.CreateFirewallRule(fir.Project, &fir.Firewall); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateFirewallRule(fir.Project, &fir.Firewall); err != nil {
 				e <- newErr("failed to create firewall", err)
 				return
 			}
-			fir.createdInWorkflow = true
+			w.firewallRules.setCreated(&fir.Resource)
 		}(fir)
 	}
 
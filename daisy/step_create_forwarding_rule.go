@@ -17,6 +17,8 @@ package daisy
 import (
 	"context"
 	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 // CreateForwardingRules is a Daisy CreateForwardingRules workflow step.
@@ -48,11 +50,11 @@ func (c *CreateForwardingRules) run(ctx context.Context, s *Step) DError {
 			defer wg.Done()
 
 			w.LogStepInfo(s.name, "CreateForwardingRules", "Creating forwarding-rule %q.", fr.Name)
-			if err := w.ComputeClient.CreateForwardingRule(fr.Project, fr.Region, &fr.ForwardingRule); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateForwardingRule(fr.Project, fr.Region, &fr.ForwardingRule); err != nil {
 				e <- newErr("failed to create forwarding rules", err)
 				return
 			}
-			fr.createdInWorkflow = true
+			w.forwardingRules.setCreated(&fr.Resource)
 		}(fr)
 	}
 
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"sync"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	"google.golang.org/api/googleapi"
 )
 
@@ -52,6 +53,18 @@ func (ci *CreateImages) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON marshals the images as the list UnmarshalJSON reads.
+func (ci *CreateImages) MarshalJSON() ([]byte, error) {
+	switch {
+	case len(ci.Images) != 0:
+		return json.Marshal(ci.Images)
+	case len(ci.ImagesBeta) != 0:
+		return json.Marshal(ci.ImagesBeta)
+	default:
+		return json.Marshal(ci.ImagesAlpha)
+	}
+}
+
 func imageUsesAlphaFeatures(imagesAlpha []*ImageAlpha) bool {
 	for _, imageAlpha := range imagesAlpha {
 		if imageAlpha != nil && imageAlpha.RolloutOverride != nil && len(imageAlpha.RolloutOverride.DefaultRolloutTime) > 0 {
@@ -125,7 +138,7 @@ func (ci *CreateImages) run(ctx context.Context, s *Step) DError {
 		// Delete existing if OverWrite is true.
 		if overwrite {
 			// Just try to delete it, a 404 here indicates the image doesn't exist.
-			if err := ci.delete(w.ComputeClient); err != nil {
+			if err := ci.delete(daisyCompute.WithContext(ctx, w.ComputeClient)); err != nil {
 				if apiErr, ok := err.(*googleapi.Error); !ok || apiErr.Code != 404 {
 					e <- Errf("error deleting existing image: %v", err)
 					return
@@ -134,11 +147,13 @@ func (ci *CreateImages) run(ctx context.Context, s *Step) DError {
 		}
 
 		w.LogStepInfo(s.name, "CreateImages", "Creating image %q.", ci.getName())
-		if err := ci.create(w.ComputeClient); err != nil {
+		if err := ci.create(daisyCompute.WithContext(ctx, w.ComputeClient)); err != nil {
 			e <- newErr("failed to create images", err)
 			return
 		}
+		w.images.mx.Lock()
 		ci.markCreatedInWorkflow()
+		w.images.mx.Unlock()
 	}
 
 	if imageUsesAlphaFeatures(ci.ImagesAlpha) {
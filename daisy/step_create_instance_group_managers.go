@@ -0,0 +1,81 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+)
+
+// CreateInstanceGroupManagers is a Daisy CreateInstanceGroupManagers workflow step.
+type CreateInstanceGroupManagers []*InstanceGroupManager
+
+func (c *CreateInstanceGroupManagers) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, igm := range *c {
+		errs = addErrs(errs, igm.populate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateInstanceGroupManagers) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, igm := range *c {
+		errs = addErrs(errs, igm.validate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateInstanceGroupManagers) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, igm := range *c {
+		wg.Add(1)
+		go func(igm *InstanceGroupManager) {
+			defer wg.Done()
+
+			if igm.InstanceTemplate != "" {
+				igm.InstanceTemplate = resolveInstanceTemplate(w, igm.InstanceTemplate)
+			}
+			for _, v := range igm.Versions {
+				v.InstanceTemplate = resolveInstanceTemplate(w, v.InstanceTemplate)
+			}
+
+			w.LogStepInfo(s.name, "CreateInstanceGroupManagers", "Creating instance group manager %q.", igm.Name)
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateInstanceGroupManager(igm.Project, igm.Zone, &igm.InstanceGroupManager); err != nil {
+				e <- newErr("failed to create instance group managers", err)
+				return
+			}
+			w.instanceGroupManagers.setCreated(&igm.Resource)
+		}(igm)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		// Wait so instance group managers being created now can be deleted.
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestInstanceGroupManagerPopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	igm := &InstanceGroupManager{InstanceGroupManager: compute.InstanceGroupManager{
+		Name:             "igm",
+		InstanceTemplate: "global/instanceTemplates/it",
+		Versions:         []*compute.InstanceGroupManagerVersion{{InstanceTemplate: "daisy-template"}},
+	}}
+	if err := igm.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if igm.Zone != testZone {
+		t.Errorf("got Zone %q, want %q", igm.Zone, testZone)
+	}
+	if igm.BaseInstanceName != igm.Name {
+		t.Errorf("got BaseInstanceName %q, want %q", igm.BaseInstanceName, igm.Name)
+	}
+	if want := "projects/test-project/global/instanceTemplates/it"; igm.InstanceTemplate != want {
+		t.Errorf("got InstanceTemplate %q, want %q", igm.InstanceTemplate, want)
+	}
+	if igm.Versions[0].InstanceTemplate != "daisy-template" {
+		t.Errorf("daisy template name should be left as-is, got %q", igm.Versions[0].InstanceTemplate)
+	}
+}
+
+func TestInstanceGroupManagerValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	w.instanceTemplates.m = map[string]*Resource{"it": {RealName: "it", link: "itlink"}}
+
+	tests := []struct {
+		desc      string
+		igm       compute.InstanceGroupManager
+		shouldErr bool
+	}{
+		{"template case", compute.InstanceGroupManager{InstanceTemplate: "it", TargetSize: 2}, false},
+		{"versions case", compute.InstanceGroupManager{Versions: []*compute.InstanceGroupManagerVersion{{InstanceTemplate: "it"}}}, false},
+		{"no template case", compute.InstanceGroupManager{}, true},
+		{"unknown template case", compute.InstanceGroupManager{InstanceTemplate: "dne"}, true},
+		{"version without template case", compute.InstanceGroupManager{Versions: []*compute.InstanceGroupManagerVersion{{Name: "v"}}}, true},
+		{"negative size case", compute.InstanceGroupManager{InstanceTemplate: "it", TargetSize: -1}, true},
+	}
+
+	for i, tt := range tests {
+		igm := &InstanceGroupManager{InstanceGroupManager: tt.igm}
+		igm.Zone = testZone
+		igm.daisyName = string(rune('a' + i))
+		igm.RealName = igm.daisyName
+		igm.Project = w.Project
+		igm.link = "projects/test-project/zones/test-zone/instanceGroupManagers/" + igm.RealName
+		err := igm.validate(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestCreateInstanceGroupManagersRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.instanceTemplates.m = map[string]*Resource{"it": {RealName: "it", link: "itlink"}}
+
+	var gotM compute.InstanceGroupManager
+	w.ComputeClient = &daisyCompute.TestClient{CreateInstanceGroupManagerFn: func(_, _ string, m *compute.InstanceGroupManager) error { gotM = *m; return nil }}
+	cms := &CreateInstanceGroupManagers{{InstanceGroupManager: compute.InstanceGroupManager{
+		Name:             "igm",
+		InstanceTemplate: "it",
+		Versions:         []*compute.InstanceGroupManagerVersion{{InstanceTemplate: "it"}},
+	}}}
+	if err := cms.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotM.InstanceTemplate != "itlink" || gotM.Versions[0].InstanceTemplate != "itlink" {
+		t.Errorf("daisy references not resolved: %+v", gotM)
+	}
+	if !(*cms)[0].createdInWorkflow {
+		t.Error("instance group manager not marked as created in workflow")
+	}
+}
@@ -0,0 +1,76 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+)
+
+// CreateInstanceTemplates is a Daisy CreateInstanceTemplates workflow step.
+type CreateInstanceTemplates []*InstanceTemplate
+
+func (c *CreateInstanceTemplates) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, it := range *c {
+		errs = addErrs(errs, it.populate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateInstanceTemplates) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, it := range *c {
+		errs = addErrs(errs, it.validate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateInstanceTemplates) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, it := range *c {
+		wg.Add(1)
+		go func(it *InstanceTemplate) {
+			defer wg.Done()
+
+			it.updateLinksBeforeCreate(w)
+
+			w.LogStepInfo(s.name, "CreateInstanceTemplates", "Creating instance template %q.", it.Name)
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateInstanceTemplate(it.Project, &it.InstanceTemplate); err != nil {
+				e <- newErr("failed to create instance templates", err)
+				return
+			}
+			w.instanceTemplates.setCreated(&it.Resource)
+		}(it)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		// Wait so instance templates being created now can be deleted.
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestInstanceTemplatePopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	it := &InstanceTemplate{InstanceTemplate: compute.InstanceTemplate{Name: "it", Properties: &compute.InstanceProperties{
+		Disks: []*compute.AttachedDisk{
+			{InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: "global/images/i"}},
+			{InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: "daisy-image"}},
+		},
+	}}}
+	if err := it.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := it.Properties
+	if p.MachineType != "n1-standard-1" {
+		t.Errorf("got MachineType %q, want n1-standard-1", p.MachineType)
+	}
+	if !p.Disks[0].Boot || p.Disks[1].Boot {
+		t.Error("only the first disk should be the boot disk")
+	}
+	if !p.Disks[0].AutoDelete || !p.Disks[1].AutoDelete {
+		t.Error("disks created from InitializeParams should be autodeleted")
+	}
+	if want := "projects/test-project/global/images/i"; p.Disks[0].InitializeParams.SourceImage != want {
+		t.Errorf("got SourceImage %q, want %q", p.Disks[0].InitializeParams.SourceImage, want)
+	}
+	if p.Disks[1].InitializeParams.SourceImage != "daisy-image" {
+		t.Errorf("daisy image name should be left as-is, got %q", p.Disks[1].InitializeParams.SourceImage)
+	}
+	if want := "projects/test-project/global/networks/default"; p.NetworkInterfaces[0].Network != want {
+		t.Errorf("got Network %q, want %q", p.NetworkInterfaces[0].Network, want)
+	}
+	if want := "projects/test-project/global/instanceTemplates/" + it.Name; it.link != want {
+		t.Errorf("got link %q, want %q", it.link, want)
+	}
+}
+
+func TestInstanceTemplateValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	w.images.m = map[string]*Resource{"i": {RealName: "i", link: "ilink"}}
+	w.networks.m = map[string]*Resource{"n": {RealName: "n", link: "nlink"}}
+
+	image := func(i string) *compute.AttachedDisk {
+		return &compute.AttachedDisk{InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: i}}
+	}
+	tests := []struct {
+		desc      string
+		disks     []*compute.AttachedDisk
+		shouldErr bool
+	}{
+		{"normal case", []*compute.AttachedDisk{image("i")}, false},
+		{"no disks case", nil, true},
+		{"unknown image case", []*compute.AttachedDisk{image("dne")}, true},
+		{"disk source case", []*compute.AttachedDisk{{Source: "d"}}, true},
+	}
+
+	for i, tt := range tests {
+		it := &InstanceTemplate{InstanceTemplate: compute.InstanceTemplate{Properties: &compute.InstanceProperties{
+			Disks:             tt.disks,
+			NetworkInterfaces: []*compute.NetworkInterface{{Network: "n"}},
+		}}}
+		it.daisyName = string(rune('a' + i))
+		it.RealName = it.daisyName
+		it.Project = w.Project
+		it.link = "projects/test-project/global/instanceTemplates/" + it.RealName
+		err := it.validate(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestCreateInstanceTemplatesRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.images.m = map[string]*Resource{"i": {RealName: "i", link: "ilink"}}
+	w.networks.m = map[string]*Resource{"n": {RealName: "n", link: "nlink"}}
+
+	var gotT compute.InstanceTemplate
+	w.ComputeClient = &daisyCompute.TestClient{CreateInstanceTemplateFn: func(_ string, t *compute.InstanceTemplate) error { gotT = *t; return nil }}
+	cts := &CreateInstanceTemplates{{InstanceTemplate: compute.InstanceTemplate{Name: "it", Properties: &compute.InstanceProperties{
+		Disks:             []*compute.AttachedDisk{{InitializeParams: &compute.AttachedDiskInitializeParams{SourceImage: "i"}}},
+		NetworkInterfaces: []*compute.NetworkInterface{{Network: "n"}},
+	}}}}
+	if err := cts.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotT.Properties.Disks[0].InitializeParams.SourceImage != "ilink" || gotT.Properties.NetworkInterfaces[0].Network != "nlink" {
+		t.Errorf("daisy references not resolved: %+v", gotT.Properties)
+	}
+	if !(*cts)[0].createdInWorkflow {
+		t.Error("instance template not marked as created in workflow")
+	}
+}
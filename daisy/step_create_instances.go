@@ -25,6 +25,7 @@ import (
 	"sync"
 	"time"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	"google.golang.org/api/googleapi"
 )
 
@@ -51,6 +52,14 @@ func (ci *CreateInstances) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON marshals the instances as the list UnmarshalJSON reads.
+func (ci *CreateInstances) MarshalJSON() ([]byte, error) {
+	if len(ci.Instances) == 0 && len(ci.InstancesBeta) != 0 {
+		return json.Marshal(ci.InstancesBeta)
+	}
+	return json.Marshal(ci.Instances)
+}
+
 func logSerialOutput(ctx context.Context, s *Step, ii InstanceInterface, ib *InstanceBase, port int64, interval time.Duration) {
 	w := s.w
 	w.stepWait.Add(1)
@@ -65,14 +74,25 @@ func logSerialOutput(ctx context.Context, s *Step, ii InstanceInterface, ib *Ins
 	var numErr int
 	tick := time.Tick(interval)
 
+	localLog, err := w.openLocalSerialLog(ii.getName(), port)
+	if err != nil {
+		w.LogStepInfo(s.name, "CreateInstances", "Instance %q: error opening local serial port log: %v", ii.getName(), err)
+	}
+	if localLog != nil {
+		defer localLog.Close()
+	}
+	var localErr bool
+
 Loop:
 	for {
 		select {
+		case <-ctx.Done():
+			break Loop
 		case <-tick:
-			resp, err := w.ComputeClient.GetSerialPortOutput(path.Base(ib.Project), path.Base(ii.getZone()), ii.getName(), port, start)
+			resp, err := daisyCompute.WithContext(ctx, w.ComputeClient).GetSerialPortOutput(path.Base(ib.Project), path.Base(ii.getZone()), ii.getName(), port, start)
 			if err != nil {
 				numErr++
-				status, sErr := w.ComputeClient.InstanceStatus(path.Base(ib.Project), path.Base(ii.getZone()), ii.getName())
+				status, sErr := daisyCompute.WithContext(ctx, w.ComputeClient).InstanceStatus(path.Base(ib.Project), path.Base(ii.getZone()), ii.getName())
 				switch status {
 				case "TERMINATED", "STOPPED", "STOPPING":
 					// Instance is stopped or stopping.
@@ -96,6 +116,12 @@ Loop:
 			numErr = 0
 			start = resp.Next
 			buf.WriteString(resp.Contents)
+			if localLog != nil {
+				if _, err := localLog.WriteString(resp.Contents); err != nil && !localErr {
+					localErr = true
+					w.LogStepInfo(s.name, "CreateInstances", "Instance %q: error writing local serial port log: %v", ii.getName(), err)
+				}
+			}
 			wc := w.StorageClient.Bucket(w.bucket).Object(logsObj).NewWriter(ctx)
 			wc.ContentType = "text/plain"
 			if _, err := wc.Write(buf.Bytes()); err != nil && !gcsErr {
@@ -161,7 +187,7 @@ func (ci *CreateInstances) run(ctx context.Context, s *Step) DError {
 	createInstance := func(ii InstanceInterface, ib *InstanceBase) {
 		// Just try to delete it, a 404 here indicates the instance doesn't exist.
 		if ib.OverWrite {
-			if err := ii.delete(w.ComputeClient, true); err != nil {
+			if err := ii.delete(daisyCompute.WithContext(ctx, w.ComputeClient), true); err != nil {
 				if apiErr, ok := err.(*googleapi.Error); !ok || apiErr.Code != 404 {
 					eChan <- Errf("error deleting existing instance: %v", err)
 					return
@@ -180,14 +206,14 @@ func (ci *CreateInstances) run(ctx context.Context, s *Step) DError {
 
 		w.LogStepInfo(s.name, "CreateInstances", "Creating instance %q.", ii.getName())
 
-		if err := ii.create(w.ComputeClient); err != nil {
+		if err := ii.create(daisyCompute.WithContext(ctx, w.ComputeClient)); err != nil {
 			// Fallback to no-external-ip mode to workaround organization policy.
 			if ib.RetryWhenExternalIPDenied && isExternalIPDeniedByOrganizationPolicy(err) {
 				w.LogStepInfo(s.name, "CreateInstances", "Falling back to no-external-ip mode "+
 					"for creating instance %v due to the fact that external IP is denied by organization policy.", ii.getName())
 
 				UpdateInstanceNoExternalIP(s)
-				err = ii.create(w.ComputeClient)
+				err = ii.create(daisyCompute.WithContext(ctx, w.ComputeClient))
 			}
 
 			if err != nil {
@@ -196,7 +222,7 @@ func (ci *CreateInstances) run(ctx context.Context, s *Step) DError {
 			}
 		}
 
-		ib.createdInWorkflow = true
+		w.instances.setCreated(&ib.Resource)
 		for _, port := range ib.SerialPortsToLog {
 			go logSerialOutput(ctx, s, ii, ib, port, 3*time.Second)
 		}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"sync"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	"google.golang.org/api/googleapi"
 )
 
@@ -60,7 +61,7 @@ func (c *CreateMachineImages) run(ctx context.Context, s *Step) DError {
 			// Delete existing machine image if OverWrite is true.
 			if mi.OverWrite {
 				// Just try to delete it, a 404 here indicates the machine image doesn't exist.
-				if err := w.ComputeClient.DeleteMachineImage(mi.Project, mi.Name); err != nil {
+				if err := daisyCompute.WithContext(ctx, w.ComputeClient).DeleteMachineImage(mi.Project, mi.Name); err != nil {
 					if apiErr, ok := err.(*googleapi.Error); !ok || apiErr.Code != 404 {
 						eChan <- Errf("error deleting existing machine image: %v", err)
 						return
@@ -70,11 +71,11 @@ func (c *CreateMachineImages) run(ctx context.Context, s *Step) DError {
 
 			w.LogStepInfo(s.name, "CreateMachineImages", "Creating machine image %q.", mi.Name)
 
-			if err := w.ComputeClient.CreateMachineImage(mi.Project, &mi.MachineImage); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateMachineImage(mi.Project, &mi.MachineImage); err != nil {
 				eChan <- newErr("failed to create machine image", err)
 				return
 			}
-			mi.createdInWorkflow = true
+			w.machineImages.setCreated(&mi.Resource)
 		}(ci)
 	}
 
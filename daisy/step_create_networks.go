@@ -17,6 +17,8 @@ package daisy
 import (
 	"context"
 	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 // CreateNetworks is a Daisy CreateNetwork workflow step.
@@ -48,11 +50,11 @@ func (c *CreateNetworks) run(ctx context.Context, s *Step) DError {
 			defer wg.Done()
 
 			w.LogStepInfo(s.name, "CreateNetworks", "Creating network %q.", n.Name)
-			if err := w.ComputeClient.CreateNetwork(n.Project, &n.Network); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateNetwork(n.Project, &n.Network); err != nil {
 				e <- newErr("failed to create networks", err)
 				return
 			}
-			n.createdInWorkflow = true
+			w.networks.setCreated(&n.Resource)
 		}(n)
 	}
 
@@ -0,0 +1,90 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+)
+
+// CreateRouters is a Daisy CreateRouters workflow step.
+type CreateRouters []*Router
+
+func (c *CreateRouters) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, r := range *c {
+		errs = addErrs(errs, r.populate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateRouters) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, r := range *c {
+		errs = addErrs(errs, r.validate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateRouters) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, r := range *c {
+		wg.Add(1)
+		go func(r *Router) {
+			defer wg.Done()
+
+			if networkRes, ok := w.networks.get(r.Network); ok {
+				r.Network = networkRes.link
+			}
+			for _, nat := range r.Nats {
+				for i, ip := range nat.NatIps {
+					if addressRes, ok := w.addresses.get(ip); ok {
+						nat.NatIps[i] = addressRes.link
+					}
+				}
+				for _, sn := range nat.Subnetworks {
+					if subnetworkRes, ok := w.subnetworks.get(sn.Name); ok {
+						sn.Name = subnetworkRes.link
+					}
+				}
+			}
+
+			w.LogStepInfo(s.name, "CreateRouters", "Creating router %q.", r.Name)
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateRouter(r.Project, r.Region, &r.Router); err != nil {
+				e <- newErr("failed to create routers", err)
+				return
+			}
+			w.routers.setCreated(&r.Resource)
+		}(r)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		// Wait so routers being created now can be deleted.
+		wg.Wait()
+		return nil
+	}
+}
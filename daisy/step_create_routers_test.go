@@ -0,0 +1,115 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestRouterPopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	r := &Router{Router: compute.Router{Name: "r", Network: "global/networks/n", Nats: []*compute.RouterNat{
+		{Name: "nat"},
+		{Name: "manual", NatIpAllocateOption: natManualOnly, NatIps: []string{"regions/test-zo/addresses/a", "a2"}},
+	}}}
+	if err := r.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Region != testRegion {
+		t.Errorf("got Region %q, want %q", r.Region, testRegion)
+	}
+	if want := "projects/test-project/global/networks/n"; r.Network != want {
+		t.Errorf("got Network %q, want %q", r.Network, want)
+	}
+	if r.Nats[0].NatIpAllocateOption != natAutoOnly || r.Nats[0].SourceSubnetworkIpRangesToNat != "ALL_SUBNETWORKS_ALL_IP_RANGES" {
+		t.Errorf("NAT defaults not set: %+v", r.Nats[0])
+	}
+	if want := "projects/test-project/regions/test-zo/addresses/a"; r.Nats[1].NatIps[0] != want {
+		t.Errorf("got NatIps[0] %q, want %q", r.Nats[1].NatIps[0], want)
+	}
+	if r.Nats[1].NatIps[1] != "a2" {
+		t.Errorf("daisy address name should be left as-is, got %q", r.Nats[1].NatIps[1])
+	}
+}
+
+func TestRouterValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	w.ComputeClient.(*daisyCompute.TestClient).ListRegionsFn = func(_ string, _ ...daisyCompute.ListCallOption) ([]*compute.Region, error) {
+		return []*compute.Region{{Name: testRegion}}, nil
+	}
+	s, _ := w.NewStep("s")
+	w.networks.m = map[string]*Resource{"n": {RealName: "n", link: "nlink"}}
+	w.addresses.m = map[string]*Resource{"a": {RealName: "a", link: "alink"}}
+
+	tests := []struct {
+		desc      string
+		nat       *compute.RouterNat
+		shouldErr bool
+	}{
+		{"auto case", &compute.RouterNat{Name: "nat", NatIpAllocateOption: natAutoOnly}, false},
+		{"manual case", &compute.RouterNat{Name: "nat", NatIpAllocateOption: natManualOnly, NatIps: []string{"a"}}, false},
+		{"manual without ips case", &compute.RouterNat{Name: "nat", NatIpAllocateOption: natManualOnly}, true},
+		{"auto with ips case", &compute.RouterNat{Name: "nat", NatIpAllocateOption: natAutoOnly, NatIps: []string{"a"}}, true},
+		{"unknown address case", &compute.RouterNat{Name: "nat", NatIpAllocateOption: natManualOnly, NatIps: []string{"dne"}}, true},
+		{"bad option case", &compute.RouterNat{Name: "nat", NatIpAllocateOption: "SOMETIMES"}, true},
+		{"no name case", &compute.RouterNat{NatIpAllocateOption: natAutoOnly}, true},
+	}
+
+	for i, tt := range tests {
+		r := &Router{Router: compute.Router{Network: "n", Region: testRegion, Nats: []*compute.RouterNat{tt.nat}}}
+		r.daisyName = string(rune('a' + i))
+		r.RealName = r.daisyName
+		r.Project = w.Project
+		r.link = "projects/test-project/regions/test-zo/routers/" + r.RealName
+		err := r.validate(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestCreateRoutersRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.networks.m = map[string]*Resource{"n": {RealName: "n", link: "nlink"}}
+	w.addresses.m = map[string]*Resource{"a": {RealName: "a", link: "alink"}}
+	w.subnetworks.m = map[string]*Resource{"sn": {RealName: "sn", link: "snlink"}}
+
+	var gotR compute.Router
+	w.ComputeClient = &daisyCompute.TestClient{CreateRouterFn: func(_, _ string, r *compute.Router) error { gotR = *r; return nil }}
+	crs := &CreateRouters{{Router: compute.Router{Name: "r", Region: testRegion, Network: "n", Nats: []*compute.RouterNat{
+		{Name: "nat", NatIps: []string{"a"}, Subnetworks: []*compute.RouterNatSubnetworkToNat{{Name: "sn"}}},
+	}}}}
+	if err := crs.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotR.Network != "nlink" || gotR.Nats[0].NatIps[0] != "alink" || gotR.Nats[0].Subnetworks[0].Name != "snlink" {
+		t.Errorf("daisy references not resolved: %+v, %+v", gotR, gotR.Nats[0])
+	}
+	if !(*crs)[0].createdInWorkflow {
+		t.Error("router not marked as created in workflow")
+	}
+}
@@ -0,0 +1,83 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+)
+
+// CreateRoutes is a Daisy CreateRoutes workflow step.
+type CreateRoutes []*Route
+
+func (c *CreateRoutes) populate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, r := range *c {
+		errs = addErrs(errs, r.populate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateRoutes) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, r := range *c {
+		errs = addErrs(errs, r.validate(ctx, s))
+	}
+	return errs
+}
+
+func (c *CreateRoutes) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError)
+	for _, r := range *c {
+		wg.Add(1)
+		go func(r *Route) {
+			defer wg.Done()
+
+			if networkRes, ok := w.networks.get(r.Network); ok {
+				r.Network = networkRes.link
+			}
+			if r.NextHopInstance != "" {
+				if instanceRes, ok := w.instances.get(r.NextHopInstance); ok {
+					r.NextHopInstance = instanceRes.link
+				}
+			}
+
+			w.LogStepInfo(s.name, "CreateRoutes", "Creating route %q.", r.Name)
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateRoute(r.Project, &r.Route); err != nil {
+				e <- newErr("failed to create routes", err)
+				return
+			}
+			w.routes.setCreated(&r.Resource)
+		}(r)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		// Wait so routes being created now can be deleted.
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/api/compute/v1"
+)
+
+func TestRoutePopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	r := &Route{Route: compute.Route{Name: "r", Network: "global/networks/n", NextHopGateway: "default-internet-gateway"}}
+	if err := r.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "projects/test-project/global/networks/n"; r.Network != want {
+		t.Errorf("got Network %q, want %q", r.Network, want)
+	}
+	if want := "projects/test-project/global/gateways/default-internet-gateway"; r.NextHopGateway != want {
+		t.Errorf("got NextHopGateway %q, want %q", r.NextHopGateway, want)
+	}
+	if want := "projects/test-project/global/routes/" + r.Name; r.link != want {
+		t.Errorf("got link %q, want %q", r.link, want)
+	}
+}
+
+func TestRouteValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	w.networks.m = map[string]*Resource{"n": {RealName: "n", link: "nlink"}}
+
+	tests := []struct {
+		desc      string
+		r         compute.Route
+		shouldErr bool
+	}{
+		{"good case", compute.Route{Name: "r1", Network: "n", DestRange: "0.0.0.0/0", NextHopGateway: "g"}, false},
+		{"no network case", compute.Route{Name: "r2", DestRange: "0.0.0.0/0", NextHopGateway: "g"}, true},
+		{"bad DestRange case", compute.Route{Name: "r3", Network: "n", DestRange: "0.0.0.0", NextHopGateway: "g"}, true},
+		{"no next hop case", compute.Route{Name: "r4", Network: "n", DestRange: "0.0.0.0/0"}, true},
+		{"two next hops case", compute.Route{Name: "r5", Network: "n", DestRange: "0.0.0.0/0", NextHopGateway: "g", NextHopIp: "10.0.0.1"}, true},
+	}
+
+	for _, tt := range tests {
+		r := &Route{Route: tt.r}
+		r.daisyName = r.Name
+		r.RealName = r.Name
+		r.Project = w.Project
+		r.link = "projects/test-project/global/routes/" + r.RealName
+		err := r.validate(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 // CreateSnapshots is a Daisy CreateSnapshots workflow step.
@@ -59,11 +61,11 @@ func (c *CreateSnapshots) run(ctx context.Context, s *Step) DError {
 
 		m := NamedSubexp(diskURLRgx, ss.SourceDisk)
 		w.LogStepInfo(s.name, "CreateSnapshots", "Creating snapshot %q.", ss.Name)
-		if err := w.ComputeClient.CreateSnapshot(m["project"], m["zone"], m["disk"], &ss.Snapshot); err != nil {
+		if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateSnapshot(m["project"], m["zone"], m["disk"], &ss.Snapshot); err != nil {
 			e <- newErr("failed to create snapshots", err)
 			return
 		}
-		ss.createdInWorkflow = true
+		w.snapshots.setCreated(&ss.Resource)
 	}
 
 	for _, ss := range *c {
@@ -17,6 +17,8 @@ package daisy
 import (
 	"context"
 	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 // CreateSubnetworks is a Daisy CreateSubnetwork workflow step.
@@ -52,11 +54,11 @@ func (c *CreateSubnetworks) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "CreateSubnetworks", "Creating subnetwork %q.", sn.Name)
-			if err := w.ComputeClient.CreateSubnetwork(sn.Project, sn.Region, &sn.Subnetwork); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateSubnetwork(sn.Project, sn.Region, &sn.Subnetwork); err != nil {
 				e <- newErr("failed to create subnetworks", err)
 				return
 			}
-			sn.createdInWorkflow = true
+			w.subnetworks.setCreated(&sn.Resource)
 		}(sn)
 	}
 
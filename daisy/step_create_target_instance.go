@@ -17,6 +17,8 @@ package daisy
 import (
 	"context"
 	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 // CreateTargetInstances is a Daisy CreateTargetInstances workflow step.
@@ -48,11 +50,11 @@ func (c *CreateTargetInstances) run(ctx context.Context, s *Step) DError {
 			defer wg.Done()
 
 			w.LogStepInfo(s.name, "CreateTargetInstances", "Creating target instance %q.", ti.Name)
-			if err := w.ComputeClient.CreateTargetInstance(ti.Project, ti.Zone, &ti.TargetInstance); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).CreateTargetInstance(ti.Project, ti.Zone, &ti.TargetInstance); err != nil {
 				e <- newErr("failed to create target instances", err)
 				return
 			}
-			ti.createdInWorkflow = true
+			w.targetInstances.setCreated(&ti.Resource)
 		}(ti)
 	}
 
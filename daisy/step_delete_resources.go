@@ -36,6 +36,9 @@ type DeleteResources struct {
 	Subnetworks   []string `json:",omitempty"`
 	GCSPaths      []string `json:",omitempty"`
 	Firewalls     []string `json:",omitempty"`
+	Addresses     []string `json:",omitempty"`
+	Routes        []string `json:",omitempty"`
+	Routers       []string `json:",omitempty"`
 }
 
 func (d *DeleteResources) populate(ctx context.Context, s *Step) DError {
@@ -74,6 +77,21 @@ func (d *DeleteResources) populate(ctx context.Context, s *Step) DError {
 			d.Firewalls[i] = extendPartialURL(firewall, s.w.Project)
 		}
 	}
+	for i, address := range d.Addresses {
+		if addressURLRegex.MatchString(address) {
+			d.Addresses[i] = extendPartialURL(address, s.w.Project)
+		}
+	}
+	for i, route := range d.Routes {
+		if routeURLRegex.MatchString(route) {
+			d.Routes[i] = extendPartialURL(route, s.w.Project)
+		}
+	}
+	for i, router := range d.Routers {
+		if routerURLRegex.MatchString(router) {
+			d.Routers[i] = extendPartialURL(router, s.w.Project)
+		}
+	}
 	return nil
 }
 
@@ -185,6 +203,27 @@ func (d *DeleteResources) validate(ctx context.Context, s *Step) DError {
 		}
 	}
 
+	// Address checking.
+	for _, a := range d.Addresses {
+		if err := s.w.addresses.regDelete(a, s); d.checkError(err, s) != nil {
+			return err
+		}
+	}
+
+	// Route checking.
+	for _, r := range d.Routes {
+		if err := s.w.routes.regDelete(r, s); d.checkError(err, s) != nil {
+			return err
+		}
+	}
+
+	// Router checking.
+	for _, r := range d.Routers {
+		if err := s.w.routers.regDelete(r, s); d.checkError(err, s) != nil {
+			return err
+		}
+	}
+
 	// GCS path checking
 	for _, p := range d.GCSPaths {
 		bkt, _, err := splitGCSPath(p)
@@ -263,7 +302,7 @@ func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
 		go func(i string) {
 			defer wg.Done()
 			w.LogStepInfo(s.name, "DeleteResources", "Deleting instance %q.", i)
-			if err := w.instances.delete(i); err != nil {
+			if err := w.instances.delete(ctx, i); err != nil {
 				if err.etype() == resourceDNEError {
 					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting instance %q: %v", i, err)
 					return
@@ -278,7 +317,7 @@ func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
 		go func(i string) {
 			defer wg.Done()
 			w.LogStepInfo(s.name, "DeleteResources", "Deleting image %q.", i)
-			if err := w.images.delete(i); err != nil {
+			if err := w.images.delete(ctx, i); err != nil {
 				if err.etype() == resourceDNEError {
 					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting image %q: %v", i, err)
 					return
@@ -293,7 +332,7 @@ func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
 		go func(i string) {
 			defer wg.Done()
 			w.LogStepInfo(s.name, "DeleteResources", "Deleting machine image %q.", i)
-			if err := w.machineImages.delete(i); err != nil {
+			if err := w.machineImages.delete(ctx, i); err != nil {
 				if err.etype() == resourceDNEError {
 					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting machine image %q: %v", i, err)
 					return
@@ -303,6 +342,36 @@ func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
 		}(i)
 	}
 
+	for _, r := range d.Routes {
+		wg.Add(1)
+		go func(r string) {
+			defer wg.Done()
+			w.LogStepInfo(s.name, "DeleteResources", "Deleting route %q.", r)
+			if err := w.routes.delete(ctx, r); err != nil {
+				if err.etype() == resourceDNEError {
+					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting route %q: %v", r, err)
+					return
+				}
+				e <- err
+			}
+		}(r)
+	}
+
+	for _, r := range d.Routers {
+		wg.Add(1)
+		go func(r string) {
+			defer wg.Done()
+			w.LogStepInfo(s.name, "DeleteResources", "Deleting router %q.", r)
+			if err := w.routers.delete(ctx, r); err != nil {
+				if err.etype() == resourceDNEError {
+					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting router %q: %v", r, err)
+					return
+				}
+				e <- err
+			}
+		}(r)
+	}
+
 	for _, p := range d.GCSPaths {
 		wg.Add(1)
 		go func(p string) {
@@ -341,7 +410,7 @@ func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
 		go func(d string) {
 			defer wg.Done()
 			w.LogStepInfo(s.name, "DeleteResources", "Deleting disk %q.", d)
-			if err := w.disks.delete(d); err != nil {
+			if err := w.disks.delete(ctx, d); err != nil {
 				if err.etype() == resourceDNEError {
 					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting disk %q: %v", d, err)
 					return
@@ -351,13 +420,29 @@ func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
 		}(d)
 	}
 
+	// Delete addresses after the instances and routers using them have been deleted.
+	for _, a := range d.Addresses {
+		wg.Add(1)
+		go func(a string) {
+			defer wg.Done()
+			w.LogStepInfo(s.name, "DeleteResources", "Deleting address %q.", a)
+			if err := w.addresses.delete(ctx, a); err != nil {
+				if err.etype() == resourceDNEError {
+					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting address %q: %v", a, err)
+					return
+				}
+				e <- err
+			}
+		}(a)
+	}
+
 	// Delete firewalls after instance have been deleted
 	for _, n := range d.Firewalls {
 		wg.Add(1)
 		go func(n string) {
 			defer wg.Done()
 			w.LogStepInfo(s.name, "DeleteResources", "Deleting firewall %q.", n)
-			if err := w.firewallRules.delete(n); err != nil {
+			if err := w.firewallRules.delete(ctx, n); err != nil {
 				if err.etype() == resourceDNEError {
 					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting firewall %q: %v", n, err)
 				}
@@ -372,7 +457,7 @@ func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
 		go func(sn string) {
 			defer wg.Done()
 			w.LogStepInfo(s.name, "DeleteResources", "Deleting subnetwork %q.", sn)
-			if err := w.subnetworks.delete(sn); err != nil {
+			if err := w.subnetworks.delete(ctx, sn); err != nil {
 				if err.etype() == resourceDNEError {
 					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting subnetwork %q: %v", sn, err)
 				}
@@ -391,7 +476,7 @@ func (d *DeleteResources) run(ctx context.Context, s *Step) DError {
 		go func(n string) {
 			defer wg.Done()
 			w.LogStepInfo(s.name, "DeleteResources", "Deleting network %q.", n)
-			if err := w.networks.delete(n); err != nil {
+			if err := w.networks.delete(ctx, n); err != nil {
 				if err.etype() == resourceDNEError {
 					w.LogStepInfo(s.name, "DeleteResources", "WARNING: Error deleting network %q: %v", n, err)
 				}
@@ -35,6 +35,9 @@ func TestDeleteResourcesPopulate(t *testing.T) {
 		Instances:     []string{"i", "zones/z/instances/i"},
 		Networks:      []string{"n", "global/networks/n"},
 		Firewalls:     []string{"n", "global/firewalls/n"},
+		Addresses:     []string{"a", "regions/r/addresses/a"},
+		Routes:        []string{"r", "global/routes/r"},
+		Routers:       []string{"r", "regions/r/routers/r"},
 	}
 
 	if err := (s.DeleteResources).populate(context.Background(), s); err != nil {
@@ -48,6 +51,9 @@ func TestDeleteResourcesPopulate(t *testing.T) {
 		Instances:     []string{"i", fmt.Sprintf("projects/%s/zones/z/instances/i", w.Project)},
 		Networks:      []string{"n", fmt.Sprintf("projects/%s/global/networks/n", w.Project)},
 		Firewalls:     []string{"n", fmt.Sprintf("projects/%s/global/firewalls/n", w.Project)},
+		Addresses:     []string{"a", fmt.Sprintf("projects/%s/regions/r/addresses/a", w.Project)},
+		Routes:        []string{"r", fmt.Sprintf("projects/%s/global/routes/r", w.Project)},
+		Routers:       []string{"r", fmt.Sprintf("projects/%s/regions/r/routers/r", w.Project)},
 	}
 	if diffRes := diff(s.DeleteResources, want, 0); diffRes != "" {
 		t.Errorf("DeleteResources not populated as expected: (-got,+want)\n%s", diffRes)
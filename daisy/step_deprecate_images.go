@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"sync"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	computeAlpha "google.golang.org/api/compute/v0.alpha"
 	"google.golang.org/api/compute/v1"
 )
@@ -86,10 +87,10 @@ func (d *DeprecateImages) run(ctx context.Context, s *Step) DError {
 			var err error
 			if di.DeprecationStatusAlpha.State != "" {
 				w.LogStepInfo(s.name, "DeprecateImages", "%q --> %q with DefaultRolloutTime %s.", di.Image, di.DeprecationStatusAlpha.State, di.DeprecationStatusAlpha.StateOverride.DefaultRolloutTime)
-				err = w.ComputeClient.DeprecateImageAlpha(di.Project, di.Image, &di.DeprecationStatusAlpha)
+				err = daisyCompute.WithContext(ctx, w.ComputeClient).DeprecateImageAlpha(di.Project, di.Image, &di.DeprecationStatusAlpha)
 			} else {
 				w.LogStepInfo(s.name, "DeprecateImages", "%q --> %q.", di.Image, di.DeprecationStatus.State)
-				err = w.ComputeClient.DeprecateImage(di.Project, di.Image, &di.DeprecationStatus)
+				err = daisyCompute.WithContext(ctx, w.ComputeClient).DeprecateImage(di.Project, di.Image, &di.DeprecationStatus)
 			}
 			if err != nil {
 				e <- newErr("failed to deprecate images", err)
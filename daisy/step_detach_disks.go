@@ -18,6 +18,8 @@ import (
 	"context"
 	"path"
 	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 // DetachDisks is a Daisy DetachDisks workflow step.
@@ -67,17 +69,17 @@ func (a *DetachDisks) validate(ctx context.Context, s *Step) (errs DError) {
 			dd.project = device["project"]
 			dd.zone = device["zone"]
 		} else {
-			// Ensure disk is in the same project and zone.
+			// Ensure disk is in the same project and zone, or region for regional disks.
 			disk := NamedSubexp(diskURLRgx, res.link)
 			if disk["project"] != instance["project"] {
 				errs = addErrs(errs, Errf("cannot detach disk in project %q from instance in project %q: %q", disk["project"], instance["project"], dd.DeviceName))
 			}
-			if disk["zone"] != instance["zone"] {
-				errs = addErrs(errs, Errf("cannot detach disk in zone %q from instance in zone %q: %q", disk["zone"], instance["zone"], dd.DeviceName))
+			if !diskInZone(disk, instance["zone"]) {
+				errs = addErrs(errs, Errf("cannot detach disk in %s from instance in zone %q: %q", diskLocation(disk), instance["zone"], dd.DeviceName))
 			}
 
 			dd.project = disk["project"]
-			dd.zone = disk["zone"]
+			dd.zone = instance["zone"]
 		}
 
 		// Register disk detachments.
@@ -89,7 +91,7 @@ func (a *DetachDisks) validate(ctx context.Context, s *Step) (errs DError) {
 func (a *DetachDisks) run(ctx context.Context, s *Step) DError {
 	var wg sync.WaitGroup
 	w := s.w
-	e := make(chan DError)
+	e := make(chan DError, len(*a)+1)
 	for _, dd := range *a {
 		wg.Add(1)
 		go func(dd *DetachDisk) {
@@ -101,7 +103,7 @@ func (a *DetachDisks) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "DetachDisks", "Detaching disk %q from instance %q.", dd.DeviceName, inst)
-			if err := w.ComputeClient.DetachDisk(dd.project, dd.zone, dd.Instance, dd.realName); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).DetachDisk(dd.project, dd.zone, dd.Instance, dd.realName); err != nil {
 				e <- newErr("failed to detach disks", err)
 				return
 			}
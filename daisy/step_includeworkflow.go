@@ -17,7 +17,6 @@ package daisy
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 	"reflect"
 	"strings"
 )
@@ -27,16 +26,20 @@ import (
 // a Subworkflow the included workflow will exist in the same namespace
 // as the parent and have access to all its resources.
 type IncludeWorkflow struct {
-	Path     string
+	// Path to the workflow: a local path, gs://, https:// or
+	// git::<repository>//<path>?ref=<ref>.
+	Path string
+	// SHA256 optionally pins the workflow file content by its hex digest.
+	SHA256   string            `json:",omitempty"`
 	Vars     map[string]string `json:",omitempty"`
 	Workflow *Workflow         `json:",omitempty"`
 }
 
 func (i *IncludeWorkflow) populate(ctx context.Context, s *Step) DError {
 	if i.Path != "" {
-		var err error
-		if i.Workflow, err = s.w.NewIncludedWorkflowFromFile(i.Path); err != nil {
-			return newErr("failed to parse duration for step includeworkflow", err)
+		var err DError
+		if i.Workflow, err = s.w.newIncludedWorkflowFromPath(ctx, i.Path, i.SHA256); err != nil {
+			return err
 		}
 	} else {
 		if i.Workflow == nil {
@@ -48,6 +51,7 @@ func (i *IncludeWorkflow) populate(ctx context.Context, s *Step) DError {
 	i.Workflow.id = i.Workflow.parent.id
 	i.Workflow.username = i.Workflow.parent.username
 	i.Workflow.ComputeClient = i.Workflow.parent.ComputeClient
+	i.Workflow.ComputeLimiter = i.Workflow.parent.ComputeLimiter
 	i.Workflow.StorageClient = i.Workflow.parent.StorageClient
 	i.Workflow.cloudLoggingClient = i.Workflow.parent.cloudLoggingClient
 	i.Workflow.GCSPath = i.Workflow.parent.GCSPath
@@ -126,8 +130,9 @@ Loop:
 		if s.w.Sources == nil {
 			s.w.Sources = map[string]string{}
 		}
-		if _, _, err := splitGCSPath(v); err != nil && !filepath.IsAbs(v) {
-			v = filepath.Join(i.Workflow.workflowDir, v)
+		v, err := i.Workflow.resolveLocalPath(v)
+		if err != nil {
+			return err
 		}
 		s.w.Sources[k] = v
 	}
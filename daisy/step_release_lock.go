@@ -0,0 +1,65 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"sync"
+)
+
+// ReleaseLock is a Daisy ReleaseLock workflow step. It releases locks taken
+// by AcquireLock steps, by name.
+type ReleaseLock []string
+
+func (rl *ReleaseLock) populate(ctx context.Context, s *Step) DError {
+	return nil
+}
+
+func (rl *ReleaseLock) validate(ctx context.Context, s *Step) DError {
+	var errs DError
+	for _, name := range *rl {
+		errs = addErrs(errs, s.w.locks.regDelete(name, s))
+	}
+	return errs
+}
+
+func (rl *ReleaseLock) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	e := make(chan DError, len(*rl)+1)
+	for _, name := range *rl {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			w.LogStepInfo(s.name, "ReleaseLock", "Releasing lock %q.", name)
+			if err := w.locks.delete(ctx, name); err != nil {
+				e <- err
+			}
+		}(name)
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		wg.Wait()
+		return nil
+	}
+}
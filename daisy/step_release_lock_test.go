@@ -0,0 +1,70 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+)
+
+func TestReleaseLockValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	acquire, _ := w.NewStep("acquire")
+	acquire.AcquireLock = &AcquireLock{{Name: "l"}}
+	release, _ := w.NewStep("release")
+	unrelated, _ := w.NewStep("unrelated")
+	w.AddDependency(release, acquire)
+	if err := acquire.AcquireLock.populate(ctx, acquire); err != nil {
+		t.Fatal(err)
+	}
+	if err := acquire.AcquireLock.validate(ctx, acquire); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&ReleaseLock{"l"}).validate(ctx, unrelated); err == nil {
+		t.Error("expected error releasing a lock without depending on its AcquireLock step, got none")
+	}
+	if err := (&ReleaseLock{"dne"}).validate(ctx, release); err == nil {
+		t.Error("expected error releasing an unknown lock, got none")
+	}
+	if err := (&ReleaseLock{"l"}).validate(ctx, release); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReleaseLockRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	f := newFakeProjectMetadata(w.ComputeClient.(*daisyCompute.TestClient), map[string]string{"keep": "v", "lock-key": "v"})
+	w.locks.m = map[string]*Resource{"l": {RealName: "lock-key", Project: testProject, creator: s, createdInWorkflow: true}}
+
+	if err := (&ReleaseLock{"l"}).run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.has("lock-key") || !f.has("keep") {
+		t.Errorf("unexpected metadata after release: %v", f.items)
+	}
+	if res, _ := w.locks.get("l"); !res.deleted {
+		t.Error("lock not marked as released")
+	}
+
+	if err := (&ReleaseLock{"dne"}).run(ctx, s); err == nil {
+		t.Error("expected error releasing an unknown lock, got none")
+	}
+}
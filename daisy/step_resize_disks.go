@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"sync"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -32,6 +33,8 @@ type ResizeDisk struct {
 	// Name of the disk to be resized
 	Name   string
 	SizeGb string "json:\"sizeGb,omitempty\""
+
+	project, zone, region string
 }
 
 func (r *ResizeDisks) populate(ctx context.Context, s *Step) DError {
@@ -61,6 +64,8 @@ func (r *ResizeDisks) validate(ctx context.Context, s *Step) DError {
 		}
 		// Reference the actual name of the disk
 		rd.Name = dr.RealName
+		disk := NamedSubexp(diskURLRgx, dr.link)
+		rd.project, rd.zone, rd.region = disk["project"], disk["zone"], disk["region"]
 
 		pre := fmt.Sprintf("cannot resize disk %q", rd.Name)
 		if rd.DisksResizeRequest.SizeGb <= 0 {
@@ -80,7 +85,13 @@ func (r *ResizeDisks) run(ctx context.Context, s *Step) DError {
 			defer wg.Done()
 
 			w.LogStepInfo(s.name, "ResizeDisks", "Resizing disk %q to %v GB.", rd.Name, rd.DisksResizeRequest.SizeGb)
-			if err := w.ComputeClient.ResizeDisk(s.w.Project, s.w.Zone, rd.Name, &rd.DisksResizeRequest); err != nil {
+			var err error
+			if rd.region != "" {
+				err = daisyCompute.WithContext(ctx, w.ComputeClient).ResizeRegionDisk(rd.project, rd.region, rd.Name, &compute.RegionDisksResizeRequest{SizeGb: rd.DisksResizeRequest.SizeGb})
+			} else {
+				err = daisyCompute.WithContext(ctx, w.ComputeClient).ResizeDisk(rd.project, rd.zone, rd.Name, &rd.DisksResizeRequest)
+			}
+			if err != nil {
 				e <- newErr("failed to resize disk", err)
 				return
 			}
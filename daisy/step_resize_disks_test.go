@@ -112,3 +112,25 @@ func TestResizeDisksRun(t *testing.T) {
 		}
 	}
 }
+
+func TestResizeDisksRunRegional(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("test")
+
+	var gotRegion string
+	var gotRdrr compute.RegionDisksResizeRequest
+	w.ComputeClient = &daisyCompute.TestClient{
+		ResizeRegionDiskFn: func(_, region, _ string, rdrr *compute.RegionDisksResizeRequest) error {
+			gotRegion, gotRdrr = region, *rdrr
+			return nil
+		},
+	}
+	rds := &ResizeDisks{{Name: "disk1", DisksResizeRequest: compute.DisksResizeRequest{SizeGb: 20}, project: testProject, region: testRegion}}
+	if err := rds.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotRegion != testRegion || gotRdrr.SizeGb != 20 {
+		t.Errorf("client got region %q and request %+v, want region %q and SizeGb 20", gotRegion, gotRdrr, testRegion)
+	}
+}
@@ -0,0 +1,71 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+)
+
+func TestResumeInstancesPopulate(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	s.ResumeInstances = &ResumeInstances{
+		Instances: []string{"i", "zones/z/instances/i"},
+	}
+
+	if err := (s.ResumeInstances).populate(context.Background(), s); err != nil {
+		t.Error("err should be nil")
+	}
+
+	want := &ResumeInstances{
+		Instances: []string{"i", fmt.Sprintf("projects/%s/zones/z/instances/i", w.Project)},
+	}
+	if diffRes := diff(s.ResumeInstances, want, 0); diffRes != "" {
+		t.Errorf("ResumeInstances not populated as expected: (-got,+want)\n%s", diffRes)
+	}
+}
+
+func TestResumeInstancesRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+
+	var resumed []string
+	tc := w.ComputeClient.(*daisyCompute.TestClient)
+	tc.SuspendInstanceFn = func(_, _, _ string) error { return nil }
+	tc.ResumeInstanceFn = func(_, _, name string) error {
+		resumed = append(resumed, name)
+		return nil
+	}
+	s, _ := w.NewStep("s")
+	in := &Resource{RealName: "in0", link: fmt.Sprintf("projects/%s/zones/%s/instances/in0", testProject, testZone)}
+	w.instances.m = map[string]*Resource{"in0": in}
+
+	if err := (&SuspendInstances{Instances: []string{"in0"}}).run(ctx, s); err != nil {
+		t.Fatalf("error running SuspendInstances.run(): %v", err)
+	}
+	if err := (&ResumeInstances{Instances: []string{"in0"}}).run(ctx, s); err != nil {
+		t.Fatalf("error running ResumeInstances.run(): %v", err)
+	}
+	if len(resumed) != 1 || resumed[0] != "in0" {
+		t.Errorf("got resumed instances %v, want [in0]", resumed)
+	}
+	if in.suspendedByWf {
+		t.Error("in0 should no longer be marked suspended")
+	}
+}
@@ -0,0 +1,129 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+// RollingUpdate is a Daisy RollingUpdate workflow step. It rolls a managed
+// instance group out to new instance template versions, then waits until the
+// group is stable and every instance runs its target version.
+type RollingUpdate struct {
+	// InstanceGroupManager to update, either the name of a MIG created in
+	// this workflow or the partial URL of an existing MIG.
+	InstanceGroupManager string
+	// Versions to roll out. Setting TargetSize on all but one of the
+	// versions canaries them on part of the group.
+	Versions []*compute.InstanceGroupManagerVersion
+	// UpdatePolicy of the rollout, Type defaults to PROACTIVE.
+	UpdatePolicy *compute.InstanceGroupManagerUpdatePolicy `json:",omitempty"`
+	// Interval to check whether the group is stable (default is 10s).
+	Interval string `json:",omitempty"`
+	interval time.Duration
+}
+
+func (ru *RollingUpdate) populate(ctx context.Context, s *Step) DError {
+	if instanceGroupManagerURLRgx.MatchString(ru.InstanceGroupManager) {
+		ru.InstanceGroupManager = extendPartialURL(ru.InstanceGroupManager, s.w.Project)
+	}
+	for _, v := range ru.Versions {
+		v.InstanceTemplate = extendInstanceTemplateURL(v.InstanceTemplate, s.w.Project)
+	}
+	if ru.UpdatePolicy == nil {
+		ru.UpdatePolicy = &compute.InstanceGroupManagerUpdatePolicy{}
+	}
+	ru.UpdatePolicy.Type = strOr(ru.UpdatePolicy.Type, "PROACTIVE")
+
+	ru.Interval = strOr(ru.Interval, defaultInterval)
+	var err error
+	if ru.interval, err = time.ParseDuration(ru.Interval); err != nil {
+		return newErr(fmt.Sprintf("failed to parse Interval for step %v", s.name), err)
+	}
+	return nil
+}
+
+func (ru *RollingUpdate) validate(ctx context.Context, s *Step) DError {
+	pre := fmt.Sprintf("cannot roll out instance group manager %q", ru.InstanceGroupManager)
+	var errs DError
+	if ru.InstanceGroupManager == "" {
+		errs = addErrs(errs, Errf("cannot roll out instance group manager: InstanceGroupManager not set"))
+	} else if _, err := s.w.instanceGroupManagers.regUse(ru.InstanceGroupManager, s); err != nil {
+		errs = addErrs(errs, err)
+	}
+
+	if len(ru.Versions) == 0 {
+		errs = addErrs(errs, Errf("%s: no Versions provided", pre))
+	}
+	errs = addErrs(errs, validateVersions(ru.Versions, s, pre))
+
+	types := []string{"PROACTIVE", "OPPORTUNISTIC"}
+	if !strIn(ru.UpdatePolicy.Type, types) {
+		errs = addErrs(errs, Errf("%s: UpdatePolicy.Type %q not one of %v", pre, ru.UpdatePolicy.Type, types))
+	}
+	return errs
+}
+
+func (ru *RollingUpdate) run(ctx context.Context, s *Step) DError {
+	w := s.w
+	res, ok := w.instanceGroupManagers.get(ru.InstanceGroupManager)
+	if !ok {
+		return Errf("cannot roll out instance group manager %q; does not exist in registry", ru.InstanceGroupManager)
+	}
+	m := NamedSubexp(instanceGroupManagerURLRgx, res.link)
+	project, zone, name := m["project"], m["zone"], m["instanceGroupManager"]
+
+	for _, v := range ru.Versions {
+		v.InstanceTemplate = resolveInstanceTemplate(w, v.InstanceTemplate)
+	}
+
+	w.LogStepInfo(s.name, "RollingUpdate", "Rolling out instance group manager %q.", name)
+	patch := &compute.InstanceGroupManager{Versions: ru.Versions, UpdatePolicy: ru.UpdatePolicy}
+	if err := daisyCompute.WithContext(ctx, w.ComputeClient).PatchInstanceGroupManager(project, zone, name, patch); err != nil {
+		return newErr("failed to update instance group manager", err)
+	}
+	return waitForInstanceGroupManagerStable(ctx, s, project, zone, name, ru.interval)
+}
+
+// waitForInstanceGroupManagerStable waits until the MIG has no pending
+// actions and every instance runs the version it's meant to run.
+func waitForInstanceGroupManagerStable(ctx context.Context, s *Step, project, zone, name string, interval time.Duration) DError {
+	w := s.w
+	w.LogStepInfo(s.name, "RollingUpdate", "Waiting for instance group manager %q to become stable.", name)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.Cancel:
+			return nil
+		case <-ctx.Done():
+			return Errf("stopped waiting for instance group manager %q to become stable: %v", name, ctx.Err())
+		case <-ticker.C:
+			igm, err := daisyCompute.WithContext(ctx, w.ComputeClient).GetInstanceGroupManager(project, zone, name)
+			if err != nil {
+				return typedErr(apiError, "failed to get instance group manager status", err)
+			}
+			if st := igm.Status; st != nil && st.IsStable && (st.VersionTarget == nil || st.VersionTarget.IsReached) {
+				w.LogStepInfo(s.name, "RollingUpdate", "Instance group manager %q is stable.", name)
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestRollingUpdatePopulate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	ru := &RollingUpdate{
+		InstanceGroupManager: "zones/z/instanceGroupManagers/igm",
+		Versions:             []*compute.InstanceGroupManagerVersion{{InstanceTemplate: "global/instanceTemplates/it"}},
+	}
+	if err := ru.populate(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "projects/test-project/zones/z/instanceGroupManagers/igm"; ru.InstanceGroupManager != want {
+		t.Errorf("got InstanceGroupManager %q, want %q", ru.InstanceGroupManager, want)
+	}
+	if want := "projects/test-project/global/instanceTemplates/it"; ru.Versions[0].InstanceTemplate != want {
+		t.Errorf("got InstanceTemplate %q, want %q", ru.Versions[0].InstanceTemplate, want)
+	}
+	if ru.UpdatePolicy.Type != "PROACTIVE" {
+		t.Errorf("got UpdatePolicy.Type %q, want PROACTIVE", ru.UpdatePolicy.Type)
+	}
+	if ru.interval != 10*time.Second {
+		t.Errorf("got interval %v, want 10s", ru.interval)
+	}
+
+	ru = &RollingUpdate{Interval: "bad"}
+	if err := ru.populate(ctx, s); err == nil {
+		t.Error("should have returned an error for a bad Interval")
+	}
+}
+
+func TestRollingUpdateValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	w.instanceGroupManagers.m = map[string]*Resource{"igm": {RealName: "igm", link: "projects/p/zones/z/instanceGroupManagers/igm"}}
+	w.instanceTemplates.m = map[string]*Resource{"it": {RealName: "it", link: "itlink"}}
+
+	versions := []*compute.InstanceGroupManagerVersion{{InstanceTemplate: "it"}}
+	tests := []struct {
+		desc      string
+		ru        RollingUpdate
+		shouldErr bool
+	}{
+		{"normal case", RollingUpdate{InstanceGroupManager: "igm", Versions: versions}, false},
+		{"no MIG case", RollingUpdate{Versions: versions}, true},
+		{"unknown MIG case", RollingUpdate{InstanceGroupManager: "dne", Versions: versions}, true},
+		{"no versions case", RollingUpdate{InstanceGroupManager: "igm"}, true},
+		{"unknown template case", RollingUpdate{InstanceGroupManager: "igm", Versions: []*compute.InstanceGroupManagerVersion{{InstanceTemplate: "dne"}}}, true},
+		{"bad policy case", RollingUpdate{InstanceGroupManager: "igm", Versions: versions, UpdatePolicy: &compute.InstanceGroupManagerUpdatePolicy{Type: "EVENTUALLY"}}, true},
+	}
+
+	for _, tt := range tests {
+		ru := tt.ru
+		if err := ru.populate(ctx, s); err != nil {
+			t.Fatalf("%s: unexpected populate error: %v", tt.desc, err)
+		}
+		err := ru.validate(ctx, s)
+		if err == nil && tt.shouldErr {
+			t.Errorf("%s: should have returned an error", tt.desc)
+		} else if err != nil && !tt.shouldErr {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+	}
+}
+
+func TestRollingUpdateRun(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s := &Step{w: w}
+	w.instanceGroupManagers.m = map[string]*Resource{"igm": {RealName: "igm", link: "projects/p/zones/z/instanceGroupManagers/igm"}}
+	w.instanceTemplates.m = map[string]*Resource{"it": {RealName: "it", link: "itlink"}}
+
+	var gotProject, gotZone, gotName string
+	var gotPatch *compute.InstanceGroupManager
+	var gets int
+	w.ComputeClient = &daisyCompute.TestClient{
+		PatchInstanceGroupManagerFn: func(p, z, n string, m *compute.InstanceGroupManager) error {
+			gotProject, gotZone, gotName, gotPatch = p, z, n, m
+			return nil
+		},
+		GetInstanceGroupManagerFn: func(_, _, _ string) (*compute.InstanceGroupManager, error) {
+			gets++
+			// Stable but not yet on the new version, then done.
+			return &compute.InstanceGroupManager{Status: &compute.InstanceGroupManagerStatus{
+				IsStable:      true,
+				VersionTarget: &compute.InstanceGroupManagerStatusVersionTarget{IsReached: gets > 1},
+			}}, nil
+		},
+	}
+	ru := &RollingUpdate{
+		InstanceGroupManager: "igm",
+		Versions:             []*compute.InstanceGroupManagerVersion{{InstanceTemplate: "it"}},
+		UpdatePolicy:         &compute.InstanceGroupManagerUpdatePolicy{Type: "PROACTIVE"},
+		interval:             time.Millisecond,
+	}
+	if err := ru.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotProject != "p" || gotZone != "z" || gotName != "igm" {
+		t.Errorf("patched wrong MIG: %s/%s/%s", gotProject, gotZone, gotName)
+	}
+	if gotPatch.Versions[0].InstanceTemplate != "itlink" || gotPatch.UpdatePolicy.Type != "PROACTIVE" {
+		t.Errorf("unexpected patch: %+v", gotPatch)
+	}
+	if gets != 2 {
+		t.Errorf("got %d status checks, want 2", gets)
+	}
+}
@@ -16,12 +16,22 @@ package daisy
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 // StopInstances stop GCE instances.
 type StopInstances struct {
 	Instances []string `json:",omitempty"`
+	// Time to wait for the instances to shut down from within the guest,
+	// e.g. at the end of sysprep, before stopping them through the API.
+	// Must be parsable by https://golang.org/pkg/time/#ParseDuration.
+	GracefulShutdownTimeout string `json:",omitempty"`
+	gracefulShutdownTimeout time.Duration
+	interval                time.Duration
 }
 
 func (st *StopInstances) populate(ctx context.Context, s *Step) DError {
@@ -30,6 +40,13 @@ func (st *StopInstances) populate(ctx context.Context, s *Step) DError {
 			st.Instances[i] = extendPartialURL(instance, s.w.Project)
 		}
 	}
+	if st.GracefulShutdownTimeout != "" {
+		var err error
+		if st.gracefulShutdownTimeout, err = time.ParseDuration(st.GracefulShutdownTimeout); err != nil {
+			return newErr(fmt.Sprintf("failed to parse GracefulShutdownTimeout for step %v", s.name), err)
+		}
+		st.interval, _ = time.ParseDuration(defaultInterval)
+	}
 	return nil
 }
 
@@ -52,8 +69,14 @@ func (st *StopInstances) run(ctx context.Context, s *Step) DError {
 		wg.Add(1)
 		go func(i string) {
 			defer wg.Done()
+			if st.gracefulShutdownTimeout > 0 {
+				if err := st.waitForGuestShutdown(ctx, s, i); err != nil {
+					e <- err
+					return
+				}
+			}
 			w.LogStepInfo(s.name, "StopInstances", "Stopping instance %q.", i)
-			if err := w.instances.stop(i); err != nil {
+			if err := w.instances.stop(ctx, i); err != nil {
 				e <- err
 			}
 		}(i)
@@ -71,3 +94,41 @@ func (st *StopInstances) run(ctx context.Context, s *Step) DError {
 		return nil
 	}
 }
+
+// waitForGuestShutdown waits up to the graceful shutdown timeout for instance
+// i to stop by itself. Stopping an instance that already shut down is a no-op,
+// so the caller stops it through the API either way.
+func (st *StopInstances) waitForGuestShutdown(ctx context.Context, s *Step, i string) DError {
+	w := s.w
+	res, ok := w.instances.get(i)
+	if !ok {
+		return Errf("unresolved instance %q", i)
+	}
+	m := NamedSubexp(instanceURLRgx, res.link)
+
+	w.LogStepInfo(s.name, "StopInstances", "Waiting up to %s for instance %q to shut down.", st.gracefulShutdownTimeout, i)
+	timeout := time.NewTimer(st.gracefulShutdownTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(st.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.Cancel:
+			return nil
+		case <-ctx.Done():
+			return Errf("stopped waiting for instance %q to shut down: %v", i, ctx.Err())
+		case <-timeout.C:
+			w.LogStepInfo(s.name, "StopInstances", "Instance %q did not shut down within %s.", i, st.gracefulShutdownTimeout)
+			return nil
+		case <-ticker.C:
+			stopped, err := daisyCompute.WithContext(ctx, w.ComputeClient).InstanceStopped(m["project"], m["zone"], m["instance"])
+			if err != nil {
+				return typedErr(apiError, "failed to check whether instance is stopped", err)
+			}
+			if stopped {
+				w.LogStepInfo(s.name, "StopInstances", "Instance %q shut down.", i)
+				return nil
+			}
+		}
+	}
+}
@@ -18,6 +18,9 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 func TestStopInstancesPopulate(t *testing.T) {
@@ -92,3 +95,61 @@ func TestStopInstancesRun(t *testing.T) {
 		}
 	}
 }
+
+func TestStopInstancesPopulateGracefulShutdown(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	st := &StopInstances{Instances: []string{"i"}, GracefulShutdownTimeout: "5m"}
+	if err := st.populate(context.Background(), s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.gracefulShutdownTimeout != 5*time.Minute {
+		t.Errorf("got gracefulShutdownTimeout %v, want 5m", st.gracefulShutdownTimeout)
+	}
+
+	st = &StopInstances{Instances: []string{"i"}, GracefulShutdownTimeout: "soon"}
+	if err := st.populate(context.Background(), s); err == nil {
+		t.Error("should have returned an error for a bad GracefulShutdownTimeout")
+	}
+}
+
+func TestStopInstancesRunGracefulShutdown(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc   string
+		status func(checks int) bool
+	}{
+		{"guest shuts down case", func(checks int) bool { return checks > 1 }},
+		{"timeout falls back to stop case", func(int) bool { return false }},
+	}
+
+	for _, tt := range tests {
+		w := testWorkflow()
+		var checks, stops int
+		tc := w.ComputeClient.(*daisyCompute.TestClient)
+		tc.InstanceStoppedFn = func(_, _, _ string) (bool, error) {
+			checks++
+			return tt.status(checks), nil
+		}
+		tc.StopInstanceFn = func(_, _, _ string) error {
+			stops++
+			return nil
+		}
+		s, _ := w.NewStep("s")
+		in := &Resource{RealName: "in0", link: fmt.Sprintf("projects/%s/zones/%s/instances/in0", testProject, testZone)}
+		w.instances.m = map[string]*Resource{"in0": in}
+
+		st := &StopInstances{Instances: []string{"in0"}, gracefulShutdownTimeout: 50 * time.Millisecond, interval: time.Millisecond}
+		if err := st.run(ctx, s); err != nil {
+			t.Fatalf("%s: error running StopInstances.run(): %v", tt.desc, err)
+		}
+		if checks == 0 {
+			t.Errorf("%s: instance status was never checked", tt.desc)
+		}
+		if stops != 1 || !in.stoppedByWf {
+			t.Errorf("%s: instance should have been stopped once, got %d stops", tt.desc, stops)
+		}
+	}
+}
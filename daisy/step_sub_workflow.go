@@ -21,16 +21,20 @@ import (
 
 // SubWorkflow defines a Daisy sub workflow.
 type SubWorkflow struct {
-	Path     string
+	// Path to the workflow: a local path, gs://, https:// or
+	// git::<repository>//<path>?ref=<ref>.
+	Path string
+	// SHA256 optionally pins the workflow file content by its hex digest.
+	SHA256   string            `json:",omitempty"`
 	Vars     map[string]string `json:",omitempty"`
 	Workflow *Workflow         `json:",omitempty"`
 }
 
 func (s *SubWorkflow) populate(ctx context.Context, st *Step) DError {
 	if s.Path != "" {
-		var err error
-		if s.Workflow, err = st.w.NewSubWorkflowFromFile(s.Path); err != nil {
-			return ToDError(err)
+		var err DError
+		if s.Workflow, err = st.w.newSubWorkflowFromPath(ctx, s.Path, s.SHA256); err != nil {
+			return err
 		}
 	}
 
@@ -45,6 +49,7 @@ func (s *SubWorkflow) populate(ctx context.Context, st *Step) DError {
 	s.Workflow.Zone = s.Workflow.parent.Zone
 	s.Workflow.OAuthPath = s.Workflow.parent.OAuthPath
 	s.Workflow.ComputeClient = s.Workflow.parent.ComputeClient
+	s.Workflow.ComputeLimiter = s.Workflow.parent.ComputeLimiter
 	s.Workflow.StorageClient = s.Workflow.parent.StorageClient
 	s.Workflow.Logger = s.Workflow.parent.Logger
 	s.Workflow.DefaultTimeout = st.Timeout
@@ -18,6 +18,7 @@ import (
 	"context"
 	"sync"
 
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -63,7 +64,7 @@ func (c *UpdateInstancesMetadata) validate(ctx context.Context, s *Step) (errs D
 func (c *UpdateInstancesMetadata) run(ctx context.Context, s *Step) DError {
 	var wg sync.WaitGroup
 	w := s.w
-	e := make(chan DError)
+	e := make(chan DError, len(*c)+1)
 	for _, sm := range *c {
 		wg.Add(1)
 		go func(sm *UpdateInstanceMetadata) {
@@ -76,7 +77,7 @@ func (c *UpdateInstancesMetadata) run(ctx context.Context, s *Step) DError {
 			}
 
 			// Get metadata fingerprint and original metadata
-			resp, err := w.ComputeClient.GetInstance(sm.project, sm.zone, sm.Instance)
+			resp, err := daisyCompute.WithContext(ctx, w.ComputeClient).GetInstance(sm.project, sm.zone, sm.Instance)
 			if err != nil {
 				e <- newErr("failed to get instance data", err)
 				return
@@ -96,7 +97,7 @@ func (c *UpdateInstancesMetadata) run(ctx context.Context, s *Step) DError {
 			}
 
 			w.LogStepInfo(s.name, "UpdateInstancesMetadata", "Set Instance %q metadata to %q.", inst, sm.Metadata)
-			if err := w.ComputeClient.SetInstanceMetadata(sm.project, sm.zone, sm.Instance, &metadata); err != nil {
+			if err := daisyCompute.WithContext(ctx, w.ComputeClient).SetInstanceMetadata(sm.project, sm.zone, sm.Instance, &metadata); err != nil {
 				e <- newErr("failed to set instance metadata", err)
 				return
 			}
@@ -0,0 +1,370 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// fingerprintRetries is how many times a label or metadata update is tried
+// when someone else changes the resource between our read and our write.
+const fingerprintRetries = 5
+
+// UpdateResources is a Daisy UpdateResources workflow step. It changes the
+// labels and metadata of resources that already exist, whether the workflow
+// created them or not.
+type UpdateResources struct {
+	// Labels to set on or remove from resources.
+	Labels []*UpdateLabels `json:",omitempty"`
+	// Metadata to set on or remove from instances.
+	InstancesMetadata []*UpdateMetadata `json:",omitempty"`
+	// Common instance metadata to set on or remove from a project.
+	ProjectMetadata *UpdateProjectMetadata `json:",omitempty"`
+}
+
+// UpdateLabels sets and removes labels on a group of resources. Machine
+// images aren't supported as the Compute API can't change their labels.
+type UpdateLabels struct {
+	Disks     []string `json:",omitempty"`
+	Images    []string `json:",omitempty"`
+	Instances []string `json:",omitempty"`
+	Snapshots []string `json:",omitempty"`
+
+	// Labels to add or overwrite.
+	Set map[string]string `json:",omitempty"`
+	// Label keys to remove, keys that aren't set are ignored.
+	Remove []string `json:",omitempty"`
+
+	links []string
+}
+
+// UpdateMetadata merges metadata into an instance's metadata.
+type UpdateMetadata struct {
+	Instance string
+
+	// Metadata items to add or overwrite.
+	Set map[string]string `json:",omitempty"`
+	// Metadata keys to remove, keys that aren't set are ignored.
+	Remove []string `json:",omitempty"`
+
+	project, zone, name string
+}
+
+// UpdateProjectMetadata merges metadata into a project's common instance
+// metadata.
+type UpdateProjectMetadata struct {
+	// Project defaults to the workflow's project.
+	Project string `json:",omitempty"`
+
+	// Metadata items to add or overwrite.
+	Set map[string]string `json:",omitempty"`
+	// Metadata keys to remove, keys that aren't set are ignored.
+	Remove []string `json:",omitempty"`
+}
+
+// validateKeyUpdate checks that an update changes something, and doesn't set
+// and remove the same key.
+func validateKeyUpdate(set map[string]string, remove []string, pre string) DError {
+	if len(set) == 0 && len(remove) == 0 {
+		return Errf("%s: one of Set or Remove must be set", pre)
+	}
+	var errs DError
+	for _, k := range remove {
+		if _, ok := set[k]; ok {
+			errs = addErrs(errs, Errf("%s: key %q is both set and removed", pre, k))
+		}
+	}
+	return errs
+}
+
+// mergeLabels returns labels with set applied and remove deleted.
+func mergeLabels(labels, set map[string]string, remove []string) map[string]string {
+	merged := map[string]string{}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	for k, v := range set {
+		merged[k] = v
+	}
+	for _, k := range remove {
+		delete(merged, k)
+	}
+	return merged
+}
+
+// mergeMetadata returns md's items with set applied and remove deleted, keeping
+// md's fingerprint.
+func mergeMetadata(md *compute.Metadata, set map[string]string, remove []string) *compute.Metadata {
+	merged := &compute.Metadata{}
+	if md == nil {
+		md = &compute.Metadata{}
+	}
+	merged.Fingerprint = md.Fingerprint
+	removed := map[string]bool{}
+	for _, k := range remove {
+		removed[k] = true
+	}
+	for _, item := range md.Items {
+		// Put only keys that are neither updated nor removed.
+		if _, ok := set[item.Key]; !ok && !removed[item.Key] {
+			merged.Items = append(merged.Items, item)
+		}
+	}
+	for k, v := range set {
+		vCopy := v
+		merged.Items = append(merged.Items, &compute.MetadataItems{Key: k, Value: &vCopy})
+	}
+	return merged
+}
+
+// isFingerprintConflict reports whether err is the API rejecting an update
+// because the fingerprint it was given is stale.
+func isFingerprintConflict(err error) bool {
+	gErr, ok := err.(*googleapi.Error)
+	return ok && gErr.Code == http.StatusPreconditionFailed
+}
+
+// retryOnFingerprintConflict calls update until it succeeds, fails for another
+// reason than a stale fingerprint, or runs out of attempts. update must read
+// the fingerprint it uses itself.
+func retryOnFingerprintConflict(update func() error) error {
+	var err error
+	for i := 0; i < fingerprintRetries; i++ {
+		if err = update(); !isFingerprintConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (u *UpdateResources) populate(ctx context.Context, s *Step) DError {
+	for _, ul := range u.Labels {
+		for _, names := range [][]string{ul.Disks, ul.Images, ul.Instances, ul.Snapshots} {
+			for i, name := range names {
+				if diskURLRgx.MatchString(name) || imageURLRgx.MatchString(name) || instanceURLRgx.MatchString(name) || snapshotURLRgx.MatchString(name) {
+					names[i] = extendPartialURL(name, s.w.Project)
+				}
+			}
+		}
+	}
+	for _, um := range u.InstancesMetadata {
+		if instanceURLRgx.MatchString(um.Instance) {
+			um.Instance = extendPartialURL(um.Instance, s.w.Project)
+		}
+	}
+	if u.ProjectMetadata != nil {
+		u.ProjectMetadata.Project = strOr(u.ProjectMetadata.Project, s.w.Project)
+	}
+	return nil
+}
+
+func (u *UpdateResources) validate(ctx context.Context, s *Step) DError {
+	if len(u.Labels) == 0 && len(u.InstancesMetadata) == 0 && u.ProjectMetadata == nil {
+		return Errf("UpdateResources: no updates given")
+	}
+
+	var errs DError
+	for _, ul := range u.Labels {
+		pre := "cannot update labels"
+		errs = addErrs(errs, validateKeyUpdate(ul.Set, ul.Remove, pre))
+		if len(ul.Disks)+len(ul.Images)+len(ul.Instances)+len(ul.Snapshots) == 0 {
+			errs = addErrs(errs, Errf("%s: no resources given", pre))
+		}
+
+		ul.links = nil
+		regs := []struct {
+			reg   *baseResourceRegistry
+			names []string
+		}{
+			{&s.w.disks.baseResourceRegistry, ul.Disks},
+			{&s.w.images.baseResourceRegistry, ul.Images},
+			{&s.w.instances.baseResourceRegistry, ul.Instances},
+			{&s.w.snapshots.baseResourceRegistry, ul.Snapshots},
+		}
+		for _, r := range regs {
+			for _, name := range r.names {
+				res, err := r.reg.regUse(name, s)
+				if err != nil {
+					errs = addErrs(errs, Errf("%s: %v", pre, err))
+					continue
+				}
+				if imageURLRgx.MatchString(res.link) && NamedSubexp(imageURLRgx, res.link)["image"] == "" {
+					errs = addErrs(errs, Errf("%s: %q is an image family, not an image", pre, name))
+					continue
+				}
+				ul.links = append(ul.links, res.link)
+			}
+		}
+	}
+
+	for _, um := range u.InstancesMetadata {
+		pre := fmt.Sprintf("cannot update metadata of instance %q", um.Instance)
+		errs = addErrs(errs, validateKeyUpdate(um.Set, um.Remove, pre))
+		res, err := s.w.instances.regUse(um.Instance, s)
+		if err != nil {
+			errs = addErrs(errs, Errf("%s: %v", pre, err))
+			continue
+		}
+		m := NamedSubexp(instanceURLRgx, res.link)
+		um.project, um.zone, um.name = m["project"], m["zone"], m["instance"]
+	}
+
+	if pm := u.ProjectMetadata; pm != nil {
+		pre := fmt.Sprintf("cannot update common instance metadata of project %q", pm.Project)
+		errs = addErrs(errs, validateKeyUpdate(pm.Set, pm.Remove, pre))
+		if exists, err := projectExists(s.w.ComputeClient, pm.Project); err != nil {
+			errs = addErrs(errs, Errf("%s: bad project lookup: %v", pre, err))
+		} else if !exists {
+			errs = addErrs(errs, Errf("%s: project does not exist", pre))
+		}
+	}
+	return errs
+}
+
+// setLabels updates the labels of the resource at link.
+func (ul *UpdateLabels) setLabels(ctx context.Context, w *Workflow, link string) error {
+	client := daisyCompute.WithContext(ctx, w.ComputeClient)
+	switch {
+	case instanceURLRgx.MatchString(link):
+		m := NamedSubexp(instanceURLRgx, link)
+		return retryOnFingerprintConflict(func() error {
+			i, err := client.GetInstance(m["project"], m["zone"], m["instance"])
+			if err != nil {
+				return err
+			}
+			return client.SetInstanceLabels(m["project"], m["zone"], m["instance"], &compute.InstancesSetLabelsRequest{Labels: mergeLabels(i.Labels, ul.Set, ul.Remove), LabelFingerprint: i.LabelFingerprint})
+		})
+	case snapshotURLRgx.MatchString(link):
+		m := NamedSubexp(snapshotURLRgx, link)
+		return retryOnFingerprintConflict(func() error {
+			sn, err := client.GetSnapshot(m["project"], m["snapshot"])
+			if err != nil {
+				return err
+			}
+			return client.SetSnapshotLabels(m["project"], m["snapshot"], &compute.GlobalSetLabelsRequest{Labels: mergeLabels(sn.Labels, ul.Set, ul.Remove), LabelFingerprint: sn.LabelFingerprint})
+		})
+	case imageURLRgx.MatchString(link):
+		m := NamedSubexp(imageURLRgx, link)
+		return retryOnFingerprintConflict(func() error {
+			i, err := client.GetImage(m["project"], m["image"])
+			if err != nil {
+				return err
+			}
+			return client.SetImageLabels(m["project"], m["image"], &compute.GlobalSetLabelsRequest{Labels: mergeLabels(i.Labels, ul.Set, ul.Remove), LabelFingerprint: i.LabelFingerprint})
+		})
+	case diskURLRgx.MatchString(link):
+		m := NamedSubexp(diskURLRgx, link)
+		if m["region"] != "" {
+			return retryOnFingerprintConflict(func() error {
+				d, err := client.GetRegionDisk(m["project"], m["region"], m["disk"])
+				if err != nil {
+					return err
+				}
+				return client.SetRegionDiskLabels(m["project"], m["region"], m["disk"], &compute.RegionSetLabelsRequest{Labels: mergeLabels(d.Labels, ul.Set, ul.Remove), LabelFingerprint: d.LabelFingerprint})
+			})
+		}
+		return retryOnFingerprintConflict(func() error {
+			d, err := client.GetDisk(m["project"], m["zone"], m["disk"])
+			if err != nil {
+				return err
+			}
+			return client.SetDiskLabels(m["project"], m["zone"], m["disk"], &compute.ZoneSetLabelsRequest{Labels: mergeLabels(d.Labels, ul.Set, ul.Remove), LabelFingerprint: d.LabelFingerprint})
+		})
+	}
+	return fmt.Errorf("unknown resource type %q", link)
+}
+
+func (u *UpdateResources) run(ctx context.Context, s *Step) DError {
+	var wg sync.WaitGroup
+	w := s.w
+	items := len(u.InstancesMetadata)
+	for _, ul := range u.Labels {
+		items += len(ul.links)
+	}
+	if u.ProjectMetadata != nil {
+		items++
+	}
+	e := make(chan DError, items+1)
+	for _, ul := range u.Labels {
+		for _, link := range ul.links {
+			wg.Add(1)
+			go func(ul *UpdateLabels, link string) {
+				defer wg.Done()
+				w.LogStepInfo(s.name, "UpdateResources", "Updating labels of %q: setting %v, removing %v.", link, ul.Set, ul.Remove)
+				if err := ul.setLabels(ctx, w, link); err != nil {
+					e <- newErr(fmt.Sprintf("failed to update labels of %q", link), err)
+				}
+			}(ul, link)
+		}
+	}
+
+	for _, um := range u.InstancesMetadata {
+		wg.Add(1)
+		go func(um *UpdateMetadata) {
+			defer wg.Done()
+			client := daisyCompute.WithContext(ctx, w.ComputeClient)
+			w.LogStepInfo(s.name, "UpdateResources", "Updating metadata of instance %q: setting %q, removing %v.", um.name, um.Set, um.Remove)
+			err := retryOnFingerprintConflict(func() error {
+				i, err := client.GetInstance(um.project, um.zone, um.name)
+				if err != nil {
+					return err
+				}
+				return client.SetInstanceMetadata(um.project, um.zone, um.name, mergeMetadata(i.Metadata, um.Set, um.Remove))
+			})
+			if err != nil {
+				e <- newErr(fmt.Sprintf("failed to update metadata of instance %q", um.name), err)
+			}
+		}(um)
+	}
+
+	if pm := u.ProjectMetadata; pm != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := daisyCompute.WithContext(ctx, w.ComputeClient)
+			w.LogStepInfo(s.name, "UpdateResources", "Updating common instance metadata of project %q: setting %q, removing %v.", pm.Project, pm.Set, pm.Remove)
+			err := retryOnFingerprintConflict(func() error {
+				p, err := client.GetProject(pm.Project)
+				if err != nil {
+					return err
+				}
+				return client.SetCommonInstanceMetadata(pm.Project, mergeMetadata(p.CommonInstanceMetadata, pm.Set, pm.Remove))
+			})
+			if err != nil {
+				e <- newErr(fmt.Sprintf("failed to update common instance metadata of project %q", pm.Project), err)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		e <- nil
+	}()
+
+	select {
+	case err := <-e:
+		return err
+	case <-w.Cancel:
+		wg.Wait()
+		return nil
+	}
+}
@@ -0,0 +1,301 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package daisy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestUpdateResourcesPopulate(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	s.UpdateResources = &UpdateResources{
+		Labels: []*UpdateLabels{{
+			Disks:     []string{"d", "regions/r/disks/d"},
+			Images:    []string{"global/images/i"},
+			Instances: []string{"zones/z/instances/i"},
+			Snapshots: []string{"global/snapshots/s"},
+		}},
+		InstancesMetadata: []*UpdateMetadata{{Instance: "zones/z/instances/i"}},
+		ProjectMetadata:   &UpdateProjectMetadata{},
+	}
+
+	if err := s.UpdateResources.populate(context.Background(), s); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	want := &UpdateResources{
+		Labels: []*UpdateLabels{{
+			Disks:     []string{"d", fmt.Sprintf("projects/%s/regions/r/disks/d", w.Project)},
+			Images:    []string{fmt.Sprintf("projects/%s/global/images/i", w.Project)},
+			Instances: []string{fmt.Sprintf("projects/%s/zones/z/instances/i", w.Project)},
+			Snapshots: []string{fmt.Sprintf("projects/%s/global/snapshots/s", w.Project)},
+		}},
+		InstancesMetadata: []*UpdateMetadata{{Instance: fmt.Sprintf("projects/%s/zones/z/instances/i", w.Project)}},
+		ProjectMetadata:   &UpdateProjectMetadata{Project: w.Project},
+	}
+	if diffRes := diff(s.UpdateResources, want, 0); diffRes != "" {
+		t.Errorf("UpdateResources not populated as expected: (-got,+want)\n%s", diffRes)
+	}
+}
+
+func TestUpdateResourcesValidate(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	w.disks.m = map[string]*Resource{testDisk: {RealName: testDisk, link: fmt.Sprintf("projects/%s/zones/%s/disks/%s", testProject, testZone, testDisk)}}
+	w.instances.m = map[string]*Resource{testInstance: {RealName: testInstance, link: fmt.Sprintf("projects/%s/zones/%s/instances/%s", testProject, testZone, testInstance)}}
+	w.images.m = map[string]*Resource{
+		testImage:  {RealName: testImage, link: fmt.Sprintf("projects/%s/global/images/%s", testProject, testImage)},
+		testFamily: {RealName: testFamily, link: fmt.Sprintf("projects/%s/global/images/family/%s", testProject, testFamily)},
+	}
+	set := map[string]string{"k": "v"}
+
+	tests := []struct {
+		desc      string
+		ur        *UpdateResources
+		wantLinks []string
+		wantErr   bool
+	}{
+		{
+			"labels",
+			&UpdateResources{Labels: []*UpdateLabels{{Disks: []string{testDisk}, Images: []string{testImage}, Instances: []string{testInstance}, Set: set, Remove: []string{"old"}}}},
+			[]string{w.disks.m[testDisk].link, w.images.m[testImage].link, w.instances.m[testInstance].link},
+			false,
+		},
+		{
+			"instance metadata",
+			&UpdateResources{InstancesMetadata: []*UpdateMetadata{{Instance: testInstance, Remove: []string{"old"}}}},
+			nil,
+			false,
+		},
+		{
+			"project metadata",
+			&UpdateResources{ProjectMetadata: &UpdateProjectMetadata{Project: testProject, Set: set}},
+			nil,
+			false,
+		},
+		{"no updates", &UpdateResources{}, nil, true},
+		{"no label changes", &UpdateResources{Labels: []*UpdateLabels{{Disks: []string{testDisk}}}}, nil, true},
+		{"no labelled resources", &UpdateResources{Labels: []*UpdateLabels{{Set: set}}}, nil, true},
+		{"set and remove same label", &UpdateResources{Labels: []*UpdateLabels{{Disks: []string{testDisk}, Set: set, Remove: []string{"k"}}}}, nil, true},
+		{"image family", &UpdateResources{Labels: []*UpdateLabels{{Images: []string{testFamily}, Set: set}}}, nil, true},
+		{"missing disk", &UpdateResources{Labels: []*UpdateLabels{{Disks: []string{"dne"}, Set: set}}}, nil, true},
+		{"missing instance", &UpdateResources{InstancesMetadata: []*UpdateMetadata{{Instance: "dne", Set: set}}}, nil, true},
+		{"no metadata changes", &UpdateResources{InstancesMetadata: []*UpdateMetadata{{Instance: testInstance}}}, nil, true},
+		{"missing project", &UpdateResources{ProjectMetadata: &UpdateProjectMetadata{Project: "dne", Set: set}}, nil, true},
+	}
+	for _, tt := range tests {
+		err := tt.ur.validate(ctx, s)
+		if tt.wantErr && err == nil {
+			t.Errorf("%s: expected error, got none", tt.desc)
+		} else if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.desc, err)
+		}
+		if tt.wantLinks != nil && !reflect.DeepEqual(tt.ur.Labels[0].links, tt.wantLinks) {
+			t.Errorf("%s: want links %v, got %v", tt.desc, tt.wantLinks, tt.ur.Labels[0].links)
+		}
+	}
+}
+
+func TestUpdateResourcesRunLabels(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	original := map[string]string{"keep": "a", "k": "old", "rm": "b"}
+	want := map[string]string{"keep": "a", "k": "v"}
+	got := map[string]map[string]string{}
+	var mx sync.Mutex
+	record := func(typ string, labels map[string]string) {
+		mx.Lock()
+		defer mx.Unlock()
+		got[typ] = labels
+	}
+	var conflicts int
+	tc := w.ComputeClient.(*daisyCompute.TestClient)
+	tc.GetDiskFn = func(_, _, _ string) (*compute.Disk, error) {
+		return &compute.Disk{Labels: original, LabelFingerprint: "fp"}, nil
+	}
+	tc.SetDiskLabelsFn = func(_, _, name string, req *compute.ZoneSetLabelsRequest) error {
+		// Fail the first attempt as if someone else updated the labels.
+		if conflicts == 0 {
+			conflicts++
+			return &googleapi.Error{Code: http.StatusPreconditionFailed}
+		}
+		record("disk", req.Labels)
+		return nil
+	}
+	tc.GetRegionDiskFn = func(_, _, _ string) (*compute.Disk, error) {
+		return &compute.Disk{Labels: original}, nil
+	}
+	tc.SetRegionDiskLabelsFn = func(_, _, _ string, req *compute.RegionSetLabelsRequest) error {
+		record("regionDisk", req.Labels)
+		return nil
+	}
+	tc.GetImageFn = func(_, _ string) (*compute.Image, error) {
+		return &compute.Image{Labels: original}, nil
+	}
+	tc.SetImageLabelsFn = func(_, _ string, req *compute.GlobalSetLabelsRequest) error {
+		record("image", req.Labels)
+		return nil
+	}
+	tc.GetInstanceFn = func(_, _, _ string) (*compute.Instance, error) {
+		return &compute.Instance{Labels: original}, nil
+	}
+	tc.SetInstanceLabelsFn = func(_, _, _ string, req *compute.InstancesSetLabelsRequest) error {
+		record("instance", req.Labels)
+		return nil
+	}
+	tc.GetSnapshotFn = func(_, _ string) (*compute.Snapshot, error) {
+		return &compute.Snapshot{Labels: original}, nil
+	}
+	tc.SetSnapshotLabelsFn = func(_, _ string, req *compute.GlobalSetLabelsRequest) error {
+		record("snapshot", req.Labels)
+		return nil
+	}
+
+	ur := &UpdateResources{Labels: []*UpdateLabels{{
+		Set:    map[string]string{"k": "v"},
+		Remove: []string{"rm", "dne"},
+		links: []string{
+			fmt.Sprintf("projects/%s/zones/%s/disks/d", testProject, testZone),
+			fmt.Sprintf("projects/%s/regions/%s/disks/d", testProject, testRegion),
+			fmt.Sprintf("projects/%s/global/images/i", testProject),
+			fmt.Sprintf("projects/%s/zones/%s/instances/i", testProject, testZone),
+			fmt.Sprintf("projects/%s/global/snapshots/s", testProject),
+		},
+	}}}
+	if err := ur.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, typ := range []string{"disk", "regionDisk", "image", "instance", "snapshot"} {
+		if !reflect.DeepEqual(got[typ], want) {
+			t.Errorf("%s: want labels %v, got %v", typ, want, got[typ])
+		}
+	}
+	if !reflect.DeepEqual(original, map[string]string{"keep": "a", "k": "old", "rm": "b"}) {
+		t.Errorf("original labels were modified: %v", original)
+	}
+
+	// Conflicts that don't go away fail the step.
+	tc.SetDiskLabelsFn = func(_, _, _ string, _ *compute.ZoneSetLabelsRequest) error {
+		return &googleapi.Error{Code: http.StatusPreconditionFailed}
+	}
+	ur.Labels[0].links = ur.Labels[0].links[:1]
+	if err := ur.run(ctx, s); err == nil {
+		t.Error("expected error, got none")
+	}
+}
+
+func metadataToMap(md *compute.Metadata) map[string]string {
+	m := map[string]string{}
+	for _, item := range md.Items {
+		m[item.Key] = *item.Value
+	}
+	return m
+}
+
+func TestUpdateResourcesRunMetadata(t *testing.T) {
+	ctx := context.Background()
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+
+	v := "value"
+	original := &compute.Metadata{Fingerprint: "fp", Items: []*compute.MetadataItems{{Key: "keep", Value: &v}, {Key: "k", Value: &v}, {Key: "rm", Value: &v}}}
+	want := map[string]string{"keep": "value", "k": "new"}
+	var gotInstance, gotProject *compute.Metadata
+	tc := w.ComputeClient.(*daisyCompute.TestClient)
+	tc.GetInstanceFn = func(_, _, _ string) (*compute.Instance, error) {
+		return &compute.Instance{Metadata: original}, nil
+	}
+	tc.SetInstanceMetadataFn = func(_, _, _ string, md *compute.Metadata) error {
+		gotInstance = md
+		return nil
+	}
+	tc.GetProjectFn = func(_ string) (*compute.Project, error) {
+		return &compute.Project{CommonInstanceMetadata: original}, nil
+	}
+	tc.SetCommonInstanceMetadataFn = func(_ string, md *compute.Metadata) error {
+		gotProject = md
+		return nil
+	}
+
+	set := map[string]string{"k": "new"}
+	ur := &UpdateResources{
+		InstancesMetadata: []*UpdateMetadata{{Instance: testInstance, Set: set, Remove: []string{"rm"}, project: testProject, zone: testZone, name: testInstance}},
+		ProjectMetadata:   &UpdateProjectMetadata{Project: testProject, Set: set, Remove: []string{"rm"}},
+	}
+	if err := ur.run(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for desc, got := range map[string]*compute.Metadata{"instance": gotInstance, "project": gotProject} {
+		if got == nil {
+			t.Errorf("%s: metadata not set", desc)
+			continue
+		}
+		if got.Fingerprint != original.Fingerprint {
+			t.Errorf("%s: want fingerprint %q, got %q", desc, original.Fingerprint, got.Fingerprint)
+		}
+		if gotMap := metadataToMap(got); !reflect.DeepEqual(gotMap, want) {
+			t.Errorf("%s: want metadata %v, got %v", desc, want, gotMap)
+		}
+	}
+}
+
+func TestUpdateResourcesRunCanceled(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("s")
+	tc := w.ComputeClient.(*daisyCompute.TestClient)
+	tc.GetInstanceFn = func(_, _, _ string) (*compute.Instance, error) {
+		// Like an API call interrupted by the canceled context.
+		<-w.Cancel
+		return nil, context.Canceled
+	}
+	tc.GetProjectFn = func(_ string) (*compute.Project, error) {
+		<-w.Cancel
+		return nil, context.Canceled
+	}
+
+	set := map[string]string{"k": "v"}
+	ur := &UpdateResources{
+		InstancesMetadata: []*UpdateMetadata{
+			{Instance: testInstance, Set: set, project: testProject, zone: testZone, name: testInstance},
+			{Instance: testInstance, Set: set, project: testProject, zone: testZone, name: testInstance},
+		},
+		ProjectMetadata: &UpdateProjectMetadata{Project: testProject, Set: set},
+	}
+	done := make(chan DError)
+	go func() { done <- ur.run(context.Background(), s) }()
+	w.CancelWorkflow()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("UpdateResources did not return after the workflow was canceled")
+	}
+}
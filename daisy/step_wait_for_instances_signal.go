@@ -22,6 +22,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	daisyCompute "github.com/GoogleCloudPlatform/compute-image-tools/daisy/compute"
 )
 
 const (
@@ -81,11 +83,13 @@ type InstanceSignal struct {
 	interval time.Duration
 	// Wait for the instance to stop.
 	Stopped bool `json:",omitempty"`
+	// Wait for the instance to be suspended.
+	Suspended bool `json:",omitempty"`
 	// Wait for a string match in the serial output.
 	SerialOutput *SerialOutput `json:",omitempty"`
 }
 
-func waitForInstanceStopped(s *Step, project, zone, name string, interval time.Duration) DError {
+func waitForInstanceStopped(ctx context.Context, s *Step, project, zone, name string, interval time.Duration) DError {
 	w := s.w
 	w.LogStepInfo(s.name, "WaitForInstancesSignal", "Waiting for instance %q to stop.", name)
 	tick := time.Tick(interval)
@@ -93,8 +97,10 @@ func waitForInstanceStopped(s *Step, project, zone, name string, interval time.D
 		select {
 		case <-s.w.Cancel:
 			return nil
+		case <-ctx.Done():
+			return Errf("stopped waiting for instance %q to stop: %v", name, ctx.Err())
 		case <-tick:
-			stopped, err := s.w.ComputeClient.InstanceStopped(project, zone, name)
+			stopped, err := daisyCompute.WithContext(ctx, s.w.ComputeClient).InstanceStopped(project, zone, name)
 			if err != nil {
 				return typedErr(apiError, "failed to check whether instance is stopped", err)
 			}
@@ -106,7 +112,30 @@ func waitForInstanceStopped(s *Step, project, zone, name string, interval time.D
 	}
 }
 
-func waitForSerialOutput(s *Step, project, zone, name string, so *SerialOutput, interval time.Duration) DError {
+func waitForInstanceSuspended(ctx context.Context, s *Step, project, zone, name string, interval time.Duration) DError {
+	w := s.w
+	w.LogStepInfo(s.name, "WaitForInstancesSignal", "Waiting for instance %q to be suspended.", name)
+	tick := time.Tick(interval)
+	for {
+		select {
+		case <-s.w.Cancel:
+			return nil
+		case <-ctx.Done():
+			return Errf("stopped waiting for instance %q to be suspended: %v", name, ctx.Err())
+		case <-tick:
+			suspended, err := daisyCompute.WithContext(ctx, s.w.ComputeClient).InstanceSuspended(project, zone, name)
+			if err != nil {
+				return typedErr(apiError, "failed to check whether instance is suspended", err)
+			}
+			if suspended {
+				w.LogStepInfo(s.name, "WaitForInstancesSignal", "Instance %q suspended.", name)
+				return nil
+			}
+		}
+	}
+}
+
+func waitForSerialOutput(ctx context.Context, s *Step, project, zone, name string, so *SerialOutput, interval time.Duration) DError {
 	w := s.w
 	msg := fmt.Sprintf("Instance %q: watching serial port %d", name, so.Port)
 	if so.SuccessMatch != "" {
@@ -126,10 +155,12 @@ func waitForSerialOutput(s *Step, project, zone, name string, so *SerialOutput,
 		select {
 		case <-s.w.Cancel:
 			return nil
+		case <-ctx.Done():
+			return Errf("stopped watching instance %q serial port %d: %v", name, so.Port, ctx.Err())
 		case <-tick:
-			resp, err := w.ComputeClient.GetSerialPortOutput(project, zone, name, so.Port, start)
+			resp, err := daisyCompute.WithContext(ctx, w.ComputeClient).GetSerialPortOutput(project, zone, name, so.Port, start)
 			if err != nil {
-				status, sErr := w.ComputeClient.InstanceStatus(project, zone, name)
+				status, sErr := daisyCompute.WithContext(ctx, w.ComputeClient).InstanceStatus(project, zone, name)
 				if sErr != nil {
 					err = fmt.Errorf("%v, error getting InstanceStatus: %v", err, sErr)
 				} else {
@@ -154,6 +185,7 @@ func waitForSerialOutput(s *Step, project, zone, name string, so *SerialOutput,
 				if so.StatusMatch != "" {
 					if i := strings.Index(ln, so.StatusMatch); i != -1 {
 						w.LogStepInfo(s.name, "WaitForInstancesSignal", "Instance %q: StatusMatch found: %q", name, strings.TrimSpace(ln[i:]))
+						w.recordInstanceStatus(s.name, name, strings.TrimSpace(ln[i:]))
 						extractOutputValue(w, ln)
 					}
 				}
@@ -213,15 +245,15 @@ func populateForWaitForInstancesSignal(w *[]*InstanceSignal, sn string) DError {
 
 func (w *WaitForInstancesSignal) run(ctx context.Context, s *Step) DError {
 	is := (*[]*InstanceSignal)(w)
-	return runForWaitForInstancesSignal(is, s, true)
+	return runForWaitForInstancesSignal(ctx, is, s, true)
 }
 
 func (w *WaitForAnyInstancesSignal) run(ctx context.Context, s *Step) DError {
 	is := (*[]*InstanceSignal)(w)
-	return runForWaitForInstancesSignal(is, s, false)
+	return runForWaitForInstancesSignal(ctx, is, s, false)
 }
 
-func runForWaitForInstancesSignal(w *[]*InstanceSignal, s *Step, waitAll bool) DError {
+func runForWaitForInstancesSignal(ctx context.Context, w *[]*InstanceSignal, s *Step, waitAll bool) DError {
 	var wg sync.WaitGroup
 	e := make(chan DError)
 	for _, is := range *w {
@@ -236,17 +268,26 @@ func runForWaitForInstancesSignal(w *[]*InstanceSignal, s *Step, waitAll bool) D
 			m := NamedSubexp(instanceURLRgx, i.link)
 			serialSig := make(chan struct{})
 			stoppedSig := make(chan struct{})
+			suspendedSig := make(chan struct{})
 			if is.Stopped {
 				go func() {
-					if err := waitForInstanceStopped(s, m["project"], m["zone"], m["instance"], is.interval); err != nil {
+					if err := waitForInstanceStopped(ctx, s, m["project"], m["zone"], m["instance"], is.interval); err != nil {
 						e <- err
 					}
 					close(stoppedSig)
 				}()
 			}
+			if is.Suspended {
+				go func() {
+					if err := waitForInstanceSuspended(ctx, s, m["project"], m["zone"], m["instance"], is.interval); err != nil {
+						e <- err
+					}
+					close(suspendedSig)
+				}()
+			}
 			if is.SerialOutput != nil {
 				go func() {
-					if err := waitForSerialOutput(s, m["project"], m["zone"], m["instance"], is.SerialOutput, is.interval); err != nil || !waitAll {
+					if err := waitForSerialOutput(ctx, s, m["project"], m["zone"], m["instance"], is.SerialOutput, is.interval); err != nil || !waitAll {
 						// send a signal to end other waiting instances
 						e <- err
 					}
@@ -258,6 +299,8 @@ func runForWaitForInstancesSignal(w *[]*InstanceSignal, s *Step, waitAll bool) D
 				return
 			case <-stoppedSig:
 				return
+			case <-suspendedSig:
+				return
 			}
 		}(is)
 	}
@@ -292,7 +335,7 @@ func validateForWaitForInstancesSignal(w *[]*InstanceSignal, s *Step) DError {
 		if i.interval == 0*time.Second {
 			return Errf("%q: cannot wait for instance signal, no interval given", i.Name)
 		}
-		if i.SerialOutput == nil && i.Stopped == false {
+		if i.SerialOutput == nil && !i.Stopped && !i.Suspended {
 			return Errf("%q: cannot wait for instance signal, nothing to wait for", i.Name)
 		}
 		if i.SerialOutput != nil {
@@ -46,11 +46,28 @@ func TestWaitForInstanceStopped(t *testing.T) {
 
 	w.ComputeClient = c
 	s := &Step{name: "foo", w: w}
-	if err := waitForInstanceStopped(s, testProject, testZone, "foo", 1*time.Microsecond); err != nil {
+	if err := waitForInstanceStopped(context.Background(), s, testProject, testZone, "foo", 1*time.Microsecond); err != nil {
 		t.Fatalf("error running waitForInstanceStopped: %v", err)
 	}
 }
 
+func TestWaitForInstanceSuspended(t *testing.T) {
+	w := testWorkflow()
+
+	var checks int
+	w.ComputeClient.(*daisyCompute.TestClient).InstanceSuspendedFn = func(_, _, _ string) (bool, error) {
+		checks++
+		return checks > 1, nil
+	}
+	s := &Step{name: "foo", w: w}
+	if err := waitForInstanceSuspended(context.Background(), s, testProject, testZone, "foo", 1*time.Microsecond); err != nil {
+		t.Fatalf("error running waitForInstanceSuspended: %v", err)
+	}
+	if checks != 2 {
+		t.Errorf("got %d status checks, want 2", checks)
+	}
+}
+
 func TestWaitForInstancesSignalPopulate(t *testing.T) {
 	testWaitForSignalPopulate(t, false)
 }
@@ -217,6 +234,7 @@ func testWaitForSignalValidate(t *testing.T, waitAny bool) {
 		shouldErr bool
 	}{
 		{"normal case Stopped", getStep(waitAny, []*InstanceSignal{{Name: "instance1", Stopped: true, interval: 1 * time.Second}}), false},
+		{"normal case Suspended", getStep(waitAny, []*InstanceSignal{{Name: "instance1", Suspended: true, interval: 1 * time.Second}}), false},
 		{"normal SerialOutput SuccessMatch", getStep(waitAny, []*InstanceSignal{{Name: "instance1", SerialOutput: &SerialOutput{Port: 1, StatusMatch: "test", SuccessMatch: "test"}, interval: 1 * time.Second}}), false},
 		{"normal SerialOutput FailureMatch", getStep(waitAny, []*InstanceSignal{{Name: "instance1", SerialOutput: &SerialOutput{Port: 1, FailureMatch: []string{"fail"}}, interval: 1 * time.Second}}), false},
 		{"normal SerialOutput SuccessMatch FailureMatch", getStep(waitAny, []*InstanceSignal{{Name: "instance1", SerialOutput: &SerialOutput{Port: 1, SuccessMatch: "test", FailureMatch: []string{"fail"}}, interval: 1 * time.Second}}), false},
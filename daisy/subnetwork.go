@@ -95,9 +95,9 @@ func newSubnetworkRegistry(w *Workflow) *subnetworkRegistry {
 	return nr
 }
 
-func (nr *subnetworkRegistry) deleteFn(res *Resource) DError {
+func (nr *subnetworkRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(subnetworkURLRegex, res.link)
-	err := nr.w.ComputeClient.DeleteSubnetwork(m["project"], m["region"], m["subnetwork"])
+	err := daisyCompute.WithContext(ctx, nr.w.ComputeClient).DeleteSubnetwork(m["project"], m["region"], m["subnetwork"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete subnetwork", err)
 	}
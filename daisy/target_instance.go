@@ -93,9 +93,9 @@ func newTargetInstanceRegistry(w *Workflow) *targetInstanceRegistry {
 	return tir
 }
 
-func (tir *targetInstanceRegistry) deleteFn(res *Resource) DError {
+func (tir *targetInstanceRegistry) deleteFn(ctx context.Context, res *Resource) DError {
 	m := NamedSubexp(targetInstanceURLRegex, res.link)
-	err := tir.w.ComputeClient.DeleteTargetInstance(m["project"], m["zone"], m["targetInstance"])
+	err := daisyCompute.WithContext(ctx, tir.w.ComputeClient).DeleteTargetInstance(m["project"], m["zone"], m["targetInstance"])
 	if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusNotFound {
 		return typedErr(resourceDNEError, "failed to delete target instance", err)
 	}
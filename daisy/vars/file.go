@@ -0,0 +1,143 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vars
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AddFile sets the variables of a file by its extension: a JSON object
+// (.json), a YAML mapping (.yaml, .yml) or dotenv lines (anything else).
+func (vs Vars) AddFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var m map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		m, err = parseJSON(data)
+	case ".yaml", ".yml":
+		m, err = parseYAML(data)
+	default:
+		m, err = parseDotenv(data)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading variable file %q: %v", path, err)
+	}
+
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		vs.Set(name, m[name], "file "+path)
+	}
+	return nil
+}
+
+// parseJSON reads an object of strings, numbers and booleans.
+func parseJSON(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	m := map[string]string{}
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			m[k] = v
+		case json.Number:
+			m[k] = v.String()
+		case bool:
+			m[k] = strconv.FormatBool(v)
+		case nil:
+			m[k] = ""
+		default:
+			return nil, fmt.Errorf("variable %q: want a string, number or boolean", k)
+		}
+	}
+	return m, nil
+}
+
+// parseYAML reads a mapping of scalars, keeping them as written, so that 1.10
+// stays 1.10.
+func parseYAML(data []byte) (map[string]string, error) {
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	m := map[string]string{}
+	for k, n := range raw {
+		if n.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("variable %q: want a string, number or boolean", k)
+		}
+		if n.Tag == "!!null" {
+			m[k] = ""
+			continue
+		}
+		m[k] = n.Value
+	}
+	return m, nil
+}
+
+// parseDotenv reads KEY=VALUE lines, with optional export prefixes, blank
+// lines and # comments. Values may be quoted: single quoted ones are kept as
+// is, double quoted ones may have escapes like \n.
+func parseDotenv(data []byte) (map[string]string, error) {
+	m := map[string]string{}
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: want KEY=VALUE", n)
+		}
+		k, v := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch {
+		case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+			v = v[1 : len(v)-1]
+		case len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"':
+			uv, err := strconv.Unquote(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			v = uv
+		default:
+			if j := strings.Index(v, " #"); j != -1 {
+				v = strings.TrimSpace(v[:j])
+			}
+		}
+		m[k] = v
+	}
+	return m, s.Err()
+}
@@ -0,0 +1,177 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package vars collects workflow variables for the command line tools, from
+// DAISY_VAR_* environment variables, variable files and flags.
+package vars
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// EnvPrefix prefixes the environment variables setting workflow variables,
+	// e.g. DAISY_VAR_zone=us-west1-a.
+	EnvPrefix = "DAISY_VAR_"
+	// FlagPrefix prefixes the flags setting workflow variables, e.g.
+	// -var:zone=us-west1-a.
+	FlagPrefix = "var:"
+)
+
+// Var is the value of a workflow variable and where it was set.
+type Var struct {
+	Value  string
+	Source string
+	// Overrides are the sources of the values this one overrides, in order.
+	Overrides []string
+	// FromEnv is set for values from the environment, which may hold
+	// variables of other workflows.
+	FromEnv bool
+}
+
+// Vars are workflow variables by name. Each value set overrides the previous
+// one, so sources are added in increasing order of precedence.
+type Vars map[string]*Var
+
+// Set sets the variable name from source.
+func (vs Vars) Set(name, value, source string) {
+	v := &Var{Value: value, Source: source}
+	if old, ok := vs[name]; ok {
+		v.Overrides = append(append([]string(nil), old.Overrides...), old.Source)
+	}
+	vs[name] = v
+}
+
+// AddEnv sets the variables of the DAISY_VAR_<name>=<value> entries of
+// environ, as returned by os.Environ.
+func (vs Vars) AddEnv(environ []string) {
+	for _, e := range environ {
+		if !strings.HasPrefix(e, EnvPrefix) {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(e, EnvPrefix), "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+		vs.Set(kv[0], kv[1], "environment variable "+EnvPrefix+kv[0])
+		vs[kv[0]].FromEnv = true
+	}
+}
+
+// AddList sets the variables of a comma separated list of key=value pairs,
+// the format of the daisy -variables flag.
+func (vs Vars) AddList(list, source string) {
+	if list == "" {
+		return
+	}
+	for _, kv := range strings.Split(list, ",") {
+		i := strings.Index(kv, "=")
+		if i == -1 {
+			continue
+		}
+		vs.Set(kv[:i], kv[i+1:], source)
+	}
+}
+
+// AddFlags sets the variables of the -var:<name> flags set on fs.
+func (vs Vars) AddFlags(fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, FlagPrefix) {
+			vs.Set(strings.TrimPrefix(f.Name, FlagPrefix), f.Value.String(), "flag -"+f.Name)
+		}
+	})
+}
+
+// Map returns the values of the variables.
+func (vs Vars) Map() map[string]string {
+	m := map[string]string{}
+	for name, v := range vs {
+		m[name] = v.Value
+	}
+	return m
+}
+
+// MapFor returns the values of the variables to set on a workflow declaring
+// the variables for which declared returns true. Values from the environment
+// are kept only for declared variables, as the environment may hold
+// variables of other workflows.
+func (vs Vars) MapFor(declared func(name string) bool) map[string]string {
+	m := map[string]string{}
+	for name, v := range vs {
+		if v.FromEnv && !declared(name) {
+			continue
+		}
+		m[name] = v.Value
+	}
+	return m
+}
+
+// Report writes where each variable was set and the sources it overrides,
+// without the values which may be secrets.
+func (vs Vars) Report(w io.Writer) {
+	var names []string
+	for name := range vs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := vs[name]
+		fmt.Fprintf(w, "  %s: %s", name, v.Source)
+		if len(v.Overrides) > 0 {
+			fmt.Fprintf(w, " (overrides %s)", strings.Join(v.Overrides, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// Flags are the command line flags setting variables, besides the -var:<name>
+// flags handled by AddFlags.
+type Flags struct {
+	files fileList
+}
+
+// RegisterFlags adds the -var_file flag to fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.Var(&f.files, "var_file", "JSON, YAML or dotenv file of workflow variables, can be repeated with later files overriding earlier ones")
+	return f
+}
+
+// Load returns the variables of DAISY_VAR_* environment variables, then of
+// the -var_file files in order, later ones overriding earlier ones.
+func (f *Flags) Load(environ []string) (Vars, error) {
+	vs := Vars{}
+	vs.AddEnv(environ)
+	for _, path := range f.files {
+		if err := vs.AddFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return vs, nil
+}
+
+type fileList []string
+
+func (l *fileList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *fileList) Set(path string) error {
+	*l = append(*l, path)
+	return nil
+}
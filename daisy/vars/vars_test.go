@@ -0,0 +1,153 @@
+//  Copyright 2021 Google Inc. All Rights Reserved.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vars
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "daisy-vars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, data string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAddFile(t *testing.T) {
+	tests := []struct {
+		name, data string
+		want       map[string]string
+	}{
+		{"vars.json", `{"a": "x,y", "n": 1.10, "b": true, "empty": null}`, map[string]string{"a": "x,y", "n": "1.10", "b": "true", "empty": ""}},
+		{"vars.yaml", "a: x,y\nn: 1.10\nb: true\nempty:\nquoted: \"#1\"\n", map[string]string{"a": "x,y", "n": "1.10", "b": "true", "empty": "", "quoted": "#1"}},
+		{"vars.YML", "a: x\n", map[string]string{"a": "x"}},
+		{
+			"vars.env",
+			"# comment\n\na=x,y\nexport b = y \nc='$x \\n'\nd=\"line\\nline\"\ne=z # comment\nf=\n",
+			map[string]string{"a": "x,y", "b": "y", "c": `$x \n`, "d": "line\nline", "e": "z", "f": ""},
+		},
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	for _, tt := range tests {
+		vs := Vars{}
+		if err := vs.AddFile(writeFile(t, dir, tt.name, tt.data)); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got := vs.Map(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: want %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAddFileErrors(t *testing.T) {
+	tests := []struct{ name, data, wantErr string }{
+		{"vars.json", `{"a": {"b": "c"}}`, `variable "a": want a string, number or boolean`},
+		{"vars.json", `["a"]`, "cannot unmarshal"},
+		{"vars.yaml", "a: [b]\n", `variable "a": want a string, number or boolean`},
+		{"vars.env", "a=b\nc\n", "line 2: want KEY=VALUE"},
+		{"vars.env", "a=\"b\\q\"\n", "line 1: invalid syntax"},
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	for _, tt := range tests {
+		vs := Vars{}
+		err := vs.AddFile(writeFile(t, dir, tt.name, tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s %q: want error containing %q, got %v", tt.name, tt.data, tt.wantErr, err)
+		}
+	}
+
+	if err := (Vars{}).AddFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for a missing file, got none")
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := RegisterFlags(fs)
+	fs.String("var:flag", "", "")
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	first := writeFile(t, dir, "first.json", `{"file": "first", "files": "first"}`)
+	second := writeFile(t, dir, "second.env", "files=second\nflag=second\n")
+	if err := fs.Parse([]string{"-var_file", first, "-var_file=" + second, "-var:flag=flag"}); err != nil {
+		t.Fatal(err)
+	}
+
+	vs, err := f.Load([]string{"PATH=/bin", "DAISY_VAR_env=env", "DAISY_VAR_file=env", "DAISY_VAR_=ignored", "DAISY_VAR_novalue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs.AddList("list=list,bad,files=list", "flag -variables")
+	vs.AddFlags(fs)
+
+	want := map[string]string{"env": "env", "file": "first", "files": "list", "flag": "flag", "list": "list"}
+	if got := vs.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if !vs["env"].FromEnv || vs["file"].FromEnv {
+		t.Errorf("want only env from the environment, got %+v, %+v", vs["env"], vs["file"])
+	}
+
+	var buf bytes.Buffer
+	vs.Report(&buf)
+	wantReport := `  env: environment variable DAISY_VAR_env
+  file: file ` + first + ` (overrides environment variable DAISY_VAR_file)
+  files: flag -variables (overrides file ` + first + `, file ` + second + `)
+  flag: flag -var:flag (overrides file ` + second + `)
+  list: flag -variables
+`
+	if buf.String() != wantReport {
+		t.Errorf("want report\n%s\ngot\n%s", wantReport, buf.String())
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	f = RegisterFlags(fs)
+	fs.Parse([]string{"-var_file", filepath.Join(dir, "missing.yaml")})
+	if _, err := f.Load(nil); err == nil {
+		t.Error("expected error for a missing file, got none")
+	}
+}
+
+func TestMapFor(t *testing.T) {
+	vs := Vars{}
+	vs.AddEnv([]string{"DAISY_VAR_declared=env", "DAISY_VAR_other=env", "DAISY_VAR_flag=env"})
+	vs.Set("flag", "flag", "flag -var:flag")
+	vs.Set("undeclared", "flag", "flag -var:undeclared")
+
+	got := vs.MapFor(func(name string) bool { return name == "declared" })
+	want := map[string]string{"declared": "env", "flag": "flag", "undeclared": "flag"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -96,6 +97,8 @@ type Workflow struct {
 	// Workflow template fields.
 	// Workflow name.
 	Name string `json:",omitempty"`
+	// Version of the workflow file schema, see Migrate.
+	SchemaVersion int `json:",omitempty"`
 	// Project to run in.
 	Project string `json:",omitempty"`
 	// Zone to run in.
@@ -119,6 +122,11 @@ type Workflow struct {
 	// Working fields.
 	autovars              map[string]string
 	workflowDir           string
+	remoteDir             string
+	includeCacheDir       string
+	offlineIncludes       bool
+	pathCheck             func(string) error
+	localLogsDir          string
 	parent                *Workflow
 	bucket                string
 	scratchPath           string
@@ -132,49 +140,74 @@ type Workflow struct {
 	stdoutLoggingDisabled bool
 	id                    string
 	Logger                Logger `json:"-"`
+	closeLogger           func()
 	cleanupHooks          []func() DError
 	cleanupHooksMx        sync.Mutex
 	recordTimeMx          sync.Mutex
 	stepWait              sync.WaitGroup
 	logProcessHook        func(string) string
+	pendingMigrations     []string
 
 	// Optional compute endpoint override.stepWait
-	ComputeEndpoint    string          `json:",omitempty"`
-	ComputeClient      compute.Client  `json:"-"`
-	StorageClient      *storage.Client `json:"-"`
+	ComputeEndpoint string          `json:",omitempty"`
+	ComputeClient   compute.Client  `json:"-"`
+	StorageClient   *storage.Client `json:"-"`
+	// Optional limiter for the compute client created by PopulateClients,
+	// may be shared between workflows.
+	ComputeLimiter     *compute.Limiter `json:"-"`
 	cloudLoggingClient *logging.Client
 
 	// Resource registries.
-	disks           *diskRegistry
-	forwardingRules *forwardingRuleRegistry
-	firewallRules   *firewallRuleRegistry
-	images          *imageRegistry
-	machineImages   *machineImageRegistry
-	instances       *instanceRegistry
-	networks        *networkRegistry
-	subnetworks     *subnetworkRegistry
-	targetInstances *targetInstanceRegistry
-	objects         *objectRegistry
-	snapshots       *snapshotRegistry
+	disks                 *diskRegistry
+	forwardingRules       *forwardingRuleRegistry
+	addresses             *addressRegistry
+	routes                *routeRegistry
+	routers               *routerRegistry
+	instanceTemplates     *instanceTemplateRegistry
+	instanceGroupManagers *instanceGroupManagerRegistry
+	firewallRules         *firewallRuleRegistry
+	images                *imageRegistry
+	machineImages         *machineImageRegistry
+	instances             *instanceRegistry
+	networks              *networkRegistry
+	subnetworks           *subnetworkRegistry
+	targetInstances       *targetInstanceRegistry
+	objects               *objectRegistry
+	snapshots             *snapshotRegistry
+	locks                 *lockRegistry
 
 	// Cache of resources
-	machineTypeCache    twoDResourceCache
-	instanceCache       twoDResourceCache
-	diskCache           twoDResourceCache
-	subnetworkCache     twoDResourceCache
-	targetInstanceCache twoDResourceCache
-	forwardingRuleCache twoDResourceCache
-	imageCache          oneDResourceCache
-	imageFamilyCache    oneDResourceCache
-	machineImageCache   oneDResourceCache
-	networkCache        oneDResourceCache
-	firewallRuleCache   oneDResourceCache
-	zonesCache          oneDResourceCache
-	regionsCache        oneDResourceCache
-	licenseCache        oneDResourceCache
-	snapshotCache       oneDResourceCache
+	machineTypeCache          twoDResourceCache
+	instanceCache             twoDResourceCache
+	diskCache                 twoDResourceCache
+	regionDiskCache           twoDResourceCache
+	subnetworkCache           twoDResourceCache
+	targetInstanceCache       twoDResourceCache
+	forwardingRuleCache       twoDResourceCache
+	addressCache              twoDResourceCache
+	routerCache               twoDResourceCache
+	instanceGroupManagerCache twoDResourceCache
+	imageCache                oneDResourceCache
+	imageFamilyCache          oneDResourceCache
+	machineImageCache         oneDResourceCache
+	networkCache              oneDResourceCache
+	firewallRuleCache         oneDResourceCache
+	routeCache                oneDResourceCache
+	instanceTemplateCache     oneDResourceCache
+	zonesCache                oneDResourceCache
+	regionsCache              oneDResourceCache
+	licenseCache              oneDResourceCache
+	snapshotCache             oneDResourceCache
 
 	stepTimeRecords             []TimeRecord
+	runningSteps                map[string]time.Time
+	failedSteps                 map[string]bool
+	instanceStatus              map[string]InstanceStatus
+	runMx                       sync.Mutex
+	stepCancels                 []context.CancelFunc
+	runStartTime, runEndTime    time.Time
+	populateTime                time.Time
+	runErr                      DError
 	serialControlOutputValues   map[string]string
 	serialControlOutputValuesMx sync.Mutex
 	//Forces cleanup on error of all resources, including those marked with NoCleanup
@@ -190,6 +223,12 @@ func (w *Workflow) DisableCloudLogging() {
 	w.cloudLoggingDisabled = true
 }
 
+// SetCloudLoggingClient sets the client this workflow sends logs to Cloud
+// Logging with, instead of one created with OAuthPath.
+func (w *Workflow) SetCloudLoggingClient(c *logging.Client) {
+	w.cloudLoggingClient = c
+}
+
 //DisableGCSLogging disables logging to GCS for this workflow.
 func (w *Workflow) DisableGCSLogging() {
 	w.gcsLoggingDisabled = true
@@ -275,6 +314,14 @@ func (w *Workflow) RunWithModifiers(
 	preValidateWorkflowModifier WorkflowModifier,
 	postValidateWorkflowModifier WorkflowModifier) (err DError) {
 
+	w.runMx.Lock()
+	w.runStartTime = time.Now()
+	w.runMx.Unlock()
+	// Runs that got past validation have already finished during cleanup.
+	defer func() {
+		w.setRunErr(err)
+		w.finishRun()
+	}()
 	w.externalLogging = true
 	if preValidateWorkflowModifier != nil {
 		preValidateWorkflowModifier(w)
@@ -291,6 +338,7 @@ func (w *Workflow) RunWithModifiers(
 		if err != nil {
 			w.forceCleanup = w.ForceCleanupOnError
 		}
+		w.setRunErr(err)
 	}()
 
 	w.LogWorkflowInfo("Workflow Project: %s", w.Project)
@@ -318,10 +366,24 @@ func (w *Workflow) RunWithModifiers(
 	return nil
 }
 
+func (w *Workflow) recordStepStart(stepName string, startTime time.Time) {
+	if w.parent == nil {
+		w.recordTimeMx.Lock()
+		if w.runningSteps == nil {
+			w.runningSteps = map[string]time.Time{}
+		}
+		w.runningSteps[stepName] = startTime
+		w.recordTimeMx.Unlock()
+	} else {
+		w.parent.recordStepStart(fmt.Sprintf("%s.%s", w.Name, stepName), startTime)
+	}
+}
+
 func (w *Workflow) recordStepTime(stepName string, startTime time.Time, endTime time.Time) {
 	if w.parent == nil {
 		w.recordTimeMx.Lock()
 		w.stepTimeRecords = append(w.stepTimeRecords, TimeRecord{stepName, startTime, endTime})
+		delete(w.runningSteps, stepName)
 		w.recordTimeMx.Unlock()
 	} else {
 		w.parent.recordStepTime(fmt.Sprintf("%s.%s", w.Name, stepName), startTime, endTime)
@@ -330,7 +392,22 @@ func (w *Workflow) recordStepTime(stepName string, startTime time.Time, endTime
 
 // GetStepTimeRecords returns time records of each steps
 func (w *Workflow) GetStepTimeRecords() []TimeRecord {
-	return w.stepTimeRecords
+	w.recordTimeMx.Lock()
+	defer w.recordTimeMx.Unlock()
+	return append([]TimeRecord(nil), w.stepTimeRecords...)
+}
+
+// GetRunningSteps returns the steps that are running, with a zero EndTime,
+// ordered by their start time.
+func (w *Workflow) GetRunningSteps() []TimeRecord {
+	w.recordTimeMx.Lock()
+	defer w.recordTimeMx.Unlock()
+	var trs []TimeRecord
+	for name, start := range w.runningSteps {
+		trs = append(trs, TimeRecord{Name: name, StartTime: start})
+	}
+	sort.Slice(trs, func(i, j int) bool { return trs[i].StartTime.Before(trs[j].StartTime) })
+	return trs
 }
 
 func (w *Workflow) cleanup() {
@@ -362,6 +439,52 @@ func (w *Workflow) cleanup() {
 	}
 	w.LogWorkflowInfo("Workflow %q finished cleanup.", w.Name)
 	w.recordStepTime("workflow cleanup", startTime, time.Now())
+
+	w.runMx.Lock()
+	for _, cancel := range w.stepCancels {
+		cancel()
+	}
+	w.stepCancels = nil
+	w.runMx.Unlock()
+
+	// The summary logs its own errors, so it goes before the logger is closed.
+	w.finishRun()
+
+	// Only the workflow that created the logger closes it; subworkflows
+	// share their parent's.
+	if w.closeLogger != nil {
+		w.closeLogger()
+		w.closeLogger = nil
+	}
+}
+
+// setRunErr records the error a run returns.
+func (w *Workflow) setRunErr(err DError) {
+	w.runMx.Lock()
+	w.runErr = err
+	w.runMx.Unlock()
+}
+
+// finishRun records the end of a run started by RunWithModifiers and writes
+// its local summary. Only the first call has an effect.
+func (w *Workflow) finishRun() {
+	w.runMx.Lock()
+	if w.runStartTime.IsZero() || !w.runEndTime.IsZero() {
+		w.runMx.Unlock()
+		return
+	}
+	w.runEndTime = time.Now()
+	w.runMx.Unlock()
+	w.writeLocalRunSummary()
+}
+
+// addStepCancel keeps the cancel func of a step's context for cleanup, on the
+// top-level workflow as included and sub workflows aren't cleaned up.
+func (w *Workflow) addStepCancel(cancel context.CancelFunc) {
+	root := w.root()
+	root.runMx.Lock()
+	root.stepCancels = append(root.stepCancels, cancel)
+	root.runMx.Unlock()
 }
 
 func (w *Workflow) genName(n string) string {
@@ -398,7 +521,11 @@ func (w *Workflow) PopulateClients(ctx context.Context) error {
 	}
 
 	if w.ComputeClient == nil {
-		w.ComputeClient, err = compute.NewClient(ctx, computeOptions...)
+		if w.ComputeLimiter != nil {
+			w.ComputeClient, err = compute.NewLimitedClient(ctx, w.ComputeLimiter, computeOptions...)
+		} else {
+			w.ComputeClient, err = compute.NewClient(ctx, computeOptions...)
+		}
 		if err != nil {
 			return typedErr(apiError, "failed to create compute client", err)
 		}
@@ -457,7 +584,13 @@ func (w *Workflow) populate(ctx context.Context) DError {
 
 	// Set some generic autovars and run first round of var substitution.
 	cwd, _ := os.Getwd()
-	now := time.Now().UTC()
+	// The time of the DATE, DATETIME and TIMESTAMP autovars and the scratch
+	// path can be fixed, for comparing workflows.
+	now := w.root().populateTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
 	w.username = getUser()
 
 	w.autovars = map[string]string{
@@ -571,6 +704,11 @@ func (w *Workflow) includeWorkflow(iw *Workflow) {
 	iw.parent = w
 	iw.disks = w.disks
 	iw.forwardingRules = w.forwardingRules
+	iw.addresses = w.addresses
+	iw.routes = w.routes
+	iw.routers = w.routers
+	iw.instanceTemplates = w.instanceTemplates
+	iw.instanceGroupManagers = w.instanceGroupManagers
 	iw.firewallRules = w.firewallRules
 	iw.images = w.images
 	iw.machineImages = w.machineImages
@@ -580,6 +718,16 @@ func (w *Workflow) includeWorkflow(iw *Workflow) {
 	iw.targetInstances = w.targetInstances
 	iw.snapshots = w.snapshots
 	iw.objects = w.objects
+	iw.locks = w.locks
+}
+
+// root returns the top level workflow this workflow is part of.
+func (w *Workflow) root() *Workflow {
+	r := w
+	for r.parent != nil {
+		r = r.parent
+	}
+	return r
 }
 
 // ID is the unique identifyier for this Workflow.
@@ -588,13 +736,15 @@ func (w *Workflow) ID() string {
 }
 
 // NewIncludedWorkflowFromFile reads and unmarshals a workflow with the same resources as the parent.
+// file may be a local path or a gs://, https:// or git:: path.
 func (w *Workflow) NewIncludedWorkflowFromFile(file string) (*Workflow, error) {
+	return w.newIncludedWorkflowFromPath(context.Background(), file, "")
+}
+
+func (w *Workflow) newIncludedWorkflowFromPath(ctx context.Context, file, digest string) (*Workflow, DError) {
 	iw := New()
 	w.includeWorkflow(iw)
-	if !filepath.IsAbs(file) {
-		file = filepath.Join(w.workflowDir, file)
-	}
-	if err := readWorkflow(file, iw); err != nil {
+	if err := w.readWorkflowFromPath(ctx, file, digest, iw); err != nil {
 		return nil, err
 	}
 	return iw, nil
@@ -623,12 +773,14 @@ func (w *Workflow) NewSubWorkflow() *Workflow {
 }
 
 // NewSubWorkflowFromFile reads and unmarshals a workflow as a child to this workflow.
+// file may be a local path or a gs://, https:// or git:: path.
 func (w *Workflow) NewSubWorkflowFromFile(file string) (*Workflow, error) {
+	return w.newSubWorkflowFromPath(context.Background(), file, "")
+}
+
+func (w *Workflow) newSubWorkflowFromPath(ctx context.Context, file, digest string) (*Workflow, DError) {
 	sw := w.NewSubWorkflow()
-	if !filepath.IsAbs(file) {
-		file = filepath.Join(w.workflowDir, file)
-	}
-	if err := readWorkflow(file, sw); err != nil {
+	if err := w.readWorkflowFromPath(ctx, file, digest, sw); err != nil {
 		return nil, err
 	}
 	return sw, nil
@@ -658,21 +810,43 @@ func (w *Workflow) run(ctx context.Context) DError {
 }
 
 func (w *Workflow) runStep(ctx context.Context, s *Step) DError {
+	// The step's context is canceled when the step times out or the workflow
+	// is canceled while it runs, interrupting its API calls. Otherwise it is
+	// canceled at cleanup rather than once the step returns, as steps can
+	// leave work running in the background, such as serial port logging,
+	// which ends with the workflow.
+	stepCtx, cancel := context.WithCancel(ctx)
+	w.addStepCancel(cancel)
 	timeout := make(chan struct{})
+	stepDone := make(chan struct{})
+	defer close(stepDone)
 	go func() {
-		time.Sleep(s.timeout)
-		close(timeout)
+		t := time.NewTimer(s.timeout)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			close(timeout)
+		case <-w.Cancel:
+		case <-ctx.Done():
+		case <-stepDone:
+			return
+		}
+		cancel()
 	}()
 
-	e := make(chan DError)
+	e := make(chan DError, 1)
 	go func() {
-		e <- s.run(ctx)
+		e <- s.run(stepCtx)
 	}()
 
 	select {
 	case err := <-e:
+		if err != nil {
+			w.recordStepFailure(s.name)
+		}
 		return err
 	case <-timeout:
+		w.recordStepFailure(s.name)
 		return s.getTimeoutError()
 	}
 }
@@ -765,6 +939,11 @@ func New() *Workflow {
 	// Resource registries and cleanup.
 	w.disks = newDiskRegistry(w)
 	w.forwardingRules = newForwardingRuleRegistry(w)
+	w.addresses = newAddressRegistry(w)
+	w.routes = newRouteRegistry(w)
+	w.routers = newRouterRegistry(w)
+	w.instanceTemplates = newInstanceTemplateRegistry(w)
+	w.instanceGroupManagers = newInstanceGroupManagerRegistry(w)
 	w.firewallRules = newFirewallRuleRegistry(w)
 	w.images = newImageRegistry(w)
 	w.machineImages = newMachineImageRegistry(w)
@@ -774,7 +953,10 @@ func New() *Workflow {
 	w.objects = newObjectRegistry(w)
 	w.targetInstances = newTargetInstanceRegistry(w)
 	w.snapshots = newSnapshotRegistry(w)
+	w.locks = newLockRegistry(w)
 	w.addCleanupHook(func() DError {
+		w.instanceGroupManagers.cleanup() // MIGs need to be done before their instance templates
+		w.instanceTemplates.cleanup()
 		w.instances.cleanup() // instances need to be done before disks/networks
 		w.images.cleanup()
 		w.machineImages.cleanup()
@@ -782,9 +964,13 @@ func New() *Workflow {
 		w.forwardingRules.cleanup()
 		w.targetInstances.cleanup()
 		w.firewallRules.cleanup()
+		w.routes.cleanup()
+		w.routers.cleanup() // routers need to be done before the addresses their NATs use
+		w.addresses.cleanup()
 		w.subnetworks.cleanup()
 		w.networks.cleanup()
 		w.snapshots.cleanup()
+		w.locks.cleanup() // locks go last, after what they guard
 		return nil
 	})
 
@@ -802,6 +988,13 @@ func NewFromFile(file string) (*Workflow, error) {
 	return w, nil
 }
 
+// PendingMigrations returns the changes Migrate would make to the file the
+// workflow was read from. The file is read as it is, daisy migrate applies
+// them.
+func (w *Workflow) PendingMigrations() []string {
+	return w.pendingMigrations
+}
+
 // JSONError turns an error from json.Unmarshal and returns a more user
 // friendly error.
 func JSONError(file string, data []byte, err error) error {
@@ -841,11 +1034,26 @@ func readWorkflow(file string, w *Workflow) DError {
 		return newErr("failed to get absolute path of workflow file", err)
 	}
 
+	// Outdated files are only reported, errors are left for Unmarshal to report.
+	if _, changes, err := Migrate(data); err == nil {
+		w.pendingMigrations = changes
+	}
+
 	if err := json.Unmarshal(data, &w); err != nil {
-		return newErr("failed to unmarshal workflow file", JSONError(file, data, err))
+		err = JSONError(file, data, err)
+		if len(w.pendingMigrations) > 0 {
+			err = fmt.Errorf("%v; the file uses deprecated forms, run \"daisy migrate %s\" to update it", err, file)
+		}
+		return newErr("failed to unmarshal workflow file", err)
+	}
+	if w.SchemaVersion > CurrentSchemaVersion {
+		return Errf("workflow file %q has schema version %d, newer than %d, the latest this version of Daisy supports", file, w.SchemaVersion, CurrentSchemaVersion)
 	}
 
 	if w.OAuthPath != "" && !filepath.IsAbs(w.OAuthPath) {
+		if w.remoteDir != "" {
+			return Errf("relative OAuthPath %q can't be used in workflow %q fetched from %q, use an absolute path", w.OAuthPath, w.Name, w.remoteDir)
+		}
 		w.OAuthPath = filepath.Join(w.workflowDir, w.OAuthPath)
 	}
 
@@ -25,6 +25,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
@@ -262,6 +263,8 @@ func TestNewFromFile(t *testing.T) {
 
 	want.workflowDir = filepath.Join(wd, "test_data")
 	want.Name = "some-name"
+	// The file has a deprecated form, which is reported.
+	want.pendingMigrations = []string{"steps.create-image-guest-os-features-compute-api.createImages[0].GuestOsFeatures: feature names instead of Compute API GuestOsFeature objects"}
 	want.Project = "some-project"
 	want.Zone = "us-central1-a"
 	want.GCSPath = "gs://some-bucket/images"
@@ -1003,6 +1006,67 @@ func TestRunStepTimeout(t *testing.T) {
 	}
 }
 
+func TestRunStepCancelsContext(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("test")
+	s.timeout = 10 * time.Millisecond
+	canceled := make(chan struct{})
+	s.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		<-ctx.Done()
+		close(canceled)
+		return nil
+	}}
+	if err := w.runStep(context.Background(), s); err == nil {
+		t.Error("expected a timeout error")
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("step context was not canceled on timeout")
+	}
+
+	w = testWorkflow()
+	s, _ = w.NewStep("test")
+	s.timeout = time.Minute
+	s.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		<-ctx.Done()
+		return Errf("interrupted: %v", ctx.Err())
+	}}
+	go w.CancelWorkflow()
+	want := `Step "test" (mockStep) is canceled.`
+	if err := w.runStep(context.Background(), s); err == nil || err.Error() != want {
+		t.Errorf("did not get expected error, got: %v, want: %q", err, want)
+	}
+}
+
+func TestRunStepDoesNotCancelContextAfterReturn(t *testing.T) {
+	w := testWorkflow()
+	s, _ := w.NewStep("test")
+	s.timeout = 10 * time.Millisecond
+	var stepCtx context.Context
+	s.testType = &mockStep{runImpl: func(ctx context.Context, s *Step) DError {
+		stepCtx = ctx
+		return nil
+	}}
+	if err := w.runStep(context.Background(), s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Work the step left in the background keeps its context past the timeout.
+	select {
+	case <-stepCtx.Done():
+		t.Error("step context was canceled after the step returned")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// It's released at cleanup.
+	w.cleanup()
+	select {
+	case <-stepCtx.Done():
+	case <-time.After(time.Second):
+		t.Error("step context was not canceled at cleanup")
+	}
+}
+
 func TestPopulateClients(t *testing.T) {
 	w := testWorkflow()
 
@@ -1143,3 +1207,28 @@ func TestOnStepCancelCustomCancelReason(t *testing.T) {
 		t.Errorf("Expected error message `%v` but got `%v` ", expectedErrorMessage, err.Error())
 	}
 }
+
+func TestGetRunningSteps(t *testing.T) {
+	w := testWorkflow()
+	sw := w.NewSubWorkflow()
+	sw.Name = "sub"
+	start := time.Now()
+	w.recordStepStart("b", start.Add(time.Second))
+	sw.recordStepStart("a", start)
+
+	var got []string
+	for _, tr := range w.GetRunningSteps() {
+		got = append(got, tr.Name)
+	}
+	if want := []string{"sub.a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want running steps %v, got %v", want, got)
+	}
+
+	sw.recordStepTime("a", start, time.Now())
+	if got := w.GetRunningSteps(); len(got) != 1 || got[0].Name != "b" {
+		t.Errorf("want only step b running, got %v", got)
+	}
+	if got := w.GetStepTimeRecords(); len(got) != 1 || got[0].Name != "sub.a" {
+		t.Errorf("want a time record for sub.a, got %v", got)
+	}
+}